@@ -0,0 +1,170 @@
+// Package batcher submits the sequenced L2 blocks to L1: the batches of new L2 blocks are encoded into channels,
+// and the channels are split into frames, which are submitted as transactions to the batch inbox.
+package batcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/txmgr"
+)
+
+const (
+	// DefaultPollInterval is the default interval to poll the engine for new L2 blocks at
+	DefaultPollInterval = time.Second * 2
+	// DefaultMaxChannelSize is the default size of the sequenced transactions, before compression, at which a channel is flushed
+	DefaultMaxChannelSize = 100_000
+	// DefaultMaxChannelDuration is the default time after which a channel is flushed, regardless of its size
+	DefaultMaxChannelDuration = time.Minute
+	// DefaultMaxFrameSize is the default size of the channel data in each batch submission
+	DefaultMaxFrameSize = 100_000
+)
+
+// Config configures the flushing of channels
+type Config struct {
+	// PollInterval is the interval to poll the engine for new L2 blocks at
+	PollInterval time.Duration
+	// MaxChannelSize is the size of the sequenced transactions, before compression, at which a channel is flushed
+	MaxChannelSize uint64
+	// MaxChannelDuration is the time after the first block was added to a channel at which the channel is flushed
+	MaxChannelDuration time.Duration
+	// MaxFrameSize is the size of the channel data in each batch submission, a channel may span multiple submissions
+	MaxFrameSize uint64
+	// Compression is the compression algorithm of the channel data
+	Compression l2.CompressionAlgo
+}
+
+// Batcher reads the new L2 blocks from the engine, and submits their batches to the batch inbox on L1.
+// The batches of consecutive L2 blocks are combined into a channel, which is flushed once it reaches
+// the max channel size, or the max channel duration elapsed, whichever comes first.
+type Batcher struct {
+	Log    log.Logger
+	Rollup *l2.Config
+	Config Config
+	// L2 is the engine to read the new L2 blocks from
+	L2     eth.BlockByNumberSource
+	Sender txmgr.TxSender
+
+	// last L2 block that was added to a channel
+	last eth.BlockID
+	// the channel that is being built, nil if no blocks were added since the last flush
+	channel *channelBuilder
+	// the frames of the flushed channel that remain to be submitted, in order
+	pending [][]byte
+}
+
+// Start submits the batches of the L2 blocks after the given L2 block, until the subscription is closed.
+// Failed submissions are retried every poll interval.
+func (b *Batcher) Start(ctx context.Context, from eth.BlockID) ethereum.Subscription {
+	b.last = from
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(b.Config.PollInterval)
+		defer ticker.Stop()
+		// submissions wait for confirmation, abort them when unsubscribing
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := b.Step(ctx, time.Now()); err != nil {
+					b.Log.Warn("Failed to submit batches", "err", err)
+				}
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// Step submits the pending frames, reads the new L2 blocks into the channel, and flushes the channel if it is due.
+func (b *Batcher) Step(ctx context.Context, now time.Time) error {
+	if err := b.submitPending(ctx); err != nil {
+		return err
+	}
+	if err := b.readBlocks(ctx, now); err != nil {
+		return err
+	}
+	if b.channel == nil {
+		return nil
+	}
+	if b.channel.size < b.Config.MaxChannelSize && now.Sub(b.channel.opened) < b.Config.MaxChannelDuration {
+		return nil
+	}
+	frames, err := b.channel.frames(b.Config.Compression, b.Config.MaxFrameSize)
+	if err != nil {
+		return fmt.Errorf("failed to encode channel %s: %v", b.channel.id, err)
+	}
+	b.Log.Info("Flushing channel", "channel", b.channel.id, "first", b.channel.first, "last", b.channel.last,
+		"blocks", len(b.channel.batches), "size", b.channel.size, "frames", len(frames))
+	b.pending = frames
+	b.channel = nil
+	return b.submitPending(ctx)
+}
+
+// readBlocks adds the new L2 blocks to the channel
+func (b *Batcher) readBlocks(ctx context.Context, now time.Time) error {
+	for {
+		block, err := b.L2.BlockByNumber(ctx, new(big.Int).SetUint64(b.last.Number+1))
+		if errors.Is(err, ethereum.NotFound) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to fetch L2 block %d: %w", b.last.Number+1, err)
+		}
+		if block.ParentHash() != b.last.Hash {
+			b.reorg(block)
+			continue
+		}
+		if b.channel == nil {
+			b.channel, err = newChannelBuilder(now)
+			if err != nil {
+				return err
+			}
+		}
+		if err := b.channel.add(block); err != nil {
+			return err
+		}
+		b.last = b.channel.last
+	}
+}
+
+// reorg handles a L2 block that does not build on the last read L2 block:
+// the channel that is being built is dropped, and rebuilt from the L2 chain after the flushed channels.
+// Blocks that were flushed already cannot be revoked.
+func (b *Batcher) reorg(block *types.Block) {
+	if b.channel != nil {
+		b.Log.Warn("L2 reorg, rebuilding channel", "channel", b.channel.id, "first", b.channel.first, "last", b.last, "new_parent", block.ParentHash())
+		b.last = eth.BlockID{Hash: b.channel.parent, Number: b.channel.first.Number - 1}
+		b.channel = nil
+		return
+	}
+	b.Log.Error("L2 reorg of submitted L2 blocks", "last", b.last, "new_parent", block.ParentHash())
+	b.last = eth.BlockID{Hash: block.ParentHash(), Number: b.last.Number}
+}
+
+// submitPending submits the pending frames in order, and stops at the first failure, to retry from there.
+func (b *Batcher) submitPending(ctx context.Context) error {
+	for len(b.pending) > 0 {
+		if err := b.Sender.SendTx(ctx, b.Rollup.BatchInboxAddr, b.pending[0]); err != nil {
+			return fmt.Errorf("failed to submit frame, %d frames pending: %w", len(b.pending), err)
+		}
+		b.pending = b.pending[1:]
+	}
+	return nil
+}
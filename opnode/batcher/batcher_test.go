@@ -0,0 +1,147 @@
+package batcher
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// fakeL2 is a L2 chain, of which each block has a deposit and a sequenced transaction
+type fakeL2 []*types.Block
+
+func (f *fakeL2) add(t *testing.T, key []byte) {
+	parent := (*f)[len(*f)-1]
+	k, err := crypto.ToECDSA(key)
+	require.NoError(t, err)
+	tx, err := types.SignNewTx(k, types.HomesteadSigner{}, &types.LegacyTx{Nonce: parent.NumberU64(), GasPrice: big.NewInt(1), Gas: 21000})
+	require.NoError(t, err)
+	l1Info, err := l2.DeriveL1InfoDeposit(&l2.Config{}, l2.BlockInputFromHeader(&types.Header{Number: parent.Number(), BaseFee: common.Big1}), 0)
+	require.NoError(t, err)
+	deposit := types.NewTx(l1Info)
+	// the extra data distinguishes the blocks of different keys, the header does not commit to the fake transactions
+	header := &types.Header{ParentHash: parent.Hash(), Number: new(big.Int).Add(parent.Number(), common.Big1), Extra: key}
+	*f = append(*f, types.NewBlockWithHeader(header).WithBody(types.Transactions{deposit, tx}, nil))
+}
+
+func (f *fakeL2) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if n := number.Uint64(); n < uint64(len(*f)) {
+		return (*f)[n], nil
+	}
+	return nil, ethereum.NotFound
+}
+
+type fakeSender struct {
+	fail bool
+	sent [][]byte
+}
+
+func (s *fakeSender) SendTx(ctx context.Context, to common.Address, data []byte) error {
+	if s.fail {
+		return errors.New("tx pool full")
+	}
+	s.sent = append(s.sent, data)
+	return nil
+}
+
+// readBatches reads the submitted batches back, like a verifier
+func readBatches(t *testing.T, sent [][]byte) []*l2.BatchData {
+	bank := l2.NewChannelBank(&l2.Config{})
+	for _, data := range sent {
+		frames, err := l2.DecodeFrames(data)
+		require.NoError(t, err)
+		bank.IngestFrames(0, frames)
+	}
+	batches, errs := bank.ReadBatches(0)
+	require.Empty(t, errs)
+	return batches
+}
+
+func TestBatcher(t *testing.T) {
+	chain := fakeL2{types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})}
+	key := common.Hash{0x01}.Bytes()
+	sender := new(fakeSender)
+	b := &Batcher{
+		Log:    log.New(),
+		Rollup: &l2.Config{BatchInboxAddr: common.Address{0xff}},
+		Config: Config{MaxChannelSize: 200, MaxChannelDuration: time.Minute, MaxFrameSize: 100, Compression: l2.NoCompression},
+		L2:     &chain,
+		Sender: sender,
+		last:   eth.BlockID{Hash: chain[0].Hash(), Number: 0},
+	}
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+
+	chain.add(t, key)
+	require.NoError(t, b.Step(ctx, now))
+	require.Empty(t, sender.sent, "channel is not due yet")
+
+	// a sequenced tx is 77 bytes, the channel is flushed once it exceeds the max channel size
+	chain.add(t, key)
+	chain.add(t, key)
+	require.NoError(t, b.Step(ctx, now))
+	require.Greater(t, len(sender.sent), 1, "channel is split over multiple frames")
+	batches := readBatches(t, sender.sent)
+	require.Len(t, batches, 3)
+	for i, batch := range batches {
+		require.Len(t, batch.Transactions, 1, "deposits are not batched")
+		data, err := chain[i+1].Transactions()[1].MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, l2.Data(data), batch.Transactions[0])
+		require.Equal(t, chain[i+1].ParentHash(), batch.ParentHash)
+		require.Equal(t, uint64(i), batch.EpochNum, "L1 origin is parsed from the L1 info deposit")
+	}
+
+	// the channel is flushed after the max duration, and failed submissions are retried
+	sender.sent = nil
+	sender.fail = true
+	chain.add(t, key)
+	require.NoError(t, b.Step(ctx, now))
+	require.Error(t, b.Step(ctx, now.Add(time.Minute)))
+	sender.fail = false
+	require.NoError(t, b.Step(ctx, now.Add(time.Minute)))
+	batches = readBatches(t, sender.sent)
+	require.Len(t, batches, 1)
+}
+
+func TestBatcher_Reorg(t *testing.T) {
+	chain := fakeL2{types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})}
+	sender := new(fakeSender)
+	b := &Batcher{
+		Log:    log.New(),
+		Rollup: &l2.Config{},
+		Config: Config{MaxChannelSize: 10_000, MaxChannelDuration: time.Minute, MaxFrameSize: 10_000, Compression: l2.ZlibCompression},
+		L2:     &chain,
+		Sender: sender,
+		last:   eth.BlockID{Hash: chain[0].Hash(), Number: 0},
+	}
+	ctx := context.Background()
+	now := time.Unix(1000, 0)
+	chain.add(t, common.Hash{0x01}.Bytes())
+	chain.add(t, common.Hash{0x01}.Bytes())
+	require.NoError(t, b.Step(ctx, now))
+
+	// replace block 2
+	chain = chain[:2]
+	chain.add(t, common.Hash{0x02}.Bytes())
+	chain.add(t, common.Hash{0x02}.Bytes())
+	require.NoError(t, b.Step(ctx, now))
+	require.NoError(t, b.Step(ctx, now.Add(time.Minute)))
+
+	batches := readBatches(t, sender.sent)
+	require.Len(t, batches, 3, "the channel is rebuilt from the reorged chain")
+	data, err := chain[2].Transactions()[1].MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, l2.Data(data), batches[1].Transactions[0])
+}
@@ -0,0 +1,105 @@
+package batcher
+
+import (
+	"crypto/rand"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// channelBuilder accumulates the batches of consecutive L2 blocks into a channel, until the channel is flushed.
+type channelBuilder struct {
+	id      l2.ChannelID
+	batches []*l2.BatchData
+	// combined size of the sequenced transactions in the channel, before compression
+	size uint64
+	// time the first block was added
+	opened time.Time
+	// first and last L2 block in the channel, and the parent of the first block
+	first  eth.BlockID
+	last   eth.BlockID
+	parent common.Hash
+}
+
+func newChannelBuilder(now time.Time) (*channelBuilder, error) {
+	var id l2.ChannelID
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate channel ID: %v", err)
+	}
+	return &channelBuilder{id: id, opened: now}, nil
+}
+
+// BlockBatch returns the batch of a L2 block: its sequenced transactions, excluding the deposits derived from L1,
+// with the parent, L1 origin and timestamp of the L2 block.
+func BlockBatch(block *types.Block) (*l2.BatchData, error) {
+	batch := &l2.BatchData{ParentHash: block.ParentHash(), Timestamp: block.Time()}
+	txs := block.Transactions()
+	if len(txs) > 0 && txs[0].Type() == types.DepositTxType {
+		l1Num, _, _, l1Hash, _, _, _, _, err := l2.ParseL1InfoDepositTxData(txs[0].Data())
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse L1 info deposit of L2 block %s: %v", block.Hash(), err)
+		}
+		batch.EpochNum, batch.EpochHash = l1Num, l1Hash
+	}
+	for i, tx := range txs {
+		if tx.Type() == types.DepositTxType {
+			continue
+		}
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode tx %d of L2 block %s: %v", i, block.Hash(), err)
+		}
+		batch.Transactions = append(batch.Transactions, data)
+	}
+	return batch, nil
+}
+
+func (cb *channelBuilder) add(block *types.Block) error {
+	batch, err := BlockBatch(block)
+	if err != nil {
+		return err
+	}
+	id := eth.BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+	if len(cb.batches) == 0 {
+		cb.first = id
+		cb.parent = block.ParentHash()
+	}
+	cb.last = id
+	cb.batches = append(cb.batches, batch)
+	for _, tx := range batch.Transactions {
+		cb.size += uint64(len(tx))
+	}
+	return nil
+}
+
+// frames encodes the channel, and splits it into frames of at most maxFrameSize bytes of channel data.
+// Each frame is encoded as the calldata of a separate batch submission.
+func (cb *channelBuilder) frames(algo l2.CompressionAlgo, maxFrameSize uint64) ([][]byte, error) {
+	data, err := l2.EncodeChannel(cb.batches, algo)
+	if err != nil {
+		return nil, err
+	}
+	var out [][]byte
+	for nr := 0; len(data) > 0 || nr == 0; nr++ {
+		if nr > 0xffff {
+			return nil, fmt.Errorf("channel of %d bytes does not fit in %d frames", len(data), nr)
+		}
+		n := uint64(len(data))
+		if n > maxFrameSize {
+			n = maxFrameSize
+		}
+		frame := l2.Frame{ID: cb.id, FrameNumber: uint16(nr), Data: data[:n], IsLast: n == uint64(len(data))}
+		calldata, err := l2.EncodeFrames([]l2.Frame{frame})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, calldata)
+		data = data[n:]
+	}
+	return out, nil
+}
@@ -0,0 +1,227 @@
+// Package challenger watches the outputs proposed to the L2 output oracle on L1,
+// and recomputes them with the L2 engine, to alert on, and optionally dispute, invalid outputs.
+package challenger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/txmgr"
+)
+
+const (
+	// DefaultPollInterval is the default interval to check L1 for newly proposed outputs
+	DefaultPollInterval = time.Second * 12
+	// MaxLogRange is the maximum number of L1 blocks to fetch the proposal logs of in a single request
+	MaxLogRange = 1000
+)
+
+// InvalidOutputErr is returned when a proposed output does not match the output computed with the L2 engine
+var InvalidOutputErr = errors.New("invalid output")
+
+// Config configures the watching of proposed outputs
+type Config struct {
+	// PollInterval is the interval to check L1 for newly proposed outputs
+	PollInterval time.Duration
+	// OracleAddr is the L1 address of the L2 output oracle
+	OracleAddr common.Address
+	// ConfDepth is the number of L1 confirmations to wait for before reading the proposals of a L1 block
+	ConfDepth uint64
+}
+
+// Proposal is an output proposed to the L2 output oracle
+type Proposal struct {
+	OutputRoot common.Hash
+	L2Block    uint64
+	// L1 is the L1 block that includes the proposal
+	L1     eth.BlockID
+	TxHash common.Hash
+}
+
+// Challenger checks every output proposed to the L2 output oracle against the output computed with the L2 engine,
+// once the L2 block of the output is safe. Invalid outputs are logged, reported with OnInvalid,
+// and disputed by deleting them from the oracle if a Sender is configured.
+type Challenger struct {
+	Log    log.Logger
+	Rollup *l2.Config
+	Config Config
+	// L1 serves the logs of the L2 output oracle
+	L1 ethereum.LogFilterer
+	// L1Head returns the L1 head
+	L1Head func() eth.BlockID
+	// L2 is the engine to compute the outputs with
+	L2     eth.BlockByNumberSource
+	Proofs l2.ProofSource
+	// SafeHead returns the safe L2 head
+	SafeHead func() eth.BlockID
+	// OnInvalid is called with every invalid proposal, and the output it should have been. Optional.
+	OnInvalid func(p Proposal, expected *l2.Output)
+	// Sender disputes invalid outputs. Nil to only alert.
+	Sender txmgr.TxSender
+
+	// next is the next L1 block to read the proposals of
+	next uint64
+	// pending are the proposals that are not checked yet, in order of proposal
+	pending []Proposal
+	// disputes are the invalid proposals that failed to be disputed, to retry
+	disputes []Proposal
+}
+
+// Start checks the outputs proposed from the given L1 block onwards every poll interval,
+// until the subscription is closed. Failed checks and disputes are retried every poll interval.
+func (c *Challenger) Start(ctx context.Context, from uint64) ethereum.Subscription {
+	c.next = from
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(c.Config.PollInterval)
+		defer ticker.Stop()
+		// disputes wait for confirmation, abort them when unsubscribing
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := c.Step(ctx); err != nil {
+					c.Log.Warn("Failed to check proposed outputs", "err", err)
+				}
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// Step retries the failed disputes, reads the new proposals of the confirmed L1 blocks,
+// and checks the proposals up to the safe L2 head.
+func (c *Challenger) Step(ctx context.Context) error {
+	for len(c.disputes) > 0 {
+		if err := c.dispute(ctx, c.disputes[0]); err != nil {
+			return err
+		}
+		c.disputes = c.disputes[1:]
+	}
+	if err := c.readProposals(ctx); err != nil {
+		return err
+	}
+	safe := c.SafeHead()
+	for len(c.pending) > 0 && c.pending[0].L2Block <= safe.Number {
+		p := c.pending[0]
+		err := c.check(ctx, p)
+		if errors.Is(err, InvalidOutputErr) {
+			c.pending = c.pending[1:]
+			if c.Sender == nil {
+				continue
+			}
+			if err := c.dispute(ctx, p); err != nil {
+				c.disputes = append(c.disputes, p)
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		c.pending = c.pending[1:]
+	}
+	return nil
+}
+
+// readProposals reads the proposals of the L1 blocks up to the L1 head minus the confirmation depth.
+// Proposals of L1 blocks that reorg after they were read are checked regardless.
+func (c *Challenger) readProposals(ctx context.Context) error {
+	head := c.L1Head()
+	if head.Number < c.Config.ConfDepth {
+		return nil
+	}
+	to := head.Number - c.Config.ConfDepth
+	for c.next <= to {
+		end := c.next + MaxLogRange - 1
+		if end > to {
+			end = to
+		}
+		logs, err := c.L1.FilterLogs(ctx, ethereum.FilterQuery{
+			FromBlock: new(big.Int).SetUint64(c.next),
+			ToBlock:   new(big.Int).SetUint64(end),
+			Addresses: []common.Address{c.Config.OracleAddr},
+			Topics:    [][]common.Hash{{l2.L2OutputAppendedEventABIHash}},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to fetch the proposals of L1 blocks %d to %d: %w", c.next, end, err)
+		}
+		for _, log := range logs {
+			if log.Removed {
+				continue
+			}
+			p, err := parseProposal(log)
+			if err != nil {
+				return fmt.Errorf("failed to decode proposal log %d of L1 block %d: %v", log.Index, log.BlockNumber, err)
+			}
+			c.pending = append(c.pending, p)
+		}
+		c.next = end + 1
+	}
+	return nil
+}
+
+// parseProposal decodes a L2OutputAppended log, of which all arguments are indexed
+func parseProposal(log types.Log) (Proposal, error) {
+	if len(log.Topics) != 4 || log.Topics[0] != l2.L2OutputAppendedEventABIHash {
+		return Proposal{}, errors.New("not a L2OutputAppended event")
+	}
+	number := log.Topics[3].Big()
+	if !number.IsUint64() {
+		return Proposal{}, fmt.Errorf("L2 block number %d overflows", number)
+	}
+	return Proposal{
+		OutputRoot: log.Topics[1],
+		L2Block:    number.Uint64(),
+		L1:         eth.BlockID{Hash: log.BlockHash, Number: log.BlockNumber},
+		TxHash:     log.TxHash,
+	}, nil
+}
+
+// check recomputes the output of the proposal, and returns InvalidOutputErr if it does not match.
+func (c *Challenger) check(ctx context.Context, p Proposal) error {
+	output, err := l2.OutputAtBlock(ctx, c.Rollup, c.L2, c.Proofs, p.L2Block)
+	if err != nil {
+		return err
+	}
+	if output.OutputRoot == p.OutputRoot {
+		c.Log.Info("Verified proposed output", "l2", output.Block, "output_root", p.OutputRoot, "l1", p.L1)
+		return nil
+	}
+	c.Log.Error("Invalid output proposed", "l2", output.Block, "proposed", p.OutputRoot, "expected", output.OutputRoot, "l1", p.L1, "tx", p.TxHash)
+	if c.OnInvalid != nil {
+		c.OnInvalid(p, output)
+	}
+	return fmt.Errorf("output %s of L2 block %d: %w", p.OutputRoot, p.L2Block, InvalidOutputErr)
+}
+
+// dispute deletes the invalid output from the L2 output oracle
+func (c *Challenger) dispute(ctx context.Context, p Proposal) error {
+	data, err := l2.L2OutputOracleABI.Pack("deleteL2Output", p.OutputRoot)
+	if err != nil {
+		return fmt.Errorf("failed to encode dispute of output %s: %v", p.OutputRoot, err)
+	}
+	c.Log.Warn("Disputing invalid output", "output_root", p.OutputRoot, "l2", p.L2Block)
+	if err := c.Sender.SendTx(ctx, c.Config.OracleAddr, data); err != nil {
+		return fmt.Errorf("failed to dispute output %s of L2 block %d: %w", p.OutputRoot, p.L2Block, err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package challenger
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// fakeL2 is a L2 chain, with a withdrawal storage root per block
+type fakeL2 []*types.Block
+
+func (f fakeL2) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if n := number.Uint64(); n < uint64(len(f)) {
+		return f[n], nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f fakeL2) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	return &gethclient.AccountResult{Address: account, StorageHash: common.BigToHash(blockNumber)}, nil
+}
+
+// fakeOracle is a L2 output oracle on L1, with the proposal logs of every L1 block, that records the disputes sent to it
+type fakeOracle struct {
+	logs     []types.Log
+	queries  int
+	fail     bool
+	disputed []common.Hash
+}
+
+func (o *fakeOracle) propose(l1 uint64, output common.Hash, l2Block uint64) {
+	o.logs = append(o.logs, types.Log{
+		Address:     common.Address{0x0a},
+		Topics:      []common.Hash{l2.L2OutputAppendedEventABIHash, output, common.BigToHash(big.NewInt(1234)), common.BigToHash(new(big.Int).SetUint64(l2Block))},
+		BlockNumber: l1,
+	})
+}
+
+func (o *fakeOracle) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	o.queries++
+	var out []types.Log
+	for _, l := range o.logs {
+		if l.BlockNumber >= q.FromBlock.Uint64() && l.BlockNumber <= q.ToBlock.Uint64() {
+			out = append(out, l)
+		}
+	}
+	return out, nil
+}
+
+func (o *fakeOracle) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func (o *fakeOracle) SendTx(ctx context.Context, to common.Address, data []byte) error {
+	if o.fail {
+		return errors.New("tx pool full")
+	}
+	args, err := l2.L2OutputOracleABI.Methods["deleteL2Output"].Inputs.Unpack(data[4:])
+	if err != nil {
+		return err
+	}
+	o.disputed = append(o.disputed, args[0].([32]byte))
+	return nil
+}
+
+func TestChallenger(t *testing.T) {
+	var chain fakeL2
+	for i := int64(0); i <= 10; i++ {
+		chain = append(chain, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i), Root: common.Hash{byte(i)}}))
+	}
+	ctx := context.Background()
+	outputAt := func(n uint64) common.Hash {
+		output, err := l2.OutputAtBlock(ctx, &l2.Config{}, chain, chain, n)
+		require.NoError(t, err)
+		return output.OutputRoot
+	}
+
+	oracle := &fakeOracle{}
+	oracle.propose(100, outputAt(4), 4)
+	oracle.propose(1500, common.Hash{0xba, 0xd}, 8)
+	oracle.propose(2500, outputAt(10), 10)
+
+	var invalid []Proposal
+	safe := uint64(9)
+	l1Head := uint64(2000)
+	c := &Challenger{
+		Log:       log.New(),
+		Rollup:    &l2.Config{},
+		Config:    Config{OracleAddr: common.Address{0x0a}, ConfDepth: 10},
+		L1:        oracle,
+		L1Head:    func() eth.BlockID { return eth.BlockID{Number: l1Head} },
+		L2:        chain,
+		Proofs:    chain,
+		SafeHead:  func() eth.BlockID { return eth.BlockID{Hash: chain[safe].Hash(), Number: safe} },
+		OnInvalid: func(p Proposal, expected *l2.Output) { invalid = append(invalid, p) },
+		Sender:    oracle,
+	}
+
+	// the confirmed L1 blocks are read in ranges, and the invalid output is disputed
+	oracle.fail = true
+	require.Error(t, c.Step(ctx))
+	require.Equal(t, 2, oracle.queries, "L1 blocks 0 to 1990 are read in ranges")
+	require.Len(t, invalid, 1)
+	require.Equal(t, uint64(8), invalid[0].L2Block)
+	require.Empty(t, oracle.disputed)
+
+	// failed disputes are retried, without alerting again
+	oracle.fail = false
+	require.NoError(t, c.Step(ctx))
+	require.Equal(t, []common.Hash{{0xba, 0xd}}, oracle.disputed)
+	require.Len(t, invalid, 1)
+
+	// proposals are checked once the L2 block is safe
+	l1Head = 3000
+	require.NoError(t, c.Step(ctx))
+	require.Len(t, c.pending, 1, "L2 block 10 is not safe yet")
+	safe = 10
+	require.NoError(t, c.Step(ctx))
+	require.Empty(t, c.pending)
+	require.Len(t, invalid, 1, "valid outputs are not reported")
+	require.Len(t, oracle.disputed, 1, "valid outputs are not disputed")
+}
+
+func TestChallengerAlertOnly(t *testing.T) {
+	chain := fakeL2{types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0)})}
+	oracle := &fakeOracle{}
+	oracle.propose(5, common.Hash{0xba, 0xd}, 0)
+	alerts := 0
+	c := &Challenger{
+		Log:       log.New(),
+		Rollup:    &l2.Config{},
+		Config:    Config{OracleAddr: common.Address{0x0a}},
+		L1:        oracle,
+		L1Head:    func() eth.BlockID { return eth.BlockID{Number: 5} },
+		L2:        chain,
+		Proofs:    chain,
+		SafeHead:  func() eth.BlockID { return eth.BlockID{} },
+		OnInvalid: func(p Proposal, expected *l2.Output) { alerts++ },
+	}
+	require.NoError(t, c.Step(context.Background()))
+	require.Equal(t, 1, alerts)
+	require.Empty(t, c.pending)
+	require.Empty(t, c.disputes)
+}
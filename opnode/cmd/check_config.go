@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/node"
+)
+
+type CheckConfigCmd struct {
+	Node node.OpNodeCmd `ask:"."`
+
+	Offline bool `ask:"--offline" help:"Only check the flags and the rollup config file, without connecting to the L1 endpoints and L2 engines to check their chain ID and genesis block"`
+}
+
+func (c *CheckConfigCmd) Help() string {
+	return "Check the node configuration, including the chain ID and genesis block of the L1 endpoints and L2 engines, and print the effective configuration as JSON. Takes the same flags as the run command."
+}
+
+func (c *CheckConfigCmd) Run(ctx context.Context, args ...string) error {
+	if err := c.Node.LoadConfig(); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	if !c.Offline {
+		if err := c.Node.CheckChains(ctx); err != nil {
+			return fmt.Errorf("invalid configuration: %w", err)
+		}
+	}
+	flags, err := c.Node.EffectiveConfig()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(flags)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/protolambda/ask"
+)
+
+// EnvPrefix is the prefix of the environment variables that set the flags, e.g. OP_NODE_L1 for --l1
+const EnvPrefix = "OP_NODE_"
+
+// EnvVar returns the name of the environment variable of a flag, e.g. OP_NODE_ROLLUP_L1_CHAIN_ID for --rollup.l1-chain-id
+func EnvVar(flag string) string {
+	return EnvPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(flag))
+}
+
+// envArgs returns the flags of the command that are set in the environment, as --flag=value arguments.
+// The arguments are parsed before the command line arguments, so the command line takes precedence.
+func envArgs(cmd interface{}, lookupEnv func(key string) (string, bool)) ([]string, error) {
+	descr, err := ask.Load(cmd)
+	if err != nil {
+		return nil, err
+	}
+	flags := make(map[string]string)
+	var args []string
+	for _, fl := range descr.All("") {
+		if fl.IsArg {
+			continue
+		}
+		key := EnvVar(fl.Path)
+		if other, ok := flags[key]; ok {
+			return nil, fmt.Errorf("flags --%s and --%s share the environment variable %s", other, fl.Path, key)
+		}
+		flags[key] = fl.Path
+		if value, ok := lookupEnv(key); ok {
+			args = append(args, "--"+fl.Path+"="+value)
+		}
+	}
+	return args, nil
+}
+
+// withEnv inserts the flags of the sub-command that are set in the environment before the command line flags.
+// Arguments without a known sub-command are returned as-is.
+func withEnv(root *MainCmd, args []string, lookupEnv func(key string) (string, bool)) ([]string, error) {
+	if len(args) == 0 {
+		return args, nil
+	}
+	cmd, err := root.Cmd(args[0])
+	if err != nil {
+		return args, nil
+	}
+	env, err := envArgs(cmd, lookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(args)+len(env))
+	out = append(out, args[0])
+	out = append(out, env...)
+	return append(out, args[1:]...), nil
+}
+
+// applyEnv rewrites the process arguments to include the flags that are set in the environment.
+func applyEnv(root *MainCmd) {
+	args, err := withEnv(root, os.Args[1:], os.LookupEnv)
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "failed to load flags from the environment: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], args...)
+}
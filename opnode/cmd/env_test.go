@@ -0,0 +1,35 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvVar(t *testing.T) {
+	require.Equal(t, "OP_NODE_L1", EnvVar("l1"))
+	require.Equal(t, "OP_NODE_ROLLUP_L1_CHAIN_ID", EnvVar("rollup.l1-chain-id"))
+}
+
+func TestWithEnv(t *testing.T) {
+	env := map[string]string{
+		"OP_NODE_L1":                "ws://l1",
+		"OP_NODE_METRICS_PORT":      "9000",
+		"OP_NODE_ROLLUP_BLOCK_TIME": "3",
+		"OP_NODE_UNKNOWN_FLAG":      "1",
+	}
+	lookupEnv := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+
+	args, err := withEnv(new(MainCmd), []string{"run", "--metrics.port=8000"}, lookupEnv)
+	require.NoError(t, err)
+	require.Equal(t, "run", args[0])
+	require.ElementsMatch(t, []string{"--l1=ws://l1", "--metrics.port=9000", "--rollup.block-time=3"}, args[1:4])
+	require.Equal(t, "--metrics.port=8000", args[4], "command line flags must be parsed last, to take precedence")
+
+	args, err = withEnv(new(MainCmd), []string{"unknown", "--l1=x"}, lookupEnv)
+	require.NoError(t, err)
+	require.Equal(t, []string{"unknown", "--l1=x"}, args)
+}
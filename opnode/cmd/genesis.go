@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/genesis"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/node"
+)
+
+type GenesisCmd struct {
+	L1Addr          string `ask:"--l1" help:"Address of the L1 JSON-RPC endpoint to fetch the deployment from"`
+	DeploymentBlock uint64 `ask:"--deployment-block" help:"L1 block number that the rollup contracts were deployed in, the L1 genesis anchor"`
+
+	DepositContractAddr common.Address `ask:"--deposit-contract" help:"L1 address of the deployed deposit contract"`
+	BatchInboxAddr      common.Address `ask:"--batch-inbox" help:"L1 address that batches are submitted to"`
+	BatcherAddr         common.Address `ask:"--batcher" help:"L1 address of the batch submitter"`
+
+	L2ChainID         uint64 `ask:"--l2-chain-id" help:"Chain ID of L2"`
+	BlockTime         uint64 `ask:"--block-time" help:"Number of seconds between L2 blocks"`
+	MaxSequencerDrift uint64 `ask:"--max-sequencer-drift" help:"Number of seconds that the timestamp of a L2 block may be ahead of the timestamp of its L1 origin"`
+	SeqWindowSize     uint64 `ask:"--seq-window-size" help:"Number of L1 blocks, starting at the L1 origin of an epoch, within which the batches of the epoch must be included on L1"`
+	GasLimit          uint64 `ask:"--gas-limit" help:"Gas limit of the L2 genesis block"`
+
+	L1InfoPredeployAddr common.Address   `ask:"--l1-info-predeploy" help:"L2 address to predeploy the L1 info contract at"`
+	PremineAddrs        []common.Address `ask:"--premine" help:"L2 addresses to fund from genesis, e.g. on devnets"`
+	PremineEth          uint64           `ask:"--premine-eth" help:"Balance, in ETH, of each premined L2 address"`
+
+	OutGenesis string `ask:"--out-genesis" help:"File to write the L2 genesis JSON to, to initialize the L2 engines with"`
+	OutRollup  string `ask:"--out-rollup" help:"File to write the rollup config JSON to, to run the rollup nodes with --rollup-config"`
+}
+
+func (c *GenesisCmd) Default() {
+	c.L1Addr = "http://127.0.0.1:8545"
+	c.DepositContractAddr = l2.DepositContractAddr
+	c.BlockTime = l2.DefaultBlockTime
+	c.MaxSequencerDrift = l2.DefaultMaxSequencerDrift
+	c.SeqWindowSize = l2.DefaultSeqWindowSize
+	c.GasLimit = genesis.DefaultGasLimit
+	c.L1InfoPredeployAddr = l2.L1InfoPredeployAddr
+	c.OutGenesis = "genesis-l2.json"
+	c.OutRollup = "rollup.json"
+}
+
+func (c *GenesisCmd) Help() string {
+	return "Generate the L2 genesis and the rollup config of a new rollup, from the deployment of the rollup contracts on L1."
+}
+
+func (c *GenesisCmd) Run(ctx context.Context, args ...string) error {
+	if c.L2ChainID == 0 {
+		return errors.New("L2 chain ID required, see --l2-chain-id")
+	}
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+	l1, err := ethclient.DialContext(ctx, c.L1Addr)
+	if err != nil {
+		return fmt.Errorf("failed to dial L1 address %q: %v", c.L1Addr, err)
+	}
+	defer l1.Close()
+	l1ChainID, err := l1.ChainID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 chain ID: %v", err)
+	}
+	if !l1ChainID.IsUint64() {
+		return fmt.Errorf("unsupported L1 chain ID %s", l1ChainID)
+	}
+	anchor, err := l1.HeaderByNumber(ctx, new(big.Int).SetUint64(c.DeploymentBlock))
+	if err != nil {
+		return fmt.Errorf("failed to fetch L1 deployment block %d: %v", c.DeploymentBlock, err)
+	}
+	// the rollup derives deposits from the L1 blocks after the anchor, the deposit contract must exist by then
+	code, err := l1.CodeAt(ctx, c.DepositContractAddr, anchor.Number)
+	if err != nil {
+		return fmt.Errorf("failed to fetch deposit contract code: %v", err)
+	}
+	if len(code) == 0 {
+		return fmt.Errorf("no deposit contract deployed at %s in L1 block %d", c.DepositContractAddr, c.DeploymentBlock)
+	}
+
+	premine := make(map[common.Address]*big.Int, len(c.PremineAddrs))
+	for _, addr := range c.PremineAddrs {
+		premine[addr] = new(big.Int).Mul(new(big.Int).SetUint64(c.PremineEth), big.NewInt(params.Ether))
+	}
+	cfg := &genesis.Config{
+		L2ChainID:           new(big.Int).SetUint64(c.L2ChainID),
+		L1Anchor:            anchor,
+		GasLimit:            c.GasLimit,
+		L1InfoPredeployAddr: c.L1InfoPredeployAddr,
+		Premine:             premine,
+	}
+	l2Genesis, err := genesis.BuildL2Genesis(cfg)
+	if err != nil {
+		return err
+	}
+	rollupConfig := &node.RollupConfig{
+		Genesis:             genesis.Anchors(cfg, l2Genesis),
+		BlockTime:           c.BlockTime,
+		MaxSequencerDrift:   c.MaxSequencerDrift,
+		SeqWindowSize:       c.SeqWindowSize,
+		L1ChainID:           l1ChainID.Uint64(),
+		L2ChainID:           c.L2ChainID,
+		DepositContractAddr: c.DepositContractAddr,
+		BatchInboxAddr:      c.BatchInboxAddr,
+		BatcherAddr:         c.BatcherAddr,
+	}
+	if err := rollupConfig.Check(); err != nil {
+		return fmt.Errorf("invalid rollup config: %w", err)
+	}
+
+	if err := writeJSON(c.OutGenesis, l2Genesis); err != nil {
+		return fmt.Errorf("failed to write L2 genesis: %v", err)
+	}
+	if err := writeJSON(c.OutRollup, rollupConfig); err != nil {
+		return fmt.Errorf("failed to write rollup config: %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "L1 anchor: %s\nL2 genesis: %s\n", rollupConfig.Genesis.L1, rollupConfig.Genesis.L2)
+	return nil
+}
+
+func writeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
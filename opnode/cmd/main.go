@@ -1,6 +1,10 @@
 package main
 
 import (
+	"os"
+	"os/signal"
+	"syscall"
+
 	"github.com/ethereum-optimism/optimistic-specs/opnode/node"
 	"github.com/protolambda/ask"
 )
@@ -16,6 +20,14 @@ func (c *MainCmd) Cmd(route string) (cmd interface{}, err error) {
 	switch route {
 	case "run":
 		return &node.OpNodeCmd{}, nil
+	case "testvectors":
+		return &TestVectorsCmd{}, nil
+	case "genesis":
+		return &GenesisCmd{}, nil
+	case "check-config":
+		return &CheckConfigCmd{}, nil
+	case "version":
+		return &VersionCmd{}, nil
 	default:
 		return nil, ask.UnrecognizedErr
 	}
@@ -23,9 +35,20 @@ func (c *MainCmd) Cmd(route string) (cmd interface{}, err error) {
 
 // TODO: we can support additional utils etc.
 func (c *MainCmd) Routes() []string {
-	return []string{"run"}
+	return []string{"run", "testvectors", "genesis", "check-config", "version"}
 }
 
 func main() {
-	ask.Run(new(MainCmd))
+	// ask.Run shuts down gracefully on os.Interrupt only, shut down the same way on SIGTERM
+	sigterm := make(chan os.Signal, 1)
+	signal.Notify(sigterm, syscall.SIGTERM)
+	go func() {
+		<-sigterm
+		if p, err := os.FindProcess(os.Getpid()); err == nil {
+			_ = p.Signal(os.Interrupt)
+		}
+	}()
+	cmd := new(MainCmd)
+	applyEnv(cmd)
+	ask.Run(cmd)
 }
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+type TestVectorsCmd struct {
+	Seed  int64  `ask:"--seed" help:"Seed of the random test vectors"`
+	Count int    `ask:"--count" help:"Number of random test vectors of each kind, in addition to the edge-cases"`
+	Out   string `ask:"--out" help:"File to write the JSON test vectors to. Empty to write to stdout."`
+}
+
+func (c *TestVectorsCmd) Default() {
+	c.Seed = 1234
+	c.Count = 10
+}
+
+func (c *TestVectorsCmd) Help() string {
+	return "Generate deposit derivation test vectors, for other client implementations to validate against."
+}
+
+func (c *TestVectorsCmd) Run(ctx context.Context, args ...string) error {
+	vectors, err := GenerateTestVectors(c.Seed, c.Count)
+	if err != nil {
+		return err
+	}
+	out := os.Stdout
+	if c.Out != "" {
+		f, err := os.Create(c.Out)
+		if err != nil {
+			return fmt.Errorf("failed to create test vectors file: %v", err)
+		}
+		defer f.Close()
+		out = f
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(vectors); err != nil {
+		return fmt.Errorf("failed to write test vectors: %v", err)
+	}
+	return nil
+}
+
+// l1InfoVectorInput implements l2.L1Info with the inputs of a L1InfoTestVector
+type l1InfoVectorInput struct {
+	v *l2.L1InfoTestVector
+}
+
+func (l l1InfoVectorInput) NumberU64() uint64 { return uint64(l.v.Number) }
+func (l l1InfoVectorInput) Time() uint64      { return uint64(l.v.Time) }
+func (l l1InfoVectorInput) Hash() common.Hash { return l.v.BlockHash }
+func (l l1InfoVectorInput) BaseFee() *big.Int { return (*big.Int)(l.v.BaseFee) }
+
+// GenerateTestVectors generates count random deposit and L1 info test vectors with the given seed,
+// in addition to a fixed set of edge-cases.
+func GenerateTestVectors(seed int64, count int) (*l2.TestVectors, error) {
+	rng := rand.New(rand.NewSource(seed))
+	var out l2.TestVectors
+
+	addDeposit := func(name string, blockHeight, txIndex uint64, ev *types.Log) error {
+		v := l2.DepositTestVector{
+			Name:             name,
+			BlockHeight:      hexutil.Uint64(blockHeight),
+			TransactionIndex: hexutil.Uint64(txIndex),
+			Log:              l2.TestVectorLog{Address: ev.Address, Topics: ev.Topics, Data: ev.Data},
+		}
+		dep, err := l2.UnmarshalDepositLog(blockHeight, txIndex, ev)
+		if err != nil {
+			v.Invalid = true
+		} else {
+			v.DepositTx, err = l2.EncodeDeposit(dep)
+			if err != nil {
+				return fmt.Errorf("failed to encode deposit %q: %v", name, err)
+			}
+		}
+		out.Deposits = append(out.Deposits, v)
+		return nil
+	}
+	depositLog := func(dep *types.DepositTx) *types.Log {
+		return l2.MarshalDepositLogEvent(l2.DepositContractAddr, dep)
+	}
+
+	creation := testutil.GenerateDeposit(1, 1, rng)
+	creation.To = nil
+	if err := addDeposit("contract creation", 1, 1, depositLog(creation)); err != nil {
+		return nil, err
+	}
+	empty := testutil.GenerateDeposit(2, 1, rng)
+	empty.Mint = nil
+	empty.Data = nil
+	if err := addDeposit("no mint and no data", 2, 1, depositLog(empty)); err != nil {
+		return nil, err
+	}
+	truncated := depositLog(testutil.GenerateDeposit(3, 1, rng))
+	truncated.Data = truncated.Data[:5*32]
+	if err := addDeposit("truncated data", 3, 1, truncated); err != nil {
+		return nil, err
+	}
+	dirty := depositLog(testutil.GenerateDeposit(4, 1, rng))
+	dirty.Topics[1][0] = 0xff
+	if err := addDeposit("dirty address padding", 4, 1, dirty); err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		blockHeight, txIndex := rng.Uint64(), uint64(rng.Intn(100)+1)
+		if err := addDeposit(fmt.Sprintf("random %d", i), blockHeight, txIndex, depositLog(testutil.GenerateDeposit(blockHeight, txIndex, rng))); err != nil {
+			return nil, err
+		}
+	}
+
+	addL1Info := func(name string, v l2.L1InfoTestVector) error {
+		v.Name = name
+		data, err := l2.EncodeL1InfoData(l1InfoVectorInput{&v}, uint64(v.SeqNumber), v.BatcherHash, v.L1FeeOverhead, v.L1FeeScalar)
+		if err != nil {
+			return fmt.Errorf("failed to encode L1 info %q: %v", name, err)
+		}
+		v.Calldata = data
+		out.L1Info = append(out.L1Info, v)
+		return nil
+	}
+	if err := addL1Info("zero", l2.L1InfoTestVector{BaseFee: new(hexutil.Big)}); err != nil {
+		return nil, err
+	}
+	maxBaseFee := new(big.Int).Sub(new(big.Int).Lsh(common.Big1, 256), common.Big1)
+	if err := addL1Info("max", l2.L1InfoTestVector{
+		Number:        ^hexutil.Uint64(0),
+		Time:          ^hexutil.Uint64(0),
+		BaseFee:       (*hexutil.Big)(maxBaseFee),
+		BlockHash:     common.Hash{0: 0xff, 31: 0xff},
+		SeqNumber:     ^hexutil.Uint64(0),
+		BatcherHash:   common.BytesToHash(testutil.GenerateAddress(rng).Bytes()),
+		L1FeeOverhead: common.Hash{0: 0xff, 31: 0xff},
+		L1FeeScalar:   common.Hash{0: 0xff, 31: 0xff},
+	}); err != nil {
+		return nil, err
+	}
+	for i := 0; i < count; i++ {
+		var blockHash common.Hash
+		rng.Read(blockHash[:])
+		if err := addL1Info(fmt.Sprintf("random %d", i), l2.L1InfoTestVector{
+			Number:        hexutil.Uint64(rng.Uint64()),
+			Time:          hexutil.Uint64(rng.Uint64()),
+			BaseFee:       (*hexutil.Big)(big.NewInt(rng.Int63n(1000_0000 * 1e9))),
+			BlockHash:     blockHash,
+			SeqNumber:     hexutil.Uint64(rng.Intn(10)),
+			BatcherHash:   common.BytesToHash(testutil.GenerateAddress(rng).Bytes()),
+			L1FeeOverhead: common.BigToHash(big.NewInt(rng.Int63n(10_000))),
+			L1FeeScalar:   common.BigToHash(big.NewInt(rng.Int63n(10_000_000))),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return &out, nil
+}
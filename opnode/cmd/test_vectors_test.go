@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// the vectors are reproducible, a change of derivation must be reflected in the vectors
+func TestGenerateTestVectors(t *testing.T) {
+	f, err := os.Open("../l2/testdata/test_vectors.json")
+	require.NoError(t, err)
+	defer f.Close()
+	vectors, err := l2.ReadTestVectors(f)
+	require.NoError(t, err)
+
+	generated, err := GenerateTestVectors(1234, 5)
+	require.NoError(t, err)
+	expected, err := json.Marshal(vectors)
+	require.NoError(t, err)
+	got, err := json.Marshal(generated)
+	require.NoError(t, err)
+	assert.JSONEq(t, string(expected), string(got))
+}
+
+func TestTestVectorsMismatch(t *testing.T) {
+	vectors, err := GenerateTestVectors(42, 1)
+	require.NoError(t, err)
+	dep := vectors.Deposits[0]
+	dep.TransactionIndex += 1
+	assert.Error(t, dep.Check())
+	invalid := vectors.Deposits[2]
+	require.True(t, invalid.Invalid)
+	invalid.Invalid = false
+	assert.Error(t, invalid.Check())
+	info := vectors.L1Info[1]
+	info.SeqNumber -= 1
+	assert.Error(t, info.Check())
+}
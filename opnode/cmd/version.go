@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/version"
+)
+
+type VersionCmd struct {
+	JSON bool `ask:"--json" help:"Print the build info as JSON"`
+}
+
+func (c *VersionCmd) Help() string {
+	return "Print the version, git commit and Go version of this build."
+}
+
+func (c *VersionCmd) Run(ctx context.Context, args ...string) error {
+	info := version.Get()
+	if c.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(info)
+	}
+	_, err := fmt.Println(info)
+	return err
+}
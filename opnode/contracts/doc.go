@@ -5,6 +5,8 @@ The contracts package provides Go bindings for our contracts.
 
 To regenerate the bindings, run `make`
 The following programs are required: `jq`, `abigen`, and having run `yarn build` in the contracts directory.
+
+The L1Block bytecode must accept the L1 info deposits of the node: see TestL1BlockPredeploy in the genesis package.
 */
 
 package contracts
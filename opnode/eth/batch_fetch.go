@@ -0,0 +1,123 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+type BatchCaller interface {
+	BatchCallContext(ctx context.Context, b []rpc.BatchElem) error
+}
+
+// BatchFetcher fetches a block with all its receipts in a single batched JSON-RPC round trip:
+// eth_getBlockByHash with full transactions, and eth_getBlockReceipts.
+//
+// If the endpoint does not support eth_getBlockReceipts, the receipts are fetched in a second batch
+// of eth_getTransactionReceipt calls, once the transactions are known.
+// Once eth_getBlockReceipts is found to be unsupported it is no longer attempted.
+//
+// The block is verified against the requested hash, and the receipts against the block.
+type BatchFetcher struct {
+	RPC BatchCaller
+
+	// set to 1 (atomic) when eth_getBlockReceipts is unsupported by the endpoint
+	blockReceiptsUnsupported uint32
+}
+
+// rpcBlockTxs is the part of a RPC block that is not part of the header
+type rpcBlockTxs struct {
+	Transactions types.Transactions `json:"transactions"`
+}
+
+// Fetch fetches the block with the given ID, and its receipts in transaction order.
+func (bf *BatchFetcher) Fetch(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+	var raw json.RawMessage
+	var receipts []*types.Receipt
+	batch := []rpc.BatchElem{{Method: "eth_getBlockByHash", Args: []interface{}{id.Hash, true}, Result: &raw}}
+	withReceipts := atomic.LoadUint32(&bf.blockReceiptsUnsupported) == 0
+	if withReceipts {
+		batch = append(batch, rpc.BatchElem{Method: "eth_getBlockReceipts", Args: []interface{}{id.Hash}, Result: &receipts})
+	}
+	if err := bf.RPC.BatchCallContext(ctx, batch); err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch block %s: %w", id, ClassifyFetchErr(err))
+	}
+	if err := batch[0].Error; err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch block %s: %w", id, ClassifyFetchErr(err))
+	}
+	block, err := decodeRPCBlock(raw)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode block %s: %w", id, err)
+	}
+	if block.Hash() != id.Hash {
+		return nil, nil, fmt.Errorf("fetched block %s does not match requested block %s: %w", block.Hash(), id, InvalidResponseErr)
+	}
+
+	if withReceipts {
+		err := batch[1].Error
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundErrCode {
+			atomic.StoreUint32(&bf.blockReceiptsUnsupported, 1)
+			withReceipts = false
+		} else if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch receipts of block %s: %w", id, ClassifyFetchErr(err))
+		}
+	}
+	if !withReceipts {
+		receipts, err = bf.txReceipts(ctx, block.Transactions())
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to fetch receipts of block %s: %w", id, err)
+		}
+	}
+	if err := VerifyReceipts(block, receipts); err != nil {
+		return nil, nil, err
+	}
+	return block, receipts, nil
+}
+
+// txReceipts fetches the receipts of the transactions one by one, in a single batch
+func (bf *BatchFetcher) txReceipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	if len(txs) == 0 {
+		return nil, nil
+	}
+	receipts := make([]*types.Receipt, len(txs))
+	batch := make([]rpc.BatchElem, len(txs))
+	for i, tx := range txs {
+		batch[i] = rpc.BatchElem{Method: "eth_getTransactionReceipt", Args: []interface{}{tx.Hash()}, Result: &receipts[i]}
+	}
+	if err := bf.RPC.BatchCallContext(ctx, batch); err != nil {
+		return nil, ClassifyFetchErr(err)
+	}
+	for i, elem := range batch {
+		if elem.Error != nil {
+			return nil, fmt.Errorf("failed to fetch receipt %d of tx %s: %w", i, txs[i].Hash(), ClassifyFetchErr(elem.Error))
+		}
+	}
+	return receipts, nil
+}
+
+// decodeRPCBlock decodes a JSON-RPC block with full transactions, and checks the transactions against the header.
+func decodeRPCBlock(raw json.RawMessage) (*types.Block, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, ClassifyFetchErr(ethereum.NotFound)
+	}
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %v: %w", err, InvalidResponseErr)
+	}
+	var body rpcBlockTxs
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, fmt.Errorf("invalid transactions: %v: %w", err, InvalidResponseErr)
+	}
+	if computed := types.DeriveSha(body.Transactions, trie.NewStackTrie(nil)); computed != header.TxHash {
+		return nil, fmt.Errorf("transactions root %s does not match header transactions root %s: %w", computed, header.TxHash, InvalidResponseErr)
+	}
+	return types.NewBlockWithHeader(&header).WithBody(body.Transactions, nil), nil
+}
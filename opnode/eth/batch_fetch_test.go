@@ -0,0 +1,118 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBatchRPC serves a single block with receipts, JSON encoded like a RPC endpoint would
+type testBatchRPC struct {
+	t        *testing.T
+	block    *types.Block
+	receipts []*types.Receipt
+	// txs to serve instead of the block transactions
+	txs types.Transactions
+
+	noBlockReceipts bool
+	batches         int
+}
+
+func (r *testBatchRPC) result(elem *rpc.BatchElem, v interface{}) {
+	data, err := json.Marshal(v)
+	require.NoError(r.t, err)
+	elem.Error = json.Unmarshal(data, elem.Result)
+}
+
+func (r *testBatchRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	r.batches++
+	for i := range b {
+		elem := &b[i]
+		switch elem.Method {
+		case "eth_getBlockByHash":
+			if elem.Args[0].(common.Hash) != r.block.Hash() {
+				r.result(elem, nil)
+				continue
+			}
+			var fields map[string]interface{}
+			data, err := json.Marshal(r.block.Header())
+			require.NoError(r.t, err)
+			require.NoError(r.t, json.Unmarshal(data, &fields))
+			txs := r.block.Transactions()
+			if r.txs != nil {
+				txs = r.txs
+			}
+			fields["transactions"] = txs
+			r.result(elem, fields)
+		case "eth_getBlockReceipts":
+			if r.noBlockReceipts {
+				elem.Error = methodNotFoundErr{}
+				continue
+			}
+			r.result(elem, r.receipts)
+		case "eth_getTransactionReceipt":
+			for _, rec := range r.receipts {
+				if rec.TxHash == elem.Args[0].(common.Hash) {
+					r.result(elem, rec)
+				}
+			}
+		default:
+			r.t.Fatalf("unexpected method %s", elem.Method)
+		}
+	}
+	return nil
+}
+
+func TestBatchFetcher(t *testing.T) {
+	block, receipts := testBlockWithReceipts(5)
+	src := &testBatchRPC{t: t, block: block, receipts: receipts}
+	bf := &BatchFetcher{RPC: src}
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+
+	bl, recs, err := bf.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), bl.Hash())
+	assert.Len(t, recs, 5)
+	assert.Equal(t, 1, src.batches, "a single round trip")
+
+	_, _, err = bf.Fetch(context.Background(), BlockID{Hash: common.Hash{1}, Number: 1})
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+}
+
+func TestBatchFetcher_TxReceipts(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	src := &testBatchRPC{t: t, block: block, receipts: receipts, noBlockReceipts: true}
+	bf := &BatchFetcher{RPC: src}
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+
+	_, recs, err := bf.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Len(t, recs, 3)
+	assert.Equal(t, 2, src.batches, "receipts are fetched in a second batch")
+
+	// eth_getBlockReceipts is not attempted again
+	_, _, err = bf.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, 4, src.batches)
+}
+
+func TestBatchFetcher_Invalid(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+
+	// transactions that do not match the header
+	src := &testBatchRPC{t: t, block: block, receipts: receipts, txs: block.Transactions()[:2]}
+	_, _, err := (&BatchFetcher{RPC: src}).Fetch(context.Background(), id)
+	assert.ErrorIs(t, err, InvalidResponseErr)
+
+	// receipts that do not match the block
+	src = &testBatchRPC{t: t, block: block, receipts: receipts[:2]}
+	_, _, err = (&BatchFetcher{RPC: src}).Fetch(context.Background(), id)
+	assert.ErrorIs(t, err, InvalidResponseErr)
+}
@@ -0,0 +1,65 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// cachedBlock is a block with all its receipts
+type cachedBlock struct {
+	block    *types.Block
+	receipts []*types.Receipt
+}
+
+// ChainCache caches blocks with their headers and receipts by block hash,
+// so re-derivation after a reorg or pipeline reset does not refetch blocks that were already processed.
+//
+// Cached data is validated before reuse: the block by block hash, the receipts against the block receipts-root.
+// Invalid entries are evicted and refetched. Fetched data is validated the same way before it is cached.
+type ChainCache struct {
+	src FetchSource
+
+	// block hash -> cachedBlock
+	blocks *lru.Cache
+}
+
+var _ FetchSource = (*ChainCache)(nil)
+
+// NewChainCache creates a ChainCache that keeps up to size blocks with their receipts.
+func NewChainCache(src FetchSource, size int) (*ChainCache, error) {
+	blocks, err := lru.New(size)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create blocks cache: %v", err)
+	}
+	return &ChainCache{src: src, blocks: blocks}, nil
+}
+
+func (c *ChainCache) Fetch(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+	if v, ok := c.blocks.Get(id.Hash); ok {
+		if cached := v.(cachedBlock); verifyBlock(id, cached.block, cached.receipts) == nil {
+			return cached.block, cached.receipts, nil
+		}
+		c.blocks.Remove(id.Hash)
+	}
+	block, receipts, err := c.src.Fetch(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := verifyBlock(id, block, receipts); err != nil {
+		return nil, nil, err
+	}
+	c.blocks.Add(id.Hash, cachedBlock{block: block, receipts: receipts})
+	return block, receipts, nil
+}
+
+// verifyBlock checks that the block has the given hash, and that the receipts match the block.
+func verifyBlock(id BlockID, block *types.Block, receipts []*types.Receipt) error {
+	if block.Hash() != id.Hash {
+		return fmt.Errorf("fetched block %s does not match requested block %s: %w", block.Hash(), id, InvalidResponseErr)
+	}
+	return VerifyReceipts(block, receipts)
+}
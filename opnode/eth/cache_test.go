@@ -0,0 +1,57 @@
+package eth
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChainCache(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+	var calls int
+	src := FetchFn(func(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+		calls += 1
+		return block, receipts, nil
+	})
+	c, err := NewChainCache(src, 10)
+	require.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		bl, got, err := c.Fetch(context.Background(), id)
+		require.NoError(t, err)
+		assert.Equal(t, block.Hash(), bl.Hash())
+		assert.Equal(t, receipts, got)
+	}
+	assert.Equal(t, 1, calls)
+
+	// corrupted cache entries are refetched
+	c.blocks.Add(block.Hash(), cachedBlock{block: block, receipts: receipts[:2]})
+	_, got, err := c.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, receipts, got)
+	assert.Equal(t, 2, calls)
+}
+
+func TestChainCache_InvalidSource(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+	c, err := NewChainCache(FetchFn(func(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+		return block, receipts[1:], nil
+	}), 10)
+	require.NoError(t, err)
+	_, _, err = c.Fetch(context.Background(), id)
+	assert.ErrorIs(t, err, InvalidResponseErr)
+	assert.Equal(t, 0, c.blocks.Len(), "invalid receipts are not cached")
+
+	other, otherReceipts := testBlockWithReceipts(2)
+	c, err = NewChainCache(FetchFn(func(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+		return other, otherReceipts, nil
+	}), 10)
+	require.NoError(t, err)
+	_, _, err = c.Fetch(context.Background(), id)
+	assert.ErrorIs(t, err, InvalidResponseErr, "block must match the requested hash")
+}
@@ -26,7 +26,7 @@ func CanonicalChain(l1Src HeaderByNumberSource) BlockLinkByNumber {
 		header, err := l1Src.HeaderByNumber(ctx, big.NewInt(int64(num)))
 		if err != nil {
 			// w%: wrap the error, we still need to detect if a canonical block is not found, a.k.a. end of chain.
-			return BlockID{}, BlockID{}, fmt.Errorf("failed to determine block-hash of height %d, could not get header: %w", num, err)
+			return BlockID{}, BlockID{}, fmt.Errorf("failed to determine block-hash of height %d, could not get header: %w", num, ClassifyFetchErr(err))
 		}
 		parentNum := num
 		if parentNum > 0 {
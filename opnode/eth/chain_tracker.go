@@ -0,0 +1,224 @@
+package eth
+
+import (
+	"context"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// ChainTracker maintains a hash-linked cache of the recent canonical chain, fed by head signals, e.g. with WatchHeadChanges.
+//
+// The cached canonical chain is always linked by parent hashes, from the latest head down to the oldest cached block:
+// when a head signal does not link up with the cached chain, the unlinked older blocks are dropped.
+// The links of blocks that are reorged out are kept (within the cache size), to find common ancestors with,
+// and to reconstruct reorgs locally without extra RPC round trips, as long as the reorg is not deeper than the cache.
+//
+// ChainTracker implements BlockLinkByNumber and HeaderByHashSource,
+// and falls back to the given sources for blocks outside the cache.
+type ChainTracker struct {
+	mu sync.RWMutex
+
+	fallback BlockLinkByNumber
+	headers  HeaderByHashSource
+	size     uint64
+
+	// canonical chain, by block number
+	byNumber map[uint64]common.Hash
+	// all known blocks, canonical or not, by hash, to their parent
+	parents map[common.Hash]BlockID
+	// full headers of the known blocks, if the head signals included them
+	headerByHash map[common.Hash]*types.Header
+
+	head   BlockID
+	lowest uint64
+}
+
+var _ BlockLinkByNumber = (*ChainTracker)(nil)
+var _ HeaderByHashSource = (*ChainTracker)(nil)
+
+// NewChainTracker creates a ChainTracker that caches the size most recent canonical blocks.
+// The fallback sources are optional, to fetch the block links and headers that are not cached.
+func NewChainTracker(fallback BlockLinkByNumber, headers HeaderByHashSource, size uint64) *ChainTracker {
+	if size == 0 {
+		size = 1
+	}
+	return &ChainTracker{
+		fallback:     fallback,
+		headers:      headers,
+		size:         size,
+		byNumber:     make(map[uint64]common.Hash),
+		parents:      make(map[common.Hash]BlockID),
+		headerByHash: make(map[common.Hash]*types.Header),
+	}
+}
+
+// AddHead updates the canonical chain with the new head. It implements HeadSignalFn.
+func (ct *ChainTracker) AddHead(sig HeadSignal) {
+	ct.mu.Lock()
+	defer ct.mu.Unlock()
+
+	ct.parents[sig.Self.Hash] = sig.Parent
+	if sig.Header != nil {
+		ct.headerByHash[sig.Self.Hash] = sig.Header
+	}
+
+	// reorg to a shorter chain: remove the blocks above the new head
+	for n := sig.Self.Number + 1; n <= ct.head.Number; n++ {
+		delete(ct.byNumber, n)
+	}
+	ct.head = sig.Self
+
+	// link the new head with the cached chain, by walking back the parents until the canonical chain matches again
+	cur := sig.Self
+	for {
+		if h, ok := ct.byNumber[cur.Number]; ok && h == cur.Hash {
+			break // the remaining older chain is already linked
+		}
+		ct.byNumber[cur.Number] = cur.Hash
+		parent, ok := ct.parents[cur.Hash]
+		if !ok || parent == (BlockID{}) || cur.Number == 0 {
+			// the chain is not linked further, drop the older blocks
+			for n := range ct.byNumber {
+				if n < cur.Number {
+					delete(ct.byNumber, n)
+				}
+			}
+			break
+		}
+		cur = parent
+	}
+	ct.lowest = sig.Self.Number
+	for n := range ct.byNumber {
+		if n < ct.lowest {
+			ct.lowest = n
+		}
+	}
+	ct.prune()
+}
+
+// prune drops the blocks that are older than the cache size
+func (ct *ChainTracker) prune() {
+	if ct.head.Number < ct.size {
+		return
+	}
+	min := ct.head.Number - ct.size + 1
+	for ; ct.lowest < min; ct.lowest++ {
+		delete(ct.byNumber, ct.lowest)
+	}
+	for h, parent := range ct.parents {
+		if parent.Number+1 < min {
+			delete(ct.parents, h)
+			delete(ct.headerByHash, h)
+		}
+	}
+}
+
+// Head returns the latest head
+func (ct *ChainTracker) Head() BlockID {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+	return ct.head
+}
+
+// Get returns the cached canonical block at the given number, or false if it is not cached.
+func (ct *ChainTracker) Get(num uint64) (BlockID, bool) {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+	h, ok := ct.byNumber[num]
+	return BlockID{Hash: h, Number: num}, ok
+}
+
+// IsCanonical returns true if the block is part of the cached canonical chain.
+// Blocks older than the cache are not considered canonical.
+func (ct *ChainTracker) IsCanonical(id BlockID) bool {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+	h, ok := ct.byNumber[id.Number]
+	return ok && h == id.Hash
+}
+
+// lowestCanonical returns the number of the oldest cached canonical block
+func (ct *ChainTracker) lowestCanonical() uint64 {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+	return ct.lowest
+}
+
+// FindCommonAncestor finds the latest common ancestor of the two blocks, by walking back their parents.
+// False is returned if the parents of either block are not known.
+func (ct *ChainTracker) FindCommonAncestor(a BlockID, b BlockID) (BlockID, bool) {
+	ancestor, _, ok := ct.FindDivergence(a, b)
+	return ancestor, ok
+}
+
+// FindDivergence finds the latest common ancestor of the two blocks, like FindCommonAncestor,
+// and also returns the blocks of the chain of b after the common ancestor, from oldest to most recent.
+// If a is an ancestor of b, then the chain of b simply extends a, and a is returned as common ancestor.
+func (ct *ChainTracker) FindDivergence(a BlockID, b BlockID) (ancestor BlockID, newChain []BlockID, ok bool) {
+	ct.mu.RLock()
+	defer ct.mu.RUnlock()
+	for a != b {
+		if a.Number >= b.Number {
+			a, ok = ct.parentOf(a)
+		} else {
+			newChain = append(newChain, b)
+			b, ok = ct.parentOf(b)
+		}
+		if !ok {
+			return BlockID{}, nil, false
+		}
+	}
+	// reverse to order the new chain from oldest to most recent
+	for i, j := 0, len(newChain)-1; i < j; i, j = i+1, j-1 {
+		newChain[i], newChain[j] = newChain[j], newChain[i]
+	}
+	return a, newChain, true
+}
+
+func (ct *ChainTracker) parentOf(id BlockID) (BlockID, bool) {
+	parent, ok := ct.parents[id.Hash]
+	if !ok || id.Number == 0 || parent == (BlockID{}) {
+		return BlockID{}, false
+	}
+	return parent, true
+}
+
+// BlockLinkByNumber returns the canonical block at the given number and its parent,
+// from the cache, or from the fallback source if the block or its parent is not cached.
+func (ct *ChainTracker) BlockLinkByNumber(ctx context.Context, num uint64) (self BlockID, parent BlockID, err error) {
+	ct.mu.RLock()
+	h, ok := ct.byNumber[num]
+	if ok {
+		self = BlockID{Hash: h, Number: num}
+		if num == 0 {
+			ct.mu.RUnlock()
+			return self, BlockID{}, nil
+		}
+		if p, ok := ct.parents[h]; ok && p != (BlockID{}) {
+			ct.mu.RUnlock()
+			return self, p, nil
+		}
+	}
+	ct.mu.RUnlock()
+	if ct.fallback == nil {
+		return BlockID{}, BlockID{}, ClassifyFetchErr(ethereum.NotFound)
+	}
+	return ct.fallback.BlockLinkByNumber(ctx, num)
+}
+
+// HeaderByHash returns the cached header of a known block, or fetches it from the fallback source if it is not cached.
+func (ct *ChainTracker) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	ct.mu.RLock()
+	h, ok := ct.headerByHash[hash]
+	ct.mu.RUnlock()
+	if ok {
+		return h, nil
+	}
+	if ct.headers == nil {
+		return nil, ClassifyFetchErr(ethereum.NotFound)
+	}
+	return ct.headers.HeaderByHash(ctx, hash)
+}
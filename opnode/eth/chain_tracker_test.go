@@ -0,0 +1,147 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errFallback = errors.New("fallback")
+
+func fallbackChain() BlockLinkByNumber {
+	return BlockLinkByNumberFn(func(ctx context.Context, num uint64) (BlockID, BlockID, error) {
+		return BlockID{}, BlockID{}, errFallback
+	})
+}
+
+func TestChainTracker(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	b := testChain(a[5], 2, 1) // fork after block 5, shorter than a
+	ct := NewChainTracker(fallbackChain(), nil, 100)
+
+	for _, h := range a {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	for i, h := range a {
+		got, ok := ct.Get(uint64(i))
+		require.True(t, ok)
+		assert.Equal(t, id(h), got)
+		assert.True(t, ct.IsCanonical(id(h)))
+	}
+	self, parent, err := ct.BlockLinkByNumber(context.Background(), 9)
+	require.NoError(t, err)
+	assert.Equal(t, id(a[9]), self)
+	assert.Equal(t, id(a[8]), parent)
+
+	// reorg to the shorter fork
+	for _, h := range b {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	assert.Equal(t, id(b[1]), ct.Head())
+	assert.False(t, ct.IsCanonical(id(a[6])))
+	assert.True(t, ct.IsCanonical(id(b[0])))
+	assert.True(t, ct.IsCanonical(id(a[5])))
+	_, ok := ct.Get(8)
+	assert.False(t, ok, "blocks above the new head are not canonical")
+	_, _, err = ct.BlockLinkByNumber(context.Background(), 8)
+	assert.ErrorIs(t, err, errFallback)
+
+	ancestor, ok := ct.FindCommonAncestor(id(a[9]), id(b[1]))
+	require.True(t, ok)
+	assert.Equal(t, id(a[5]), ancestor)
+	ancestor, ok = ct.FindCommonAncestor(id(a[3]), id(b[1]))
+	require.True(t, ok)
+	assert.Equal(t, id(a[3]), ancestor)
+
+	// reorg back to the original chain, by skipping blocks: the chain is relinked through the known parents
+	ct.AddHead(HeadSignalFromHeader(a[9]))
+	for _, h := range a {
+		assert.True(t, ct.IsCanonical(id(h)))
+	}
+	assert.False(t, ct.IsCanonical(id(b[0])))
+}
+
+func TestChainTrackerGap(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	b := testChain(a[2], 7, 1) // fork after block 2
+	ct := NewChainTracker(fallbackChain(), nil, 100)
+	for _, h := range a[:6] {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	// a head of a fork, with unknown parents, drops the unlinked older blocks
+	ct.AddHead(HeadSignalFromHeader(b[6]))
+	assert.True(t, ct.IsCanonical(id(b[6])))
+	assert.True(t, ct.IsCanonical(id(b[5])), "the parent is known from the head signal")
+	for _, h := range a[:6] {
+		assert.False(t, ct.IsCanonical(id(h)))
+	}
+	_, ok := ct.FindCommonAncestor(id(a[5]), id(b[6]))
+	assert.False(t, ok, "the fork is not linked")
+}
+
+func TestChainTrackerPrune(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	ct := NewChainTracker(fallbackChain(), nil, 4)
+	for _, h := range a {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	for i, h := range a {
+		assert.Equal(t, i >= 6, ct.IsCanonical(id(h)), "block %d", i)
+	}
+	_, ok := ct.FindCommonAncestor(id(a[9]), id(a[2]))
+	assert.False(t, ok)
+}
+
+func TestChainTrackerFindDivergence(t *testing.T) {
+	a := testChain(nil, 8, 0)
+	b := testChain(a[4], 2, 1) // fork after block 4, shorter than a
+	ct := NewChainTracker(fallbackChain(), nil, 16)
+	for _, h := range a[2:] {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	for _, h := range b {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+
+	ancestor, newChain, ok := ct.FindDivergence(id(a[7]), id(b[1]))
+	require.True(t, ok)
+	assert.Equal(t, id(a[4]), ancestor)
+	assert.Equal(t, []BlockID{id(b[0]), id(b[1])}, newChain)
+
+	ancestor, newChain, ok = ct.FindDivergence(id(a[5]), id(a[7]))
+	require.True(t, ok)
+	assert.Equal(t, id(a[5]), ancestor, "a simple extension")
+	assert.Equal(t, []BlockID{id(a[6]), id(a[7])}, newChain)
+
+	ancestor, newChain, ok = ct.FindDivergence(id(a[7]), id(a[7]))
+	require.True(t, ok)
+	assert.Equal(t, id(a[7]), ancestor)
+	assert.Empty(t, newChain)
+
+	// the parent of the oldest known block is known, but not beyond that
+	_, _, ok = ct.FindDivergence(id(a[0]), id(b[1]))
+	assert.False(t, ok, "divergence point older than the cache")
+}
+
+func TestChainTrackerHeaderByHash(t *testing.T) {
+	a := testChain(nil, 6, 0)
+	ct := NewChainTracker(fallbackChain(), nil, 4)
+	for _, h := range a {
+		ct.AddHead(HeadSignalFromHeader(h))
+	}
+	h, err := ct.HeaderByHash(context.Background(), a[4].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, a[4], h)
+	_, err = ct.HeaderByHash(context.Background(), a[0].Hash())
+	assert.ErrorIs(t, err, ethereum.NotFound, "pruned headers are not kept")
+
+	ct = NewChainTracker(fallbackChain(), headersByHash(a), 4)
+	ct.AddHead(HeadSignalFromHeader(a[5]))
+	h, err = ct.HeaderByHash(context.Background(), a[0].Hash())
+	require.NoError(t, err)
+	assert.Equal(t, a[0], h, "headers that are not cached are fetched")
+}
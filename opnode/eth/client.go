@@ -0,0 +1,231 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	DefaultRPCTimeout      = time.Second * 10
+	DefaultRPCRetries      = 3
+	DefaultRPCRetryBackoff = time.Millisecond * 500
+)
+
+// RPC is the subset of the *rpc.Client methods that the Client is built on.
+type RPC interface {
+	RPCCaller
+	BatchCaller
+	EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error)
+	Close()
+}
+
+// RPCRequestFn is called before every RPC call, e.g. to log the request.
+type RPCRequestFn func(method string, args []interface{})
+
+// RPCResponseFn is called after every RPC call attempt, with the duration of the attempt, e.g. to log the response.
+type RPCResponseFn func(method string, duration time.Duration, err error)
+
+// Client is a L1 client on top of a RPC client, to use instead of the raw ethclient and RPC clients:
+// every call is limited by a timeout, and calls that fail with a TemporaryRPCErr are retried a bounded number of times.
+// The returned errors are classified with ClassifyFetchErr.
+//
+// Subscriptions are not retried, see Resubscriber to keep a subscription alive.
+type Client struct {
+	rpc RPC
+
+	// Timeout limits the duration of each call attempt. No timeout if zero.
+	Timeout time.Duration
+	// MaxRetries is the number of times a call is retried after a temporary failure.
+	MaxRetries int
+	// RetryBackoff is the delay before the first retry, doubled for every next retry.
+	RetryBackoff time.Duration
+
+	// OnRequest is optional, to log the requests
+	OnRequest RPCRequestFn
+	// OnResponse is optional, to log the responses
+	OnResponse RPCResponseFn
+}
+
+var (
+	_ L1Source          = (*Client)(nil)
+	_ BlockNumberSource = (*Client)(nil)
+	_ RPCCaller         = (*Client)(nil)
+	_ BatchCaller       = (*Client)(nil)
+	_ LogSubscriber     = (*Client)(nil)
+)
+
+// NewClient wraps the RPC client, with the default timeout and retries.
+func NewClient(rpc RPC) *Client {
+	return &Client{
+		rpc:          rpc,
+		Timeout:      DefaultRPCTimeout,
+		MaxRetries:   DefaultRPCRetries,
+		RetryBackoff: DefaultRPCRetryBackoff,
+	}
+}
+
+// retry calls fn until it does not fail with a temporary error, or the retries are exhausted.
+func (c *Client) retry(ctx context.Context, method string, args []interface{}, fn func(ctx context.Context) error) error {
+	if c.OnRequest != nil {
+		c.OnRequest(method, args)
+	}
+	backoff := c.RetryBackoff
+	for i := 0; ; i++ {
+		start := time.Now()
+		err := c.attempt(ctx, fn)
+		if c.OnResponse != nil {
+			c.OnResponse(method, time.Since(start), err)
+		}
+		if err == nil || i >= c.MaxRetries || !errors.Is(err, TemporaryRPCErr) || ctx.Err() != nil {
+			return err
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		}
+		backoff *= 2
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, fn func(ctx context.Context) error) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+	return ClassifyFetchErr(fn(ctx))
+}
+
+func (c *Client) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	return c.retry(ctx, method, args, func(ctx context.Context) error {
+		return c.rpc.CallContext(ctx, result, method, args...)
+	})
+}
+
+// BatchCallContext sends the batch, and retries the whole batch if the batch fails with a temporary error.
+// Errors of individual batch elements are not retried.
+func (c *Client) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return c.retry(ctx, "batch", nil, func(ctx context.Context) error {
+		return c.rpc.BatchCallContext(ctx, b)
+	})
+}
+
+// callNotNull calls the method, and returns ethereum.NotFound (classified) if the result is null
+func (c *Client) callNotNull(ctx context.Context, result *json.RawMessage, method string, args ...interface{}) error {
+	if err := c.CallContext(ctx, result, method, args...); err != nil {
+		return err
+	}
+	if len(*result) == 0 || string(*result) == "null" {
+		return ClassifyFetchErr(ethereum.NotFound)
+	}
+	return nil
+}
+
+func (c *Client) header(ctx context.Context, method string, args ...interface{}) (*types.Header, error) {
+	var raw json.RawMessage
+	if err := c.callNotNull(ctx, &raw, method, args...); err != nil {
+		return nil, err
+	}
+	var header types.Header
+	if err := json.Unmarshal(raw, &header); err != nil {
+		return nil, fmt.Errorf("invalid header: %v: %w", err, InvalidResponseErr)
+	}
+	return &header, nil
+}
+
+func (c *Client) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return c.header(ctx, "eth_getBlockByHash", hash, false)
+}
+
+func (c *Client) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return c.header(ctx, "eth_getBlockByNumber", toBlockNumArg(number), false)
+}
+
+func (c *Client) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	var raw json.RawMessage
+	if err := c.callNotNull(ctx, &raw, "eth_getBlockByHash", hash, true); err != nil {
+		return nil, err
+	}
+	return decodeRPCBlock(raw)
+}
+
+func (c *Client) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	var raw json.RawMessage
+	if err := c.callNotNull(ctx, &raw, "eth_getTransactionReceipt", txHash); err != nil {
+		return nil, err
+	}
+	var receipt types.Receipt
+	if err := json.Unmarshal(raw, &receipt); err != nil {
+		return nil, fmt.Errorf("invalid receipt: %v: %w", err, InvalidResponseErr)
+	}
+	return &receipt, nil
+}
+
+func (c *Client) BlockNumber(ctx context.Context) (uint64, error) {
+	var num hexutil.Uint64
+	if err := c.CallContext(ctx, &num, "eth_blockNumber"); err != nil {
+		return 0, err
+	}
+	return uint64(num), nil
+}
+
+func (c *Client) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub, err := c.rpc.EthSubscribe(ctx, ch, "newHeads")
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// SubscribeFilterLogs subscribes to the logs that match the query, as they are included in new blocks.
+// Logs of blocks that are reorged out are sent again with Removed set.
+func (c *Client) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	sub, err := c.rpc.EthSubscribe(ctx, ch, "logs", toFilterArg(q))
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+func (c *Client) Close() {
+	c.rpc.Close()
+}
+
+// toFilterArg encodes the filter query of a logs subscription, like ethclient does
+func toFilterArg(q ethereum.FilterQuery) interface{} {
+	arg := map[string]interface{}{
+		"address": q.Addresses,
+		"topics":  q.Topics,
+	}
+	if q.BlockHash != nil {
+		arg["blockHash"] = *q.BlockHash
+		return arg
+	}
+	if q.FromBlock != nil {
+		arg["fromBlock"] = toBlockNumArg(q.FromBlock)
+	}
+	if q.ToBlock != nil {
+		arg["toBlock"] = toBlockNumArg(q.ToBlock)
+	}
+	return arg
+}
+
+func toBlockNumArg(number *big.Int) string {
+	if number == nil {
+		return "latest"
+	}
+	return hexutil.EncodeBig(number)
+}
@@ -0,0 +1,117 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testRPC answers calls with the JSON encoding of the next result, or fails with the next error
+type testRPC struct {
+	t       *testing.T
+	errs    []error
+	results []interface{}
+	calls   []string
+	args    [][]interface{}
+}
+
+func (r *testRPC) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	_, hasDeadline := ctx.Deadline()
+	assert.True(r.t, hasDeadline, "calls have a timeout")
+	r.calls = append(r.calls, method)
+	r.args = append(r.args, args)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		if err != nil {
+			return err
+		}
+	}
+	var res interface{}
+	if len(r.results) > 0 {
+		res = r.results[0]
+		r.results = r.results[1:]
+	}
+	data, err := json.Marshal(res)
+	require.NoError(r.t, err)
+	return json.Unmarshal(data, result)
+}
+
+func (r *testRPC) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	return errors.New("not supported")
+}
+
+func (r *testRPC) EthSubscribe(ctx context.Context, channel interface{}, args ...interface{}) (*rpc.ClientSubscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func (r *testRPC) Close() {}
+
+func testClient(r *testRPC) *Client {
+	cl := NewClient(r)
+	cl.RetryBackoff = time.Millisecond
+	return cl
+}
+
+func TestClient_Retries(t *testing.T) {
+	h := testHeader(5, common.Hash{1}, 0)
+	r := &testRPC{t: t, errs: []error{errors.New("connection reset"), errors.New("timeout"), nil}, results: []interface{}{h}}
+	cl := testClient(r)
+	var responses []error
+	cl.OnResponse = func(method string, d time.Duration, err error) {
+		assert.Equal(t, "eth_getBlockByNumber", method)
+		responses = append(responses, err)
+	}
+	got, err := cl.HeaderByNumber(context.Background(), big.NewInt(5))
+	require.NoError(t, err)
+	assert.Equal(t, h.Hash(), got.Hash())
+	assert.Len(t, responses, 3, "two temporary failures are retried")
+	assert.Equal(t, []interface{}{"0x5", false}, r.args[0])
+
+	// the retries are bounded
+	r = &testRPC{t: t, errs: []error{errors.New("a"), errors.New("b"), errors.New("c"), errors.New("d"), errors.New("e")}}
+	cl = testClient(r)
+	_, err = cl.BlockNumber(context.Background())
+	assert.ErrorIs(t, err, TemporaryRPCErr)
+	assert.Len(t, r.calls, DefaultRPCRetries+1)
+}
+
+func TestClient_NotFound(t *testing.T) {
+	r := &testRPC{t: t}
+	cl := testClient(r)
+	var requests []string
+	cl.OnRequest = func(method string, args []interface{}) {
+		requests = append(requests, method)
+	}
+	_, err := cl.HeaderByNumber(context.Background(), nil)
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	_, err = cl.TransactionReceipt(context.Background(), common.Hash{})
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+	assert.Equal(t, []string{"eth_getBlockByNumber", "eth_getTransactionReceipt"}, r.calls, "not found is not retried")
+	assert.Equal(t, r.calls, requests)
+	assert.Equal(t, []interface{}{"latest", false}, r.args[0])
+}
+
+func TestClient_BlockByHash(t *testing.T) {
+	block, _ := testBlockWithReceipts(2)
+	br := &testBatchRPC{t: t, block: block}
+	var raw json.RawMessage
+	req := []rpc.BatchElem{{Method: "eth_getBlockByHash", Args: []interface{}{block.Hash(), true}, Result: &raw}}
+	require.NoError(t, br.BatchCallContext(context.Background(), req))
+
+	cl := testClient(&testRPC{t: t, results: []interface{}{raw}})
+	got, err := cl.BlockByHash(context.Background(), block.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), got.Hash())
+	assert.Len(t, got.Transactions(), 2)
+}
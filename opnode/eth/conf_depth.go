@@ -0,0 +1,45 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+)
+
+// ConfDepth delays head signals by a number of confirmations:
+// for every new head, the canonical block Depth blocks below it is signalled instead,
+// so consumers do not process blocks that are likely to be reorged out.
+//
+// The confirmed head is only signalled when it changes. A head with fewer than Depth blocks before it is not signalled.
+// A confirmed head that fails to be fetched is skipped, and picked up with the next head.
+type ConfDepth struct {
+	Depth   uint64
+	Headers HeaderByNumberSource
+
+	// last signalled confirmed head
+	last BlockID
+}
+
+// Wrap returns a HeadSignalFn that feeds the confirmed heads to fn.
+// With a zero Depth all heads are forwarded as-is.
+// The returned HeadSignalFn is not safe for concurrent use.
+func (cd *ConfDepth) Wrap(ctx context.Context, fn HeadSignalFn) HeadSignalFn {
+	return func(sig HeadSignal) {
+		if cd.Depth == 0 {
+			fn(sig)
+			return
+		}
+		if sig.Self.Number < cd.Depth {
+			return
+		}
+		header, err := cd.Headers.HeaderByNumber(ctx, new(big.Int).SetUint64(sig.Self.Number-cd.Depth))
+		if err != nil {
+			return
+		}
+		confirmed := HeadSignalFromHeader(header)
+		if confirmed.Self == cd.last {
+			return
+		}
+		cd.last = confirmed.Self
+		fn(confirmed)
+	}
+}
@@ -0,0 +1,61 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfDepth(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	b := testChain(a[6], 3, 1) // fork after block 6
+	canonical := a
+	headers := HeaderByNumberFn(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+		n := number.Uint64()
+		if n >= uint64(len(canonical)) {
+			return nil, ethereum.NotFound
+		}
+		return canonical[n], nil
+	})
+	var confirmed []BlockID
+	cd := &ConfDepth{Depth: 3, Headers: headers}
+	fn := cd.Wrap(context.Background(), func(sig HeadSignal) {
+		confirmed = append(confirmed, sig.Self)
+	})
+
+	// heads with less than 3 blocks before them are not confirmed yet
+	for _, h := range a[:3] {
+		fn(HeadSignalFromHeader(h))
+	}
+	assert.Empty(t, confirmed)
+	for _, h := range a[3:] {
+		fn(HeadSignalFromHeader(h))
+	}
+	assert.Equal(t, []BlockID{id(a[0]), id(a[1]), id(a[2]), id(a[3]), id(a[4]), id(a[5]), id(a[6])}, confirmed)
+
+	// a shallow reorg does not change the confirmed head
+	confirmed = nil
+	canonical = append(a[:7:7], b...)
+	fn(HeadSignalFromHeader(b[2]))
+	assert.Empty(t, confirmed)
+
+	// a deeper reorg does
+	canonical = append(a[:6:6], testChain(a[5], 4, 2)...)
+	fn(HeadSignalFromHeader(canonical[9]))
+	assert.Equal(t, []BlockID{id(canonical[6])}, confirmed)
+}
+
+func TestConfDepthZero(t *testing.T) {
+	a := testChain(nil, 2, 0)
+	var got []HeadSignal
+	cd := &ConfDepth{}
+	fn := cd.Wrap(context.Background(), func(sig HeadSignal) {
+		got = append(got, sig)
+	})
+	fn(HeadSignalFromHeader(a[1]))
+	assert.Equal(t, []HeadSignal{HeadSignalFromHeader(a[1])}, got)
+}
@@ -0,0 +1,60 @@
+package eth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum"
+)
+
+// Fetching L1 data fails with one of these errors (wrapped), so the caller can decide how to proceed.
+var (
+	// BlockNotFoundErr is returned when the requested data does not exist (yet): retry after the chain progressed.
+	BlockNotFoundErr = errors.New("block not found")
+	// TemporaryRPCErr is returned when the RPC failed, e.g. a network failure or timeout: retry later.
+	TemporaryRPCErr = errors.New("temporary RPC error")
+	// InvalidResponseErr is returned when the returned data is wrong, e.g. it does not match the requested hash:
+	// retrying with the same source will not help.
+	InvalidResponseErr = errors.New("invalid RPC response")
+)
+
+// fetchErr classifies an error as one of the fetching errors, while preserving the original error.
+type fetchErr struct {
+	kind error
+	err  error
+}
+
+func (e *fetchErr) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *fetchErr) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *fetchErr) Unwrap() error {
+	return e.err
+}
+
+// ClassifyFetchErr classifies an error returned by a L1 source as one of the fetching errors:
+// ethereum.NotFound as BlockNotFoundErr, and any other error as TemporaryRPCErr.
+// Nil, errors that are already classified, and context errors of the caller are returned as-is.
+func ClassifyFetchErr(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, BlockNotFoundErr), errors.Is(err, TemporaryRPCErr), errors.Is(err, InvalidResponseErr):
+		return err
+	case errors.Is(err, context.Canceled):
+		return err
+	case errors.Is(err, ethereum.NotFound):
+		return &fetchErr{kind: BlockNotFoundErr, err: err}
+	default:
+		return &fetchErr{kind: TemporaryRPCErr, err: err}
+	}
+}
+
+// IsRetryable returns true if fetching may succeed when retried later with the same source.
+func IsRetryable(err error) bool {
+	return errors.Is(err, BlockNotFoundErr) || errors.Is(err, TemporaryRPCErr)
+}
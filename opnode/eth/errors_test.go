@@ -0,0 +1,36 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassifyFetchErr(t *testing.T) {
+	assert.NoError(t, ClassifyFetchErr(nil))
+
+	err := ClassifyFetchErr(ethereum.NotFound)
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+	assert.ErrorIs(t, err, ethereum.NotFound, "the original error is preserved")
+	assert.True(t, IsRetryable(err))
+
+	rpcErr := errors.New("connection refused")
+	err = ClassifyFetchErr(rpcErr)
+	assert.ErrorIs(t, err, TemporaryRPCErr)
+	assert.ErrorIs(t, err, rpcErr)
+	assert.NotErrorIs(t, err, BlockNotFoundErr)
+	assert.True(t, IsRetryable(err))
+	assert.ErrorIs(t, ClassifyFetchErr(context.DeadlineExceeded), TemporaryRPCErr)
+
+	// already classified errors are not classified again
+	invalid := fmt.Errorf("bad header: %w", InvalidResponseErr)
+	assert.Equal(t, invalid, ClassifyFetchErr(invalid))
+	assert.False(t, IsRetryable(invalid))
+
+	// the caller cancelling is not a failure of the source
+	assert.Equal(t, context.Canceled, ClassifyFetchErr(context.Canceled))
+}
@@ -0,0 +1,264 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// DefaultHealthCheckInterval is the default interval to check the health of the sources of a FailoverL1Source at.
+const DefaultHealthCheckInterval = time.Second * 30
+
+// SourceFailureFn is called when a source of a FailoverL1Source fails, and is marked as unhealthy.
+type SourceFailureFn func(i int, err error)
+
+// FailoverL1Source implements L1Source with multiple redundant endpoints:
+// all requests and subscriptions go to the first healthy source, in the given order of preference.
+//
+// A source that fails a request is marked unhealthy, and the request is retried with the next source.
+// A source that fails a subscription is marked unhealthy, and the subscription continues with the next source,
+// without surfacing the error to the subscriber. Only when all the sources fail the error is returned.
+// Not-found results and cancelled requests are not failures of the source.
+//
+// Unhealthy sources are marked healthy again by a successful request or health check (see WatchHealth).
+// Requests then fail back to the preferred source, subscriptions stay with the source they switched to.
+type FailoverL1Source struct {
+	mu      sync.RWMutex
+	sources []L1Source
+	healthy []bool
+
+	// OnFailure is optional, to surface failures of individual sources
+	OnFailure SourceFailureFn
+}
+
+var _ L1Source = (*FailoverL1Source)(nil)
+
+func NewFailoverL1Source(sources []L1Source) *FailoverL1Source {
+	if len(sources) == 0 {
+		panic("need at least 1 source")
+	}
+	healthy := make([]bool, len(sources))
+	for i := range healthy {
+		healthy[i] = true
+	}
+	return &FailoverL1Source{sources: sources, healthy: healthy}
+}
+
+// Healthy returns true if source i is currently considered healthy.
+func (fs *FailoverL1Source) Healthy(i int) bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.healthy[i]
+}
+
+// AnyHealthy returns true if at least one source is currently considered healthy.
+func (fs *FailoverL1Source) AnyHealthy() bool {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	for _, ok := range fs.healthy {
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// order returns the indices of the healthy sources, followed by the unhealthy ones, as a last resort
+func (fs *FailoverL1Source) order() []int {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	out := make([]int, 0, len(fs.sources))
+	for i, ok := range fs.healthy {
+		if ok {
+			out = append(out, i)
+		}
+	}
+	for i, ok := range fs.healthy {
+		if !ok {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+func (fs *FailoverL1Source) mark(i int, err error) {
+	fs.mu.Lock()
+	fs.healthy[i] = err == nil
+	fs.mu.Unlock()
+	if err != nil && fs.OnFailure != nil {
+		fs.OnFailure(i, err)
+	}
+}
+
+// isSourceFailure returns false for errors that are not caused by the source
+func isSourceFailure(ctx context.Context, err error) bool {
+	return err != nil && !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil
+}
+
+// try calls fn with the sources in order of preference, until a source does not fail.
+func (fs *FailoverL1Source) try(ctx context.Context, fn func(src L1Source) error) (err error) {
+	return fs.tryIndex(ctx, func(i int) error {
+		return fn(fs.sources[i])
+	})
+}
+
+// tryIndex calls fn with the indices of the sources in order of preference, until a source does not fail.
+func (fs *FailoverL1Source) tryIndex(ctx context.Context, fn func(i int) error) (err error) {
+	for _, i := range fs.order() {
+		err = fn(i)
+		if !isSourceFailure(ctx, err) {
+			if err == nil {
+				fs.mark(i, nil)
+			}
+			return err
+		}
+		fs.mark(i, err)
+	}
+	return err
+}
+
+func (fs *FailoverL1Source) HeaderByHash(ctx context.Context, hash common.Hash) (out *types.Header, err error) {
+	err = fs.try(ctx, func(src L1Source) (err error) {
+		out, err = src.HeaderByHash(ctx, hash)
+		return
+	})
+	return
+}
+
+func (fs *FailoverL1Source) HeaderByNumber(ctx context.Context, number *big.Int) (out *types.Header, err error) {
+	err = fs.try(ctx, func(src L1Source) (err error) {
+		out, err = src.HeaderByNumber(ctx, number)
+		return
+	})
+	return
+}
+
+func (fs *FailoverL1Source) TransactionReceipt(ctx context.Context, txHash common.Hash) (out *types.Receipt, err error) {
+	err = fs.try(ctx, func(src L1Source) (err error) {
+		out, err = src.TransactionReceipt(ctx, txHash)
+		return
+	})
+	return
+}
+
+func (fs *FailoverL1Source) BlockByHash(ctx context.Context, hash common.Hash) (out *types.Block, err error) {
+	err = fs.try(ctx, func(src L1Source) (err error) {
+		out, err = src.BlockByHash(ctx, hash)
+		return
+	})
+	return
+}
+
+func (fs *FailoverL1Source) subscribe(ctx context.Context, ch chan<- *types.Header) (sub ethereum.Subscription, active int, err error) {
+	for _, i := range fs.order() {
+		sub, err = fs.sources[i].SubscribeNewHead(ctx, ch)
+		if err == nil {
+			return sub, i, nil
+		}
+		if ctx.Err() != nil {
+			return nil, 0, err
+		}
+		fs.mark(i, err)
+	}
+	return nil, 0, err
+}
+
+func (fs *FailoverL1Source) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	sub, active, err := fs.subscribe(ctx, ch)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		for {
+			select {
+			case err := <-sub.Err():
+				sub.Unsubscribe()
+				if err == nil {
+					return nil
+				}
+				fs.mark(active, err)
+				sub, active, err = fs.subscribe(ctx, ch)
+				if err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				sub.Unsubscribe()
+				return ctx.Err()
+			case <-quit:
+				sub.Unsubscribe()
+				return nil
+			}
+		}
+	}), nil
+}
+
+// CheckHealth checks all the sources by requesting their latest header, and marks them healthy or unhealthy.
+func (fs *FailoverL1Source) CheckHealth(ctx context.Context) {
+	for i, src := range fs.sources {
+		_, err := src.HeaderByNumber(ctx, nil)
+		if ctx.Err() != nil {
+			return
+		}
+		fs.mark(i, err)
+	}
+}
+
+// WatchHealth runs CheckHealth at the given interval, until the subscription is closed.
+func (fs *FailoverL1Source) WatchHealth(ctx context.Context, interval time.Duration) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				checkCtx, cancel := context.WithTimeout(ctx, interval)
+				fs.CheckHealth(checkCtx)
+				cancel()
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+func (fs *FailoverL1Source) Close() {
+	for _, src := range fs.sources {
+		src.Close()
+	}
+}
+
+// FailoverFetchSource implements FetchSource with a fetch source for each source of a FailoverL1Source,
+// to fetch blocks with their receipts from the same endpoint that the FailoverL1Source prefers.
+// An endpoint that fails a fetch is marked unhealthy, like a failed request of the FailoverL1Source,
+// and the fetch is retried with the next endpoint.
+type FailoverFetchSource struct {
+	fs       *FailoverL1Source
+	fetchers []FetchSource
+}
+
+var _ FetchSource = (*FailoverFetchSource)(nil)
+
+// NewFailoverFetchSource creates a FailoverFetchSource with a fetch source for each of the sources of fs, in the same order.
+func NewFailoverFetchSource(fs *FailoverL1Source, fetchers []FetchSource) *FailoverFetchSource {
+	if len(fetchers) != len(fs.sources) {
+		panic("need a fetch source for each source")
+	}
+	return &FailoverFetchSource{fs: fs, fetchers: fetchers}
+}
+
+func (ff *FailoverFetchSource) Fetch(ctx context.Context, id BlockID) (block *types.Block, receipts []*types.Receipt, err error) {
+	err = ff.fs.tryIndex(ctx, func(i int) (err error) {
+		block, receipts, err = ff.fetchers[i].Fetch(ctx, id)
+		return
+	})
+	return
+}
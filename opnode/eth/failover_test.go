@@ -0,0 +1,215 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestSource = errors.New("source failure")
+
+// failoverTestSource is a L1Source that serves a single header, and can be made to fail by tests
+type failoverTestSource struct {
+	L1Source
+	feedHeadSource
+
+	mu     sync.Mutex
+	header *types.Header
+	fail   bool
+	calls  int
+	// subscriptions fail after subscribing
+	dropSubs bool
+}
+
+func (s *failoverTestSource) setFail(fail bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.fail = fail
+}
+
+func (s *failoverTestSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	if s.fail {
+		return nil, errTestSource
+	}
+	return s.header, nil
+}
+
+func (s *failoverTestSource) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return nil, ethereum.NotFound
+}
+
+func (s *failoverTestSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail {
+		return nil, errTestSource
+	}
+	if s.dropSubs {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			return errTestSource
+		}), nil
+	}
+	return s.feedHeadSource.SubscribeNewHead(ctx, ch)
+}
+
+func (s *failoverTestSource) Close() {}
+
+func TestFailoverL1Source_Requests(t *testing.T) {
+	a := &failoverTestSource{header: testHeader(1, common.Hash{}, 0)}
+	b := &failoverTestSource{header: testHeader(1, common.Hash{}, 1)}
+	var failures []int
+	fs := NewFailoverL1Source([]L1Source{a, b})
+	fs.OnFailure = func(i int, err error) {
+		assert.ErrorIs(t, err, errTestSource)
+		failures = append(failures, i)
+	}
+	ctx := context.Background()
+
+	h, err := fs.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, a.header, h, "the preferred source is used first")
+
+	a.setFail(true)
+	h, err = fs.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, b.header, h, "fail over to the next source")
+	assert.False(t, fs.Healthy(0))
+	assert.Equal(t, []int{0}, failures)
+
+	// the unhealthy source is not tried first anymore
+	_, err = fs.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, 2, a.calls)
+
+	// not found is not a failure
+	_, err = fs.HeaderByHash(ctx, common.Hash{})
+	assert.ErrorIs(t, err, ethereum.NotFound)
+	assert.True(t, fs.Healthy(1))
+
+	assert.True(t, fs.AnyHealthy())
+
+	// all sources fail
+	b.setFail(true)
+	_, err = fs.HeaderByNumber(ctx, nil)
+	assert.ErrorIs(t, err, errTestSource)
+	assert.False(t, fs.AnyHealthy())
+
+	// a health check restores the preferred source
+	a.setFail(false)
+	fs.CheckHealth(ctx)
+	assert.True(t, fs.AnyHealthy())
+	assert.True(t, fs.Healthy(0))
+	assert.False(t, fs.Healthy(1))
+	h, err = fs.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, a.header, h)
+}
+
+func TestFailoverFetchSource(t *testing.T) {
+	block, receipts := testBlockWithReceipts(2)
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+	a := &failoverTestSource{header: testHeader(1, common.Hash{}, 0)}
+	b := &failoverTestSource{header: testHeader(1, common.Hash{}, 1)}
+	fs := NewFailoverL1Source([]L1Source{a, b})
+	var calls [2]int
+	fetcher := func(i int, err error) FetchSource {
+		return FetchFn(func(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+			calls[i]++
+			if err != nil {
+				return nil, nil, err
+			}
+			return block, receipts, nil
+		})
+	}
+	ff := NewFailoverFetchSource(fs, []FetchSource{fetcher(0, fmt.Errorf("bad receipts: %w", InvalidResponseErr)), fetcher(1, nil)})
+	ctx := context.Background()
+
+	bl, recs, err := ff.Fetch(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, block, bl)
+	assert.Equal(t, receipts, recs)
+	assert.False(t, fs.Healthy(0), "the failing endpoint is unhealthy for all requests")
+
+	// the L1 source of the unhealthy endpoint is not preferred anymore either
+	h, err := fs.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, b.header, h)
+	assert.Equal(t, 0, a.calls)
+
+	_, _, err = ff.Fetch(ctx, id)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 2}, calls)
+
+	// not found is not a failure
+	ff = NewFailoverFetchSource(fs, []FetchSource{fetcher(0, nil), fetcher(1, ClassifyFetchErr(ethereum.NotFound))})
+	_, _, err = ff.Fetch(ctx, id)
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+	assert.True(t, fs.Healthy(1))
+}
+
+// wrappedNotFoundSource returns not-found results classified like Client does
+type wrappedNotFoundSource struct {
+	failoverTestSource
+}
+
+func (s *wrappedNotFoundSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls++
+	return nil, ClassifyFetchErr(ethereum.NotFound)
+}
+
+func TestFailoverL1Source_WrappedNotFound(t *testing.T) {
+	a := &wrappedNotFoundSource{}
+	b := &failoverTestSource{header: testHeader(1, common.Hash{}, 1)}
+	fs := NewFailoverL1Source([]L1Source{a, b})
+	fs.OnFailure = func(i int, err error) {
+		t.Errorf("unexpected failure of source %d: %v", i, err)
+	}
+
+	_, err := fs.HeaderByNumber(context.Background(), big.NewInt(2))
+	require.ErrorIs(t, err, ethereum.NotFound)
+	require.ErrorIs(t, err, BlockNotFoundErr)
+	require.True(t, fs.Healthy(0), "not found is not a failure of the source")
+	require.Equal(t, 0, b.calls, "not found is not retried with the next source")
+}
+
+func TestFailoverL1Source_Subscription(t *testing.T) {
+	dropping := &failoverTestSource{dropSubs: true}
+	failing := &failoverTestSource{fail: true}
+	a := &failoverTestSource{}
+	b := &failoverTestSource{}
+	fs := NewFailoverL1Source([]L1Source{dropping, failing, a, b})
+
+	out := make(chan *types.Header, 10)
+	sub, err := fs.SubscribeNewHead(context.Background(), out)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	assert.Eventually(t, func() bool { return !fs.Healthy(0) && !fs.Healthy(1) }, time.Second, time.Millisecond*10)
+	// a is subscribed to now
+	assert.Eventually(t, func() bool { return a.feed.Send(testHeader(1, common.Hash{}, 0)) == 1 }, time.Second, time.Millisecond*10)
+	got := <-out
+	assert.Equal(t, uint64(1), got.Number.Uint64())
+	assert.Equal(t, 0, b.feed.Send(testHeader(1, common.Hash{}, 1)), "b is not subscribed to")
+
+	select {
+	case err := <-sub.Err():
+		t.Fatalf("subscription failed: %v", err)
+	default:
+	}
+}
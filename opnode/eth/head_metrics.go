@@ -0,0 +1,136 @@
+package eth
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// HeadMetrics tracks the health of a head subscription, to alert on a stalled L1 connection:
+// the latest head, how far it lags behind the head reported by eth_blockNumber,
+// the time since the last head, the number of reconnects, the backlog of unprocessed heads,
+// and the number and depth of reorgs, by whether the common ancestor was within the window of recent heads.
+//
+// All methods are no-ops on a nil HeadMetrics. Like the go-ethereum metrics,
+// the metrics are no-ops unless metrics.Enabled is set before creating the HeadMetrics.
+type HeadMetrics struct {
+	// unix nanoseconds of the last head (atomic)
+	lastHeadTime int64
+	// number of the last head (atomic)
+	lastHeadNum uint64
+
+	headNumber   metrics.Gauge
+	remoteNumber metrics.Gauge
+	headLag      metrics.Gauge
+	sinceHead    metrics.Gauge
+	reconnects   metrics.Counter
+	backlog      metrics.Gauge
+
+	reorgsWithin      metrics.Counter
+	reorgsBeyond      metrics.Counter
+	reorgDepthsWithin metrics.Histogram
+	reorgDepthsBeyond metrics.Histogram
+}
+
+var _ ReorgMetrics = (*HeadMetrics)(nil)
+
+// NewHeadMetrics registers the head metrics in the given registry, e.g. metrics.DefaultRegistry.
+func NewHeadMetrics(r metrics.Registry) *HeadMetrics {
+	return &HeadMetrics{
+		lastHeadTime: time.Now().UnixNano(),
+		headNumber:   metrics.NewRegisteredGauge("opnode/l1/head/number", r),
+		remoteNumber: metrics.NewRegisteredGauge("opnode/l1/head/remote", r),
+		headLag:      metrics.NewRegisteredGauge("opnode/l1/head/lag", r),
+		sinceHead:    metrics.NewRegisteredGauge("opnode/l1/head/since", r),
+		reconnects:   metrics.NewRegisteredCounter("opnode/l1/head/reconnects", r),
+		backlog:      metrics.NewRegisteredGauge("opnode/l1/head/backlog", r),
+
+		reorgsWithin:      metrics.NewRegisteredCounter("opnode/l1/reorgs/within_window", r),
+		reorgsBeyond:      metrics.NewRegisteredCounter("opnode/l1/reorgs/beyond_window", r),
+		reorgDepthsWithin: metrics.NewRegisteredHistogram("opnode/l1/reorgs/depth/within_window", r, metrics.NewExpDecaySample(1028, 0.015)),
+		reorgDepthsBeyond: metrics.NewRegisteredHistogram("opnode/l1/reorgs/depth/beyond_window", r, metrics.NewExpDecaySample(1028, 0.015)),
+	}
+}
+
+// RecordHead records a new head, and the number of heads that are still queued up behind it.
+func (m *HeadMetrics) RecordHead(head BlockID, backlog int) {
+	if m == nil {
+		return
+	}
+	atomic.StoreInt64(&m.lastHeadTime, time.Now().UnixNano())
+	atomic.StoreUint64(&m.lastHeadNum, head.Number)
+	m.headNumber.Update(int64(head.Number))
+	m.sinceHead.Update(0)
+	m.backlog.Update(int64(backlog))
+}
+
+// RecordReconnect records a re-subscription after the head subscription failed.
+func (m *HeadMetrics) RecordReconnect() {
+	if m == nil {
+		return
+	}
+	m.reconnects.Inc(1)
+}
+
+// RecordReorg records a reorg of the given depth. Reorgs with an unknown common ancestor are beyond the window,
+// and their depth is the number of recent heads that were reorged out.
+func (m *HeadMetrics) RecordReorg(depth uint64, withinWindow bool) {
+	if m == nil {
+		return
+	}
+	if withinWindow {
+		m.reorgsWithin.Inc(1)
+		m.reorgDepthsWithin.Update(int64(depth))
+	} else {
+		m.reorgsBeyond.Inc(1)
+		m.reorgDepthsBeyond.Update(int64(depth))
+	}
+}
+
+// RecordRemoteHead records the latest block number reported by the endpoint,
+// and updates the lag of the last head and the time since the last head.
+func (m *HeadMetrics) RecordRemoteHead(num uint64) {
+	if m == nil {
+		return
+	}
+	m.remoteNumber.Update(int64(num))
+	lag := int64(num) - int64(atomic.LoadUint64(&m.lastHeadNum))
+	if lag < 0 {
+		lag = 0
+	}
+	m.headLag.Update(lag)
+	m.sinceHead.Update(int64(m.SinceLastHead() / time.Second))
+}
+
+// SinceLastHead returns the time since the last head was recorded, or since the metrics were created.
+func (m *HeadMetrics) SinceLastHead() time.Duration {
+	return time.Since(time.Unix(0, atomic.LoadInt64(&m.lastHeadTime)))
+}
+
+// WatchRemoteHead polls the latest block number at the given interval, to record the head lag,
+// until the subscription is closed. Failed polls are skipped.
+func (m *HeadMetrics) WatchRemoteHead(ctx context.Context, src BlockNumberSource, interval time.Duration) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				pollCtx, cancel := context.WithTimeout(ctx, interval)
+				num, err := src.BlockNumber(pollCtx)
+				cancel()
+				if err == nil {
+					m.RecordRemoteHead(num)
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
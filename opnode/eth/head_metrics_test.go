@@ -0,0 +1,53 @@
+package eth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeadMetrics(t *testing.T) {
+	// the metrics are no-ops unless enabled, use explicit implementations to inspect them
+	m := &HeadMetrics{
+		headNumber:   new(metrics.StandardGauge),
+		remoteNumber: new(metrics.StandardGauge),
+		headLag:      new(metrics.StandardGauge),
+		sinceHead:    new(metrics.StandardGauge),
+		reconnects:   metrics.NewCounterForced(),
+		backlog:      new(metrics.StandardGauge),
+	}
+	m.RecordHead(BlockID{Number: 10}, 3)
+	assert.Equal(t, int64(10), m.headNumber.Value())
+	assert.Equal(t, int64(3), m.backlog.Value())
+	assert.Less(t, m.SinceLastHead(), time.Second)
+
+	m.RecordRemoteHead(14)
+	assert.Equal(t, int64(14), m.remoteNumber.Value())
+	assert.Equal(t, int64(4), m.headLag.Value())
+
+	// a remote endpoint that is behind does not lag
+	m.RecordRemoteHead(9)
+	assert.Equal(t, int64(0), m.headLag.Value())
+
+	m.RecordReconnect()
+	m.RecordReconnect()
+	assert.Equal(t, int64(2), m.reconnects.Count())
+
+	remote := BlockNumberFn(func(ctx context.Context) (uint64, error) {
+		return 20, nil
+	})
+	sub := m.WatchRemoteHead(context.Background(), remote, time.Millisecond)
+	defer sub.Unsubscribe()
+	require.Eventually(t, func() bool { return m.headLag.Value() == 10 }, time.Second, time.Millisecond)
+
+	// a nil HeadMetrics is a no-op
+	var noop *HeadMetrics
+	noop.RecordHead(BlockID{Number: 1}, 0)
+	noop.RecordRemoteHead(1)
+	noop.RecordReconnect()
+	noop.RecordReorg(1, true)
+}
@@ -0,0 +1,167 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// DefaultHeadMuxBuffer is the default number of heads buffered per HeadMux consumer
+const DefaultHeadMuxBuffer = 16
+
+// SlowConsumerErr is the error of a HeadMux consumer subscription that was disconnected for falling behind
+var SlowConsumerErr = errors.New("slow head consumer")
+
+// SlowConsumerFn is called when a head could not be buffered for a consumer that falls behind.
+// The consumer is identified by the order it subscribed in.
+type SlowConsumerFn func(consumer int, header *types.Header)
+
+// HeadMux implements NewHeadSource by multiplexing a single upstream head subscription to multiple consumers,
+// e.g. the driver, metrics and batcher, so they do not each open their own subscription.
+//
+// The upstream subscription is opened with the first consumer, and closed after the last consumer unsubscribes.
+// If the upstream subscription fails, all consumer subscriptions fail with the same error,
+// and the next consumer subscription opens a new upstream subscription.
+//
+// Every consumer has its own buffer, so a slow consumer does not block the others. When the buffer of a consumer is full,
+// the oldest buffered head is dropped for the newest, or, with DisconnectSlow, the consumer subscription fails with SlowConsumerErr.
+type HeadMux struct {
+	Source NewHeadSource
+	// Buffer is the number of heads buffered per consumer. DefaultHeadMuxBuffer if zero.
+	Buffer int
+	// DisconnectSlow fails the subscription of a consumer with a full buffer, instead of dropping its oldest head.
+	DisconnectSlow bool
+	// OnSlowConsumer is optional, to surface consumers that fall behind
+	OnSlowConsumer SlowConsumerFn
+
+	mu        sync.Mutex
+	consumers map[int]*muxConsumer
+	nextID    int
+	// closed to stop the upstream subscription, nil if there is no upstream subscription
+	stop chan struct{}
+}
+
+var _ NewHeadSource = (*HeadMux)(nil)
+
+type muxConsumer struct {
+	queue chan *types.Header
+	fail  chan error
+}
+
+// SubscribeNewHead subscribes a consumer, opening the upstream subscription if this is the first consumer.
+func (m *HeadMux) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stop == nil {
+		headers := make(chan *types.Header, 10)
+		sub, err := m.Source.SubscribeNewHead(ctx, headers)
+		if err != nil {
+			return nil, err
+		}
+		m.stop = make(chan struct{})
+		go m.forward(sub, headers, m.stop)
+	}
+	if m.consumers == nil {
+		m.consumers = make(map[int]*muxConsumer)
+	}
+	size := m.Buffer
+	if size <= 0 {
+		size = DefaultHeadMuxBuffer
+	}
+	c := &muxConsumer{
+		queue: make(chan *types.Header, size),
+		fail:  make(chan error, 1),
+	}
+	id := m.nextID
+	m.nextID++
+	m.consumers[id] = c
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer m.remove(id)
+		for {
+			select {
+			case h := <-c.queue:
+				select {
+				case ch <- h:
+				case err := <-c.fail:
+					return err
+				case <-quit:
+					return nil
+				}
+			case err := <-c.fail:
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// forward dispatches the upstream heads to the consumers, until the upstream subscription fails or is stopped.
+func (m *HeadMux) forward(sub ethereum.Subscription, headers <-chan *types.Header, stop <-chan struct{}) {
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case h := <-headers:
+			m.dispatch(h)
+		case err := <-sub.Err():
+			m.mu.Lock()
+			// a new upstream subscription may already be running if this one was stopped
+			if m.stop == stop {
+				m.stop = nil
+			}
+			for id, c := range m.consumers {
+				c.fail <- err
+				delete(m.consumers, id)
+			}
+			m.mu.Unlock()
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (m *HeadMux) dispatch(h *types.Header) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, c := range m.consumers {
+		select {
+		case c.queue <- h:
+			continue
+		default:
+		}
+		if m.OnSlowConsumer != nil {
+			m.OnSlowConsumer(id, h)
+		}
+		if m.DisconnectSlow {
+			c.fail <- SlowConsumerErr
+			delete(m.consumers, id)
+			continue
+		}
+		// drop the oldest head, unless the consumer just made room itself
+		select {
+		case <-c.queue:
+		default:
+		}
+		select {
+		case c.queue <- h:
+		default:
+		}
+	}
+}
+
+// remove removes the consumer, and stops the upstream subscription if it was the last consumer.
+func (m *HeadMux) remove(id int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.consumers, id)
+	if len(m.consumers) == 0 && m.stop != nil {
+		close(m.stop)
+		m.stop = nil
+	}
+}
@@ -0,0 +1,161 @@
+package eth
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// muxTestSource is a NewHeadSource that counts its subscriptions, and can make them fail
+type muxTestSource struct {
+	feedHeadSource
+	subs int32
+	fail chan error
+}
+
+func (s *muxTestSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	atomic.AddInt32(&s.subs, 1)
+	inner := s.feed.Subscribe(ch)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer inner.Unsubscribe()
+		select {
+		case err := <-s.fail:
+			return err
+		case <-quit:
+			return nil
+		}
+	}), nil
+}
+
+func recvHead(t *testing.T, ch <-chan *types.Header) *types.Header {
+	select {
+	case h := <-ch:
+		return h
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for head")
+		return nil
+	}
+}
+
+func TestHeadMux(t *testing.T) {
+	src := &muxTestSource{fail: make(chan error, 1)}
+	mux := &HeadMux{Source: src}
+	ctx := context.Background()
+
+	chA := make(chan *types.Header, 10)
+	subA, err := mux.SubscribeNewHead(ctx, chA)
+	require.NoError(t, err)
+	chB := make(chan *types.Header, 10)
+	subB, err := mux.SubscribeNewHead(ctx, chB)
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&src.subs), "consumers share the upstream subscription")
+
+	chain := testChain(nil, 3, 0)
+	for _, h := range chain {
+		src.feed.Send(h)
+	}
+	for _, h := range chain {
+		assert.Equal(t, h, recvHead(t, chA))
+		assert.Equal(t, h, recvHead(t, chB))
+	}
+
+	// the upstream subscription is closed after the last consumer unsubscribes
+	subA.Unsubscribe()
+	subB.Unsubscribe()
+	require.Eventually(t, func() bool {
+		return src.feed.Send(chain[0]) == 0
+	}, time.Second*5, time.Millisecond*10)
+
+	// and opened again for a new consumer
+	sub, err := mux.SubscribeNewHead(ctx, make(chan *types.Header))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&src.subs))
+}
+
+func TestHeadMuxUpstreamFailure(t *testing.T) {
+	src := &muxTestSource{fail: make(chan error, 1)}
+	mux := &HeadMux{Source: src}
+	ctx := context.Background()
+
+	subA, err := mux.SubscribeNewHead(ctx, make(chan *types.Header))
+	require.NoError(t, err)
+	subB, err := mux.SubscribeNewHead(ctx, make(chan *types.Header))
+	require.NoError(t, err)
+
+	src.fail <- errTestSource
+	assert.ErrorIs(t, <-subA.Err(), errTestSource)
+	assert.ErrorIs(t, <-subB.Err(), errTestSource)
+
+	// a new consumer resubscribes upstream
+	sub, err := mux.SubscribeNewHead(ctx, make(chan *types.Header))
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	assert.Equal(t, int32(2), atomic.LoadInt32(&src.subs))
+}
+
+func TestHeadMuxSlowConsumer(t *testing.T) {
+	src := &muxTestSource{fail: make(chan error, 1)}
+	var mu sync.Mutex
+	dropped := 0
+	mux := &HeadMux{Source: src, Buffer: 2, OnSlowConsumer: func(consumer int, header *types.Header) {
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Equal(t, 1, consumer)
+		dropped++
+	}}
+	ctx := context.Background()
+
+	fast := make(chan *types.Header, 10)
+	subFast, err := mux.SubscribeNewHead(ctx, fast)
+	require.NoError(t, err)
+	defer subFast.Unsubscribe()
+	slow := make(chan *types.Header) // not read until all heads are sent
+	subSlow, err := mux.SubscribeNewHead(ctx, slow)
+	require.NoError(t, err)
+	defer subSlow.Unsubscribe()
+
+	chain := testChain(nil, 6, 0)
+	for _, h := range chain {
+		src.feed.Send(h)
+		// the slow consumer does not hold back the fast consumer
+		assert.Equal(t, h, recvHead(t, fast))
+	}
+
+	mu.Lock()
+	assert.NotZero(t, dropped)
+	mu.Unlock()
+	// the oldest heads are dropped: at most the head in flight and the buffered heads are left, ending with the latest
+	var got []*types.Header
+	for len(got) == 0 || got[len(got)-1] != chain[5] {
+		got = append(got, recvHead(t, slow))
+	}
+	assert.LessOrEqual(t, len(got), 3)
+}
+
+func TestHeadMuxDisconnectSlow(t *testing.T) {
+	src := &muxTestSource{fail: make(chan error, 1)}
+	mux := &HeadMux{Source: src, Buffer: 1, DisconnectSlow: true}
+	ctx := context.Background()
+
+	fast := make(chan *types.Header, 10)
+	subFast, err := mux.SubscribeNewHead(ctx, fast)
+	require.NoError(t, err)
+	defer subFast.Unsubscribe()
+	subSlow, err := mux.SubscribeNewHead(ctx, make(chan *types.Header))
+	require.NoError(t, err)
+
+	for _, h := range testChain(nil, 4, 0) {
+		src.feed.Send(h)
+		assert.Equal(t, h, recvHead(t, fast))
+	}
+	assert.ErrorIs(t, <-subSlow.Err(), SlowConsumerErr)
+}
@@ -2,6 +2,8 @@ package eth
 
 import (
 	"context"
+	"fmt"
+	"math/big"
 
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -11,6 +13,23 @@ import (
 type HeadSignal struct {
 	Parent BlockID
 	Self   BlockID
+
+	// Time is the timestamp of the Self block
+	Time uint64
+	// BaseFee is the base fee of the Self block, nil if the block has no base fee
+	BaseFee *big.Int
+	// Header is the full header of the Self block, for consumers to not refetch it. Nil if not available.
+	Header *types.Header
+}
+
+// HeadSignalFromHeader creates the head signal of the given header
+func HeadSignalFromHeader(header *types.Header) HeadSignal {
+	self := BlockID{Hash: header.Hash(), Number: header.Number.Uint64()}
+	parent := BlockID{}
+	if self.Number > 0 {
+		parent = BlockID{Hash: header.ParentHash, Number: self.Number - 1}
+	}
+	return HeadSignal{Parent: parent, Self: self, Time: header.Time, BaseFee: header.BaseFee, Header: header}
 }
 
 // HeadSignalFn is used as callback function to accept head-signals
@@ -28,14 +47,84 @@ func WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (
 		for {
 			select {
 			case header := <-headChanges:
-				hash := header.Hash()
-				height := header.Number.Uint64()
-				self := BlockID{Hash: hash, Number: height}
-				parent := BlockID{}
-				if height > 0 {
-					parent = BlockID{Hash: header.ParentHash, Number: height - 1}
+				fn(HeadSignalFromHeader(header))
+			case err := <-sub.Err():
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// ReorgSignal signals that a new head does not build on the previously seen head.
+type ReorgSignal struct {
+	OldHead BlockID
+	NewHead BlockID
+	// CommonAncestor is the latest block of both the old and the new chain,
+	// or zero if it is not within the window of recent heads.
+	CommonAncestor BlockID
+	// Depth is the number of blocks of the old chain that were reorged out.
+	// If the common ancestor is unknown, all the recent heads in the window are considered reorged out.
+	Depth uint64
+}
+
+// ReorgSignalFn is used as callback function to accept reorg-signals
+type ReorgSignalFn func(sig ReorgSignal)
+
+// ReorgMetrics records the heads and reorgs seen by a ReorgDetector. HeadMetrics implements it.
+type ReorgMetrics interface {
+	// RecordHead records a new head, and the number of heads that are still queued up behind it.
+	RecordHead(head BlockID, backlog int)
+	// RecordReorg records a reorg of the given depth,
+	// and whether the common ancestor was within the window of recent heads.
+	RecordReorg(depth uint64, withinWindow bool)
+}
+
+// ReorgDetector keeps a window of recent heads, to detect reorgs in the head changes of a NewHeadSource.
+type ReorgDetector struct {
+	// Window is the number of recent heads to keep, to find the common ancestor in. At least 1 head is kept.
+	Window uint64
+	// Headers is used to walk back the new chain to the common ancestor,
+	// and to fill gaps between heads that are not reorgs.
+	Headers HeaderByHashSource
+	OnReorg ReorgSignalFn
+	// Metrics is optional, to record the heads, the backlog of heads that are not processed yet, and the reorgs
+	Metrics ReorgMetrics
+}
+
+// WatchHeadChanges wraps WatchHeadChanges, to feed the given fn, while detecting reorgs.
+// OnReorg is called before the head signal of the new head.
+func (rd *ReorgDetector) WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (ethereum.Subscription, error) {
+	headChanges := make(chan *types.Header, 10)
+	sub, err := src.SubscribeNewHead(ctx, headChanges)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		recent := NewChainTracker(nil, nil, rd.Window)
+		m := rd.Metrics
+		if m == nil {
+			m = (*HeadMetrics)(nil)
+		}
+		for {
+			select {
+			case header := <-headChanges:
+				m.RecordHead(BlockID{Hash: header.Hash(), Number: header.Number.Uint64()}, len(headChanges))
+				sig, err := rd.onHead(ctx, recent, header)
+				if err != nil {
+					return err
+				}
+				if sig != nil {
+					m.RecordReorg(sig.Depth, sig.CommonAncestor != (BlockID{}))
+					if rd.OnReorg != nil {
+						rd.OnReorg(*sig)
+					}
 				}
-				fn(HeadSignal{Parent: parent, Self: self})
+				fn(HeadSignalFromHeader(header))
 			case err := <-sub.Err():
 				return err
 			case <-ctx.Done():
@@ -46,3 +135,63 @@ func WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (
 		}
 	}), nil
 }
+
+// onHead registers the new head in the recent canonical chain,
+// and returns a reorg signal if the new head does not build on the previous head.
+func (rd *ReorgDetector) onHead(ctx context.Context, recent *ChainTracker, header *types.Header) (*ReorgSignal, error) {
+	headSig := HeadSignalFromHeader(header)
+	self := headSig.Self
+	head := recent.Head()
+	if head == (BlockID{}) {
+		recent.AddHead(headSig)
+		return nil, nil
+	}
+	if self == head {
+		return nil, nil
+	}
+
+	// walk back the new chain, until a recent canonical head is found
+	lowest := recent.lowestCanonical()
+	var newChain []HeadSignal
+	var ancestor BlockID
+	cur := headSig
+	for {
+		if recent.IsCanonical(cur.Self) {
+			ancestor = cur.Self
+			break
+		}
+		newChain = append(newChain, cur)
+		if cur.Self.Number <= lowest {
+			break // no common ancestor within the window
+		}
+		if recent.IsCanonical(cur.Parent) {
+			ancestor = cur.Parent
+			break
+		}
+		h, err := rd.Headers.HeaderByHash(ctx, cur.Parent.Hash)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch header %s to find common ancestor: %w", cur.Parent, err)
+		}
+		if err := VerifyHeader(h, cur.Parent); err != nil {
+			return nil, fmt.Errorf("fetched invalid header to find common ancestor: %w", err)
+		}
+		cur = HeadSignalFromHeader(h)
+	}
+
+	var sig *ReorgSignal
+	if ancestor != head {
+		sig = &ReorgSignal{OldHead: head, NewHead: self, CommonAncestor: ancestor}
+		if ancestor == (BlockID{}) {
+			sig.Depth = head.Number + 1 - lowest
+		} else {
+			sig.Depth = head.Number - ancestor.Number
+		}
+	}
+
+	// add the new chain from oldest to most recent, the tracker drops the reorged-out heads
+	for i := len(newChain) - 1; i >= 0; i-- {
+		recent.AddHead(newChain[i])
+	}
+	recent.AddHead(headSig)
+	return sig, nil
+}
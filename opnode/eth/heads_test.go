@@ -0,0 +1,211 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testHeader(num uint64, parent common.Hash, extra byte) *types.Header {
+	return &types.Header{ParentHash: parent, Number: new(big.Int).SetUint64(num), Difficulty: common.Big0, Extra: []byte{extra}}
+}
+
+// feedHeadSource is a NewHeadSource that can be fed headers by tests
+type feedHeadSource struct {
+	feed event.Feed
+}
+
+func (f *feedHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return f.feed.Subscribe(ch), nil
+}
+
+// testChain builds a chain of n headers on top of the parent, with the extra byte to distinguish forks
+func testChain(parent *types.Header, n int, extra byte) []*types.Header {
+	var out []*types.Header
+	for i := 0; i < n; i++ {
+		var h *types.Header
+		if parent == nil {
+			h = testHeader(0, common.Hash{}, extra)
+		} else {
+			h = testHeader(parent.Number.Uint64()+1, parent.Hash(), extra)
+		}
+		out = append(out, h)
+		parent = h
+	}
+	return out
+}
+
+func headersByHash(chains ...[]*types.Header) HeaderByHashSource {
+	m := make(map[common.Hash]*types.Header)
+	for _, chain := range chains {
+		for _, h := range chain {
+			m[h.Hash()] = h
+		}
+	}
+	return HeaderByHashFn(func(ctx context.Context, hash common.Hash) (*types.Header, error) {
+		h, ok := m[hash]
+		if !ok {
+			return nil, ethereum.NotFound
+		}
+		return h, nil
+	})
+}
+
+func id(h *types.Header) BlockID {
+	return BlockID{Hash: h.Hash(), Number: h.Number.Uint64()}
+}
+
+type reorgTest struct {
+	src      *feedHeadSource
+	registry metrics.Registry
+	mu       sync.Mutex
+	heads    []HeadSignal
+	reorgs   []ReorgSignal
+}
+
+func newReorgTest(t *testing.T, window uint64, headers HeaderByHashSource) *reorgTest {
+	rt := &reorgTest{src: new(feedHeadSource), registry: metrics.NewRegistry()}
+	rd := &ReorgDetector{
+		Window:  window,
+		Headers: headers,
+		Metrics: NewHeadMetrics(rt.registry),
+		OnReorg: func(sig ReorgSignal) {
+			rt.mu.Lock()
+			defer rt.mu.Unlock()
+			rt.reorgs = append(rt.reorgs, sig)
+		},
+	}
+	sub, err := rd.WatchHeadChanges(context.Background(), rt.src, func(sig HeadSignal) {
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		rt.heads = append(rt.heads, sig)
+	})
+	require.NoError(t, err)
+	t.Cleanup(sub.Unsubscribe)
+	return rt
+}
+
+func (rt *reorgTest) send(t *testing.T, h *types.Header) {
+	rt.mu.Lock()
+	n := len(rt.heads)
+	rt.mu.Unlock()
+	rt.src.feed.Send(h)
+	require.Eventually(t, func() bool {
+		rt.mu.Lock()
+		defer rt.mu.Unlock()
+		return len(rt.heads) == n+1
+	}, time.Second, time.Millisecond)
+}
+
+func (rt *reorgTest) lastReorgs() []ReorgSignal {
+	rt.mu.Lock()
+	defer rt.mu.Unlock()
+	return append([]ReorgSignal(nil), rt.reorgs...)
+}
+
+func TestReorgDetector(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	b := testChain(a[5], 6, 1) // fork after block 5
+	rt := newReorgTest(t, 8, headersByHash(a, b))
+
+	for _, h := range a[:8] {
+		rt.send(t, h)
+	}
+	assert.Empty(t, rt.lastReorgs())
+
+	// a gap is filled in, and not a reorg
+	rt.send(t, a[9])
+	assert.Empty(t, rt.lastReorgs())
+
+	// the fork reorgs out blocks 6-9
+	rt.send(t, b[3])
+	reorgs := rt.lastReorgs()
+	require.Len(t, reorgs, 1)
+	assert.Equal(t, ReorgSignal{OldHead: id(a[9]), NewHead: id(b[3]), CommonAncestor: id(a[5]), Depth: 4}, reorgs[0])
+
+	// building on the fork is not a reorg
+	rt.send(t, b[4])
+	assert.Len(t, rt.lastReorgs(), 1)
+
+	// reorg back to the original chain, the fork blocks are part of the recent heads now
+	rt.send(t, a[8])
+	reorgs = rt.lastReorgs()
+	require.Len(t, reorgs, 2)
+	assert.Equal(t, ReorgSignal{OldHead: id(b[4]), NewHead: id(a[8]), CommonAncestor: id(a[5]), Depth: 5}, reorgs[1])
+}
+
+func TestReorgDetectorBeyondWindow(t *testing.T) {
+	a := testChain(nil, 10, 0)
+	b := testChain(a[1], 9, 1) // fork after block 1
+	rt := newReorgTest(t, 3, headersByHash(a, b))
+	for _, h := range a {
+		rt.send(t, h)
+	}
+	// recent heads 7, 8, 9 are all reorged out, the common ancestor is unknown
+	rt.send(t, b[8])
+	reorgs := rt.lastReorgs()
+	require.Len(t, reorgs, 1)
+	assert.Equal(t, ReorgSignal{OldHead: id(a[9]), NewHead: id(b[8]), Depth: 3}, reorgs[0])
+}
+
+func TestReorgDetectorMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	a := testChain(nil, 10, 0)
+	b := testChain(a[7], 2, 1)
+	c := testChain(a[6], 4, 2)
+	d := testChain(a[1], 11, 3)
+	e := testChain(d[9], 2, 4)
+	rt := newReorgTest(t, 4, headersByHash(a, b, c, d, e))
+	for _, h := range a {
+		rt.send(t, h)
+	}
+
+	// reorgs arrive back-to-back, without waiting for the previous one to be processed
+	for _, h := range []*types.Header{b[1], c[3], d[10], e[1]} {
+		rt.src.feed.Send(h)
+	}
+	require.Eventually(t, func() bool { return len(rt.lastReorgs()) == 4 }, time.Second, time.Millisecond)
+	reorgs := rt.lastReorgs()
+	assert.Equal(t, ReorgSignal{OldHead: id(a[9]), NewHead: id(b[1]), CommonAncestor: id(a[7]), Depth: 2}, reorgs[0])
+	assert.Equal(t, ReorgSignal{OldHead: id(b[1]), NewHead: id(c[3]), CommonAncestor: id(a[6]), Depth: 3}, reorgs[1])
+	assert.Equal(t, ReorgSignal{OldHead: id(c[3]), NewHead: id(d[10]), Depth: 4}, reorgs[2], "common ancestor beyond the window")
+	assert.Equal(t, ReorgSignal{OldHead: id(d[10]), NewHead: id(e[1]), CommonAncestor: id(d[9]), Depth: 1}, reorgs[3])
+
+	within := rt.registry.Get("opnode/l1/reorgs/depth/within_window").(metrics.Histogram)
+	beyond := rt.registry.Get("opnode/l1/reorgs/depth/beyond_window").(metrics.Histogram)
+	assert.Equal(t, int64(3), rt.registry.Get("opnode/l1/reorgs/within_window").(metrics.Counter).Count())
+	assert.Equal(t, int64(3), within.Count())
+	assert.Equal(t, int64(2+3+1), within.Sum())
+	assert.Equal(t, int64(3), within.Max())
+	assert.Equal(t, int64(1), rt.registry.Get("opnode/l1/reorgs/beyond_window").(metrics.Counter).Count())
+	assert.Equal(t, int64(1), beyond.Count())
+	assert.Equal(t, int64(4), beyond.Sum())
+}
+
+func TestHeadSignalFromHeader(t *testing.T) {
+	genesis := &types.Header{Number: big.NewInt(0), Time: 100, BaseFee: big.NewInt(7)}
+	sig := HeadSignalFromHeader(genesis)
+	assert.Equal(t, BlockID{}, sig.Parent, "genesis has no parent")
+	assert.Equal(t, id(genesis), sig.Self)
+
+	h := &types.Header{ParentHash: genesis.Hash(), Number: big.NewInt(1), Time: 112, BaseFee: big.NewInt(8)}
+	sig = HeadSignalFromHeader(h)
+	assert.Equal(t, id(genesis), sig.Parent)
+	assert.Equal(t, id(h), sig.Self)
+	assert.Equal(t, uint64(112), sig.Time)
+	assert.Equal(t, big.NewInt(8), sig.BaseFee)
+	assert.Same(t, h, sig.Header)
+}
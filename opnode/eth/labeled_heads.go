@@ -0,0 +1,88 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// HeadLabel is the block tag of a head, as used with eth_getBlockByNumber
+type HeadLabel string
+
+const (
+	// UnsafeHead is the latest head, which may be reorged
+	UnsafeHead HeadLabel = "latest"
+	// SafeHead is the head that is unlikely to be reorged, as attested by the L1 consensus
+	SafeHead HeadLabel = "safe"
+	// FinalizedHead is the head that is irreversible, as finalized by the L1 consensus
+	FinalizedHead HeadLabel = "finalized"
+)
+
+// LabeledHeadSignalFn is used as callback function to accept head-signals of labeled heads
+type LabeledHeadSignalFn func(label HeadLabel, sig HeadSignal)
+
+// HeaderByLabel fetches the header of the labeled head with eth_getBlockByNumber.
+// An error wrapping BlockNotFoundErr (and ethereum.NotFound) is returned if the node does not know the head yet,
+// e.g. no block was finalized yet.
+func HeaderByLabel(ctx context.Context, rpc RPCCaller, label HeadLabel) (*types.Header, error) {
+	var header *types.Header
+	if err := rpc.CallContext(ctx, &header, "eth_getBlockByNumber", string(label), false); err != nil {
+		return nil, ClassifyFetchErr(err)
+	}
+	if header == nil {
+		return nil, ClassifyFetchErr(ethereum.NotFound)
+	}
+	return header, nil
+}
+
+// LabeledHeadsTracker polls the labeled heads, e.g. the safe and finalized heads, which cannot be subscribed to,
+// to emit head signals alongside the unsafe head signals of WatchHeadChanges.
+//
+// A signal is emitted when a labeled head changes. Failed polls, e.g. of a node that does not support the label,
+// are retried at the next interval, and do not end the subscription.
+type LabeledHeadsTracker struct {
+	RPC      RPCCaller
+	Interval time.Duration
+	Labels   []HeadLabel
+}
+
+// Watch polls the labeled heads until the subscription is closed, to feed the given fn.
+func (lt *LabeledHeadsTracker) Watch(ctx context.Context, fn LabeledHeadSignalFn) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(lt.Interval)
+		defer ticker.Stop()
+
+		last := make(map[HeadLabel]BlockID, len(lt.Labels))
+		for {
+			for _, label := range lt.Labels {
+				sig, err := lt.poll(ctx, label)
+				if err != nil || last[label] == sig.Self {
+					continue
+				}
+				last[label] = sig.Self
+				fn(label, sig)
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+func (lt *LabeledHeadsTracker) poll(ctx context.Context, label HeadLabel) (HeadSignal, error) {
+	pollCtx, cancel := context.WithTimeout(ctx, lt.Interval)
+	defer cancel()
+	header, err := HeaderByLabel(pollCtx, lt.RPC, label)
+	if err != nil {
+		return HeadSignal{}, fmt.Errorf("failed to fetch %s head: %w", label, err)
+	}
+	return HeadSignalFromHeader(header), nil
+}
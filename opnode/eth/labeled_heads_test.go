@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// labeledHeadsRPC serves eth_getBlockByNumber for head labels, through a JSON round-trip like a real RPC
+type labeledHeadsRPC struct {
+	mu    sync.Mutex
+	heads map[HeadLabel]*types.Header
+}
+
+func (r *labeledHeadsRPC) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if method != "eth_getBlockByNumber" || len(args) != 2 {
+		return errors.New("unexpected call")
+	}
+	label, ok := args[0].(string)
+	if !ok {
+		return errors.New("unexpected block number arg")
+	}
+	r.mu.Lock()
+	h, ok := r.heads[HeadLabel(label)]
+	r.mu.Unlock()
+	if !ok {
+		return errors.New("unsupported block tag")
+	}
+	data, err := json.Marshal(h)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (r *labeledHeadsRPC) set(label HeadLabel, h *types.Header) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heads[label] = h
+}
+
+func TestLabeledHeadsTracker(t *testing.T) {
+	chain := testChain(nil, 10, 0)
+	rpc := &labeledHeadsRPC{heads: map[HeadLabel]*types.Header{FinalizedHead: nil}}
+
+	_, err := HeaderByLabel(context.Background(), rpc, SafeHead)
+	assert.Error(t, err, "unsupported label")
+
+	lt := &LabeledHeadsTracker{RPC: rpc, Interval: time.Millisecond * 10, Labels: []HeadLabel{SafeHead, FinalizedHead}}
+	var mu sync.Mutex
+	signals := make(map[HeadLabel][]HeadSignal)
+	sub := lt.Watch(context.Background(), func(label HeadLabel, sig HeadSignal) {
+		mu.Lock()
+		defer mu.Unlock()
+		signals[label] = append(signals[label], sig)
+	})
+	defer sub.Unsubscribe()
+
+	count := func(label HeadLabel) func() int {
+		return func() int {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(signals[label])
+		}
+	}
+	// nothing is finalized yet, and the safe label is unsupported
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, 0, count(SafeHead)())
+	assert.Equal(t, 0, count(FinalizedHead)())
+
+	rpc.set(SafeHead, chain[5])
+	rpc.set(FinalizedHead, chain[2])
+	require.Eventually(t, func() bool { return count(SafeHead)() == 1 && count(FinalizedHead)() == 1 }, time.Second, time.Millisecond*5)
+
+	// unchanged heads are not repeated
+	rpc.set(SafeHead, chain[6])
+	require.Eventually(t, func() bool { return count(SafeHead)() == 2 }, time.Second, time.Millisecond*5)
+	time.Sleep(time.Millisecond * 50)
+	assert.Equal(t, 1, count(FinalizedHead)())
+
+	mu.Lock()
+	defer mu.Unlock()
+	finalized := signals[FinalizedHead][0]
+	assert.Equal(t, BlockID{Hash: chain[1].Hash(), Number: 1}, finalized.Parent)
+	assert.Equal(t, BlockID{Hash: chain[2].Hash(), Number: 2}, finalized.Self)
+	assert.Equal(t, chain[2].Hash(), finalized.Header.Hash(), "the signal carries the full header")
+	assert.Equal(t, BlockID{Hash: chain[6].Hash(), Number: 6}, signals[SafeHead][1].Self)
+}
@@ -0,0 +1,206 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// HeadPolicy determines when a MultiHeadSource forwards a head reported by its sources
+type HeadPolicy uint8
+
+const (
+	// FirstSeenHead forwards a head as soon as any source reports it
+	FirstSeenHead HeadPolicy = iota
+	// MajorityHead forwards a head once a majority of the live sources agree on the block hash at its height
+	MajorityHead
+)
+
+// Heads older than this many blocks below the highest reported head are ignored, to bound the tracked votes.
+const multiHeadWindow = 64
+
+// HeadDisagreementFn is called with the latest block hash reported by each source (by index) at the height,
+// when the sources do not agree on the block hash at that height.
+type HeadDisagreementFn func(height uint64, hashes map[int]common.Hash)
+
+// HeadSourceErrFn is called when the head subscription of a source fails.
+// The remaining sources continue to be watched.
+type HeadSourceErrFn func(i int, err error)
+
+// MultiHeadSource implements NewHeadSource by watching the heads of multiple redundant sources,
+// and reconciling the reported heads into a single stream with a HeadPolicy.
+// A lagging or failed source does not stall the stream, as long as the policy is met by the other sources.
+type MultiHeadSource struct {
+	Sources []NewHeadSource
+	Policy  HeadPolicy
+
+	// OnDisagreement is optional, to surface a diagnostic when sources report a different head at the same height
+	OnDisagreement HeadDisagreementFn
+	// OnSourceErr is optional, to surface failures of individual sources
+	OnSourceErr HeadSourceErrFn
+}
+
+var _ NewHeadSource = (*MultiHeadSource)(nil)
+
+type sourceHeader struct {
+	src    int
+	header *types.Header
+}
+
+type sourceErr struct {
+	src int
+	err error
+}
+
+// headVotes tracks which block hash each source reported at each height
+type headVotes struct {
+	// height -> source -> latest hash
+	votes map[uint64]map[int]common.Hash
+	// hashes that were already forwarded
+	forwarded map[common.Hash]uint64
+	highest   uint64
+}
+
+func (hv *headVotes) prune() {
+	if hv.highest < multiHeadWindow {
+		return
+	}
+	min := hv.highest - multiHeadWindow
+	for height := range hv.votes {
+		if height < min {
+			delete(hv.votes, height)
+		}
+	}
+	for h, height := range hv.forwarded {
+		if height < min {
+			delete(hv.forwarded, h)
+		}
+	}
+}
+
+func (m *MultiHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if len(m.Sources) == 0 {
+		return nil, errors.New("no head sources")
+	}
+	done := make(chan struct{})
+	merged := make(chan sourceHeader, 10)
+	failed := make(chan sourceErr, len(m.Sources))
+	subs := make([]ethereum.Subscription, 0, len(m.Sources))
+	unsubscribe := func() {
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	}
+	for i, src := range m.Sources {
+		headers := make(chan *types.Header, 10)
+		sub, err := src.SubscribeNewHead(ctx, headers)
+		if err != nil {
+			unsubscribe()
+			return nil, fmt.Errorf("failed to subscribe to head source %d: %w", i, err)
+		}
+		subs = append(subs, sub)
+		go func(i int, sub ethereum.Subscription, headers <-chan *types.Header) {
+			for {
+				select {
+				case header := <-headers:
+					select {
+					case merged <- sourceHeader{src: i, header: header}:
+					case <-done:
+						return
+					}
+				case err, ok := <-sub.Err():
+					if ok {
+						failed <- sourceErr{src: i, err: err}
+					}
+					return
+				case <-done:
+					return
+				}
+			}
+		}(i, sub, headers)
+	}
+
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer close(done)
+		defer unsubscribe()
+
+		hv := &headVotes{
+			votes:     make(map[uint64]map[int]common.Hash),
+			forwarded: make(map[common.Hash]uint64),
+		}
+		live := len(subs)
+		for {
+			select {
+			case sh := <-merged:
+				if header := m.reconcile(hv, live, sh); header != nil {
+					select {
+					case ch <- header:
+					case <-quit:
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				}
+			case se := <-failed:
+				live -= 1
+				if m.OnSourceErr != nil {
+					m.OnSourceErr(se.src, se.err)
+				}
+				if live == 0 {
+					return fmt.Errorf("all head sources failed, last error from source %d: %w", se.src, se.err)
+				}
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// reconcile registers the header reported by a source, and returns it if it is to be forwarded, or nil otherwise.
+func (m *MultiHeadSource) reconcile(hv *headVotes, live int, sh sourceHeader) *types.Header {
+	height := sh.header.Number.Uint64()
+	if hv.highest >= multiHeadWindow && height < hv.highest-multiHeadWindow {
+		return nil // too old, a severely lagging source
+	}
+	if height > hv.highest {
+		hv.highest = height
+		hv.prune()
+	}
+	hash := sh.header.Hash()
+	atHeight, ok := hv.votes[height]
+	if !ok {
+		atHeight = make(map[int]common.Hash)
+		hv.votes[height] = atHeight
+	}
+	atHeight[sh.src] = hash
+
+	agree := 0
+	for _, h := range atHeight {
+		if h == hash {
+			agree += 1
+		}
+	}
+	if agree < len(atHeight) && m.OnDisagreement != nil {
+		hashes := make(map[int]common.Hash, len(atHeight))
+		for src, h := range atHeight {
+			hashes[src] = h
+		}
+		m.OnDisagreement(height, hashes)
+	}
+
+	if _, ok := hv.forwarded[hash]; ok {
+		return nil
+	}
+	if m.Policy == MajorityHead && agree < live/2+1 {
+		return nil
+	}
+	hv.forwarded[hash] = height
+	return sh.header
+}
@@ -0,0 +1,165 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type disagreement struct {
+	height uint64
+	hashes map[int]common.Hash
+}
+
+type multiHeadTest struct {
+	sources       []*feedHeadSource
+	out           chan *types.Header
+	sub           ethereum.Subscription
+	mu            sync.Mutex
+	disagreements []disagreement
+}
+
+func newMultiHeadTest(t *testing.T, n int, policy HeadPolicy) *multiHeadTest {
+	mt := &multiHeadTest{out: make(chan *types.Header, 10)}
+	var sources []NewHeadSource
+	for i := 0; i < n; i++ {
+		src := new(feedHeadSource)
+		mt.sources = append(mt.sources, src)
+		sources = append(sources, src)
+	}
+	multi := &MultiHeadSource{
+		Sources: sources,
+		Policy:  policy,
+		OnDisagreement: func(height uint64, hashes map[int]common.Hash) {
+			mt.mu.Lock()
+			defer mt.mu.Unlock()
+			mt.disagreements = append(mt.disagreements, disagreement{height, hashes})
+		},
+	}
+	sub, err := multi.SubscribeNewHead(context.Background(), mt.out)
+	require.NoError(t, err)
+	mt.sub = sub
+	t.Cleanup(sub.Unsubscribe)
+	return mt
+}
+
+func (mt *multiHeadTest) send(src int, header *types.Header) {
+	mt.sources[src].feed.Send(header)
+}
+
+func (mt *multiHeadTest) expect(t *testing.T, header *types.Header) {
+	select {
+	case got := <-mt.out:
+		assert.Equal(t, header.Hash(), got.Hash())
+	case <-time.After(time.Second):
+		t.Fatalf("expected head %d %s", header.Number, header.Hash())
+	}
+}
+
+func (mt *multiHeadTest) expectNone(t *testing.T) {
+	select {
+	case got := <-mt.out:
+		t.Fatalf("unexpected head %d %s", got.Number, got.Hash())
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestMultiHeadSource_Majority(t *testing.T) {
+	mt := newMultiHeadTest(t, 3, MajorityHead)
+	a1 := testHeader(1, common.Hash{}, 0)
+	b1 := testHeader(1, common.Hash{}, 1)
+
+	mt.send(0, a1)
+	mt.expectNone(t)
+	// source 2 disagrees
+	mt.send(2, b1)
+	mt.expectNone(t)
+	// source 1 agrees with source 0, forming a majority
+	mt.send(1, a1)
+	mt.expect(t, a1)
+
+	mt.mu.Lock()
+	require.NotEmpty(t, mt.disagreements)
+	d := mt.disagreements[len(mt.disagreements)-1]
+	mt.mu.Unlock()
+	assert.Equal(t, uint64(1), d.height)
+	assert.Equal(t, map[int]common.Hash{0: a1.Hash(), 1: a1.Hash(), 2: b1.Hash()}, d.hashes)
+
+	// source 2 lags behind and does not stall the others
+	a2 := testHeader(2, a1.Hash(), 0)
+	mt.send(0, a2)
+	mt.send(1, a2)
+	mt.expect(t, a2)
+
+	// a head is not forwarded twice
+	mt.send(2, a1)
+	mt.send(2, a2)
+	mt.expectNone(t)
+}
+
+func TestMultiHeadSource_FirstSeen(t *testing.T) {
+	mt := newMultiHeadTest(t, 3, FirstSeenHead)
+	a1 := testHeader(1, common.Hash{}, 0)
+	b1 := testHeader(1, common.Hash{}, 1)
+
+	mt.send(0, a1)
+	mt.expect(t, a1)
+	mt.send(1, a1)
+	mt.expectNone(t)
+	mt.send(2, b1)
+	mt.expect(t, b1)
+
+	mt.mu.Lock()
+	assert.Len(t, mt.disagreements, 1)
+	mt.mu.Unlock()
+}
+
+func TestMultiHeadSource_Failures(t *testing.T) {
+	failing := NewHeadFn(func(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+		return event.NewSubscription(func(quit <-chan struct{}) error {
+			return errors.New("test failure")
+		}), nil
+	})
+	working := new(feedHeadSource)
+	var sourceErrs int
+	var errMu sync.Mutex
+	multi := &MultiHeadSource{
+		Sources: []NewHeadSource{failing, working},
+		Policy:  MajorityHead,
+		OnSourceErr: func(i int, err error) {
+			errMu.Lock()
+			defer errMu.Unlock()
+			assert.Equal(t, 0, i)
+			sourceErrs += 1
+		},
+	}
+	out := make(chan *types.Header, 10)
+	sub, err := multi.SubscribeNewHead(context.Background(), out)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	assert.Eventually(t, func() bool {
+		errMu.Lock()
+		defer errMu.Unlock()
+		return sourceErrs == 1
+	}, time.Second, time.Millisecond*10)
+
+	// the remaining live source forms the majority
+	a1 := testHeader(1, common.Hash{}, 0)
+	working.feed.Send(a1)
+	select {
+	case got := <-out:
+		assert.Equal(t, a1.Hash(), got.Hash())
+	case <-time.After(time.Second):
+		t.Fatal("expected head from remaining source")
+	}
+}
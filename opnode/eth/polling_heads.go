@@ -0,0 +1,104 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// DefaultPollInterval is the default interval to poll for new heads at, when subscriptions are not available.
+const DefaultPollInterval = time.Second * 4
+
+type BlockNumberHeaderSource interface {
+	BlockNumberSource
+	HeaderByNumberSource
+}
+
+// PollingHeadSource implements NewHeadSource by polling the latest block number (eth_blockNumber)
+// and fetching the header (eth_getBlockByNumber) when the block number changes,
+// for endpoints that do not support subscriptions, like HTTP endpoints.
+//
+// Failed polls are retried at the next interval, and do not end the subscription.
+// A reorg that does not change the block number is picked up with the next block.
+type PollingHeadSource struct {
+	Src      BlockNumberHeaderSource
+	Interval time.Duration
+}
+
+var _ NewHeadSource = (*PollingHeadSource)(nil)
+
+func (p *PollingHeadSource) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(p.Interval)
+		defer ticker.Stop()
+
+		var lastNum uint64
+		var lastHash common.Hash
+		for {
+			if header := p.poll(ctx, lastNum, lastHash); header != nil {
+				lastNum, lastHash = header.Number.Uint64(), header.Hash()
+				select {
+				case ch <- header:
+				case <-ctx.Done():
+					return ctx.Err()
+				case <-quit:
+					return nil
+				}
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+// poll returns the latest header if it is new, or nil otherwise
+func (p *PollingHeadSource) poll(ctx context.Context, lastNum uint64, lastHash common.Hash) *types.Header {
+	pollCtx, cancel := context.WithTimeout(ctx, p.Interval)
+	defer cancel()
+	num, err := p.Src.BlockNumber(pollCtx)
+	if err != nil || (num == lastNum && lastHash != (common.Hash{})) {
+		return nil
+	}
+	header, err := p.Src.HeaderByNumber(pollCtx, new(big.Int).SetUint64(num))
+	if err != nil || header.Hash() == lastHash {
+		return nil
+	}
+	return header
+}
+
+// PollingL1Source is a L1Source that polls for new heads instead of subscribing to them.
+type PollingL1Source struct {
+	L1Source
+	Heads *PollingHeadSource
+}
+
+// NewPollingL1Source wraps the L1 source to poll for new heads at the given interval.
+func NewPollingL1Source(src interface {
+	L1Source
+	BlockNumberSource
+}, interval time.Duration) *PollingL1Source {
+	return &PollingL1Source{
+		L1Source: src,
+		Heads:    &PollingHeadSource{Src: src, Interval: interval},
+	}
+}
+
+func (s *PollingL1Source) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return s.Heads.SubscribeNewHead(ctx, ch)
+}
+
+// IsPollingTransport returns true if the RPC endpoint address uses a transport without subscription support (HTTP).
+func IsPollingTransport(addr string) bool {
+	t, err := DetectTransport(addr)
+	return err == nil && !t.SupportsSubscriptions()
+}
@@ -0,0 +1,105 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPollChain is a chain with a changing head, to poll
+type testPollChain struct {
+	mu      sync.Mutex
+	headers map[uint64]*types.Header
+	head    uint64
+	fail    bool
+}
+
+func (c *testPollChain) BlockNumber(ctx context.Context) (uint64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.fail {
+		return 0, errors.New("test failure")
+	}
+	return c.head, nil
+}
+
+func (c *testPollChain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	h, ok := c.headers[number.Uint64()]
+	if !ok {
+		return nil, ethereum.NotFound
+	}
+	return h, nil
+}
+
+func (c *testPollChain) setHead(h *types.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.headers[h.Number.Uint64()] = h
+	c.head = h.Number.Uint64()
+}
+
+func (c *testPollChain) setFail(fail bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fail = fail
+}
+
+func TestPollingHeadSource(t *testing.T) {
+	h1 := testHeader(1, common.Hash{}, 0)
+	chain := &testPollChain{headers: make(map[uint64]*types.Header)}
+	chain.setHead(h1)
+
+	src := &PollingHeadSource{Src: chain, Interval: time.Millisecond * 10}
+	var mu sync.Mutex
+	var heads []HeadSignal
+	sub, err := WatchHeadChanges(context.Background(), src, func(sig HeadSignal) {
+		mu.Lock()
+		defer mu.Unlock()
+		heads = append(heads, sig)
+	})
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	expectHeads := func(n int) {
+		assert.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(heads) == n
+		}, time.Second, time.Millisecond*5)
+	}
+	expectHeads(1)
+	// an unchanged head is not repeated
+	time.Sleep(time.Millisecond * 50)
+	expectHeads(1)
+
+	// failed polls are retried
+	chain.setFail(true)
+	h2 := testHeader(2, h1.Hash(), 0)
+	chain.setHead(h2)
+	time.Sleep(time.Millisecond * 50)
+	expectHeads(1)
+	chain.setFail(false)
+	expectHeads(2)
+
+	mu.Lock()
+	assert.Equal(t, HeadSignalFromHeader(h2), heads[1])
+	mu.Unlock()
+}
+
+func TestIsPollingTransport(t *testing.T) {
+	assert.True(t, IsPollingTransport("http://127.0.0.1:8545"))
+	assert.True(t, IsPollingTransport("HTTPS://example.com"))
+	assert.False(t, IsPollingTransport("ws://127.0.0.1:8546"))
+	assert.False(t, IsPollingTransport("/tmp/geth.ipc"))
+}
@@ -0,0 +1,175 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"golang.org/x/time/rate"
+)
+
+// RateLimiter is a token-bucket rate limiter for outbound RPC calls, to stay within the quota of a RPC provider.
+// Calls that exceed the rate wait for a token, and are counted as throttled.
+type RateLimiter struct {
+	limiter *rate.Limiter
+
+	// number of calls waiting for a token
+	queued int64
+
+	throttled  metrics.Counter
+	queueDepth metrics.Gauge
+}
+
+// NewRateLimiter creates a RateLimiter that allows rps calls per second, with bursts of up to burst calls.
+// The metrics are registered in the given registry, e.g. metrics.DefaultRegistry,
+// and are no-ops unless metrics.Enabled is set before creating the RateLimiter.
+func NewRateLimiter(rps float64, burst int, r metrics.Registry) *RateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &RateLimiter{
+		limiter:    rate.NewLimiter(rate.Limit(rps), burst),
+		throttled:  metrics.NewRegisteredCounter("opnode/l1/rpc/throttled", r),
+		queueDepth: metrics.NewRegisteredGauge("opnode/l1/rpc/queue", r),
+	}
+}
+
+// Wait blocks until the call is allowed, or returns an error if the context is done first.
+func (rl *RateLimiter) Wait(ctx context.Context) error {
+	res := rl.limiter.Reserve()
+	delay := res.Delay()
+	if delay == 0 {
+		return nil
+	}
+	rl.throttled.Inc(1)
+	rl.queueDepth.Update(atomic.AddInt64(&rl.queued, 1))
+	defer func() {
+		rl.queueDepth.Update(atomic.AddInt64(&rl.queued, -1))
+	}()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		res.Cancel()
+		return ctx.Err()
+	}
+}
+
+// Queued returns the number of calls currently waiting for a token.
+func (rl *RateLimiter) Queued() int64 {
+	return atomic.LoadInt64(&rl.queued)
+}
+
+// RateLimitedL1Source wraps a L1Source to rate limit all its calls with a RateLimiter.
+// Creating a subscription counts as a call, the subscribed heads do not.
+type RateLimitedL1Source struct {
+	src     L1Source
+	limiter *RateLimiter
+}
+
+var _ L1Source = (*RateLimitedL1Source)(nil)
+
+func NewRateLimitedL1Source(src L1Source, limiter *RateLimiter) *RateLimitedL1Source {
+	return &RateLimitedL1Source{src: src, limiter: limiter}
+}
+
+func (s *RateLimitedL1Source) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.src.SubscribeNewHead(ctx, ch)
+}
+
+func (s *RateLimitedL1Source) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.src.HeaderByHash(ctx, hash)
+}
+
+func (s *RateLimitedL1Source) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.src.HeaderByNumber(ctx, number)
+}
+
+func (s *RateLimitedL1Source) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.src.TransactionReceipt(ctx, txHash)
+}
+
+func (s *RateLimitedL1Source) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if err := s.limiter.Wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.src.BlockByHash(ctx, hash)
+}
+
+// BlockNumber implements BlockNumberSource, if the wrapped source does, to poll the wrapped source for new heads.
+func (s *RateLimitedL1Source) BlockNumber(ctx context.Context) (uint64, error) {
+	src, ok := s.src.(BlockNumberSource)
+	if !ok {
+		return 0, fmt.Errorf("source %T does not support block numbers", s.src)
+	}
+	if err := s.limiter.Wait(ctx); err != nil {
+		return 0, err
+	}
+	return src.BlockNumber(ctx)
+}
+
+func (s *RateLimitedL1Source) Close() {
+	s.src.Close()
+}
+
+// RateLimitedRPC wraps a RPCCaller to rate limit its calls with a RateLimiter.
+type RateLimitedRPC struct {
+	rpc     RPCCaller
+	limiter *RateLimiter
+}
+
+var _ RPCCaller = (*RateLimitedRPC)(nil)
+
+func NewRateLimitedRPC(rpc RPCCaller, limiter *RateLimiter) *RateLimitedRPC {
+	return &RateLimitedRPC{rpc: rpc, limiter: limiter}
+}
+
+func (r *RateLimitedRPC) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	if err := r.limiter.Wait(ctx); err != nil {
+		return err
+	}
+	return r.rpc.CallContext(ctx, result, method, args...)
+}
+
+// RateLimitedBatchCaller wraps a BatchCaller to rate limit its batches with a RateLimiter:
+// every call in a batch counts as a call.
+type RateLimitedBatchCaller struct {
+	rpc     BatchCaller
+	limiter *RateLimiter
+}
+
+var _ BatchCaller = (*RateLimitedBatchCaller)(nil)
+
+func NewRateLimitedBatchCaller(rpc BatchCaller, limiter *RateLimiter) *RateLimitedBatchCaller {
+	return &RateLimitedBatchCaller{rpc: rpc, limiter: limiter}
+}
+
+func (r *RateLimitedBatchCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	for range b {
+		if err := r.limiter.Wait(ctx); err != nil {
+			return err
+		}
+	}
+	return r.rpc.BatchCallContext(ctx, b)
+}
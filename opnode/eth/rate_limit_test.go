@@ -0,0 +1,88 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimiter(t *testing.T) {
+	rl := NewRateLimiter(10, 2, metrics.NewRegistry())
+	ctx := context.Background()
+
+	// the burst is not throttled
+	start := time.Now()
+	require.NoError(t, rl.Wait(ctx))
+	require.NoError(t, rl.Wait(ctx))
+	assert.Less(t, time.Since(start), time.Millisecond*50)
+
+	// the next call waits for a token
+	done := make(chan error)
+	go func() {
+		done <- rl.Wait(ctx)
+	}()
+	assert.Eventually(t, func() bool { return rl.Queued() == 1 }, time.Second, time.Millisecond)
+	require.NoError(t, <-done)
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*50)
+	assert.Equal(t, int64(0), rl.Queued())
+
+	// a waiting call can be cancelled
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond)
+	defer cancel()
+	require.NoError(t, rl.Wait(ctx))
+	assert.ErrorIs(t, rl.Wait(cctx), context.DeadlineExceeded)
+	assert.Equal(t, int64(0), rl.Queued())
+}
+
+func TestRateLimitedL1Source(t *testing.T) {
+	src := &failoverTestSource{header: testHeader(1, [32]byte{}, 0)}
+	s := NewRateLimitedL1Source(src, NewRateLimiter(1, 1, metrics.NewRegistry()))
+	ctx := context.Background()
+
+	h, err := s.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	assert.Equal(t, src.header, h)
+
+	// the source is not called while the call is throttled
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	_, err = s.HeaderByNumber(cctx, big.NewInt(1))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, src.calls)
+
+	_, err = s.BlockNumber(ctx)
+	assert.Error(t, err, "the source does not support block numbers")
+
+	_, err = s.SubscribeNewHead(cctx, make(chan *types.Header))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+type countingBatchCaller struct {
+	calls int
+}
+
+func (c *countingBatchCaller) BatchCallContext(ctx context.Context, b []rpc.BatchElem) error {
+	c.calls += len(b)
+	return nil
+}
+
+func TestRateLimitedBatchCaller(t *testing.T) {
+	src := new(countingBatchCaller)
+	bc := NewRateLimitedBatchCaller(src, NewRateLimiter(1, 2, metrics.NewRegistry()))
+	ctx := context.Background()
+	require.NoError(t, bc.BatchCallContext(ctx, make([]rpc.BatchElem, 2)))
+	assert.Equal(t, 2, src.calls)
+
+	// every call of the batch takes a token, the batch is not sent while throttled
+	cctx, cancel := context.WithTimeout(ctx, time.Millisecond*10)
+	defer cancel()
+	assert.ErrorIs(t, bc.BatchCallContext(cctx, make([]rpc.BatchElem, 1)), context.DeadlineExceeded)
+	assert.Equal(t, 2, src.calls)
+}
@@ -0,0 +1,257 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// DefaultReceiptsConcurrency is the number of concurrent TransactionReceipt requests per block, if not configured
+const DefaultReceiptsConcurrency = 16
+
+// ReceiptsFetchedFn is called after fetching the receipts of a block, with the number of receipts and the fetch duration
+type ReceiptsFetchedFn func(blockHash common.Hash, receipts int, duration time.Duration)
+
+// JSON-RPC error code of a method that the endpoint does not support
+const methodNotFoundErrCode = -32601
+
+type BlockReceiptsSource interface {
+	BlockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error)
+}
+
+type BlockReceiptsFn func(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error)
+
+func (fn BlockReceiptsFn) BlockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+	return fn(ctx, blockHash)
+}
+
+type RPCCaller interface {
+	CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error
+}
+
+// RPCBlockReceipts implements BlockReceiptsSource with the eth_getBlockReceipts RPC method,
+// which is not part of the standard JSON-RPC, but supported by various L1 clients.
+type RPCBlockReceipts struct {
+	RPC RPCCaller
+}
+
+func (r RPCBlockReceipts) BlockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+	var receipts []*types.Receipt
+	if err := r.RPC.CallContext(ctx, &receipts, "eth_getBlockReceipts", blockHash); err != nil {
+		return nil, ClassifyFetchErr(err)
+	}
+	return receipts, nil
+}
+
+// ReceiptsFetcher fetches all receipts of a block, and verifies them against the block.
+//
+// The receipts are fetched at once with BlockReceipts if available,
+// and fall back to concurrent TransactionReceipt requests otherwise,
+// split between a bounded number of workers.
+// Once BlockReceipts is found to be unsupported by the endpoint it is no longer attempted.
+type ReceiptsFetcher struct {
+	// Block is optional, to fetch all receipts at once
+	Block BlockReceiptsSource
+	// Tx is used to fetch receipts one by one, if Block is not available
+	Tx ReceiptSource
+
+	// Concurrency is the max number of concurrent TransactionReceipt requests per block,
+	// DefaultReceiptsConcurrency if 0.
+	Concurrency int
+	// RequestTimeout is optional, to limit the duration of each individual request
+	RequestTimeout time.Duration
+	// OnFetched is optional, to track the fetch duration
+	OnFetched ReceiptsFetchedFn
+
+	// set to 1 (atomic) when the Block source is unsupported by the endpoint
+	blockUnsupported uint32
+}
+
+// FetchReceipts fetches the receipts of the given block, in transaction order,
+// and verifies that the receipts match the receipts-root and transactions of the block.
+func (rf *ReceiptsFetcher) FetchReceipts(ctx context.Context, block *types.Block) ([]*types.Receipt, error) {
+	start := time.Now()
+	receipts, err := rf.fetch(ctx, block)
+	if err != nil {
+		return nil, err
+	}
+	if rf.OnFetched != nil {
+		rf.OnFetched(block.Hash(), len(receipts), time.Since(start))
+	}
+	return receipts, nil
+}
+
+func (rf *ReceiptsFetcher) fetch(ctx context.Context, block *types.Block) ([]*types.Receipt, error) {
+	if rf.Block != nil && atomic.LoadUint32(&rf.blockUnsupported) == 0 {
+		receipts, err := rf.blockReceipts(ctx, block.Hash())
+		if err == nil {
+			if err := VerifyReceipts(block, receipts); err != nil {
+				return nil, err
+			}
+			return receipts, nil
+		}
+		var rpcErr rpc.Error
+		if errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundErrCode {
+			atomic.StoreUint32(&rf.blockUnsupported, 1)
+		}
+		// fall back to fetching the receipts one by one
+	}
+	if rf.Tx == nil {
+		return nil, errors.New("no receipt source available")
+	}
+	receipts, err := rf.txReceipts(ctx, block.Transactions())
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyReceipts(block, receipts); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+func (rf *ReceiptsFetcher) blockReceipts(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+	if rf.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rf.RequestTimeout)
+		defer cancel()
+	}
+	return rf.Block.BlockReceipts(ctx, blockHash)
+}
+
+// txReceipts fetches the receipt of each of the transactions with a pool of workers, and returns them in order.
+func (rf *ReceiptsFetcher) txReceipts(ctx context.Context, txs types.Transactions) ([]*types.Receipt, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	workers := rf.Concurrency
+	if workers <= 0 {
+		workers = DefaultReceiptsConcurrency
+	}
+	if workers > len(txs) {
+		workers = len(txs)
+	}
+	receipts := make([]*types.Receipt, len(txs))
+	tasks := make(chan int, len(txs))
+	for i := range txs {
+		tasks <- i
+	}
+	close(tasks)
+
+	var firstErr error
+	var errOnce sync.Once
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := range tasks {
+				if ctx.Err() != nil {
+					return
+				}
+				rec, err := rf.txReceipt(ctx, txs[i].Hash())
+				if err != nil {
+					errOnce.Do(func() {
+						firstErr = fmt.Errorf("failed to fetch receipt %d of tx %s: %w", i, txs[i].Hash(), ClassifyFetchErr(err))
+					})
+					cancel() // no need to continue fetching the other receipts
+					return
+				}
+				receipts[i] = rec
+			}
+		}()
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return receipts, nil
+}
+
+func (rf *ReceiptsFetcher) txReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	if rf.RequestTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, rf.RequestTimeout)
+		defer cancel()
+	}
+	return rf.Tx.TransactionReceipt(ctx, txHash)
+}
+
+// ReceiptsMetrics tracks the fetching of receipts: the duration to fetch the receipts of a block,
+// and the number of receipts per block.
+//
+// Like the go-ethereum metrics, the metrics are no-ops unless metrics.Enabled is set before creating the ReceiptsMetrics.
+type ReceiptsMetrics struct {
+	fetchTime metrics.Timer
+	receipts  metrics.Histogram
+}
+
+// NewReceiptsMetrics registers the receipts metrics in the given registry, e.g. metrics.DefaultRegistry.
+func NewReceiptsMetrics(r metrics.Registry) *ReceiptsMetrics {
+	return &ReceiptsMetrics{
+		fetchTime: metrics.NewRegisteredTimer("opnode/l1/receipts/fetch_time", r),
+		receipts:  metrics.NewRegisteredHistogram("opnode/l1/receipts/per_block", r, metrics.NewExpDecaySample(1028, 0.015)),
+	}
+}
+
+// RecordFetch implements ReceiptsFetchedFn.
+func (m *ReceiptsMetrics) RecordFetch(blockHash common.Hash, receipts int, duration time.Duration) {
+	m.fetchTime.Update(duration)
+	m.receipts.Update(int64(receipts))
+}
+
+// ReceiptsDownloader implements FetchSource: it fetches the block by hash, and then its receipts with the ReceiptsFetcher.
+type ReceiptsDownloader struct {
+	Blocks   BlockByHashSource
+	Receipts *ReceiptsFetcher
+}
+
+var _ FetchSource = (*ReceiptsDownloader)(nil)
+
+func (rd *ReceiptsDownloader) Fetch(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+	block, err := rd.Blocks.BlockByHash(ctx, id.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch block %s: %w", id, ClassifyFetchErr(err))
+	}
+	if block.Hash() != id.Hash {
+		return nil, nil, fmt.Errorf("fetched block %s does not match requested block %s: %w", block.Hash(), id, InvalidResponseErr)
+	}
+	receipts, err := rd.Receipts.FetchReceipts(ctx, block)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch receipts of block %s: %w", id, err)
+	}
+	return block, receipts, nil
+}
+
+// VerifyReceipts checks that the receipts belong to the transactions of the block, in order,
+// and that they match the receipts-root of the block. The returned error wraps InvalidResponseErr.
+func VerifyReceipts(block *types.Block, receipts []*types.Receipt) error {
+	txs := block.Transactions()
+	if len(receipts) != len(txs) {
+		return fmt.Errorf("got %d receipts, but block %s has %d transactions: %w", len(receipts), block.Hash(), len(txs), InvalidResponseErr)
+	}
+	for i, rec := range receipts {
+		if rec == nil {
+			return fmt.Errorf("missing receipt %d: %w", i, InvalidResponseErr)
+		}
+		if rec.TxHash != txs[i].Hash() {
+			return fmt.Errorf("receipt %d is for tx %s, expected tx %s: %w", i, rec.TxHash, txs[i].Hash(), InvalidResponseErr)
+		}
+	}
+	computed := types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil))
+	if computed != block.ReceiptHash() {
+		return fmt.Errorf("receipts root %s does not match block %s receipts root %s: %w", computed, block.Hash(), block.ReceiptHash(), InvalidResponseErr)
+	}
+	return nil
+}
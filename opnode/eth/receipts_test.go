@@ -0,0 +1,201 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type methodNotFoundErr struct{}
+
+func (methodNotFoundErr) Error() string  { return "the method eth_getBlockReceipts does not exist" }
+func (methodNotFoundErr) ErrorCode() int { return methodNotFoundErrCode }
+
+func testBlockWithReceipts(n int) (*types.Block, []*types.Receipt) {
+	var txs []*types.Transaction
+	var receipts []*types.Receipt
+	for i := 0; i < n; i++ {
+		tx := types.NewTransaction(uint64(i), common.Address{0x42}, big.NewInt(1), 21000, big.NewInt(1), nil)
+		txs = append(txs, tx)
+		receipts = append(receipts, &types.Receipt{
+			Type:              types.LegacyTxType,
+			Status:            types.ReceiptStatusSuccessful,
+			CumulativeGasUsed: uint64(21000 * (i + 1)),
+			Logs:              []*types.Log{},
+			TxHash:            tx.Hash(),
+		})
+	}
+	block := types.NewBlock(&types.Header{Number: big.NewInt(1), Difficulty: common.Big0}, txs, nil, receipts, trie.NewStackTrie(nil))
+	return block, receipts
+}
+
+func receiptsByTx(receipts []*types.Receipt, calls *int32) ReceiptFn {
+	return func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+		atomic.AddInt32(calls, 1)
+		for _, rec := range receipts {
+			if rec.TxHash == txHash {
+				return rec, nil
+			}
+		}
+		return nil, errors.New("not found")
+	}
+}
+
+func TestReceiptsFetcher_BlockReceipts(t *testing.T) {
+	block, receipts := testBlockWithReceipts(5)
+	var txCalls int32
+	rf := &ReceiptsFetcher{
+		Block: BlockReceiptsFn(func(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+			assert.Equal(t, block.Hash(), blockHash)
+			return receipts, nil
+		}),
+		Tx: receiptsByTx(receipts, &txCalls),
+	}
+	got, err := rf.FetchReceipts(context.Background(), block)
+	require.NoError(t, err)
+	assert.Equal(t, receipts, got)
+	assert.Equal(t, int32(0), txCalls)
+}
+
+func TestReceiptsFetcher_Fallback(t *testing.T) {
+	block, receipts := testBlockWithReceipts(5)
+	var blockCalls, txCalls int32
+	rf := &ReceiptsFetcher{
+		Block: BlockReceiptsFn(func(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+			atomic.AddInt32(&blockCalls, 1)
+			return nil, methodNotFoundErr{}
+		}),
+		Tx: receiptsByTx(receipts, &txCalls),
+	}
+	for i := 0; i < 2; i++ {
+		got, err := rf.FetchReceipts(context.Background(), block)
+		require.NoError(t, err)
+		assert.Equal(t, receipts, got)
+	}
+	assert.Equal(t, int32(1), blockCalls, "unsupported method is not retried")
+	assert.Equal(t, int32(10), txCalls)
+}
+
+func TestReceiptsFetcher_Verify(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	var txCalls int32
+
+	bad := make([]*types.Receipt, len(receipts))
+	copy(bad, receipts)
+	changed := *bad[1]
+	changed.Status = types.ReceiptStatusFailed
+	bad[1] = &changed
+
+	rf := &ReceiptsFetcher{
+		Block: BlockReceiptsFn(func(ctx context.Context, blockHash common.Hash) ([]*types.Receipt, error) {
+			return bad, nil
+		}),
+		Tx: receiptsByTx(receipts, &txCalls),
+	}
+	_, err := rf.FetchReceipts(context.Background(), block)
+	assert.Error(t, err, "receipts root mismatch")
+
+	assert.ErrorIs(t, VerifyReceipts(block, receipts[:2]), InvalidResponseErr, "missing receipt")
+	assert.Error(t, VerifyReceipts(block, []*types.Receipt{receipts[1], receipts[0], receipts[2]}), "out of order")
+	assert.NoError(t, VerifyReceipts(block, receipts))
+}
+
+func TestReceiptsFetcher_Concurrency(t *testing.T) {
+	block, receipts := testBlockWithReceipts(50)
+	var active, maxActive int32
+	var fetched []int
+	rf := &ReceiptsFetcher{
+		Tx: ReceiptFn(func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			n := atomic.AddInt32(&active, 1)
+			defer atomic.AddInt32(&active, -1)
+			for {
+				max := atomic.LoadInt32(&maxActive)
+				if n <= max || atomic.CompareAndSwapInt32(&maxActive, max, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			var calls int32
+			return receiptsByTx(receipts, &calls)(ctx, txHash)
+		}),
+		Concurrency: 4,
+		OnFetched: func(blockHash common.Hash, n int, duration time.Duration) {
+			assert.Equal(t, block.Hash(), blockHash)
+			fetched = append(fetched, n)
+		},
+	}
+	got, err := rf.FetchReceipts(context.Background(), block)
+	require.NoError(t, err)
+	assert.Equal(t, receipts, got, "receipts are assembled in order")
+	assert.LessOrEqual(t, maxActive, int32(4))
+	assert.Greater(t, maxActive, int32(1))
+	assert.Equal(t, []int{50}, fetched)
+}
+
+func TestReceiptsFetcher_RequestTimeout(t *testing.T) {
+	block, _ := testBlockWithReceipts(3)
+	rf := &ReceiptsFetcher{
+		Tx: ReceiptFn(func(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}),
+		RequestTimeout: time.Millisecond * 10,
+	}
+	_, err := rf.FetchReceipts(context.Background(), block)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), context.DeadlineExceeded.Error())
+}
+
+func TestReceiptsDownloader(t *testing.T) {
+	block, receipts := testBlockWithReceipts(3)
+	id := BlockID{Hash: block.Hash(), Number: block.NumberU64()}
+	var txCalls int32
+	rd := &ReceiptsDownloader{
+		Blocks: BlockByHashFn(func(ctx context.Context, hash common.Hash) (*types.Block, error) {
+			if hash != block.Hash() {
+				return nil, ethereum.NotFound
+			}
+			return block, nil
+		}),
+		Receipts: &ReceiptsFetcher{Tx: receiptsByTx(receipts, &txCalls)},
+	}
+	bl, got, err := rd.Fetch(context.Background(), id)
+	require.NoError(t, err)
+	assert.Equal(t, block, bl)
+	assert.Equal(t, receipts, got)
+
+	_, _, err = rd.Fetch(context.Background(), BlockID{Hash: common.Hash{1}, Number: 1})
+	assert.ErrorIs(t, err, BlockNotFoundErr)
+
+	rd.Receipts = &ReceiptsFetcher{Tx: receiptsByTx(receipts[1:], &txCalls)}
+	_, _, err = rd.Fetch(context.Background(), id)
+	assert.Error(t, err, "missing receipt")
+}
+
+func TestReceiptsMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+	r := metrics.NewRegistry()
+	m := NewReceiptsMetrics(r)
+
+	block, receipts := testBlockWithReceipts(3)
+	var txCalls int32
+	rf := &ReceiptsFetcher{Tx: receiptsByTx(receipts, &txCalls), OnFetched: m.RecordFetch}
+	_, err := rf.FetchReceipts(context.Background(), block)
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), r.Get("opnode/l1/receipts/fetch_time").(metrics.Timer).Count())
+	assert.Equal(t, int64(3), r.Get("opnode/l1/receipts/per_block").(metrics.Histogram).Max())
+}
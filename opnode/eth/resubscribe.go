@@ -0,0 +1,100 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+const (
+	DefaultResubscribeInitialBackoff = time.Second
+	DefaultResubscribeMaxBackoff     = time.Minute
+)
+
+// SubscribeFn creates a new subscription, e.g. by wrapping WatchHeadChanges.
+type SubscribeFn func(ctx context.Context) (ethereum.Subscription, error)
+
+// Resubscriber keeps a subscription alive: when subscribing fails, or the subscription ends with an error,
+// it re-subscribes after an exponential backoff with jitter.
+//
+// The combined subscription only fails after MaxFailures consecutive failures.
+// A successful subscription resets the count, a disconnect after that counts as the first failure.
+type Resubscriber struct {
+	// InitialBackoff is the backoff after the first failure, doubled with every consecutive failure.
+	// Defaults to DefaultResubscribeInitialBackoff if zero.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the backoff. Defaults to DefaultResubscribeMaxBackoff if zero.
+	MaxBackoff time.Duration
+	// MaxFailures is the number of consecutive failures after which the error is surfaced.
+	// Zero to never give up.
+	MaxFailures int
+	// OnDisconnect is optional, and called with the reason of every failure, and the count of consecutive failures.
+	OnDisconnect func(err error, failures int)
+}
+
+// Subscribe subscribes with fn, and re-subscribes with fn on failures.
+func (r *Resubscriber) Subscribe(ctx context.Context, fn SubscribeFn) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		failures := 0
+		for {
+			sub, err := fn(ctx)
+			if err == nil {
+				failures = 0
+				select {
+				case err = <-sub.Err():
+					sub.Unsubscribe()
+					if err == nil {
+						err = fmt.Errorf("subscription ended")
+					}
+				case <-ctx.Done():
+					sub.Unsubscribe()
+					return ctx.Err()
+				case <-quit:
+					sub.Unsubscribe()
+					return nil
+				}
+			}
+			failures++
+			if r.OnDisconnect != nil {
+				r.OnDisconnect(err, failures)
+			}
+			if r.MaxFailures > 0 && failures >= r.MaxFailures {
+				return fmt.Errorf("giving up after %d consecutive failures: %w", failures, err)
+			}
+			backoff := time.NewTimer(r.backoff(failures))
+			select {
+			case <-backoff.C:
+			case <-ctx.Done():
+				backoff.Stop()
+				return ctx.Err()
+			case <-quit:
+				backoff.Stop()
+				return nil
+			}
+		}
+	})
+}
+
+// backoff returns the backoff after the given number of consecutive failures,
+// with a random jitter of up to half the backoff, to not re-subscribe in lockstep with other clients.
+func (r *Resubscriber) backoff(failures int) time.Duration {
+	d := r.InitialBackoff
+	if d == 0 {
+		d = DefaultResubscribeInitialBackoff
+	}
+	max := r.MaxBackoff
+	if max == 0 {
+		max = DefaultResubscribeMaxBackoff
+	}
+	for i := 1; i < failures && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
@@ -0,0 +1,86 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errTestDisconnect = errors.New("disconnected")
+
+func TestResubscriber(t *testing.T) {
+	attempts := 0
+	// fail to subscribe twice, then subscribe and disconnect, then stay subscribed
+	fn := func(ctx context.Context) (ethereum.Subscription, error) {
+		attempts++
+		switch attempts {
+		case 1, 2:
+			return nil, errTestDisconnect
+		case 3:
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				return errTestDisconnect
+			}), nil
+		default:
+			return event.NewSubscription(func(quit <-chan struct{}) error {
+				<-quit
+				return nil
+			}), nil
+		}
+	}
+	var failures []int
+	done := make(chan struct{})
+	r := &Resubscriber{
+		InitialBackoff: time.Millisecond,
+		MaxBackoff:     time.Millisecond * 4,
+		MaxFailures:    3,
+		OnDisconnect: func(err error, n int) {
+			assert.ErrorIs(t, err, errTestDisconnect)
+			failures = append(failures, n)
+			if len(failures) == 3 {
+				close(done)
+			}
+		},
+	}
+	sub := r.Subscribe(context.Background(), fn)
+	select {
+	case <-done:
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	// the successful subscription resets the count, the limit of 3 is not reached
+	assert.Equal(t, []int{1, 2, 1}, failures)
+	sub.Unsubscribe()
+	assert.NoError(t, <-sub.Err())
+}
+
+func TestResubscriberMaxFailures(t *testing.T) {
+	attempts := 0
+	r := &Resubscriber{InitialBackoff: time.Millisecond, MaxFailures: 3}
+	sub := r.Subscribe(context.Background(), func(ctx context.Context) (ethereum.Subscription, error) {
+		attempts++
+		return nil, errTestDisconnect
+	})
+	select {
+	case err := <-sub.Err():
+		require.ErrorIs(t, err, errTestDisconnect)
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, 3, attempts)
+}
+
+func TestResubscriberBackoff(t *testing.T) {
+	r := &Resubscriber{InitialBackoff: time.Second, MaxBackoff: time.Second * 5}
+	for i, max := range []time.Duration{time.Second, time.Second * 2, time.Second * 4, time.Second * 5, time.Second * 5} {
+		failures := i + 1
+		d := r.backoff(failures)
+		assert.LessOrEqual(t, d, max, "failures %d", failures)
+		assert.GreaterOrEqual(t, d, max/2, "failures %d", failures)
+	}
+}
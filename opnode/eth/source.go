@@ -25,6 +25,10 @@ type HeaderByNumberSource interface {
 	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
 }
 
+type BlockNumberSource interface {
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
 type ReceiptSource interface {
 	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 }
@@ -37,6 +41,15 @@ type BlockByNumberSource interface {
 	BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error)
 }
 
+type LogSubscriber interface {
+	SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error)
+}
+
+// FetchSource fetches a block with all its receipts, like the L1 downloader.
+type FetchSource interface {
+	Fetch(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error)
+}
+
 type L1Source interface {
 	NewHeadSource
 	HeaderByHashSource
@@ -59,6 +72,12 @@ func (fn NewHeadFn) SubscribeNewHead(ctx context.Context, ch chan<- *types.Heade
 	return fn(ctx, ch)
 }
 
+type FetchFn func(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error)
+
+func (fn FetchFn) Fetch(ctx context.Context, id BlockID) (*types.Block, []*types.Receipt, error) {
+	return fn(ctx, id)
+}
+
 type HeaderByHashFn func(ctx context.Context, hash common.Hash) (*types.Header, error)
 
 func (fn HeaderByHashFn) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
@@ -71,6 +90,12 @@ func (fn HeaderByNumberFn) HeaderByNumber(ctx context.Context, number *big.Int)
 	return fn(ctx, number)
 }
 
+type BlockNumberFn func(ctx context.Context) (uint64, error)
+
+func (fn BlockNumberFn) BlockNumber(ctx context.Context) (uint64, error) {
+	return fn(ctx)
+}
+
 type ReceiptFn func(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
 
 func (fn ReceiptFn) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
@@ -0,0 +1,86 @@
+package eth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Transport is the transport of a RPC endpoint, which determines its capabilities.
+type Transport uint8
+
+const (
+	HTTPTransport Transport = iota
+	WSTransport
+	IPCTransport
+)
+
+func (t Transport) String() string {
+	switch t {
+	case HTTPTransport:
+		return "http"
+	case WSTransport:
+		return "ws"
+	case IPCTransport:
+		return "ipc"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(t))
+	}
+}
+
+// SupportsSubscriptions returns true if the transport supports subscriptions, e.g. to new heads.
+func (t Transport) SupportsSubscriptions() bool {
+	return t == WSTransport || t == IPCTransport
+}
+
+// DetectTransport detects the transport of the RPC endpoint address, like rpc.DialContext:
+// http(s):// and ws(s):// URLs, or a path to an IPC socket file (conventionally ending in .ipc).
+func DetectTransport(addr string) (Transport, error) {
+	u, err := url.Parse(addr)
+	if err != nil {
+		return 0, fmt.Errorf("invalid RPC address %q: %v", addr, err)
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		return HTTPTransport, nil
+	case "ws", "wss":
+		return WSTransport, nil
+	case "":
+		if addr == "" {
+			return 0, fmt.Errorf("empty RPC address")
+		}
+		return IPCTransport, nil
+	default:
+		return 0, fmt.Errorf("unsupported RPC transport %q of address %q", u.Scheme, addr)
+	}
+}
+
+// HeadTrackingMode determines how new heads are tracked: by subscribing to them, or by polling for them.
+type HeadTrackingMode string
+
+const (
+	// AutoHeads subscribes to new heads if the transport supports it, and polls otherwise
+	AutoHeads HeadTrackingMode = "auto"
+	// SubscribeHeads subscribes to new heads
+	SubscribeHeads HeadTrackingMode = "subscribe"
+	// PollHeads polls for new heads, e.g. for a WS endpoint of a provider that drops subscriptions
+	PollHeads HeadTrackingMode = "poll"
+)
+
+// Polling returns true if heads of an endpoint with the given transport are polled for,
+// or an error if the mode is invalid or not supported by the transport.
+func (m HeadTrackingMode) Polling(t Transport) (bool, error) {
+	switch m {
+	case AutoHeads, "":
+		return !t.SupportsSubscriptions(), nil
+	case SubscribeHeads:
+		if !t.SupportsSubscriptions() {
+			return false, fmt.Errorf("%s transport does not support head subscriptions", t)
+		}
+		return false, nil
+	case PollHeads:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown head tracking mode %q", string(m))
+	}
+}
@@ -0,0 +1,49 @@
+package eth
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectTransport(t *testing.T) {
+	for addr, expected := range map[string]Transport{
+		"http://127.0.0.1:8545":    HTTPTransport,
+		"HTTPS://example.com/key":  HTTPTransport,
+		"ws://127.0.0.1:8546":      WSTransport,
+		"wss://example.com/ws/key": WSTransport,
+		"/tmp/geth.ipc":            IPCTransport,
+		"geth.ipc":                 IPCTransport,
+	} {
+		got, err := DetectTransport(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, expected, got, addr)
+	}
+	_, err := DetectTransport("ftp://example.com")
+	assert.Error(t, err)
+	_, err = DetectTransport("")
+	assert.Error(t, err)
+}
+
+func TestHeadTrackingMode(t *testing.T) {
+	polling, err := AutoHeads.Polling(HTTPTransport)
+	require.NoError(t, err)
+	assert.True(t, polling)
+	polling, err = AutoHeads.Polling(IPCTransport)
+	require.NoError(t, err)
+	assert.False(t, polling)
+
+	_, err = SubscribeHeads.Polling(HTTPTransport)
+	assert.Error(t, err, "http does not support subscriptions")
+	polling, err = SubscribeHeads.Polling(WSTransport)
+	require.NoError(t, err)
+	assert.False(t, polling)
+
+	polling, err = PollHeads.Polling(WSTransport)
+	require.NoError(t, err)
+	assert.True(t, polling)
+
+	_, err = HeadTrackingMode("push").Polling(WSTransport)
+	assert.Error(t, err)
+}
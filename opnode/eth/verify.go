@@ -0,0 +1,142 @@
+package eth
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// VerifyHeader checks that the header hashes to the expected block hash, and has the expected number.
+// The returned error wraps InvalidResponseErr.
+func VerifyHeader(header *types.Header, id BlockID) error {
+	if header.Number == nil {
+		return fmt.Errorf("header %s has no number: %w", id, InvalidResponseErr)
+	}
+	if computed := header.Hash(); computed != id.Hash {
+		return fmt.Errorf("header hashes to %s, expected %s: %w", computed, id, InvalidResponseErr)
+	}
+	if header.Number.Uint64() != id.Number {
+		return fmt.Errorf("header %s has number %d, expected %d: %w", id.Hash, header.Number.Uint64(), id.Number, InvalidResponseErr)
+	}
+	return nil
+}
+
+// VerifyHeaderLink checks that the child header builds on the parent header.
+// The returned error wraps InvalidResponseErr.
+func VerifyHeaderLink(parent *types.Header, child *types.Header) error {
+	parentHash := parent.Hash()
+	if child.ParentHash != parentHash {
+		return fmt.Errorf("header %s has parent %s, expected %s: %w", child.Hash(), child.ParentHash, parentHash, InvalidResponseErr)
+	}
+	if child.Number.Uint64() != parent.Number.Uint64()+1 {
+		return fmt.Errorf("header %s has number %d, but parent %s has number %d: %w",
+			child.Hash(), child.Number.Uint64(), parentHash, parent.Number.Uint64(), InvalidResponseErr)
+	}
+	if child.Time <= parent.Time {
+		return fmt.Errorf("header %s has time %d, not after parent %s time %d: %w",
+			child.Hash(), child.Time, parentHash, parent.Time, InvalidResponseErr)
+	}
+	return nil
+}
+
+// VerifyBlock checks that the block has the expected hash, and that the body matches the header.
+// The returned error wraps InvalidResponseErr.
+func VerifyBlock(block *types.Block, hash common.Hash) error {
+	if block.Hash() != hash {
+		return fmt.Errorf("block hashes to %s, expected %s: %w", block.Hash(), hash, InvalidResponseErr)
+	}
+	if computed := types.DeriveSha(block.Transactions(), trie.NewStackTrie(nil)); computed != block.TxHash() {
+		return fmt.Errorf("block %s transactions root %s does not match header %s: %w", hash, computed, block.TxHash(), InvalidResponseErr)
+	}
+	if computed := types.CalcUncleHash(block.Uncles()); computed != block.UncleHash() {
+		return fmt.Errorf("block %s uncles hash %s does not match header %s: %w", hash, computed, block.UncleHash(), InvalidResponseErr)
+	}
+	return nil
+}
+
+// VerifyingL1Source wraps a L1Source to verify the integrity of the fetched data, before it is used,
+// to protect against buggy or malicious RPC providers:
+// headers and blocks must hash to the requested hash, bodies must match their header,
+// and receipts must be of the requested transaction.
+// Headers by number only have their number verified: the canonical chain is verified when linking the headers.
+type VerifyingL1Source struct {
+	src L1Source
+}
+
+var _ L1Source = (*VerifyingL1Source)(nil)
+
+func NewVerifyingL1Source(src L1Source) *VerifyingL1Source {
+	return &VerifyingL1Source{src: src}
+}
+
+func (s *VerifyingL1Source) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return s.src.SubscribeNewHead(ctx, ch)
+}
+
+func (s *VerifyingL1Source) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	header, err := s.src.HeaderByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if header.Number == nil {
+		return nil, fmt.Errorf("header %s has no number: %w", hash, InvalidResponseErr)
+	}
+	if err := VerifyHeader(header, BlockID{Hash: hash, Number: header.Number.Uint64()}); err != nil {
+		return nil, err
+	}
+	return header, nil
+}
+
+func (s *VerifyingL1Source) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	header, err := s.src.HeaderByNumber(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	if header.Number == nil {
+		return nil, fmt.Errorf("header %s has no number: %w", header.Hash(), InvalidResponseErr)
+	}
+	if number != nil && header.Number.Cmp(number) != 0 {
+		return nil, fmt.Errorf("header %s has number %d, expected %d: %w", header.Hash(), header.Number, number, InvalidResponseErr)
+	}
+	return header, nil
+}
+
+func (s *VerifyingL1Source) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	receipt, err := s.src.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, err
+	}
+	if receipt.TxHash != txHash {
+		return nil, fmt.Errorf("receipt is of tx %s, expected %s: %w", receipt.TxHash, txHash, InvalidResponseErr)
+	}
+	return receipt, nil
+}
+
+func (s *VerifyingL1Source) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	block, err := s.src.BlockByHash(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyBlock(block, hash); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// BlockNumber implements BlockNumberSource, if the wrapped source does, to poll the wrapped source for new heads.
+func (s *VerifyingL1Source) BlockNumber(ctx context.Context) (uint64, error) {
+	src, ok := s.src.(BlockNumberSource)
+	if !ok {
+		return 0, fmt.Errorf("source %T does not support block numbers", s.src)
+	}
+	return src.BlockNumber(ctx)
+}
+
+func (s *VerifyingL1Source) Close() {
+	s.src.Close()
+}
@@ -0,0 +1,105 @@
+package eth
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyTestSource is a L1Source that serves the same fixed data for any request
+type verifyTestSource struct {
+	L1Source
+	header  *types.Header
+	block   *types.Block
+	receipt *types.Receipt
+}
+
+func (s *verifyTestSource) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	return s.header, nil
+}
+
+func (s *verifyTestSource) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return s.header, nil
+}
+
+func (s *verifyTestSource) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return s.block, nil
+}
+
+func (s *verifyTestSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return s.receipt, nil
+}
+
+func TestVerifyHeaderLink(t *testing.T) {
+	chain := testChain(nil, 3, 0)
+	for i, h := range chain {
+		h.Time = uint64(i) * 10
+	}
+	require.NoError(t, VerifyHeaderLink(chain[0], chain[1]))
+
+	assert.ErrorIs(t, VerifyHeaderLink(chain[0], chain[2]), InvalidResponseErr, "not the parent")
+
+	wrongNum := testHeader(5, chain[0].Hash(), 0)
+	wrongNum.Time = 10
+	assert.ErrorIs(t, VerifyHeaderLink(chain[0], wrongNum), InvalidResponseErr, "number does not follow parent")
+
+	sameTime := testHeader(1, chain[0].Hash(), 0)
+	assert.ErrorIs(t, VerifyHeaderLink(chain[0], sameTime), InvalidResponseErr, "time does not increase")
+}
+
+func TestVerifyingL1Source_Headers(t *testing.T) {
+	ctx := context.Background()
+	header := testHeader(10, common.Hash{0xaa}, 0)
+	src := NewVerifyingL1Source(&verifyTestSource{header: header})
+
+	h, err := src.HeaderByHash(ctx, header.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, header, h)
+	_, err = src.HeaderByHash(ctx, common.Hash{0xbb})
+	assert.ErrorIs(t, err, InvalidResponseErr, "header does not hash to requested hash")
+
+	h, err = src.HeaderByNumber(ctx, big.NewInt(10))
+	require.NoError(t, err)
+	assert.Equal(t, header, h)
+	_, err = src.HeaderByNumber(ctx, nil)
+	require.NoError(t, err, "latest header can have any number")
+	_, err = src.HeaderByNumber(ctx, big.NewInt(11))
+	assert.ErrorIs(t, err, InvalidResponseErr, "header does not have requested number")
+}
+
+func TestVerifyingL1Source_Block(t *testing.T) {
+	ctx := context.Background()
+	txs := types.Transactions{types.NewTransaction(0, common.Address{1}, big.NewInt(1), 21000, big.NewInt(1), nil)}
+	block := types.NewBlock(testHeader(10, common.Hash{0xaa}, 0), txs, nil, nil, trie.NewStackTrie(nil))
+	src := NewVerifyingL1Source(&verifyTestSource{block: block})
+
+	b, err := src.BlockByHash(ctx, block.Hash())
+	require.NoError(t, err)
+	assert.Equal(t, block.Hash(), b.Hash())
+	_, err = src.BlockByHash(ctx, common.Hash{0xbb})
+	assert.ErrorIs(t, err, InvalidResponseErr, "block does not hash to requested hash")
+
+	// the header commits to the transactions, but the served body does not match
+	tampered := block.WithBody(types.Transactions{types.NewTransaction(1, common.Address{2}, big.NewInt(1), 21000, big.NewInt(1), nil)}, nil)
+	src = NewVerifyingL1Source(&verifyTestSource{block: tampered})
+	_, err = src.BlockByHash(ctx, block.Hash())
+	assert.ErrorIs(t, err, InvalidResponseErr, "body does not match header")
+}
+
+func TestVerifyingL1Source_Receipt(t *testing.T) {
+	ctx := context.Background()
+	receipt := &types.Receipt{TxHash: common.Hash{0xaa}}
+	src := NewVerifyingL1Source(&verifyTestSource{receipt: receipt})
+
+	r, err := src.TransactionReceipt(ctx, common.Hash{0xaa})
+	require.NoError(t, err)
+	assert.Equal(t, receipt, r)
+	_, err = src.TransactionReceipt(ctx, common.Hash{0xbb})
+	assert.ErrorIs(t, err, InvalidResponseErr, "receipt of other tx")
+}
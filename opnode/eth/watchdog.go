@@ -0,0 +1,76 @@
+package eth
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// StallFn is called when no new head arrived for too long, with the last head (zero if none arrived yet),
+// and the time since the last head arrived (or since watching started).
+type StallFn func(lastHead BlockID, since time.Duration)
+
+// HeadWatchdog detects a stalled head subscription: a subscription that is alive, but does not deliver any new heads.
+//
+// A head is expected every ExpectedBlockTime. Heads that are late by up to Timeout are attributed to a slow chain
+// (e.g. missed block proposals). If no head arrives within ExpectedBlockTime + Timeout the subscription is
+// considered stalled, and OnStall is called. OnStall is repeated every ExpectedBlockTime + Timeout while stalled.
+type HeadWatchdog struct {
+	ExpectedBlockTime time.Duration
+	Timeout           time.Duration
+	OnStall           StallFn
+}
+
+// WatchHeadChanges wraps WatchHeadChanges, to feed the given fn, while watching for stalls.
+func (w *HeadWatchdog) WatchHeadChanges(ctx context.Context, src NewHeadSource, fn HeadSignalFn) (ethereum.Subscription, error) {
+	heads := make(chan HeadSignal, 10)
+	done := make(chan struct{})
+	sub, err := WatchHeadChanges(ctx, src, func(sig HeadSignal) {
+		select {
+		case heads <- sig:
+		case <-done:
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		defer close(done)
+
+		interval := w.ExpectedBlockTime + w.Timeout
+		timer := time.NewTimer(interval)
+		defer timer.Stop()
+
+		var lastHead BlockID
+		lastTime := time.Now()
+		for {
+			select {
+			case sig := <-heads:
+				lastHead = sig.Self
+				lastTime = time.Now()
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				timer.Reset(interval)
+				fn(sig)
+			case <-timer.C:
+				if w.OnStall != nil {
+					w.OnStall(lastHead, time.Since(lastTime))
+				}
+				timer.Reset(interval)
+			case err := <-sub.Err():
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
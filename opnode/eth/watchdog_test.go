@@ -0,0 +1,95 @@
+package eth
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type stall struct {
+	lastHead BlockID
+	since    time.Duration
+}
+
+// watchdogRecorder records the head signals and stalls of a HeadWatchdog
+type watchdogRecorder struct {
+	mu     sync.Mutex
+	stalls []stall
+	heads  []HeadSignal
+}
+
+func (r *watchdogRecorder) onStall(lastHead BlockID, since time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stalls = append(r.stalls, stall{lastHead, since})
+}
+
+func (r *watchdogRecorder) onHead(sig HeadSignal) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.heads = append(r.heads, sig)
+}
+
+func (r *watchdogRecorder) counts() (heads int, stalls int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.heads), len(r.stalls)
+}
+
+func TestHeadWatchdog(t *testing.T) {
+	h1 := testHeader(1, common.Hash{}, 0)
+	h2 := testHeader(2, h1.Hash(), 0)
+
+	t.Run("live", func(t *testing.T) {
+		src := new(feedHeadSource)
+		var rec watchdogRecorder
+		// the heads arrive well within the expected block time
+		w := &HeadWatchdog{ExpectedBlockTime: time.Hour, Timeout: time.Hour, OnStall: rec.onStall}
+		sub, err := w.WatchHeadChanges(context.Background(), src, rec.onHead)
+		require.NoError(t, err)
+		defer sub.Unsubscribe()
+
+		src.feed.Send(h1)
+		src.feed.Send(h2)
+		require.Eventually(t, func() bool {
+			heads, _ := rec.counts()
+			return heads == 2
+		}, time.Second, time.Millisecond*5)
+		_, stalls := rec.counts()
+		assert.Zero(t, stalls)
+	})
+
+	t.Run("stalled", func(t *testing.T) {
+		src := new(feedHeadSource)
+		var rec watchdogRecorder
+		w := &HeadWatchdog{ExpectedBlockTime: time.Millisecond * 20, Timeout: time.Millisecond * 40, OnStall: rec.onStall}
+		sub, err := w.WatchHeadChanges(context.Background(), src, rec.onHead)
+		require.NoError(t, err)
+		defer sub.Unsubscribe()
+
+		src.feed.Send(h1)
+		src.feed.Send(h2)
+		require.Eventually(t, func() bool {
+			heads, _ := rec.counts()
+			return heads == 2
+		}, time.Second, time.Millisecond*5)
+
+		// withhold heads past the timeout, the stall is reported repeatedly
+		_, before := rec.counts()
+		require.Eventually(t, func() bool {
+			_, stalls := rec.counts()
+			return stalls >= before+2
+		}, time.Second*5, time.Millisecond*5)
+		rec.mu.Lock()
+		defer rec.mu.Unlock()
+		for _, st := range rec.stalls[before:] {
+			assert.Equal(t, BlockID{Hash: h2.Hash(), Number: 2}, st.lastHead)
+			assert.GreaterOrEqual(t, st.since, w.ExpectedBlockTime+w.Timeout)
+		}
+	})
+}
@@ -0,0 +1,56 @@
+// Package events provides a lightweight event bus, to wire the node subsystems together loosely:
+// subsystems publish typed events, and other components (metrics, batcher, RPC, etc.) observe them
+// without direct coupling to the publisher.
+package events
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/event"
+)
+
+// Bus carries typed events. Every event type has its own feed: a subscriber receives the events of the
+// element type of its channel. Events are published by value, the bus does not copy them.
+//
+// Like event.Feed, publishing blocks until every subscriber of the event type received the event:
+// subscribers should use a buffered channel and keep up, to not stall the publisher.
+//
+// A nil Bus is valid: publishing to it is a no-op.
+type Bus struct {
+	mu    sync.Mutex
+	feeds map[reflect.Type]*event.Feed
+}
+
+func NewBus() *Bus {
+	return &Bus{feeds: make(map[reflect.Type]*event.Feed)}
+}
+
+func (b *Bus) feed(typ reflect.Type) *event.Feed {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	f, ok := b.feeds[typ]
+	if !ok {
+		f = new(event.Feed)
+		b.feeds[typ] = f
+	}
+	return f
+}
+
+// Subscribe subscribes the channel to the events of its element type, e.g. a chan L1Head to L1Head events.
+// It panics if ch is not a channel that events can be sent to.
+func (b *Bus) Subscribe(ch interface{}) event.Subscription {
+	chanTyp := reflect.TypeOf(ch)
+	if chanTyp == nil || chanTyp.Kind() != reflect.Chan || chanTyp.ChanDir()&reflect.SendDir == 0 {
+		panic("events: subscribe argument must be a sendable channel")
+	}
+	return b.feed(chanTyp.Elem()).Subscribe(ch)
+}
+
+// Publish sends the event to all subscribers of its type, and returns the number of subscribers it was sent to.
+func (b *Bus) Publish(ev interface{}) int {
+	if b == nil || ev == nil {
+		return 0
+	}
+	return b.feed(reflect.TypeOf(ev)).Send(ev)
+}
@@ -0,0 +1,44 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	bus := NewBus()
+	heads := make(chan L1Head, 1)
+	headSub := bus.Subscribe(heads)
+	defer headSub.Unsubscribe()
+	reorgs := make(chan L1Reorg, 1)
+	reorgSub := bus.Subscribe(reorgs)
+	defer reorgSub.Unsubscribe()
+
+	head := L1Head{HeadSignal: eth.HeadSignal{Self: eth.BlockID{Hash: common.Hash{1}, Number: 1}}}
+	assert.Equal(t, 1, bus.Publish(head))
+	require.Len(t, heads, 1)
+	assert.Equal(t, head, <-heads)
+	assert.Empty(t, reorgs, "events are only sent to subscribers of their type")
+
+	reorg := L1Reorg{ReorgSignal: eth.ReorgSignal{Depth: 2}}
+	assert.Equal(t, 1, bus.Publish(reorg))
+	assert.Equal(t, reorg, <-reorgs)
+
+	headSub.Unsubscribe()
+	assert.Equal(t, 0, bus.Publish(head))
+}
+
+func TestNilBus(t *testing.T) {
+	var bus *Bus
+	assert.Equal(t, 0, bus.Publish(L1Head{}))
+}
+
+func TestBusSubscribeNonChannel(t *testing.T) {
+	assert.Panics(t, func() {
+		NewBus().Subscribe(L1Head{})
+	})
+}
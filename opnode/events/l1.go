@@ -0,0 +1,13 @@
+package events
+
+import "github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+
+// L1Head is published for every new L1 head
+type L1Head struct {
+	eth.HeadSignal
+}
+
+// L1Reorg is published when the L1 head changes to a block that does not build on the previous head
+type L1Reorg struct {
+	eth.ReorgSignal
+}
@@ -0,0 +1,98 @@
+// Package genesis builds the L2 genesis of a new rollup, anchored on the L1 block the rollup contracts were deployed in.
+package genesis
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/params"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/contracts/l1block"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// DefaultGasLimit is the gas limit of the L2 genesis block, if none is configured
+const DefaultGasLimit = 15_000_000
+
+// Config configures the L2 genesis
+type Config struct {
+	// L2ChainID is the chain ID of the L2 chain
+	L2ChainID *big.Int
+
+	// L1Anchor is the L1 block that the rollup contracts were deployed in.
+	// The L2 genesis block has the same timestamp, and L2 blocks are derived from the L1 blocks after it.
+	L1Anchor *types.Header
+
+	// GasLimit is the gas limit of the L2 genesis block. Zero defaults to DefaultGasLimit.
+	GasLimit uint64
+
+	// L1InfoPredeployAddr is the L2 address to predeploy the L1 info contract at. Zero defaults to l2.L1InfoPredeployAddr.
+	L1InfoPredeployAddr common.Address
+
+	// Premine is optional, to fund accounts on L2 from genesis, e.g. on devnets
+	Premine map[common.Address]*big.Int
+}
+
+// BuildL2Genesis builds the L2 genesis: the chain config with all forks active from genesis,
+// the precompiles, the L1 info predeploy and the premined accounts.
+func BuildL2Genesis(cfg *Config) (*core.Genesis, error) {
+	if cfg.L2ChainID == nil || cfg.L2ChainID.Sign() <= 0 {
+		return nil, errors.New("L2 chain ID must be positive")
+	}
+	if cfg.L1Anchor == nil {
+		return nil, errors.New("missing L1 anchor block")
+	}
+	gasLimit := cfg.GasLimit
+	if gasLimit == 0 {
+		gasLimit = DefaultGasLimit
+	}
+	predeploy := (&l2.Config{L1InfoPredeployAddr: cfg.L1InfoPredeployAddr}).L1InfoPredeploy()
+
+	alloc := make(core.GenesisAlloc)
+	// precompiles are funded, so they are not removed from the state as empty accounts
+	var addr common.Address
+	for i := 0; i < 256; i++ {
+		addr[common.AddressLength-1] = byte(i)
+		alloc[addr] = core.GenesisAccount{Balance: common.Big1}
+	}
+	for addr, balance := range cfg.Premine {
+		alloc[addr] = core.GenesisAccount{Balance: new(big.Int).Set(balance)}
+	}
+	alloc[predeploy] = core.GenesisAccount{Code: common.FromHex(l1block.L1blockDeployedBin), Balance: common.Big0}
+
+	return &core.Genesis{
+		Config: &params.ChainConfig{
+			ChainID:                 new(big.Int).Set(cfg.L2ChainID),
+			HomesteadBlock:          common.Big0,
+			EIP150Block:             common.Big0,
+			EIP155Block:             common.Big0,
+			EIP158Block:             common.Big0,
+			ByzantiumBlock:          common.Big0,
+			ConstantinopleBlock:     common.Big0,
+			PetersburgBlock:         common.Big0,
+			IstanbulBlock:           common.Big0,
+			BerlinBlock:             common.Big0,
+			LondonBlock:             common.Big0,
+			MergeForkBlock:          common.Big0,
+			TerminalTotalDifficulty: common.Big0,
+		},
+		Alloc:      alloc,
+		Difficulty: common.Big1,
+		GasLimit:   gasLimit,
+		Timestamp:  cfg.L1Anchor.Time,
+		BaseFee:    big.NewInt(params.InitialBaseFee),
+	}, nil
+}
+
+// Anchors returns the L1 and L2 genesis anchors of the rollup config, for the L2 genesis built from the config.
+func Anchors(cfg *Config, l2Genesis *core.Genesis) l2.Genesis {
+	block := l2Genesis.ToBlock(nil)
+	return l2.Genesis{
+		L1: eth.BlockID{Hash: cfg.L1Anchor.Hash(), Number: cfg.L1Anchor.Number.Uint64()},
+		L2: eth.BlockID{Hash: block.Hash(), Number: block.NumberU64()},
+	}
+}
@@ -0,0 +1,105 @@
+package genesis
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/rawdb"
+	"github.com/ethereum/go-ethereum/core/state"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/core/vm/runtime"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/contracts/l1block"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+func TestBuildL2Genesis(t *testing.T) {
+	funded := common.Address{0xf0}
+	cfg := &Config{
+		L2ChainID: big.NewInt(901),
+		L1Anchor:  &types.Header{Number: big.NewInt(100), Time: 1_600_000_000, Difficulty: common.Big1},
+		Premine:   map[common.Address]*big.Int{funded: big.NewInt(1e18)},
+	}
+	gen, err := BuildL2Genesis(cfg)
+	require.NoError(t, err)
+	require.Equal(t, cfg.L1Anchor.Time, gen.Timestamp, "L2 genesis starts at the time of the L1 anchor")
+	require.Equal(t, uint64(DefaultGasLimit), gen.GasLimit)
+	require.NotEmpty(t, gen.Alloc[l2.L1InfoPredeployAddr].Code)
+	require.Equal(t, big.NewInt(1e18), gen.Alloc[funded].Balance)
+
+	anchors := Anchors(cfg, gen)
+	require.Equal(t, cfg.L1Anchor.Hash(), anchors.L1.Hash)
+	require.Equal(t, uint64(100), anchors.L1.Number)
+	require.Equal(t, uint64(0), anchors.L2.Number)
+
+	again, err := BuildL2Genesis(cfg)
+	require.NoError(t, err)
+	require.Equal(t, anchors.L2, Anchors(cfg, again).L2, "the L2 genesis is deterministic")
+
+	_, err = BuildL2Genesis(&Config{L1Anchor: cfg.L1Anchor})
+	require.Error(t, err, "the L2 chain ID is required")
+}
+
+func TestL1BlockPredeploy(t *testing.T) {
+	gen, err := BuildL2Genesis(&Config{
+		L2ChainID: big.NewInt(901),
+		L1Anchor:  &types.Header{Number: big.NewInt(100), Time: 1_600_000_000, Difficulty: common.Big1},
+	})
+	require.NoError(t, err)
+	statedb, err := state.New(common.Hash{}, state.NewDatabase(rawdb.NewMemoryDatabase()), nil)
+	require.NoError(t, err)
+	statedb.SetCode(l2.L1InfoPredeployAddr, gen.Alloc[l2.L1InfoPredeployAddr].Code)
+	abi, err := l1block.L1blockMetaData.GetAbi()
+	require.NoError(t, err)
+
+	call := func(from common.Address, input []byte) ([]byte, error) {
+		ret, _, err := runtime.Call(l2.L1InfoPredeployAddr, input, &runtime.Config{State: statedb, Origin: from, GasLimit: 1_000_000})
+		return ret, err
+	}
+	get := func(method string) interface{} {
+		input, err := abi.Pack(method)
+		require.NoError(t, err)
+		ret, err := call(common.Address{}, input)
+		require.NoError(t, err, method)
+		out, err := abi.Unpack(method, ret)
+		require.NoError(t, err, method)
+		return out[0]
+	}
+	getBig := func(method string) uint64 {
+		return get(method).(*big.Int).Uint64()
+	}
+
+	l1 := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(123), Time: 1_600_000_456, BaseFee: big.NewInt(7e9)})
+	batcherHash := common.Hash{31: 0xba}
+	overhead, scalar := common.BigToHash(big.NewInt(2100)), common.BigToHash(big.NewInt(1_000_000))
+	data, err := l2.EncodeL1InfoData(l1, 3, batcherHash, overhead, scalar)
+	require.NoError(t, err)
+	dep := l2.L1InfoDepositTx(&l2.Config{}, 1, data, 1_000_000)
+
+	ret, err := call(common.Address{0x01}, dep.Data)
+	require.ErrorIs(t, err, vm.ErrExecutionReverted, "only the depositor may set the L1 block values")
+	onlyDepositor := abi.Errors["OnlyDepositor"].ID
+	require.Equal(t, onlyDepositor[:4], ret)
+	require.Equal(t, uint64(0), getBig("number"), "the values are unchanged")
+
+	_, err = call(dep.From, dep.Data)
+	require.NoError(t, err)
+	require.Equal(t, dep.From, get("DEPOSITOR_ACCOUNT"))
+	require.Equal(t, uint64(123), getBig("number"))
+	require.Equal(t, uint64(1_600_000_456), getBig("timestamp"))
+	require.Equal(t, uint64(7e9), getBig("basefee"))
+	require.Equal(t, [32]byte(l1.Hash()), get("hash"))
+	require.Equal(t, uint64(3), get("sequenceNumber"))
+	require.Equal(t, [32]byte(batcherHash), get("batcherHash"))
+	require.Equal(t, uint64(2100), getBig("l1FeeOverhead"))
+	require.Equal(t, uint64(1_000_000), getBig("l1FeeScalar"))
+
+	_, err = call(dep.From, dep.Data[:len(dep.Data)-1])
+	require.ErrorIs(t, err, vm.ErrExecutionReverted, "truncated calldata")
+	dep.Data[4+4*32+23] = 1
+	_, err = call(dep.From, dep.Data)
+	require.ErrorIs(t, err, vm.ErrExecutionReverted, "the sequence number must fit in a uint64")
+}
@@ -0,0 +1,62 @@
+// Package testutil provides random test data generators, for tests and test vector generation.
+package testutil
+
+import (
+	"math/big"
+	"math/rand"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// GenerateAddress returns a random address.
+func GenerateAddress(rng *rand.Rand) (out common.Address) {
+	rng.Read(out[:])
+	return
+}
+
+// RandETH returns a random whole amount of ETH, in wei, below max ETH.
+func RandETH(rng *rand.Rand, max int64) *big.Int {
+	x := big.NewInt(rng.Int63n(max))
+	x = new(big.Int).Mul(x, big.NewInt(1e18))
+	return x
+}
+
+// GenerateDeposit returns a random deposit, at the given L1 block number and deposit index.
+// The recipient and the mint are each absent half of the time.
+func GenerateDeposit(blockNum uint64, txIndex uint64, rng *rand.Rand) *types.DepositTx {
+	dataLen := rng.Int63n(10_000)
+	data := make([]byte, dataLen)
+	rng.Read(data)
+
+	var to *common.Address
+	if rng.Intn(2) == 0 {
+		x := GenerateAddress(rng)
+		to = &x
+	}
+	var mint *big.Int
+	if rng.Intn(2) == 0 {
+		mint = RandETH(rng, 200)
+	}
+
+	dep := &types.DepositTx{
+		BlockHeight:      blockNum,
+		TransactionIndex: txIndex,
+		From:             GenerateAddress(rng),
+		To:               to,
+		Value:            RandETH(rng, 200),
+		Gas:              uint64(rng.Int63n(10 * 1e6)), // 10 M gas max
+		Data:             data,
+		Mint:             mint,
+	}
+	return dep
+}
+
+// GenerateLog returns an EVM log entry with the given address, topics and data, and zeroed block and tx fields.
+func GenerateLog(addr common.Address, topics []common.Hash, data []byte) *types.Log {
+	return &types.Log{
+		Address: addr,
+		Topics:  topics,
+		Data:    data,
+	}
+}
@@ -164,7 +164,11 @@ func (dl *downloader) Fetch(ctx context.Context, id eth.BlockID) (*types.Block,
 			defer cancel()
 			bl, err := dl.src.BlockByHash(ctx, id.Hash)
 			if err != nil {
-				dlTask.Finish(wrappedErr{fmt.Errorf("failed to download block %s: %v", id.Hash, err)})
+				dlTask.Finish(wrappedErr{fmt.Errorf("failed to download block %s: %w", id.Hash, eth.ClassifyFetchErr(err))})
+				return
+			}
+			if bl.Hash() != id.Hash {
+				dlTask.Finish(wrappedErr{fmt.Errorf("downloaded block %s does not match requested hash %s: %w", bl.Hash(), id.Hash, eth.InvalidResponseErr)})
 				return
 			}
 
@@ -212,7 +216,7 @@ func (dl *downloader) processTask(task *receiptTask) {
 		// if a single receipt fails out of the whole block, we can retry a few times.
 		if task.retry >= maxReceiptRetry {
 			// Failed to get the receipt too many times, block fails!
-			task.dest.Finish(wrappedErr{fmt.Errorf("failed to download receipt again, and reached max %d retries: %v", maxReceiptRetry, err)})
+			task.dest.Finish(wrappedErr{fmt.Errorf("failed to download receipt again, and reached max %d retries: %w", maxReceiptRetry, eth.ClassifyFetchErr(err))})
 			return
 		} else {
 			task.retry += 1
@@ -222,7 +226,7 @@ func (dl *downloader) processTask(task *receiptTask) {
 				return
 			default:
 				// failed to schedule, too much receipt work, stop block to relieve pressure.
-				task.dest.Finish(wrappedErr{fmt.Errorf("receipt downloader too busy, not downloading receipt again (%d retries): %v", task.retry, err)})
+				task.dest.Finish(wrappedErr{fmt.Errorf("receipt downloader too busy, not downloading receipt again (%d retries): %w", task.retry, eth.ClassifyFetchErr(err))})
 				return
 			}
 		}
@@ -2,8 +2,11 @@ package l2
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"reflect"
+	"sync/atomic"
 
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
 
@@ -21,7 +24,12 @@ import (
 type ErrorCode int
 
 const (
-	UnavailablePayload ErrorCode = -32001
+	MethodNotFound           ErrorCode = -32601
+	InvalidParams            ErrorCode = -32602
+	UnavailablePayload       ErrorCode = -32001 // pre-release engine API versions
+	UnknownPayload           ErrorCode = -38001
+	InvalidForkchoiceState   ErrorCode = -38002
+	InvalidPayloadAttributes ErrorCode = -38003
 )
 
 type Bytes32 [32]byte
@@ -90,8 +98,24 @@ type Uint256Quantity = uint256.Int
 
 type Data = hexutil.Bytes
 
-// TODO: implement neat 8 byte typed payload ID and upstream it to geth api definitions
-type PayloadID = hexutil.Bytes
+// PayloadID identifies a payload that the engine is building
+type PayloadID [8]byte
+
+func (id *PayloadID) UnmarshalJSON(text []byte) error {
+	return hexutil.UnmarshalFixedJSON(reflect.TypeOf(PayloadID{}), text, id[:])
+}
+
+func (id *PayloadID) UnmarshalText(text []byte) error {
+	return hexutil.UnmarshalFixedText("PayloadID", text, id[:])
+}
+
+func (id PayloadID) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(id[:]).MarshalText()
+}
+
+func (id PayloadID) String() string {
+	return hexutil.Encode(id[:])
+}
 
 type ExecutionPayload struct {
 	ParentHash    common.Hash     `json:"parentHash"`
@@ -125,6 +149,16 @@ type PayloadAttributes struct {
 	SuggestedFeeRecipient common.Address `json:"suggestedFeeRecipient"`
 	// Transactions to build the block with, omitted if the local tx pool of the engine should be used instead
 	Transactions []Data `json:"transactions,omitempty"`
+	// NoTxPool is true if the block may only contain the given transactions.
+	// If false, the engine adds transactions from its tx pool after the given transactions, e.g. when sequencing.
+	NoTxPool bool `json:"noTxPool,omitempty"`
+	// Gas limit of the new payload, omitted if the engine should use its own default gas limit
+	GasLimit *Uint64Quantity `json:"gasLimit,omitempty"`
+	// Withdrawals of the new payload, from PayloadV2 on. Omitted before.
+	Withdrawals *[]Withdrawal `json:"withdrawals,omitempty"`
+
+	// Version of the attributes, to select the engine API method with. Zero is PayloadV1.
+	Version PayloadVersion `json:"-"`
 }
 
 type ExecutePayloadStatus string
@@ -136,15 +170,29 @@ const (
 	ExecutionInvalid ExecutePayloadStatus = "INVALID"
 	// sync process is in progress
 	ExecutionSyncing ExecutePayloadStatus = "SYNCING"
+	// payload is accepted, but not validated yet, since it does not extend the canonical chain
+	ExecutionAccepted ExecutePayloadStatus = "ACCEPTED"
+	// the block hash of the payload does not match the payload contents
+	ExecutionInvalidBlockHash ExecutePayloadStatus = "INVALID_BLOCK_HASH"
+	// the payload builds on an invalid terminal PoW block
+	ExecutionInvalidTerminalBlock ExecutePayloadStatus = "INVALID_TERMINAL_BLOCK"
 )
 
-type ExecutePayloadResult struct {
+// Invalid returns true if the status rejects the payload as invalid
+func (s ExecutePayloadStatus) Invalid() bool {
+	return s == ExecutionInvalid || s == ExecutionInvalidBlockHash || s == ExecutionInvalidTerminalBlock
+}
+
+// legacy forkchoice-updated status of pre-release engine API versions, equivalent to ExecutionValid
+const legacyUpdateSuccess ExecutePayloadStatus = "SUCCESS"
+
+type PayloadStatusV1 struct {
 	// the result of the payload execution
 	Status ExecutePayloadStatus `json:"status"`
-	// the hash of the most recent valid block in the branch defined by payload and its ancestors
-	LatestValidHash common.Hash `json:"latestValidHash"`
+	// the hash of the most recent valid block in the branch defined by payload and its ancestors, if known
+	LatestValidHash *common.Hash `json:"latestValidHash,omitempty"`
 	// additional details on the result
-	ValidationError string `json:"validationError"`
+	ValidationError *string `json:"validationError,omitempty"`
 }
 
 type ForkchoiceState struct {
@@ -156,25 +204,38 @@ type ForkchoiceState struct {
 	FinalizedBlockHash common.Hash `json:"finalizedBlockHash"`
 }
 
-type ForkchoiceUpdatedStatus string
-
-const (
-	// given payload is valid
-	UpdateSuccess ForkchoiceUpdatedStatus = "SUCCESS"
-	// sync process is in progress
-	UpdateSyncing ForkchoiceUpdatedStatus = "SYNCING"
-)
-
 type ForkchoiceUpdatedResult struct {
-	// the result of the payload execution
-	Status ForkchoiceUpdatedStatus `json:"status"`
+	// the result of the forkchoice update, of the new head block
+	PayloadStatus PayloadStatusV1 `json:"payloadStatus"`
 	// the payload id if requested
 	PayloadID *PayloadID `json:"payloadId"`
 }
 
+// UnmarshalJSON decodes the result, and also accepts the flat status of pre-release engine API versions.
+func (r *ForkchoiceUpdatedResult) UnmarshalJSON(data []byte) error {
+	var res struct {
+		PayloadStatus *PayloadStatusV1     `json:"payloadStatus"`
+		Status        ExecutePayloadStatus `json:"status"`
+		PayloadID     *PayloadID           `json:"payloadId"`
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	r.PayloadID = res.PayloadID
+	if res.PayloadStatus != nil {
+		r.PayloadStatus = *res.PayloadStatus
+		return nil
+	}
+	if res.Status == legacyUpdateSuccess {
+		res.Status = ExecutionValid
+	}
+	r.PayloadStatus = PayloadStatusV1{Status: res.Status}
+	return nil
+}
+
 type EngineAPI interface {
 	GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error)
-	ExecutePayload(ctx context.Context, payload *ExecutionPayload) (*ExecutePayloadResult, error)
+	NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error)
 	ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error)
 	Close()
 }
@@ -190,10 +251,18 @@ type EthBackend interface {
 	eth.NewHeadSource
 }
 
+// EngineClient implements the EngineAPI with the engine_ JSON-RPC methods.
+// The returned errors are classified with ClassifyEngineErr.
+//
+// NewPayload falls back to the engine_executePayloadV1 method of pre-release engine API versions,
+// if the engine does not support engine_newPayloadV1.
 type EngineClient struct {
 	RPCBackend
 	EthBackend
 	Log log.Logger
+
+	// set once the engine is known to only support the pre-release engine_executePayloadV1 method (atomic)
+	legacyExecute uint32
 }
 
 func (el *EngineClient) GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error) {
@@ -202,16 +271,11 @@ func (el *EngineClient) GetPayload(ctx context.Context, payloadId PayloadID) (*E
 	var result ExecutionPayload
 	err := el.CallContext(ctx, &result, "engine_getPayloadV1", payloadId)
 	if err != nil {
-		e = log.New("payload_id", "err", err)
-		if rpcErr, ok := err.(rpc.Error); ok {
-			code := ErrorCode(rpcErr.ErrorCode())
-			if code != UnavailablePayload {
-				e.Warn("unexpected error code in get-payload response", "code", code)
-			} else {
-				e.Warn("unavailable payload in get-payload request")
-			}
+		err = ClassifyEngineErr(err)
+		if errors.Is(err, UnknownPayloadErr) {
+			e.Warn("unavailable payload in get-payload request", "err", err)
 		} else {
-			e.Error("failed to get payload")
+			e.Error("failed to get payload", "err", err)
 		}
 		return nil, err
 	}
@@ -219,16 +283,35 @@ func (el *EngineClient) GetPayload(ctx context.Context, payloadId PayloadID) (*E
 	return &result, nil
 }
 
-func (el *EngineClient) ExecutePayload(ctx context.Context, payload *ExecutionPayload) (*ExecutePayloadResult, error) {
+func (el *EngineClient) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
 	e := el.Log.New("block_hash", payload.BlockHash)
 	e.Debug("sending payload for execution")
-	var result ExecutePayloadResult
-	err := el.CallContext(ctx, &result, "engine_executePayloadV1", payload)
+	var result PayloadStatusV1
+	var err error
+	if atomic.LoadUint32(&el.legacyExecute) == 0 {
+		err = el.CallContext(ctx, &result, "engine_newPayloadV1", payload)
+		if rpcErr, ok := err.(rpc.Error); ok && ErrorCode(rpcErr.ErrorCode()) == MethodNotFound {
+			e.Warn("engine does not support engine_newPayloadV1, falling back to engine_executePayloadV1")
+			atomic.StoreUint32(&el.legacyExecute, 1)
+		}
+	}
+	if atomic.LoadUint32(&el.legacyExecute) == 1 {
+		err = el.CallContext(ctx, &result, "engine_executePayloadV1", payload)
+	}
 	if err != nil {
+		err = ClassifyEngineErr(err)
 		e.Error("Payload execution failed", "err", err)
 		return nil, err
 	}
-	e.Debug("Received payload execution result", "status", result.Status, "latestValidHash", result.LatestValidHash, "message", result.ValidationError)
+	var latestValid common.Hash
+	if result.LatestValidHash != nil {
+		latestValid = *result.LatestValidHash
+	}
+	var validationErr string
+	if result.ValidationError != nil {
+		validationErr = *result.ValidationError
+	}
+	e.Debug("Received payload execution result", "status", result.Status, "latestValidHash", latestValid, "validationError", validationErr)
 	return &result, nil
 }
 
@@ -236,21 +319,25 @@ func (el *EngineClient) ForkchoiceUpdated(ctx context.Context, state *Forkchoice
 	e := el.Log.New("state", state, "attr", attr)
 	e.Debug("Sharing forkchoice-updated signal")
 
+	if attr != nil {
+		if err := attr.CheckVersion(); err != nil {
+			return ForkchoiceUpdatedResult{}, err
+		}
+	}
 	var result ForkchoiceUpdatedResult
-	err := el.CallContext(ctx, &result, "engine_forkchoiceUpdatedV1", state, attr)
+	err := el.CallContext(ctx, &result, forkchoiceUpdatedMethod(attr), state, attr)
 	if err == nil {
-		e.Debug("Shared forkchoice-updated signal")
+		e.Debug("Shared forkchoice-updated signal", "status", result.PayloadStatus.Status)
 		if attr != nil {
 			e.Debug("Received payload id", "payloadId", result.PayloadID)
 		}
 		return result, nil
 	} else {
-		e = e.New("err", err)
-		if rpcErr, ok := err.(rpc.Error); ok {
-			code := ErrorCode(rpcErr.ErrorCode())
-			e.Warn("Unexpected error code in forkchoice-updated response", "code", code)
+		err = ClassifyEngineErr(err)
+		if errors.Is(err, eth.TemporaryRPCErr) {
+			e.Error("Failed to share forkchoice-updated signal", "err", err)
 		} else {
-			e.Error("Failed to share forkchoice-updated signal")
+			e.Warn("Unexpected error in forkchoice-updated response", "err", err)
 		}
 		return result, err
 	}
@@ -0,0 +1,203 @@
+package l2
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// BatchV0 is the version byte of a batch submission with a single RLP-encoded BatchData
+const BatchV0 = 0
+
+// BatchData is a batch of sequenced L2 transactions, submitted as calldata to the batch inbox on L1.
+// A batch holds the sequenced transactions of a single L2 block, and identifies the L2 block by its parent,
+// L1 origin and timestamp, so that verifiers can check the batch against the L2 block they derive next.
+type BatchData struct {
+	// ParentHash is the hash of the parent of the L2 block
+	ParentHash common.Hash
+	// EpochNum and EpochHash identify the L1 origin of the L2 block
+	EpochNum  uint64
+	EpochHash common.Hash
+	// Timestamp of the L2 block
+	Timestamp uint64
+	// Opaque (binary encoded) L2 transactions, excluding deposits
+	Transactions []Data
+}
+
+// BatchCheck is the outcome of checking a batch against the next L2 block to derive
+type BatchCheck uint8
+
+const (
+	// BatchAccept marks a batch of the next L2 block
+	BatchAccept BatchCheck = iota
+	// BatchDrop marks an invalid batch, that is dropped
+	BatchDrop
+	// BatchFuture marks a batch of a later L2 block, that is kept until the L2 block is derived
+	BatchFuture
+)
+
+func (c BatchCheck) String() string {
+	switch c {
+	case BatchAccept:
+		return "accept"
+	case BatchDrop:
+		return "drop"
+	case BatchFuture:
+		return "future"
+	default:
+		return fmt.Sprintf("BatchCheck(%d)", uint8(c))
+	}
+}
+
+// BatchDropReason describes why a batch is dropped, e.g. to report it in the metrics.
+type BatchDropReason string
+
+const (
+	// BatchMisaligned marks a batch with a timestamp that is not aligned to the L2 block time
+	BatchMisaligned BatchDropReason = "misaligned"
+	// BatchOutdated marks a batch of a L2 block that was derived already
+	BatchOutdated BatchDropReason = "outdated"
+	// BatchWrongEpoch marks a batch with a different L1 origin than the L2 block it is for
+	BatchWrongEpoch BatchDropReason = "epoch"
+	// BatchWrongParent marks a batch that does not build on the parent of the L2 block it is for
+	BatchWrongParent BatchDropReason = "parent"
+	// BatchPastWindow marks a batch that was included on L1 after the sequencing window of its epoch
+	BatchPastWindow BatchDropReason = "window"
+)
+
+// NextL2Block is the L2 block that is derived next, to check batches against.
+type NextL2Block struct {
+	// ParentHash is the hash of the parent L2 block. Zero if unknown, to not check the parent of batches.
+	ParentHash common.Hash
+	// Epoch is the L1 origin of the L2 block
+	Epoch eth.BlockID
+	// Timestamp of the L2 block
+	Timestamp uint64
+}
+
+// CheckBatch checks a batch against the next L2 block to derive. Invalid batches are dropped with a reason:
+// the batcher is not trusted, and invalid batches must neither halt nor corrupt the derivation.
+// Batches of later L2 blocks are kept for later. Bounds of the timestamp relative to the L1 origin are
+// enforced by the derivation of the next L2 block: a batch must match its epoch and timestamp exactly.
+func CheckBatch(cfg *Config, batch *BatchData, next NextL2Block) (BatchCheck, BatchDropReason) {
+	if cfg.BlockTime != 0 && batch.Timestamp%cfg.BlockTime != next.Timestamp%cfg.BlockTime {
+		return BatchDrop, BatchMisaligned
+	}
+	if batch.Timestamp < next.Timestamp {
+		return BatchDrop, BatchOutdated
+	}
+	if batch.Timestamp > next.Timestamp {
+		return BatchFuture, ""
+	}
+	if batch.EpochNum != next.Epoch.Number || batch.EpochHash != next.Epoch.Hash {
+		return BatchDrop, BatchWrongEpoch
+	}
+	if next.ParentHash != (common.Hash{}) && batch.ParentHash != next.ParentHash {
+		return BatchDrop, BatchWrongParent
+	}
+	return BatchAccept, ""
+}
+
+// InSeqWindow checks if a batch that was included in the given L1 block is within the sequencing window of its epoch.
+func InSeqWindow(cfg *Config, batch *BatchData, l1Inclusion uint64) bool {
+	return cfg.SeqWindowSize == 0 || l1Inclusion < batch.EpochNum+cfg.SeqWindowSize
+}
+
+type BlockTransactions interface {
+	Transactions() types.Transactions
+}
+
+// EncodeBatch encodes the batch as calldata for a batch submission to the batch inbox.
+func EncodeBatch(batch *BatchData) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(BatchV0)
+	if err := rlp.Encode(&buf, batch); err != nil {
+		return nil, fmt.Errorf("failed to encode batch: %v", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeBatch decodes the calldata of a batch submission, and checks that the batch contains only valid,
+// non-deposit, L2 transactions: deposits can only be derived from L1 and not be sequenced.
+func DecodeBatch(data []byte) (*BatchData, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty batch data")
+	}
+	if data[0] != BatchV0 {
+		return nil, fmt.Errorf("unknown batch version %d", data[0])
+	}
+	var batch BatchData
+	if err := rlp.DecodeBytes(data[1:], &batch); err != nil {
+		return nil, fmt.Errorf("failed to decode batch: %v", err)
+	}
+	for i, opaqueTx := range batch.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(opaqueTx); err != nil {
+			return nil, fmt.Errorf("invalid batch tx %d: %v", i, err)
+		}
+		if tx.Type() == types.DepositTxType {
+			return nil, fmt.Errorf("batch tx %d is a deposit", i)
+		}
+	}
+	return &batch, nil
+}
+
+// DeriveBatches derives the sequenced L2 transactions from the batch submissions in the L1 block transactions:
+// the calldata of transactions sent by the batcher to the batch inbox.
+//
+// Invalid batches are ignored: anyone can submit a batch, so invalid batch data must not halt the derivation.
+//
+// Batch derivation is disabled if no batch inbox is configured.
+func DeriveBatches(cfg *Config, txs types.Transactions) ([]Data, error) {
+	if cfg.BatchInboxAddr == (common.Address{}) {
+		return nil, nil
+	}
+	// calldata is readily available, and does not need a context
+	datas, err := CalldataSource{}.BatchData(context.Background(), cfg, eth.BlockID{}, txs)
+	if err != nil {
+		return nil, err
+	}
+	return DeriveBatchesFromData(datas), nil
+}
+
+// DeriveBatchesFromData decodes the batch submission data, as retrieved from any DataSource,
+// into sequenced L2 transactions. Invalid batches are ignored.
+func DeriveBatchesFromData(datas [][]byte) []Data {
+	var out []Data
+	for _, data := range datas {
+		batch, err := DecodeBatch(data)
+		if err != nil {
+			continue
+		}
+		out = append(out, batch.Transactions...)
+	}
+	return out
+}
+
+// BatcherTransactions filters the L1 block transactions for the batch submissions:
+// the transactions sent by the batcher to the batch inbox.
+func BatcherTransactions(cfg *Config, txs types.Transactions) (types.Transactions, error) {
+	var out types.Transactions
+	for i, tx := range txs {
+		if to := tx.To(); to == nil || *to != cfg.BatchInboxAddr {
+			continue
+		}
+		// a legacy tx without replay protection has a zero chain ID, and recovers with the homestead signer.
+		sender, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to recover sender of tx %d: %v", i, err)
+		}
+		if sender != cfg.Batcher() {
+			continue // not submitted by the batcher
+		}
+		out = append(out, tx)
+	}
+	return out, nil
+}
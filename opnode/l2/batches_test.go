@@ -0,0 +1,176 @@
+package l2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+var testL1ChainID = big.NewInt(900)
+
+func testL2Tx(t testing.TB, nonce uint64) Data {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	tx := types.MustSignNewTx(key, types.LatestSignerForChainID(big.NewInt(901)),
+		&types.DynamicFeeTx{Nonce: nonce, Gas: 21000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &common.Address{0x42}})
+	opaqueTx, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return opaqueTx
+}
+
+func testBatchSubmission(t *testing.T, key *ecdsa.PrivateKey, to common.Address, batch *BatchData) *types.Transaction {
+	data, err := EncodeBatch(batch)
+	require.NoError(t, err)
+	return types.MustSignNewTx(key, types.LatestSignerForChainID(testL1ChainID),
+		&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &to, Data: data})
+}
+
+func TestBatchEncoding(t *testing.T) {
+	batch := &BatchData{Transactions: []Data{testL2Tx(t, 0), testL2Tx(t, 1)}}
+	data, err := EncodeBatch(batch)
+	require.NoError(t, err)
+	assert.Equal(t, byte(BatchV0), data[0])
+	decoded, err := DecodeBatch(data)
+	require.NoError(t, err)
+	assert.Equal(t, batch, decoded)
+
+	t.Run("unknown version", func(t *testing.T) {
+		bad := append([]byte{}, data...)
+		bad[0] = 0xff
+		_, err := DecodeBatch(bad)
+		assert.Error(t, err)
+	})
+	t.Run("empty", func(t *testing.T) {
+		_, err := DecodeBatch(nil)
+		assert.Error(t, err)
+	})
+	t.Run("deposit", func(t *testing.T) {
+		dep, err := types.NewTx(testutil.GenerateDeposit(100, 1, rand.New(rand.NewSource(1234)))).MarshalBinary()
+		require.NoError(t, err)
+		data, err := EncodeBatch(&BatchData{Transactions: []Data{dep}})
+		require.NoError(t, err)
+		_, err = DecodeBatch(data)
+		assert.Error(t, err)
+	})
+}
+
+func TestDeriveBatches(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	otherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+	}
+	a, b, c := testL2Tx(t, 0), testL2Tx(t, 1), testL2Tx(t, 2)
+	txs := types.Transactions{
+		testBatchSubmission(t, batcherKey, cfg.BatchInboxAddr, &BatchData{Transactions: []Data{a, b}}),
+		// not submitted by the batcher
+		testBatchSubmission(t, otherKey, cfg.BatchInboxAddr, &BatchData{Transactions: []Data{testL2Tx(t, 3)}}),
+		// not submitted to the inbox
+		testBatchSubmission(t, batcherKey, common.Address{0x42}, &BatchData{Transactions: []Data{testL2Tx(t, 4)}}),
+		// invalid batch data is ignored
+		types.MustSignNewTx(batcherKey, types.LatestSignerForChainID(testL1ChainID),
+			&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &cfg.BatchInboxAddr, Data: []byte{BatchV0, 0xc3}}),
+		testBatchSubmission(t, batcherKey, cfg.BatchInboxAddr, &BatchData{Transactions: []Data{c}}),
+	}
+	out, err := DeriveBatches(cfg, txs)
+	require.NoError(t, err)
+	assert.Equal(t, []Data{a, b, c}, out)
+
+	out, err = DeriveBatches(&Config{BatcherAddr: cfg.BatcherAddr}, txs)
+	require.NoError(t, err)
+	assert.Empty(t, out, "no batch inbox, no batches")
+}
+
+func TestDeriveBlockInputsBatches(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+	}
+	rng := rand.New(rand.NewSource(1234))
+	batchTx := testL2Tx(t, 0)
+	txs := types.Transactions{
+		testBatchSubmission(t, batcherKey, cfg.BatchInboxAddr, &BatchData{Transactions: []Data{batchTx}}),
+		types.NewTransaction(0, DepositContractAddr, common.Big0, 100_000, common.Big1, nil),
+	}
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 30_000, Logs: []*types.Log{}},
+		{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 60_000, Logs: []*types.Log{MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(1, 1, rng))}},
+	}
+	header := &types.Header{Number: big.NewInt(1), Difficulty: common.Big0, BaseFee: big.NewInt(7)}
+	block := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+
+	attrs, err := DeriveBlockInputs(cfg, BlockInputFromBlock(block), receipts)
+	require.NoError(t, err)
+	require.Len(t, attrs.Transactions, 3)
+	var tx types.Transaction
+	require.NoError(t, tx.UnmarshalBinary(attrs.Transactions[1]))
+	assert.Equal(t, uint8(types.DepositTxType), tx.Type(), "deposits come first")
+	assert.Equal(t, batchTx, attrs.Transactions[2], "followed by the sequenced txs")
+
+	_, err = DeriveBlockInputs(cfg, BlockInputFromHeader(block.Header()), receipts)
+	assert.Error(t, err, "a header does not have the transactions to derive batches from")
+}
+
+func TestCalldataSource(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+	}
+	batch := &BatchData{Transactions: []Data{testL2Tx(t, 0)}}
+	submission := testBatchSubmission(t, batcherKey, cfg.BatchInboxAddr, batch)
+	txs := types.Transactions{
+		types.NewTransaction(0, common.Address{0x42}, common.Big0, 21000, common.Big1, nil),
+		submission,
+	}
+	var src DataSource = CalldataSource{}
+	datas, err := src.BatchData(context.Background(), cfg, eth.BlockID{Number: 1}, txs)
+	require.NoError(t, err)
+	assert.Equal(t, [][]byte{submission.Data()}, datas)
+	assert.Equal(t, batch.Transactions, DeriveBatchesFromData(datas))
+}
+
+func TestCheckBatch(t *testing.T) {
+	cfg := &Config{BlockTime: 2, SeqWindowSize: 4}
+	epoch := eth.BlockID{Hash: common.Hash{0xa}, Number: 10}
+	next := NextL2Block{ParentHash: common.Hash{0x1}, Epoch: epoch, Timestamp: 1000}
+	valid := BatchData{ParentHash: next.ParentHash, EpochNum: epoch.Number, EpochHash: epoch.Hash, Timestamp: 1000}
+
+	check := func(expected BatchCheck, expectedReason BatchDropReason, mod func(b *BatchData)) {
+		batch := valid
+		mod(&batch)
+		got, reason := CheckBatch(cfg, &batch, next)
+		assert.Equal(t, expected, got)
+		assert.Equal(t, expectedReason, reason)
+	}
+	check(BatchAccept, "", func(b *BatchData) {})
+	check(BatchFuture, "", func(b *BatchData) { b.Timestamp = 1002 })
+	check(BatchDrop, BatchMisaligned, func(b *BatchData) { b.Timestamp = 1003 })
+	check(BatchDrop, BatchOutdated, func(b *BatchData) { b.Timestamp = 998 })
+	check(BatchDrop, BatchWrongEpoch, func(b *BatchData) { b.EpochNum = 11 })
+	check(BatchDrop, BatchWrongEpoch, func(b *BatchData) { b.EpochHash = common.Hash{0xb} })
+	check(BatchDrop, BatchWrongParent, func(b *BatchData) { b.ParentHash = common.Hash{0x2} })
+
+	unknownParent := next
+	unknownParent.ParentHash = common.Hash{}
+	got, _ := CheckBatch(cfg, &BatchData{ParentHash: common.Hash{0x2}, EpochNum: epoch.Number, EpochHash: epoch.Hash, Timestamp: 1000}, unknownParent)
+	assert.Equal(t, BatchAccept, got, "parent is not checked if unknown")
+
+	assert.True(t, InSeqWindow(cfg, &valid, 13))
+	assert.False(t, InSeqWindow(cfg, &valid, 14))
+	assert.True(t, InSeqWindow(&Config{}, &valid, 1000), "no window")
+}
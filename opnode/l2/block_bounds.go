@@ -0,0 +1,49 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// InvalidTimestampErr is returned when the timestamp of a L2 block is not within the bounds of the rollup configuration.
+var InvalidTimestampErr = errors.New("invalid L2 block timestamp")
+
+// InvalidEpochErr is returned when the L1 origin or the sequence number of a L2 block does not follow those of its parent.
+var InvalidEpochErr = errors.New("invalid L2 block epoch")
+
+// CheckBlockEpoch checks the L1 origin and sequence number of a L2 block against those of its parent:
+// a L2 block either continues the epoch of its parent with the next sequence number,
+// or starts the epoch of the next L1 block with sequence number 0.
+func CheckBlockEpoch(parentOrigin eth.BlockID, parentSeqNumber uint64, origin eth.BlockID, seqNumber uint64) error {
+	if origin == parentOrigin {
+		if seqNumber != parentSeqNumber+1 {
+			return fmt.Errorf("sequence number %d does not follow parent sequence number %d in epoch %s: %w", seqNumber, parentSeqNumber, origin, InvalidEpochErr)
+		}
+		return nil
+	}
+	if origin.Number != parentOrigin.Number+1 {
+		return fmt.Errorf("L1 origin %s does not follow parent L1 origin %s: %w", origin, parentOrigin, InvalidEpochErr)
+	}
+	if seqNumber != 0 {
+		return fmt.Errorf("sequence number %d does not start new epoch %s at 0: %w", seqNumber, origin, InvalidEpochErr)
+	}
+	return nil
+}
+
+// CheckBlockTimestamp checks the timestamp of a L2 block against the timestamp of its parent and of its L1 origin:
+// the timestamp must follow the parent by exactly the block time, and may not be before its L1 origin,
+// nor more than the max sequencer drift after it.
+func CheckBlockTimestamp(cfg *Config, parentTime uint64, originTime uint64, timestamp uint64) error {
+	if cfg.BlockTime != 0 && timestamp != parentTime+cfg.BlockTime {
+		return fmt.Errorf("timestamp %d does not follow parent timestamp %d by block time %d: %w", timestamp, parentTime, cfg.BlockTime, InvalidTimestampErr)
+	}
+	if timestamp < originTime {
+		return fmt.Errorf("timestamp %d is before L1 origin timestamp %d: %w", timestamp, originTime, InvalidTimestampErr)
+	}
+	if timestamp > originTime+cfg.MaxSequencerDrift {
+		return fmt.Errorf("timestamp %d is more than %d seconds ahead of L1 origin timestamp %d: %w", timestamp, cfg.MaxSequencerDrift, originTime, InvalidTimestampErr)
+	}
+	return nil
+}
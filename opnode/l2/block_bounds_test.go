@@ -0,0 +1,32 @@
+package l2
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+func TestCheckBlockEpoch(t *testing.T) {
+	a := eth.BlockID{Hash: common.Hash{0xa}, Number: 10}
+	b := eth.BlockID{Hash: common.Hash{0xb}, Number: 11}
+	require.NoError(t, CheckBlockEpoch(a, 0, a, 1), "continues epoch")
+	require.NoError(t, CheckBlockEpoch(a, 3, b, 0), "starts next epoch")
+	require.ErrorIs(t, CheckBlockEpoch(a, 1, a, 1), InvalidEpochErr, "repeats sequence number")
+	require.ErrorIs(t, CheckBlockEpoch(a, 1, a, 3), InvalidEpochErr, "skips sequence number")
+	require.ErrorIs(t, CheckBlockEpoch(a, 3, b, 4), InvalidEpochErr, "new epoch does not start at 0")
+	require.ErrorIs(t, CheckBlockEpoch(a, 0, eth.BlockID{Hash: common.Hash{0xc}, Number: 12}, 0), InvalidEpochErr, "skips L1 origin")
+	require.ErrorIs(t, CheckBlockEpoch(b, 0, a, 0), InvalidEpochErr, "L1 origin goes back")
+}
+
+func TestCheckBlockTimestamp(t *testing.T) {
+	cfg := &Config{BlockTime: 2, MaxSequencerDrift: 10}
+	require.NoError(t, CheckBlockTimestamp(cfg, 1000, 1000, 1002))
+	require.NoError(t, CheckBlockTimestamp(cfg, 1008, 1000, 1010), "at max drift")
+	require.ErrorIs(t, CheckBlockTimestamp(cfg, 1000, 1000, 1003), InvalidTimestampErr, "not aligned to block time")
+	require.ErrorIs(t, CheckBlockTimestamp(cfg, 1010, 1000, 1012), InvalidTimestampErr, "exceeds drift")
+	require.ErrorIs(t, CheckBlockTimestamp(cfg, 998, 1001, 1000), InvalidTimestampErr, "before L1 origin")
+	require.NoError(t, CheckBlockTimestamp(&Config{MaxSequencerDrift: 10}, 990, 1000, 1005), "zero block time disables alignment")
+}
@@ -0,0 +1,360 @@
+package l2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// FramesV0 is the version byte of a batch submission with one or more channel frames,
+// as opposed to a single batch (BatchV0).
+const FramesV0 = 1
+
+// MaxFrameDataLen limits the data of a single frame, frames are split over L1 transactions and must fit in one.
+const MaxFrameDataLen = 1_000_000
+
+const (
+	// DefaultChannelTimeout is the default number of L1 blocks after the first frame of a channel within which the channel must complete
+	DefaultChannelTimeout = 300
+	// DefaultMaxChannelBankSize is the default limit of the combined data size of the pending channels, in bytes
+	DefaultMaxChannelBankSize = 100_000_000
+)
+
+type ChannelID [16]byte
+
+func (id ChannelID) String() string {
+	return fmt.Sprintf("%x", id[:])
+}
+
+// Frame is a chunk of the data of a channel.
+// Channels can be larger than a single L1 transaction, and are split into frames to submit them.
+//
+// Encoded as: channel_id (16 bytes) ++ frame_number (uint16) ++ data_len (uint32) ++ data ++ is_last (1 byte)
+type Frame struct {
+	ID          ChannelID
+	FrameNumber uint16
+	Data        []byte
+	IsLast      bool
+}
+
+func (f *Frame) MarshalBinary(w io.Writer) error {
+	if len(f.Data) > MaxFrameDataLen {
+		return fmt.Errorf("frame data too large: %d", len(f.Data))
+	}
+	var buf [16 + 2 + 4]byte
+	copy(buf[:16], f.ID[:])
+	binary.BigEndian.PutUint16(buf[16:18], f.FrameNumber)
+	binary.BigEndian.PutUint32(buf[18:22], uint32(len(f.Data)))
+	if _, err := w.Write(buf[:]); err != nil {
+		return err
+	}
+	if _, err := w.Write(f.Data); err != nil {
+		return err
+	}
+	last := byte(0)
+	if f.IsLast {
+		last = 1
+	}
+	_, err := w.Write([]byte{last})
+	return err
+}
+
+func (f *Frame) UnmarshalBinary(r io.Reader) error {
+	var buf [16 + 2 + 4]byte
+	if _, err := io.ReadFull(r, buf[:]); err != nil {
+		return fmt.Errorf("failed to read frame header: %w", err)
+	}
+	copy(f.ID[:], buf[:16])
+	f.FrameNumber = binary.BigEndian.Uint16(buf[16:18])
+	dataLen := binary.BigEndian.Uint32(buf[18:22])
+	if dataLen > MaxFrameDataLen {
+		return fmt.Errorf("frame data too large: %d", dataLen)
+	}
+	f.Data = make([]byte, dataLen)
+	if _, err := io.ReadFull(r, f.Data); err != nil {
+		return fmt.Errorf("failed to read frame data: %w", err)
+	}
+	var last [1]byte
+	if _, err := io.ReadFull(r, last[:]); err != nil {
+		return fmt.Errorf("failed to read frame is_last: %w", err)
+	}
+	switch last[0] {
+	case 0:
+		f.IsLast = false
+	case 1:
+		f.IsLast = true
+	default:
+		return fmt.Errorf("invalid frame is_last byte: %d", last[0])
+	}
+	return nil
+}
+
+// EncodeFrames encodes the frames as calldata for a batch submission to the batch inbox.
+func EncodeFrames(frames []Frame) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(FramesV0)
+	for i := range frames {
+		if err := frames[i].MarshalBinary(&buf); err != nil {
+			return nil, fmt.Errorf("failed to encode frame %d: %v", i, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeFrames decodes the frames of a batch submission. All the data must be consumed by the frames.
+func DecodeFrames(data []byte) ([]Frame, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty frames data")
+	}
+	if data[0] != FramesV0 {
+		return nil, fmt.Errorf("unknown frames version %d", data[0])
+	}
+	r := bytes.NewReader(data[1:])
+	var frames []Frame
+	for r.Len() > 0 {
+		var f Frame
+		if err := f.UnmarshalBinary(r); err != nil {
+			return nil, fmt.Errorf("failed to decode frame %d: %v", len(frames), err)
+		}
+		frames = append(frames, f)
+	}
+	if len(frames) == 0 {
+		return nil, errors.New("no frames")
+	}
+	return frames, nil
+}
+
+// EncodeChannel encodes the batches as channel data: a RLP stream of encoded batches,
+// compressed with the given algorithm, and prefixed with the compression algorithm byte.
+func EncodeChannel(batches []*BatchData, algo CompressionAlgo) ([]byte, error) {
+	var buf bytes.Buffer
+	for i, batch := range batches {
+		data, err := EncodeBatch(batch)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode batch %d: %v", i, err)
+		}
+		if err := rlp.Encode(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to encode batch %d: %v", i, err)
+		}
+	}
+	return compress(algo, buf.Bytes())
+}
+
+// DecodeChannel decodes the batches of a complete channel, the inverse of EncodeChannel.
+func DecodeChannel(data []byte) ([]*BatchData, error) {
+	data, err := decompress(data)
+	if err != nil {
+		return nil, err
+	}
+	s := rlp.NewStream(bytes.NewReader(data), uint64(len(data)))
+	var batches []*BatchData
+	for {
+		batchData, err := s.Bytes()
+		if err == io.EOF {
+			return batches, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read batch %d: %v", len(batches), err)
+		}
+		batch, err := DecodeBatch(batchData)
+		if err != nil {
+			return nil, fmt.Errorf("invalid batch %d: %v", len(batches), err)
+		}
+		batches = append(batches, batch)
+	}
+}
+
+type channel struct {
+	id ChannelID
+	// L1 block number the first frame of the channel was included in
+	openBlock uint64
+	// frame number -> frame data
+	frames map[uint16][]byte
+	// the number of frames, known once the last frame is ingested
+	lastFrame *uint16
+	size      uint64
+}
+
+func (ch *channel) complete() bool {
+	return ch.lastFrame != nil && len(ch.frames) == int(*ch.lastFrame)+1
+}
+
+func (ch *channel) data() []byte {
+	numbers := make([]int, 0, len(ch.frames))
+	for nr := range ch.frames {
+		numbers = append(numbers, int(nr))
+	}
+	sort.Ints(numbers)
+	out := make([]byte, 0, ch.size)
+	for _, nr := range numbers {
+		out = append(out, ch.frames[uint16(nr)]...)
+	}
+	return out
+}
+
+// ChannelBank reassembles channels from frames, which may be spread over multiple L1 transactions and blocks,
+// and may arrive out of order.
+//
+// Channels time out ChannelTimeout L1 blocks after their first frame was included:
+// incomplete channels are then dropped, and frames of timed out channels are ignored.
+// If the combined size of the pending channels exceeds MaxChannelBankSize,
+// the oldest channels are evicted first.
+//
+// Complete channels are read in the order they were opened.
+// The ChannelBank is not safe for concurrent use.
+type ChannelBank struct {
+	cfg *Config
+
+	channels map[ChannelID]*channel
+	// channel IDs in order of opening
+	queue []ChannelID
+	// channels that were read or timed out, by the L1 block they were opened in, to ignore any later frames
+	closed map[ChannelID]uint64
+	size   uint64
+}
+
+func NewChannelBank(cfg *Config) *ChannelBank {
+	return &ChannelBank{
+		cfg:      cfg,
+		channels: make(map[ChannelID]*channel),
+		closed:   make(map[ChannelID]uint64),
+	}
+}
+
+// IngestFrames ingests the frames of a batch submission included in the given L1 block.
+// Duplicate frames, frames past the last frame, and frames of closed channels are ignored.
+func (cb *ChannelBank) IngestFrames(l1Num uint64, frames []Frame) {
+	cb.prune(l1Num)
+	for _, f := range frames {
+		cb.ingest(l1Num, f)
+	}
+	cb.evict()
+}
+
+func (cb *ChannelBank) ingest(l1Num uint64, f Frame) {
+	if _, ok := cb.closed[f.ID]; ok {
+		return
+	}
+	ch, ok := cb.channels[f.ID]
+	if !ok {
+		ch = &channel{id: f.ID, openBlock: l1Num, frames: make(map[uint16][]byte)}
+		cb.channels[f.ID] = ch
+		cb.queue = append(cb.queue, f.ID)
+	}
+	if _, ok := ch.frames[f.FrameNumber]; ok {
+		return // duplicate
+	}
+	if ch.lastFrame != nil && f.FrameNumber > *ch.lastFrame {
+		return // past the end of the channel
+	}
+	if f.IsLast {
+		if ch.lastFrame != nil {
+			return // conflicting last frame
+		}
+		// drop any frames that were ingested past the last frame
+		for nr, data := range ch.frames {
+			if nr > f.FrameNumber {
+				ch.size -= uint64(len(data))
+				cb.size -= uint64(len(data))
+				delete(ch.frames, nr)
+			}
+		}
+		last := f.FrameNumber
+		ch.lastFrame = &last
+	}
+	ch.frames[f.FrameNumber] = f.Data
+	ch.size += uint64(len(f.Data))
+	cb.size += uint64(len(f.Data))
+}
+
+// Read returns the data of the oldest channel, if it is complete.
+// Incomplete older channels block newer complete channels, until they complete or time out.
+func (cb *ChannelBank) Read(l1Num uint64) (id ChannelID, data []byte, ok bool) {
+	cb.prune(l1Num)
+	if len(cb.queue) == 0 {
+		return ChannelID{}, nil, false
+	}
+	ch := cb.channels[cb.queue[0]]
+	if !ch.complete() {
+		return ChannelID{}, nil, false
+	}
+	cb.remove(ch)
+	return ch.id, ch.data(), true
+}
+
+// ReadBatches decodes the batches of all channels that can be read.
+// Channels with invalid data are skipped, and reported in the returned error slice.
+func (cb *ChannelBank) ReadBatches(l1Num uint64) (batches []*BatchData, errs []error) {
+	for {
+		id, data, ok := cb.Read(l1Num)
+		if !ok {
+			return
+		}
+		chBatches, err := DecodeChannel(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid channel %s: %v", id, err))
+			continue
+		}
+		batches = append(batches, chBatches...)
+	}
+}
+
+// Size returns the combined size of the data of all pending channels.
+func (cb *ChannelBank) Size() uint64 {
+	return cb.size
+}
+
+// Reset drops all channels, e.g. after a L1 reorg.
+func (cb *ChannelBank) Reset() {
+	cb.channels = make(map[ChannelID]*channel)
+	cb.closed = make(map[ChannelID]uint64)
+	cb.queue = nil
+	cb.size = 0
+}
+
+func (cb *ChannelBank) remove(ch *channel) {
+	delete(cb.channels, ch.id)
+	cb.closed[ch.id] = ch.openBlock
+	for i, id := range cb.queue {
+		if id == ch.id {
+			cb.queue = append(cb.queue[:i], cb.queue[i+1:]...)
+			break
+		}
+	}
+	cb.size -= ch.size
+}
+
+// prune drops the channels that timed out, and forgets closed channels that can no longer receive frames.
+func (cb *ChannelBank) prune(l1Num uint64) {
+	timeout := cb.cfg.ChannelTimeout
+	if timeout == 0 {
+		return
+	}
+	for len(cb.queue) > 0 {
+		ch := cb.channels[cb.queue[0]]
+		if ch.openBlock+timeout >= l1Num {
+			break
+		}
+		cb.remove(ch)
+	}
+	for id, openBlock := range cb.closed {
+		if openBlock+timeout < l1Num {
+			delete(cb.closed, id)
+		}
+	}
+}
+
+// evict drops the oldest channels until the bank fits within MaxChannelBankSize.
+func (cb *ChannelBank) evict() {
+	if cb.cfg.MaxChannelBankSize == 0 {
+		return
+	}
+	for cb.size > cb.cfg.MaxChannelBankSize && len(cb.queue) > 0 {
+		cb.remove(cb.channels[cb.queue[0]])
+	}
+}
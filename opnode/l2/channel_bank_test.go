@@ -0,0 +1,126 @@
+package l2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testFrames(id byte, data []byte, n int) []Frame {
+	var frames []Frame
+	chunk := (len(data) + n - 1) / n
+	for i := 0; i < n; i++ {
+		end := (i + 1) * chunk
+		if end > len(data) {
+			end = len(data)
+		}
+		frames = append(frames, Frame{ID: ChannelID{id}, FrameNumber: uint16(i), Data: data[i*chunk : end], IsLast: i == n-1})
+	}
+	return frames
+}
+
+func TestFramesEncoding(t *testing.T) {
+	frames := testFrames(1, []byte("hello world"), 3)
+	data, err := EncodeFrames(frames)
+	require.NoError(t, err)
+	decoded, err := DecodeFrames(data)
+	require.NoError(t, err)
+	assert.Equal(t, frames, decoded)
+
+	_, err = DecodeFrames(data[:len(data)-1])
+	assert.Error(t, err, "truncated")
+	bad := append([]byte{}, data...)
+	bad[len(bad)-1] = 2
+	_, err = DecodeFrames(bad)
+	assert.Error(t, err, "invalid is_last")
+	_, err = DecodeFrames([]byte{BatchV0})
+	assert.Error(t, err, "not frames")
+}
+
+func TestChannelEncoding(t *testing.T) {
+	batches := []*BatchData{
+		{Transactions: []Data{testL2Tx(t, 0)}},
+		{Transactions: []Data{testL2Tx(t, 1), testL2Tx(t, 2)}},
+	}
+	data, err := EncodeChannel(batches, ZlibCompression)
+	require.NoError(t, err)
+	decoded, err := DecodeChannel(data)
+	require.NoError(t, err)
+	assert.Equal(t, batches, decoded)
+}
+
+func TestChannelBank_OutOfOrder(t *testing.T) {
+	batches := []*BatchData{{Transactions: []Data{testL2Tx(t, 0), testL2Tx(t, 1)}}}
+	data, err := EncodeChannel(batches, ZlibCompression)
+	require.NoError(t, err)
+	frames := testFrames(1, data, 4)
+
+	cb := NewChannelBank(&Config{ChannelTimeout: 10})
+	cb.IngestFrames(100, []Frame{frames[3], frames[1]})
+	_, _, ok := cb.Read(100)
+	assert.False(t, ok)
+	// duplicate frames are ignored
+	cb.IngestFrames(101, []Frame{frames[1], frames[0]})
+	_, _, ok = cb.Read(101)
+	assert.False(t, ok)
+	cb.IngestFrames(102, []Frame{frames[2]})
+
+	out, errs := cb.ReadBatches(102)
+	assert.Empty(t, errs)
+	assert.Equal(t, batches, out)
+	assert.Equal(t, uint64(0), cb.Size())
+
+	// late frames of a read channel do not reopen it
+	cb.IngestFrames(103, []Frame{frames[0]})
+	_, _, ok = cb.Read(103)
+	assert.False(t, ok)
+	assert.Equal(t, uint64(0), cb.Size())
+}
+
+func TestChannelBank_Timeout(t *testing.T) {
+	a := testFrames(1, []byte("aaaa"), 2)
+	b := testFrames(2, []byte("bbbb"), 1)
+	cb := NewChannelBank(&Config{ChannelTimeout: 10})
+	cb.IngestFrames(100, a[:1])
+	cb.IngestFrames(101, b)
+	// the incomplete older channel blocks the newer channel
+	_, _, ok := cb.Read(110)
+	assert.False(t, ok)
+	// until it times out
+	id, data, ok := cb.Read(111)
+	assert.True(t, ok)
+	assert.Equal(t, ChannelID{2}, id)
+	assert.Equal(t, []byte("bbbb"), data)
+	// frames of the timed out channel are ignored
+	cb.IngestFrames(111, a[1:])
+	_, _, ok = cb.Read(111)
+	assert.False(t, ok)
+}
+
+func TestChannelBank_Evict(t *testing.T) {
+	a := testFrames(1, []byte("aaaa"), 2)
+	b := testFrames(2, []byte("bbbb"), 2)
+	cb := NewChannelBank(&Config{MaxChannelBankSize: 5})
+	cb.IngestFrames(100, a[:1])
+	cb.IngestFrames(100, b[:1])
+	assert.Equal(t, uint64(4), cb.Size())
+	cb.IngestFrames(101, b[1:])
+	// the oldest channel is evicted to make room
+	assert.Equal(t, uint64(4), cb.Size())
+	id, data, ok := cb.Read(101)
+	assert.True(t, ok)
+	assert.Equal(t, ChannelID{2}, id)
+	assert.Equal(t, []byte("bbbb"), data)
+}
+
+func TestChannelBank_InvalidChannel(t *testing.T) {
+	cb := NewChannelBank(&Config{})
+	cb.IngestFrames(100, testFrames(1, []byte{0xc3}, 1))
+	valid, err := EncodeChannel([]*BatchData{{Transactions: []Data{testL2Tx(t, 0)}}}, ZlibCompression)
+	require.NoError(t, err)
+	cb.IngestFrames(100, testFrames(2, valid, 1))
+	out, errs := cb.ReadBatches(100)
+	assert.Len(t, errs, 1)
+	assert.Len(t, out, 1)
+}
@@ -0,0 +1,103 @@
+package l2
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io"
+)
+
+// MaxChannelDataLen limits the decompressed data of a channel, to avoid decompression bombs.
+const MaxChannelDataLen = 10_000_000
+
+// CompressionAlgo identifies the compression of the channel data, encoded as the first byte of the channel.
+type CompressionAlgo byte
+
+const (
+	NoCompression   CompressionAlgo = 0
+	ZlibCompression CompressionAlgo = 1
+)
+
+// Codec compresses and decompresses channel data.
+// Decompress must not produce more than maxLen bytes.
+type Codec struct {
+	Compress   func(data []byte) ([]byte, error)
+	Decompress func(data []byte, maxLen uint64) ([]byte, error)
+}
+
+// Codecs maps each supported compression algorithm to its codec.
+// Additional algorithms (e.g. brotli, zstd) can be registered here, all nodes must support the same algorithms.
+var Codecs = map[CompressionAlgo]Codec{
+	NoCompression: {
+		Compress: func(data []byte) ([]byte, error) {
+			return data, nil
+		},
+		Decompress: func(data []byte, maxLen uint64) ([]byte, error) {
+			if uint64(len(data)) > maxLen {
+				return nil, fmt.Errorf("data too large: %d, expected max %d", len(data), maxLen)
+			}
+			return data, nil
+		},
+	},
+	ZlibCompression: {
+		Compress: func(data []byte) ([]byte, error) {
+			var buf bytes.Buffer
+			w := zlib.NewWriter(&buf)
+			if _, err := w.Write(data); err != nil {
+				return nil, err
+			}
+			if err := w.Close(); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		},
+		Decompress: func(data []byte, maxLen uint64) ([]byte, error) {
+			r, err := zlib.NewReader(bytes.NewReader(data))
+			if err != nil {
+				return nil, err
+			}
+			defer r.Close()
+			return readLimited(r, maxLen)
+		},
+	},
+}
+
+// readLimited reads all data, and errors if there is more than maxLen bytes
+func readLimited(r io.Reader, maxLen uint64) ([]byte, error) {
+	out, err := io.ReadAll(io.LimitReader(r, int64(maxLen)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(out)) > maxLen {
+		return nil, fmt.Errorf("decompressed data exceeds max %d bytes", maxLen)
+	}
+	return out, nil
+}
+
+func compress(algo CompressionAlgo, data []byte) ([]byte, error) {
+	codec, ok := Codecs[algo]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression algorithm %d", algo)
+	}
+	compressed, err := codec.Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress with algorithm %d: %v", algo, err)
+	}
+	return append([]byte{byte(algo)}, compressed...), nil
+}
+
+func decompress(data []byte) ([]byte, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty channel data")
+	}
+	algo := CompressionAlgo(data[0])
+	codec, ok := Codecs[algo]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression algorithm %d", algo)
+	}
+	out, err := codec.Decompress(data[1:], MaxChannelDataLen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress with algorithm %d: %v", algo, err)
+	}
+	return out, nil
+}
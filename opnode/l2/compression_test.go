@@ -0,0 +1,50 @@
+package l2
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression(t *testing.T) {
+	data := bytes.Repeat([]byte("batch data "), 100)
+	for _, algo := range []CompressionAlgo{NoCompression, ZlibCompression} {
+		compressed, err := compress(algo, data)
+		require.NoError(t, err)
+		assert.Equal(t, byte(algo), compressed[0])
+		out, err := decompress(compressed)
+		require.NoError(t, err)
+		assert.Equal(t, data, out)
+	}
+
+	compressed, err := compress(ZlibCompression, data)
+	require.NoError(t, err)
+	assert.Less(t, len(compressed), len(data))
+
+	_, err = compress(CompressionAlgo(0xff), data)
+	assert.Error(t, err)
+	_, err = decompress(append([]byte{0xff}, data...))
+	assert.Error(t, err)
+	_, err = decompress(nil)
+	assert.Error(t, err)
+
+	for _, algo := range []CompressionAlgo{NoCompression, ZlibCompression} {
+		compressed, err := Codecs[algo].Compress(data)
+		require.NoError(t, err)
+		_, err = Codecs[algo].Decompress(compressed, uint64(len(data)-1))
+		assert.Error(t, err, "decompressed data must not exceed the max length")
+	}
+}
+
+func TestChannelCompression(t *testing.T) {
+	batches := []*BatchData{{Transactions: []Data{testL2Tx(t, 0), testL2Tx(t, 1)}}}
+	for _, algo := range []CompressionAlgo{NoCompression, ZlibCompression} {
+		data, err := EncodeChannel(batches, algo)
+		require.NoError(t, err)
+		decoded, err := DecodeChannel(data)
+		require.NoError(t, err)
+		assert.Equal(t, batches, decoded)
+	}
+}
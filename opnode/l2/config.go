@@ -0,0 +1,178 @@
+package l2
+
+import "github.com/ethereum/go-ethereum/common"
+
+// Config configures the derivation of L2 blocks from L1 data.
+// All nodes of the same rollup must use the same configuration to derive the same L2 chain.
+type Config struct {
+	// MaxTotalDepositGas limits the combined gas of all deposits in a L2 block, including the L1 info deposit.
+	// Zero disables the limit.
+	MaxTotalDepositGas uint64
+
+	// MaxDeposits limits the number of user deposits in a L2 block, excluding the L1 info deposit.
+	// Zero disables the limit.
+	MaxDeposits uint64
+
+	// VerifyLogsBloom enables checking the block logs bloom against the receipts, in addition to the receipts root.
+	// This only rejects inconsistent L1 data, and does not affect the derived L2 blocks.
+	VerifyLogsBloom bool
+
+	// DepositContractAddr is the L1 address of the deposit contract to derive user deposits from.
+	// Zero defaults to DepositContractAddr.
+	DepositContractAddr common.Address
+
+	// ExtraDepositContractAddrs are additional L1 deposit contracts to derive user deposits from,
+	// e.g. both the legacy and the new deposit contract during a migration.
+	ExtraDepositContractAddrs []common.Address
+
+	// DepositContractDecoders optionally overrides the decoder of the deposit logs of specific deposit contracts.
+	// The logs of deposit contracts without decoder are decoded with UnmarshalDepositLog.
+	DepositContractDecoders map[common.Address]DepositDecoder
+
+	// L1InfoPredeployAddr is the L2 address of the predeploy that receives the L1 info deposit.
+	// Zero defaults to L1InfoPredeployAddr.
+	L1InfoPredeployAddr common.Address
+
+	// WithdrawalContractAddr is the L2 address of the withdrawal contract, committed to in the output roots.
+	// Zero defaults to WithdrawalContractAddr.
+	WithdrawalContractAddr common.Address
+
+	// BatcherAddr is the L1 address of the batch submitter, committed to in the L1 info deposit.
+	BatcherAddr common.Address
+
+	// SequencerFeeRecipient is the L2 address that receives the transaction fees of sequenced L2 blocks.
+	// L2 blocks that are derived without batch, from deposits only, leave the fees to the zero address.
+	SequencerFeeRecipient common.Address
+
+	// BatchInboxAddr is the L1 address that the batcher submits batches to.
+	// Zero disables the derivation of sequenced transactions from batches.
+	BatchInboxAddr common.Address
+
+	// BlockTime is the number of seconds between L2 blocks.
+	// Zero disables the alignment of L2 block timestamps, e.g. when deriving a L2 block for every L1 block.
+	BlockTime uint64
+
+	// MaxSequencerDrift is the number of seconds that the timestamp of a L2 block may be ahead of its L1 origin.
+	// A L2 block that would drift further must adopt the next L1 origin.
+	MaxSequencerDrift uint64
+
+	// PayloadV2Time is the L2 timestamp from which L2 blocks are built with PayloadV2 payload attributes.
+	// Zero does not schedule PayloadV2.
+	PayloadV2Time uint64
+
+	// SeqWindowSize is the number of L1 blocks, starting at the L1 origin of an epoch,
+	// within which the batches of the epoch must be included on L1. L2 blocks without batch are derived empty
+	// once the window elapsed. Zero disables the window: L2 blocks without batch are derived empty right away.
+	SeqWindowSize uint64
+
+	// P2PSequencerAddr is the address of the sequencer key that signs the unsafe L2 blocks gossiped to verifiers.
+	// Zero disables the import of gossiped unsafe L2 blocks.
+	P2PSequencerAddr common.Address
+
+	// ChannelTimeout is the number of L1 blocks after the first frame of a channel within which the channel must complete.
+	// Zero disables the timeout.
+	ChannelTimeout uint64
+
+	// MaxChannelBankSize limits the combined data size of the pending channels, the oldest channels are evicted first.
+	// Zero disables the limit.
+	MaxChannelBankSize uint64
+
+	// SystemConfigAddr is the L1 address of the SystemConfig contract, of which the ConfigUpdate events
+	// update the SystemConfig. Zero disables the tracking of the system config.
+	SystemConfigAddr common.Address
+
+	// SystemConfig is the rollup-governed configuration of the L2 system, initially, and as tracked by the derivation
+	SystemConfig SystemConfig
+}
+
+// SystemConfig is the rollup-governed configuration of the L2 system, that L2 blocks are built with.
+type SystemConfig struct {
+	// BatcherAddr overrides the BatcherAddr of the rollup config, if not zero
+	BatcherAddr common.Address `json:"batcherAddr"`
+	// Overhead and Scalar are the L1 fee scalars, committed to in the L1 info deposit
+	Overhead common.Hash `json:"overhead"`
+	Scalar   common.Hash `json:"scalar"`
+	// GasLimit is the gas limit of L2 blocks. Zero leaves the gas limit to the engine.
+	GasLimit uint64 `json:"gasLimit"`
+	// FeeRecipient overrides the SequencerFeeRecipient of the rollup config, if not zero
+	FeeRecipient common.Address `json:"feeRecipient"`
+}
+
+// DepositContract returns the configured deposit contract address, or the default if not configured.
+func (cfg *Config) DepositContract() common.Address {
+	if cfg.DepositContractAddr == (common.Address{}) {
+		return DepositContractAddr
+	}
+	return cfg.DepositContractAddr
+}
+
+// DepositContracts returns all the deposit contracts to derive user deposits from:
+// the DepositContract, followed by the extra deposit contracts, without duplicates.
+func (cfg *Config) DepositContracts() []common.Address {
+	out := []common.Address{cfg.DepositContract()}
+	for _, addr := range cfg.ExtraDepositContractAddrs {
+		if !containsAddress(out, addr) {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
+
+// IsDepositContract returns true if user deposits are derived from the logs of the given address.
+func (cfg *Config) IsDepositContract(addr common.Address) bool {
+	return addr == cfg.DepositContract() || containsAddress(cfg.ExtraDepositContractAddrs, addr)
+}
+
+// DepositDecoder returns the decoder of the deposit logs of the given deposit contract.
+func (cfg *Config) DepositDecoder(addr common.Address) DepositDecoder {
+	if decode, ok := cfg.DepositContractDecoders[addr]; ok {
+		return decode
+	}
+	return UnmarshalDepositLog
+}
+
+func containsAddress(addrs []common.Address, addr common.Address) bool {
+	for _, a := range addrs {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// L1InfoPredeploy returns the configured L1 info predeploy address, or the default if not configured.
+func (cfg *Config) L1InfoPredeploy() common.Address {
+	if cfg.L1InfoPredeployAddr == (common.Address{}) {
+		return L1InfoPredeployAddr
+	}
+	return cfg.L1InfoPredeployAddr
+}
+
+// WithdrawalContract returns the configured withdrawal contract address, or the default if not configured.
+func (cfg *Config) WithdrawalContract() common.Address {
+	if cfg.WithdrawalContractAddr == (common.Address{}) {
+		return WithdrawalContractAddr
+	}
+	return cfg.WithdrawalContractAddr
+}
+
+// Batcher returns the address of the batch submitter: as updated in the system config, or else as configured.
+func (cfg *Config) Batcher() common.Address {
+	if cfg.SystemConfig.BatcherAddr != (common.Address{}) {
+		return cfg.SystemConfig.BatcherAddr
+	}
+	return cfg.BatcherAddr
+}
+
+// FeeRecipient returns the fee recipient of sequenced L2 blocks: as updated in the system config, or else as configured.
+func (cfg *Config) FeeRecipient() common.Address {
+	if cfg.SystemConfig.FeeRecipient != (common.Address{}) {
+		return cfg.SystemConfig.FeeRecipient
+	}
+	return cfg.SequencerFeeRecipient
+}
+
+// BatcherHash returns the batcher hash committed to in the L1 info deposit: the left-padded batcher address.
+func (cfg *Config) BatcherHash() common.Hash {
+	return common.BytesToHash(cfg.Batcher().Bytes())
+}
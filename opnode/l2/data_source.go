@@ -0,0 +1,37 @@
+package l2
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/core/types"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// DataSource retrieves the data of the batch submissions in a L1 block,
+// so the derivation can treat the different data-availability sources uniformly.
+//
+// Only calldata is supported for now: blob transactions cannot be decoded with the geth dependency,
+// so the versioned hashes to retrieve blob sidecars by are not available yet.
+type DataSource interface {
+	// BatchData returns the data of each batch submission in the L1 block with the given transactions, in order.
+	// The config is the rollup config at the L1 block, with the batcher that batches are accepted from.
+	BatchData(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error)
+}
+
+// CalldataSource implements DataSource with the calldata of the batcher transactions.
+type CalldataSource struct{}
+
+var _ DataSource = CalldataSource{}
+
+func (CalldataSource) BatchData(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error) {
+	batcherTxs, err := BatcherTransactions(cfg, txs)
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]byte, 0, len(batcherTxs))
+	for _, tx := range batcherTxs {
+		out = append(out, tx.Data())
+	}
+	return out, nil
+}
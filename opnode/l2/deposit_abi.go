@@ -0,0 +1,80 @@
+package l2
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/contracts/deposit"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DepositContractABI is the ABI of the deposit contract, from the generated contract bindings.
+var DepositContractABI = mustParseABI(deposit.DepositMetaData.ABI)
+
+func mustParseABI(def string) *abi.ABI {
+	parsed, err := abi.JSON(bytes.NewReader([]byte(def)))
+	if err != nil {
+		panic(fmt.Errorf("invalid ABI: %v", err))
+	}
+	return &parsed
+}
+
+// unpackEventStrict decodes the topics and data of the log into out, a struct with a field per event argument.
+//
+// Unlike the abi package, the decoding is strict: the log must have exactly the topics of the event,
+// indexed values must be encoded canonically, and the data must be the canonical encoding of the non-indexed values
+// (no unexpected offsets, no dirty padding, no trailing bytes).
+func unpackEventStrict(contract *abi.ABI, name string, ev *types.Log, out interface{}) error {
+	event, ok := contract.Events[name]
+	if !ok {
+		return fmt.Errorf("unknown event %q", name)
+	}
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(ev.Topics) != 1+len(indexed) {
+		return fmt.Errorf("expected %d event topics (event identity and indexed args), got %d", 1+len(indexed), len(ev.Topics))
+	}
+	if ev.Topics[0] != event.ID {
+		return fmt.Errorf("invalid %s event selector: %s, expected %s", name, ev.Topics[0], event.ID)
+	}
+	for i, arg := range indexed {
+		if arg.Type.T == abi.AddressTy && !isZero(ev.Topics[1+i][:common.HashLength-common.AddressLength]) {
+			return fmt.Errorf("indexed %s argument %q has dirty padding: %s", name, arg.Name, ev.Topics[1+i])
+		}
+	}
+	if err := abi.ParseTopics(out, indexed, ev.Topics[1:]); err != nil {
+		return fmt.Errorf("failed to decode indexed %s arguments: %v", name, err)
+	}
+
+	nonIndexed := event.Inputs.NonIndexed()
+	values, err := nonIndexed.Unpack(ev.Data)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s data: %v", name, err)
+	}
+	canonical, err := nonIndexed.Pack(values...)
+	if err != nil {
+		return fmt.Errorf("failed to re-encode %s data: %v", name, err)
+	}
+	if !bytes.Equal(canonical, ev.Data) {
+		return fmt.Errorf("%s data is not canonically encoded: %x", name, ev.Data)
+	}
+	if err := nonIndexed.Copy(out, values); err != nil {
+		return fmt.Errorf("failed to copy %s data: %v", name, err)
+	}
+	return nil
+}
+
+func isZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,226 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var PossiblyMissingLogsErr = errors.New("logs bloom indicates possibly missing logs")
+
+// LogsTrustLevel determines how much deposit logs from eth_getLogs are trusted,
+// as opposed to deriving deposits from the full receipts, which are verified against the receipts root.
+// The zero value is the strictest level.
+type LogsTrustLevel uint8
+
+const (
+	// LogsTrustStrict checks the logs like LogsTrustBloom, and additionally rejects an empty result
+	// if the logs bloom indicates there may be deposits, since the logs bloom cannot tell false positives
+	// and withheld logs apart. The deposits should then be derived from the receipts instead.
+	LogsTrustStrict LogsTrustLevel = iota
+	// LogsTrustBloom checks that every log belongs to the block, and is included in the logs bloom of the block
+	LogsTrustBloom
+	// LogsTrustFull accepts the logs as-is
+	LogsTrustFull
+)
+
+func (t LogsTrustLevel) String() string {
+	switch t {
+	case LogsTrustStrict:
+		return "strict"
+	case LogsTrustBloom:
+		return "bloom"
+	case LogsTrustFull:
+		return "full"
+	default:
+		return fmt.Sprintf("LogsTrustLevel(%d)", uint8(t))
+	}
+}
+
+// ParseLogsTrustLevel parses the name of a trust level, as returned by String.
+func ParseLogsTrustLevel(name string) (LogsTrustLevel, error) {
+	for _, t := range []LogsTrustLevel{LogsTrustStrict, LogsTrustBloom, LogsTrustFull} {
+		if t.String() == name {
+			return t, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown logs trust level %q", name)
+}
+
+// DepositLogsFilter is the logs filter for the deposit logs of any L1 block, e.g. to subscribe to new deposits.
+func DepositLogsFilter(cfg *Config) ethereum.FilterQuery {
+	return ethereum.FilterQuery{
+		Addresses: cfg.DepositContracts(),
+		Topics:    [][]common.Hash{{DepositEventABIHash}},
+	}
+}
+
+// DepositLogsQuery is the eth_getLogs filter for the deposit logs of the given L1 block.
+func DepositLogsQuery(cfg *Config, blockHash common.Hash) ethereum.FilterQuery {
+	q := DepositLogsFilter(cfg)
+	q.BlockHash = &blockHash
+	return q
+}
+
+// FetchDepositLogs fetches the deposit logs of the L1 block with eth_getLogs,
+// as an alternative to downloading all the receipts of the block.
+func FetchDepositLogs(ctx context.Context, src ethereum.LogFilterer, cfg *Config, blockHash common.Hash) ([]types.Log, error) {
+	logs, err := src.FilterLogs(ctx, DepositLogsQuery(cfg, blockHash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch deposit logs of block %s: %v", blockHash, err)
+	}
+	return logs, nil
+}
+
+// L1LogsQuery is the eth_getLogs filter for the deposit logs and the ConfigUpdate logs of the given L1 block.
+// The result may include other events of the contracts, which are ignored by the derivation.
+func L1LogsQuery(cfg *Config, blockHash common.Hash) ethereum.FilterQuery {
+	q := DepositLogsQuery(cfg, blockHash)
+	if cfg.SystemConfigAddr != (common.Address{}) {
+		q.Addresses = append(q.Addresses, cfg.SystemConfigAddr)
+		q.Topics = [][]common.Hash{{DepositEventABIHash, ConfigUpdateEventABIHash}}
+	}
+	return q
+}
+
+// L1LogsFetcher fetches L1 blocks with their deposit and ConfigUpdate logs through eth_getLogs,
+// as an alternative to downloading all the receipts of every L1 block.
+type L1LogsFetcher struct {
+	Blocks eth.BlockByHashSource
+	Logs   ethereum.LogFilterer
+	// Trust is the level the logs are checked with. With LogsTrustStrict, PossiblyMissingLogsErr is returned
+	// if the block may contain logs that were not returned, and the receipts should be fetched instead.
+	Trust LogsTrustLevel
+}
+
+// Fetch fetches the L1 block with its deposit and ConfigUpdate logs, and checks the logs according to the trust level.
+func (f *L1LogsFetcher) Fetch(ctx context.Context, cfg *Config, id eth.BlockID) (*types.Block, []types.Log, error) {
+	bl, err := f.Blocks.BlockByHash(ctx, id.Hash)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch L1 block %s: %w", id, err)
+	}
+	if bl.Hash() != id.Hash {
+		return nil, nil, fmt.Errorf("expected L1 block %s, got %s: %w", id, bl.Hash(), eth.InvalidResponseErr)
+	}
+	logs, err := f.Logs.FilterLogs(ctx, L1LogsQuery(cfg, id.Hash))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch logs of L1 block %s: %w", id, err)
+	}
+	var deposits, updates []types.Log
+	for i := range logs {
+		if isDepositLog(cfg, &logs[i]) {
+			deposits = append(deposits, logs[i])
+		} else if isConfigUpdateLog(cfg, &logs[i]) {
+			updates = append(updates, logs[i])
+		}
+	}
+	block := BlockInputFromBlock(bl)
+	if err := CheckDepositLogs(cfg, f.Trust, block, deposits); err != nil {
+		return nil, nil, err
+	}
+	if err := CheckConfigUpdateLogs(cfg, f.Trust, block, updates); err != nil {
+		return nil, nil, err
+	}
+	return bl, logs, nil
+}
+
+// DeriveBlockInputsFromLogs derives the block inputs of the L2 block of a L1 block from the logs of the block,
+// as fetched with eth_getLogs, like DeriveBlockInputs does from the receipts.
+// The deposit logs are checked according to the trust level, other logs are ignored.
+func DeriveBlockInputsFromLogs(cfg *Config, trust LogsTrustLevel, block BlockInput, logs []types.Log) (*PayloadAttributes, error) {
+	var deposits []types.Log
+	for i := range logs {
+		if isDepositLog(cfg, &logs[i]) {
+			deposits = append(deposits, logs[i])
+		}
+	}
+	userDeposits, err := DeriveUserDepositsFromLogs(cfg, trust, block, deposits)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive user deposits: %w", err)
+	}
+	return deriveBlockInputs(cfg, block, userDeposits)
+}
+
+// DeriveUserDepositsFromLogs derives the user deposits from the deposit logs of a L1 block, as fetched with eth_getLogs,
+// after checking the logs according to the trust level.
+// Logs of reverted transactions are not included by eth_getLogs, and thus do not need to be filtered out.
+func DeriveUserDepositsFromLogs(cfg *Config, trust LogsTrustLevel, block BlockInput, logs []types.Log) ([]*types.DepositTx, error) {
+	if err := CheckDepositLogs(cfg, trust, block, logs); err != nil {
+		return nil, err
+	}
+	return deriveUserDeposits(cfg, block.NumberU64(), sortLogs(logs))
+}
+
+// sortLogs returns the logs in block order
+func sortLogs(logs []types.Log) []*types.Log {
+	sorted := make([]*types.Log, len(logs))
+	for i := range logs {
+		sorted[i] = &logs[i]
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Index < sorted[j].Index
+	})
+	return sorted
+}
+
+// CheckDepositLogs checks the deposit logs of a L1 block according to the trust level.
+func CheckDepositLogs(cfg *Config, trust LogsTrustLevel, block BlockInput, logs []types.Log) error {
+	isDeposit := func(log *types.Log) bool { return isDepositLog(cfg, log) }
+	return checkLogs(trust, block, logs, "deposit", isDeposit, DepositEventABIHash, cfg.DepositContracts())
+}
+
+// CheckConfigUpdateLogs checks the ConfigUpdate logs of a L1 block according to the trust level.
+func CheckConfigUpdateLogs(cfg *Config, trust LogsTrustLevel, block BlockInput, logs []types.Log) error {
+	if cfg.SystemConfigAddr == (common.Address{}) {
+		return nil
+	}
+	isUpdate := func(log *types.Log) bool { return isConfigUpdateLog(cfg, log) }
+	return checkLogs(trust, block, logs, "ConfigUpdate", isUpdate, ConfigUpdateEventABIHash, []common.Address{cfg.SystemConfigAddr})
+}
+
+// checkLogs checks the logs of the given event of a L1 block, emitted by any of the given contracts, according to the trust level.
+func checkLogs(trust LogsTrustLevel, block BlockInput, logs []types.Log, kind string, isEvent func(log *types.Log) bool, topic common.Hash, contracts []common.Address) error {
+	if trust == LogsTrustFull {
+		return nil
+	}
+	bloom := block.Bloom()
+	seen := make(map[uint]struct{}, len(logs))
+	for i := range logs {
+		log := &logs[i]
+		if log.Removed {
+			return fmt.Errorf("%s log %d was removed", kind, i)
+		}
+		if log.BlockHash != block.Hash() || log.BlockNumber != block.NumberU64() {
+			return fmt.Errorf("%s log %d is from block %s (%d), expected %s (%d)", kind, i, log.BlockHash, log.BlockNumber, block.Hash(), block.NumberU64())
+		}
+		if !isEvent(log) {
+			return fmt.Errorf("%s log %d is not a %s event", kind, i, kind)
+		}
+		if _, ok := seen[log.Index]; ok {
+			return fmt.Errorf("duplicate %s log index %d", kind, log.Index)
+		}
+		seen[log.Index] = struct{}{}
+		if !bloom.Test(log.Address.Bytes()) {
+			return fmt.Errorf("%s log %d address is not in the logs bloom", kind, i)
+		}
+		for j, t := range log.Topics {
+			if !bloom.Test(t.Bytes()) {
+				return fmt.Errorf("%s log %d topic %d is not in the logs bloom", kind, i, j)
+			}
+		}
+	}
+	if trust == LogsTrustStrict && len(logs) == 0 && bloom.Test(topic.Bytes()) {
+		for _, addr := range contracts {
+			if bloom.Test(addr.Bytes()) {
+				return fmt.Errorf("%s logs: %w", kind, PossiblyMissingLogsErr)
+			}
+		}
+	}
+	return nil
+}
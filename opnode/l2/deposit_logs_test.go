@@ -0,0 +1,195 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+type filterLogsFn func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error)
+
+func (fn filterLogsFn) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	return fn(ctx, q)
+}
+
+func (fn filterLogsFn) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func testDepositBlock(t *testing.T, rng *rand.Rand) (BlockInput, []*types.Receipt, []types.Log) {
+	var receipts []*types.Receipt
+	for i := 0; i < 3; i++ {
+		rec := &types.Receipt{Status: types.ReceiptStatusSuccessful}
+		for j := 0; j < 2; j++ {
+			rec.Logs = append(rec.Logs, MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, 1, rng)))
+		}
+		rec.Bloom = types.CreateBloom(types.Receipts{rec})
+		receipts = append(receipts, rec)
+	}
+	header := &types.Header{Number: big.NewInt(100), Difficulty: common.Big0, BaseFee: big.NewInt(7), Bloom: types.CreateBloom(receipts)}
+	var logs []types.Log
+	index := uint(0)
+	for _, rec := range receipts {
+		for _, log := range rec.Logs {
+			log.BlockHash = header.Hash()
+			log.BlockNumber = 100
+			log.Index = index
+			index += 1
+			logs = append(logs, *log)
+		}
+	}
+	return BlockInputFromHeader(header), receipts, logs
+}
+
+func TestDeriveUserDepositsFromLogs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	block, receipts, logs := testDepositBlock(t, rng)
+	cfg := &Config{}
+
+	expected, err := DeriveUserDeposits(cfg, block.NumberU64(), receipts)
+	require.NoError(t, err)
+
+	src := filterLogsFn(func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+		assert.Equal(t, DepositLogsQuery(cfg, block.Hash()), q)
+		// out of order results are sorted by log index
+		return []types.Log{logs[3], logs[4], logs[5], logs[0], logs[1], logs[2]}, nil
+	})
+	fetched, err := FetchDepositLogs(context.Background(), src, cfg, block.Hash())
+	require.NoError(t, err)
+	for _, trust := range []LogsTrustLevel{LogsTrustFull, LogsTrustBloom, LogsTrustStrict} {
+		deps, err := DeriveUserDepositsFromLogs(cfg, trust, block, fetched)
+		require.NoError(t, err)
+		assert.Equal(t, expected, deps)
+	}
+}
+
+func TestCheckDepositLogs(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	block, _, logs := testDepositBlock(t, rng)
+	cfg := &Config{}
+
+	// a log of another block is not in the bloom
+	_, _, otherLogs := testDepositBlock(t, rng)
+	foreign := otherLogs[0]
+	foreign.BlockHash = block.Hash()
+	assert.NoError(t, CheckDepositLogs(cfg, LogsTrustFull, block, []types.Log{foreign}))
+	assert.Error(t, CheckDepositLogs(cfg, LogsTrustBloom, block, []types.Log{foreign}))
+
+	wrongBlock := logs[0]
+	wrongBlock.BlockHash = common.Hash{1}
+	assert.Error(t, CheckDepositLogs(cfg, LogsTrustBloom, block, []types.Log{wrongBlock}))
+
+	removed := logs[0]
+	removed.Removed = true
+	assert.Error(t, CheckDepositLogs(cfg, LogsTrustBloom, block, []types.Log{removed}))
+
+	assert.Error(t, CheckDepositLogs(cfg, LogsTrustBloom, block, []types.Log{logs[0], logs[0]}), "duplicate")
+
+	// withheld logs
+	assert.NoError(t, CheckDepositLogs(cfg, LogsTrustBloom, block, nil))
+	err := CheckDepositLogs(cfg, LogsTrustStrict, block, nil)
+	assert.True(t, errors.Is(err, PossiblyMissingLogsErr))
+	empty := BlockInputFromHeader(&types.Header{Number: big.NewInt(100), Difficulty: common.Big0})
+	assert.NoError(t, CheckDepositLogs(cfg, LogsTrustStrict, empty, nil))
+}
+
+func TestMultipleDepositContracts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	legacy := common.Address{0x1e, 0x9a}
+	cfg := &Config{
+		ExtraDepositContractAddrs: []common.Address{legacy, DepositContractAddr, legacy},
+		DepositContractDecoders: map[common.Address]DepositDecoder{
+			// the legacy contract has no mint
+			legacy: func(blockNum uint64, txIndex uint64, ev *types.Log) (*types.DepositTx, error) {
+				dep, err := UnmarshalDepositLog(blockNum, txIndex, ev)
+				if err != nil {
+					return nil, err
+				}
+				dep.Mint = nil
+				return dep, nil
+			},
+		},
+	}
+	assert.Equal(t, []common.Address{DepositContractAddr, legacy}, cfg.DepositContracts())
+	assert.True(t, cfg.IsDepositContract(legacy))
+	assert.False(t, cfg.IsDepositContract(common.Address{0x42}))
+
+	block, receipts, logs := testDepositBlock(t, rng)
+	// the second log of each receipt is emitted by the legacy contract
+	for _, rec := range receipts {
+		rec.Logs[1].Address = legacy
+		rec.Bloom = types.CreateBloom(types.Receipts{rec})
+	}
+	header := &types.Header{Number: big.NewInt(100), Difficulty: common.Big0, BaseFee: big.NewInt(7), Bloom: types.CreateBloom(receipts)}
+	block = BlockInputFromHeader(header)
+	for i := range logs {
+		logs[i].BlockHash = header.Hash()
+		if i%2 == 1 {
+			logs[i].Address = legacy
+		}
+	}
+
+	deps, err := DeriveUserDeposits(cfg, 100, receipts)
+	require.NoError(t, err)
+	require.Len(t, deps, 6)
+	for i, dep := range deps {
+		// deposits of both contracts are interleaved in log order
+		expected, err := UnmarshalDepositLog(100, uint64(i+1), &logs[i])
+		require.NoError(t, err)
+		if i%2 == 1 {
+			expected.Mint = nil
+		}
+		assert.Equal(t, expected, dep, "deposit %d", i)
+	}
+
+	fromLogs, err := DeriveUserDepositsFromLogs(cfg, LogsTrustStrict, block, logs)
+	require.NoError(t, err)
+	assert.Equal(t, deps, fromLogs)
+
+	// the extra deposit contract is not a deposit contract without configuration
+	_, err = DeriveUserDepositsFromLogs(&Config{}, LogsTrustBloom, block, logs)
+	assert.Error(t, err)
+	onlyPrimary, err := DeriveUserDeposits(&Config{}, 100, receipts)
+	require.NoError(t, err)
+	assert.Len(t, onlyPrimary, 3)
+
+	// withheld logs of only the legacy contract are detected
+	legacyOnly := BlockInputFromHeader(&types.Header{Number: big.NewInt(100), Difficulty: common.Big0,
+		Bloom: types.BytesToBloom(types.LogsBloom([]*types.Log{{Address: legacy, Topics: []common.Hash{DepositEventABIHash}}}))})
+	err = CheckDepositLogs(cfg, LogsTrustStrict, legacyOnly, nil)
+	assert.True(t, errors.Is(err, PossiblyMissingLogsErr))
+}
+
+func TestLogsTrustLevel(t *testing.T) {
+	var zero LogsTrustLevel
+	assert.Equal(t, LogsTrustStrict, zero, "the zero value is the strictest level")
+	for _, trust := range []LogsTrustLevel{LogsTrustStrict, LogsTrustBloom, LogsTrustFull} {
+		parsed, err := ParseLogsTrustLevel(trust.String())
+		require.NoError(t, err)
+		assert.Equal(t, trust, parsed)
+	}
+	_, err := ParseLogsTrustLevel("none")
+	assert.Error(t, err)
+}
+
+func TestL1LogsQuery(t *testing.T) {
+	cfg := &Config{}
+	blockHash := common.Hash{1}
+	assert.Equal(t, DepositLogsQuery(cfg, blockHash), L1LogsQuery(cfg, blockHash), "only deposits without SystemConfig contract")
+
+	cfg.SystemConfigAddr = testSystemConfigAddr
+	q := L1LogsQuery(cfg, blockHash)
+	assert.Equal(t, []common.Address{DepositContractAddr, testSystemConfigAddr}, q.Addresses)
+	assert.Equal(t, [][]common.Hash{{DepositEventABIHash, ConfigUpdateEventABIHash}}, q.Topics)
+	assert.Equal(t, blockHash, *q.BlockHash)
+}
@@ -0,0 +1,86 @@
+package l2
+
+import (
+	"encoding/binary"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Deposit source domains, to separate the source-hashes of the different kinds of deposits.
+const (
+	UserDepositSourceDomain    = 0
+	L1InfoDepositSourceDomain  = 1
+	UpgradeDepositSourceDomain = 2
+)
+
+// UserDepositSource identifies a user deposit by the L1 log that emitted it.
+type UserDepositSource struct {
+	L1BlockHash common.Hash
+	LogIndex    uint64
+}
+
+// SourceHash computes the source-hash of the user deposit:
+// keccak256(bytes32(uint256(0)), keccak256(l1BlockHash, bytes32(uint256(logIndex))))
+func (dep *UserDepositSource) SourceHash() common.Hash {
+	return depositSourceHash(UserDepositSourceDomain, dep.L1BlockHash, dep.LogIndex)
+}
+
+// L1InfoDepositSource identifies a L1 info deposit by the L1 block and the L2 sequence number in the epoch.
+type L1InfoDepositSource struct {
+	L1BlockHash common.Hash
+	SeqNumber   uint64
+}
+
+// SourceHash computes the source-hash of the L1 info deposit:
+// keccak256(bytes32(uint256(1)), keccak256(l1BlockHash, bytes32(uint256(seqNumber))))
+func (dep *L1InfoDepositSource) SourceHash() common.Hash {
+	return depositSourceHash(L1InfoDepositSourceDomain, dep.L1BlockHash, dep.SeqNumber)
+}
+
+// UpgradeDepositSource identifies a deposit that upgrades the L2 system, e.g. a predeploy, by the intent of the upgrade.
+// The intent must be unique for every upgrade.
+type UpgradeDepositSource struct {
+	Intent string
+}
+
+// SourceHash computes the source-hash of the upgrade deposit:
+// keccak256(bytes32(uint256(2)), keccak256(intent))
+func (dep *UpgradeDepositSource) SourceHash() common.Hash {
+	intentHash := crypto.Keccak256Hash([]byte(dep.Intent))
+	var domainInput [32 * 2]byte
+	binary.BigEndian.PutUint64(domainInput[32-8:32], UpgradeDepositSourceDomain)
+	copy(domainInput[32:], intentHash[:])
+	return crypto.Keccak256Hash(domainInput[:])
+}
+
+// DeriveDepositSources derives the source-hashes of the deposits of a L2 block with the given L1 origin and sequence number,
+// in the order of the deposits: the L1 info deposit, followed by the user deposits in the receipts, as derived by DeriveUserDeposits.
+// Only the first L2 block of the epoch (sequence number 0) includes user deposits, the receipts are ignored otherwise.
+// The source-hashes are not part of the derived deposits yet: the DepositTx of the geth dependency has no SourceHash field,
+// and still relies on BlockHeight and TransactionIndex for uniqueness.
+func DeriveDepositSources(cfg *Config, l1BlockHash common.Hash, seqNumber uint64, receipts []*types.Receipt) []common.Hash {
+	out := []common.Hash{(&L1InfoDepositSource{L1BlockHash: l1BlockHash, SeqNumber: seqNumber}).SourceHash()}
+	if seqNumber != 0 {
+		return out
+	}
+	for _, log := range depositLogs(cfg, receipts) {
+		out = append(out, (&UserDepositSource{L1BlockHash: l1BlockHash, LogIndex: uint64(log.Index)}).SourceHash())
+	}
+	return out
+}
+
+// depositSourceHash is unique per deposit: the L1 block hash changes with reorgs,
+// unlike the block height that is used for the tx-hash uniqueness of deposits otherwise.
+func depositSourceHash(domain uint64, l1BlockHash common.Hash, index uint64) common.Hash {
+	var input [32 * 2]byte
+	copy(input[:32], l1BlockHash[:])
+	binary.BigEndian.PutUint64(input[32*2-8:], index)
+	depositIDHash := crypto.Keccak256Hash(input[:])
+
+	var domainInput [32 * 2]byte
+	binary.BigEndian.PutUint64(domainInput[32-8:32], domain)
+	copy(domainInput[32:], depositIDHash[:])
+	return crypto.Keccak256Hash(domainInput[:])
+}
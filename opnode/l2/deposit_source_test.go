@@ -0,0 +1,68 @@
+package l2
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func TestDepositSourceHash(t *testing.T) {
+	blockA := common.Hash{0xa}
+	blockB := common.Hash{0xb}
+
+	user := &UserDepositSource{L1BlockHash: blockA, LogIndex: 3}
+	info := &L1InfoDepositSource{L1BlockHash: blockA, SeqNumber: 3}
+
+	// domain-separated: the same block hash and index result in a different source-hash
+	assert.NotEqual(t, user.SourceHash(), info.SourceHash())
+	// unique per log index and L1 block
+	assert.NotEqual(t, user.SourceHash(), (&UserDepositSource{L1BlockHash: blockA, LogIndex: 4}).SourceHash())
+	assert.NotEqual(t, user.SourceHash(), (&UserDepositSource{L1BlockHash: blockB, LogIndex: 3}).SourceHash())
+	assert.NotEqual(t, info.SourceHash(), (&L1InfoDepositSource{L1BlockHash: blockB, SeqNumber: 3}).SourceHash())
+
+	// matches the abi-encoded solidity equivalent
+	inner := crypto.Keccak256(blockA[:], common.BigToHash(common.Big3).Bytes())
+	expected := crypto.Keccak256Hash(common.BigToHash(common.Big1).Bytes(), inner)
+	assert.Equal(t, expected, info.SourceHash())
+}
+
+func TestUpgradeDepositSourceHash(t *testing.T) {
+	upgrade := &UpgradeDepositSource{Intent: "upgrade L1 info predeploy"}
+	expected := crypto.Keccak256Hash(common.BigToHash(common.Big2).Bytes(), crypto.Keccak256([]byte(upgrade.Intent)))
+	assert.Equal(t, expected, upgrade.SourceHash())
+	assert.NotEqual(t, upgrade.SourceHash(), (&UpgradeDepositSource{Intent: "another upgrade"}).SourceHash())
+}
+
+func TestDeriveDepositSources(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	blockHash := common.Hash{0xa}
+	depositLog := func(index uint) *types.Log {
+		log := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, 1, rng))
+		log.Index = index
+		return log
+	}
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{depositLog(0), testutil.GenerateLog(testutil.GenerateAddress(rng), nil, nil), depositLog(2)}},
+		{Status: types.ReceiptStatusFailed, Logs: []*types.Log{depositLog(3)}},
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{depositLog(4)}},
+	}
+	sources := DeriveDepositSources(&Config{}, blockHash, 0, receipts)
+	deps, err := DeriveUserDeposits(&Config{}, 100, receipts)
+	require.NoError(t, err)
+	require.Len(t, sources, 1+len(deps), "a source-hash for the L1 info deposit and every user deposit")
+	assert.Equal(t, (&L1InfoDepositSource{L1BlockHash: blockHash, SeqNumber: 0}).SourceHash(), sources[0])
+	for i, logIndex := range []uint64{0, 2, 4} {
+		assert.Equal(t, (&UserDepositSource{L1BlockHash: blockHash, LogIndex: logIndex}).SourceHash(), sources[1+i])
+	}
+
+	// later blocks of the epoch only have the L1 info deposit
+	sources = DeriveDepositSources(&Config{}, blockHash, 1, receipts)
+	assert.Equal(t, []common.Hash{(&L1InfoDepositSource{L1BlockHash: blockHash, SeqNumber: 1}).SourceHash()}, sources)
+}
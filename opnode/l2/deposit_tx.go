@@ -0,0 +1,72 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// NotDepositErr is returned by DecodeDeposit for transactions that are not deposits.
+var NotDepositErr = errors.New("not a deposit transaction")
+
+// depositRLP is the RLP layout of a deposit transaction, after the DepositTxType byte.
+// The fields are encoded in the order they are declared here.
+type depositRLP struct {
+	BlockHeight      uint64
+	TransactionIndex uint64
+	From             common.Address
+	To               *common.Address `rlp:"nil"`
+	Mint             *big.Int        `rlp:"nil"`
+	Value            *big.Int
+	Gas              uint64
+	Data             []byte
+}
+
+// EncodeDeposit encodes a derived deposit as typed L2 transaction: the DepositTxType byte followed by the RLP of the deposit.
+// The encoding is defined here, not by the go-ethereum fork that is linked, so verification tooling can decode it the same way.
+func EncodeDeposit(dep *types.DepositTx) (Data, error) {
+	value := dep.Value
+	if value == nil {
+		value = new(big.Int)
+	}
+	data, err := rlp.EncodeToBytes(&depositRLP{
+		BlockHeight:      dep.BlockHeight,
+		TransactionIndex: dep.TransactionIndex,
+		From:             dep.From,
+		To:               dep.To,
+		Mint:             dep.Mint,
+		Value:            value,
+		Gas:              dep.Gas,
+		Data:             dep.Data,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode deposit: %v", err)
+	}
+	return append([]byte{types.DepositTxType}, data...), nil
+}
+
+// DecodeDeposit decodes a deposit transaction encoded with EncodeDeposit,
+// or returns an error wrapping NotDepositErr if the transaction is of another type.
+func DecodeDeposit(data []byte) (*types.DepositTx, error) {
+	if len(data) == 0 || data[0] != types.DepositTxType {
+		return nil, NotDepositErr
+	}
+	var dep depositRLP
+	if err := rlp.DecodeBytes(data[1:], &dep); err != nil {
+		return nil, fmt.Errorf("invalid deposit: %v", err)
+	}
+	return &types.DepositTx{
+		BlockHeight:      dep.BlockHeight,
+		TransactionIndex: dep.TransactionIndex,
+		From:             dep.From,
+		To:               dep.To,
+		Mint:             dep.Mint,
+		Value:            dep.Value,
+		Gas:              dep.Gas,
+		Data:             dep.Data,
+	}, nil
+}
@@ -0,0 +1,43 @@
+package l2
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func TestDepositEncoding(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	for i := 0; i < 100; i++ {
+		dep := testutil.GenerateDeposit(uint64(i), uint64(i), rng)
+		data, err := EncodeDeposit(dep)
+		require.NoError(t, err)
+
+		// the linked go-ethereum encodes and decodes deposits the same way
+		expected, err := types.NewTx(dep).MarshalBinary()
+		require.NoError(t, err)
+		require.Equal(t, expected, []byte(data))
+		var tx types.Transaction
+		require.NoError(t, tx.UnmarshalBinary(data))
+		require.Equal(t, uint8(types.DepositTxType), tx.Type())
+
+		decoded, err := DecodeDeposit(data)
+		require.NoError(t, err)
+		require.Equal(t, types.NewTx(dep).Hash(), types.NewTx(decoded).Hash())
+	}
+
+	userTx, err := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(901)}).MarshalBinary()
+	require.NoError(t, err)
+	_, err = DecodeDeposit(userTx)
+	assert.ErrorIs(t, err, NotDepositErr)
+	_, err = DecodeDeposit(nil)
+	assert.ErrorIs(t, err, NotDepositErr)
+	_, err = DecodeDeposit([]byte{types.DepositTxType, 0x01})
+	assert.Error(t, err)
+}
@@ -0,0 +1,59 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var UnknownDepositVersionErr = errors.New("unknown deposit event version")
+
+// DepositEventVersion0 is the original, unversioned, TransactionDeposited event encoding.
+const DepositEventVersion0 = 0
+
+// DepositDecoder decodes a deposit event log of a specific version into typed deposit data.
+type DepositDecoder func(blockNum uint64, txIndex uint64, ev *types.Log) (*types.DepositTx, error)
+
+// DepositDecoders maps each supported deposit event version to its decoder.
+var DepositDecoders = map[uint64]DepositDecoder{
+	DepositEventVersion0: UnmarshalLogEvent,
+}
+
+// DepositEventVersion determines the encoding version of a deposit event log.
+//
+// Versioned deposit events carry the version as an additional indexed topic, after the indexed from and to topics.
+// Logs without version topic are DepositEventVersion0.
+// The version topic is reserved for later versions: an explicit version 0 is invalid.
+func DepositEventVersion(ev *types.Log) (uint64, error) {
+	switch len(ev.Topics) {
+	case 3:
+		return DepositEventVersion0, nil
+	case 4:
+		topic := ev.Topics[3]
+		if !isZero(topic[:24]) {
+			return 0, fmt.Errorf("deposit event version too large: %s: %w", topic, UnknownDepositVersionErr)
+		}
+		version := topic.Big().Uint64()
+		if version == DepositEventVersion0 {
+			return 0, errors.New("deposit event version 0 must not have a version topic")
+		}
+		return version, nil
+	default:
+		return 0, fmt.Errorf("unexpected number of deposit event topics: %d", len(ev.Topics))
+	}
+}
+
+// UnmarshalDepositLog decodes a deposit event log with the decoder of its version.
+// An error wrapping UnknownDepositVersionErr is returned if the version is not supported.
+func UnmarshalDepositLog(blockNum uint64, txIndex uint64, ev *types.Log) (*types.DepositTx, error) {
+	version, err := DepositEventVersion(ev)
+	if err != nil {
+		return nil, err
+	}
+	decode, ok := DepositDecoders[version]
+	if !ok {
+		return nil, fmt.Errorf("deposit event version %d: %w", version, UnknownDepositVersionErr)
+	}
+	return decode(blockNum, txIndex, ev)
+}
@@ -0,0 +1,53 @@
+package l2
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func TestUnmarshalDepositLog(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	depInput := testutil.GenerateDeposit(100, 1, rng)
+
+	log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+	version, err := DepositEventVersion(log)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(DepositEventVersion0), version)
+	depOutput, err := UnmarshalDepositLog(100, 1, log)
+	require.NoError(t, err)
+	assert.Equal(t, depInput, depOutput)
+
+	t.Run("unknown version", func(t *testing.T) {
+		log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		log.Topics = append(log.Topics, common.BigToHash(common.Big2))
+		version, err := DepositEventVersion(log)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(2), version)
+		_, err = UnmarshalDepositLog(100, 1, log)
+		assert.True(t, errors.Is(err, UnknownDepositVersionErr))
+
+		// the typed error is preserved through the derivation
+		_, err = DeriveUserDeposits(&Config{}, 100, []*types.Receipt{{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{log}}})
+		assert.True(t, errors.Is(err, UnknownDepositVersionErr))
+	})
+	t.Run("explicit version 0", func(t *testing.T) {
+		log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		log.Topics = append(log.Topics, common.Hash{})
+		_, err := UnmarshalDepositLog(100, 1, log)
+		assert.Error(t, err)
+	})
+	t.Run("oversized version", func(t *testing.T) {
+		log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		log.Topics = append(log.Topics, common.Hash{0: 1})
+		_, err := UnmarshalDepositLog(100, 1, log)
+		assert.True(t, errors.Is(err, UnknownDepositVersionErr))
+	})
+}
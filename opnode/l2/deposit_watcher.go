@@ -0,0 +1,158 @@
+package l2
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// prefetchTimeout limits the duration of pre-warming the download of a L1 block with new deposits
+const prefetchTimeout = time.Second * 20
+
+// PendingDeposit is a deposit of a L1 block that was not derived yet.
+type PendingDeposit struct {
+	L1       eth.BlockID
+	TxHash   common.Hash
+	LogIndex uint
+	// SourceHash uniquely identifies the deposit, also across L1 reorgs
+	SourceHash common.Hash
+	// Deposit is decoded from the log. The transaction index is preliminary:
+	// it assumes the deposit logs of the L1 block were all seen in order.
+	Deposit *types.DepositTx
+}
+
+// PendingDepositFn is called for every new pending deposit
+type PendingDepositFn func(dep PendingDeposit)
+
+// DepositWatcher subscribes to the deposit logs of new L1 blocks, to learn about new deposits immediately:
+// the download of the L1 block is pre-warmed before the block is derived,
+// and the deposits can be viewed as pending until the block is derived.
+//
+// The pending deposits are a best-effort view: derivation does not depend on them.
+type DepositWatcher struct {
+	Config *Config
+	Logs   eth.LogSubscriber
+	// Prefetch is optional, to start downloading the L1 blocks with new deposits before they are derived
+	Prefetch Downloader
+	// OnDeposit is optional, to observe new pending deposits
+	OnDeposit PendingDepositFn
+	// OnDecodeErr is optional, to surface deposit logs that cannot be decoded
+	OnDecodeErr func(log types.Log, err error)
+
+	mu sync.Mutex
+	// L1 block hash -> pending deposits of the block, in order of arrival
+	pending map[common.Hash][]PendingDeposit
+}
+
+// Watch subscribes to the deposit logs, until the subscription is closed or fails.
+func (w *DepositWatcher) Watch(ctx context.Context) (ethereum.Subscription, error) {
+	logs := make(chan types.Log, 100)
+	sub, err := w.Logs.SubscribeFilterLogs(ctx, DepositLogsFilter(w.Config), logs)
+	if err != nil {
+		return nil, err
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Unsubscribe()
+		for {
+			select {
+			case log := <-logs:
+				w.onLog(ctx, log)
+			case err := <-sub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	}), nil
+}
+
+func (w *DepositWatcher) onLog(ctx context.Context, log types.Log) {
+	if log.Removed {
+		w.removeBlock(log.BlockHash)
+		return
+	}
+	id := eth.BlockID{Hash: log.BlockHash, Number: log.BlockNumber}
+
+	w.mu.Lock()
+	if w.pending == nil {
+		w.pending = make(map[common.Hash][]PendingDeposit)
+	}
+	deps := w.pending[id.Hash]
+	for _, d := range deps {
+		if d.LogIndex == log.Index {
+			w.mu.Unlock()
+			return // duplicate
+		}
+	}
+	txIndex, err := UserDepositIndex(w.Config, uint64(len(deps)))
+	var dep *types.DepositTx
+	if err == nil {
+		dep, err = w.Config.DepositDecoder(log.Address)(log.BlockNumber, txIndex, &log)
+	}
+	if err != nil {
+		w.mu.Unlock()
+		if w.OnDecodeErr != nil {
+			w.OnDecodeErr(log, err)
+		}
+		return
+	}
+	source := UserDepositSource{L1BlockHash: id.Hash, LogIndex: uint64(log.Index)}
+	pd := PendingDeposit{L1: id, TxHash: log.TxHash, LogIndex: log.Index, SourceHash: source.SourceHash(), Deposit: dep}
+	w.pending[id.Hash] = append(deps, pd)
+	w.mu.Unlock()
+
+	// the first deposit of the block starts the download of the block
+	if len(deps) == 0 && w.Prefetch != nil {
+		go func() {
+			ctx, cancel := context.WithTimeout(ctx, prefetchTimeout)
+			defer cancel()
+			_, _, _ = w.Prefetch.Fetch(ctx, id)
+		}()
+	}
+	if w.OnDeposit != nil {
+		w.OnDeposit(pd)
+	}
+}
+
+func (w *DepositWatcher) removeBlock(hash common.Hash) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.pending, hash)
+}
+
+// Processed removes the pending deposits of the L1 blocks up to and including the given derived L1 block.
+// Deposits of blocks that were reorged out without a removal log are removed this way too.
+func (w *DepositWatcher) Processed(l1 eth.BlockID) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for h, deps := range w.pending {
+		if len(deps) > 0 && deps[0].L1.Number <= l1.Number {
+			delete(w.pending, h)
+		}
+	}
+}
+
+// PendingDeposits returns the pending deposits, ordered by L1 block number and log index.
+func (w *DepositWatcher) PendingDeposits() []PendingDeposit {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	var out []PendingDeposit
+	for _, deps := range w.pending {
+		out = append(out, deps...)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].L1.Number != out[j].L1.Number {
+			return out[i].L1.Number < out[j].L1.Number
+		}
+		return out[i].LogIndex < out[j].LogIndex
+	})
+	return out
+}
@@ -0,0 +1,93 @@
+package l2
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+type feedLogSubscriber struct {
+	feed event.Feed
+	q    ethereum.FilterQuery
+}
+
+func (f *feedLogSubscriber) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	f.q = q
+	return f.feed.Subscribe(ch), nil
+}
+
+type prefetchDownloader chan eth.BlockID
+
+func (p prefetchDownloader) Fetch(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+	p <- id
+	return nil, nil, ethereum.NotFound
+}
+
+func TestDepositWatcher(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	cfg := &Config{}
+	logs := &feedLogSubscriber{}
+	prefetched := make(prefetchDownloader, 10)
+	seen := make(chan PendingDeposit, 10)
+	w := &DepositWatcher{Config: cfg, Logs: logs, Prefetch: prefetched, OnDeposit: func(dep PendingDeposit) {
+		seen <- dep
+	}}
+	sub, err := w.Watch(context.Background())
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	assert.Equal(t, DepositLogsFilter(cfg), logs.q)
+
+	blockA := eth.BlockID{Hash: common.Hash{0xa}, Number: 10}
+	blockB := eth.BlockID{Hash: common.Hash{0xb}, Number: 11}
+	depositLog := func(id eth.BlockID, index uint) types.Log {
+		log := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(id.Number, 1, rng))
+		log.BlockHash, log.BlockNumber, log.Index = id.Hash, id.Number, index
+		return *log
+	}
+	send := func(log types.Log) {
+		logs.feed.Send(log)
+		select {
+		case <-seen:
+		case <-time.After(time.Second * 5):
+			t.Fatal("timed out waiting for deposit")
+		}
+	}
+	send(depositLog(blockB, 3))
+	send(depositLog(blockA, 5))
+	send(depositLog(blockA, 7))
+	assert.ElementsMatch(t, []eth.BlockID{blockA, blockB}, []eth.BlockID{<-prefetched, <-prefetched})
+	assert.Empty(t, prefetched, "a block is prefetched once")
+
+	pending := w.PendingDeposits()
+	require.Len(t, pending, 3)
+	assert.Equal(t, blockA, pending[0].L1)
+	assert.Equal(t, uint(5), pending[0].LogIndex)
+	assert.Equal(t, uint64(1), pending[0].Deposit.TransactionIndex, "first user deposit after the L1 info deposit")
+	assert.Equal(t, uint(7), pending[1].LogIndex)
+	assert.Equal(t, uint64(2), pending[1].Deposit.TransactionIndex)
+	assert.Equal(t, blockB, pending[2].L1)
+
+	w.Processed(blockA)
+	pending = w.PendingDeposits()
+	require.Len(t, pending, 1)
+	assert.Equal(t, blockB, pending[0].L1)
+
+	// the deposit of block B is reorged out
+	removed := depositLog(blockB, 3)
+	removed.Removed = true
+	logs.feed.Send(removed)
+	require.Eventually(t, func() bool {
+		return len(w.PendingDeposits()) == 0
+	}, time.Second*5, time.Millisecond*10)
+}
@@ -2,12 +2,18 @@ package l2
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"math/big"
 	"sync"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/event"
 
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/events"
 
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -21,23 +27,58 @@ type Driver interface {
 	// If the engine is fully synced, then the last derived L1 block, and parent L2 block, is repeated.
 	// An error is returned if the sync starting point could not be determined (due to timeouts, wrong-chain, etc.)
 	findSyncStart(ctx context.Context) (nextRefL1 eth.BlockID, refL2 eth.BlockID, err error)
-	// driverStep explicitly calls the engine to derive a L1 block into a L2 block, and apply it on top of the given L2 block.
+	// driverStep explicitly calls the engine to derive the next L2 block from L1, and apply it on top of the given L2 block.
+	// The next L2 block is derived from the next L1 block, or, with a L2 block time, may continue the epoch of the given L2 block.
 	// The finalized L2 block is provided to update the engine with finality, but does not affect the derivation step itself.
-	// The resulting L2 block ID is returned, or an error if the derivation fails.
-	driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l2ID eth.BlockID, err error)
+	// The L1 origin and ID of the resulting L2 block are returned, or an error if the derivation fails.
+	driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l1Origin eth.BlockID, l2ID eth.BlockID, err error)
 }
 
 type EngineDriver struct {
 	Log log.Logger
+	// Rollup configuration, to derive L2 blocks with
+	Config Config
 	// API bindings to execution engine
-	RPC     DriverAPI
+	RPC DriverAPI
+	// L1 chain to derive from, traversed by number
+	L1      eth.HeaderByNumberSource
 	DL      Downloader
 	SyncRef SyncReference
+	// L1Logs is optional, to derive from the deposit and ConfigUpdate logs of L1 blocks instead of all their receipts
+	L1Logs *L1LogsFetcher
+	// DataSource is optional, to retrieve the batch submission data of L1 blocks from instead of the calldata
+	DataSource DataSource
+	// Metrics is optional, to monitor the derivation
+	Metrics Metrics
+	// Events is optional, to publish the derivation events to
+	Events *events.Bus
+	// Sequencer is optional, to sequence new L2 blocks instead of deriving them from L1
+	Sequencer *Sequencer
+	// EngineSync is optional, to pause derivation while the engine is syncing. The RPC should be the same EngineSync.
+	EngineSync *EngineSync
+	// PipelineStore is optional, to persist the derivation pipeline after every derived L2 block,
+	// and to resume from it after a restart instead of replaying L1 blocks
+	PipelineStore PipelineStore
 
 	// The current driving force, to shutdown before closing the engine.
 	driveSub ethereum.Subscription
 	// There may only be 1 driver at a time
 	driveLock sync.Mutex
+	// Pause (true) and resume (false) requests to the driving force
+	pauseReq chan bool
+	// Feed of the ReorgEvent of every driver step that replaces previously derived L2 blocks
+	reorgFeed event.Feed
+	// Serializes the derivation steps with the import of unsafe payloads, both extend the L2 head of the engine
+	stepLock sync.Mutex
+	// Locks the sequencer start and stop requests
+	seqLock sync.Mutex
+	// Sequencing was stopped with StopSequencer, to hand over sequencing
+	seqStopped bool
+
+	// Derives the block inputs of the L2 blocks, created by the first driver step
+	pipeline *DerivationPipeline
+	// The L2 block built from the last block inputs of the pipeline, zero if the pipeline must be reset
+	pipelineL2 eth.BlockID
 
 	EngineDriverState
 }
@@ -49,10 +90,141 @@ func (e *EngineDriver) Drive(ctx context.Context, l1Heads <-chan eth.HeadSignal)
 		return e.driveSub
 	}
 
-	e.driveSub = event.NewSubscription(NewDriverLoop(ctx, &e.EngineDriverState, e.Log, l1Heads, e))
+	e.pauseReq = make(chan bool)
+	if e.Sequencer != nil {
+		e.driveSub = event.NewSubscription(NewSequencerLoop(ctx, &e.EngineDriverState, e.Log, l1Heads, e.pauseReq, e.Sequencer, e))
+	} else {
+		e.driveSub = event.NewSubscription(NewDriverLoop(ctx, &e.EngineDriverState, e.Log, l1Heads, e.pauseReq, e))
+	}
 	return e.driveSub
 }
 
+// Pause stops the driver from deriving new L2 blocks, while it keeps consuming L1 head signals.
+// The latest L1 head received while paused becomes the sync target, to derive towards after Resume.
+// Pause returns once any ongoing derivation step completes, or with an error if the ctx is done first.
+func (e *EngineDriver) Pause(ctx context.Context) error {
+	return e.requestPause(ctx, true)
+}
+
+// Resume continues derivation after Pause, starting from the current L2 head of the engine.
+func (e *EngineDriver) Resume(ctx context.Context) error {
+	return e.requestPause(ctx, false)
+}
+
+func (e *EngineDriver) requestPause(ctx context.Context, pause bool) error {
+	e.driveLock.Lock()
+	pauseReq := e.pauseReq
+	e.driveLock.Unlock()
+	if pauseReq == nil {
+		return errors.New("engine driver is not driving")
+	}
+	select {
+	case pauseReq <- pause:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// StopSequencer stops sequencing new L2 blocks, and returns the unsafe L2 head to hand over sequencing at:
+// the sequencer that takes over starts sequencing on top of it. Unsafe payloads can be imported while stopped.
+// StopSequencer returns once any ongoing sequencing step completes, or with an error if the ctx is done first.
+func (e *EngineDriver) StopSequencer(ctx context.Context) (common.Hash, error) {
+	if e.Sequencer == nil {
+		return common.Hash{}, errors.New("engine driver is not sequencing")
+	}
+	e.seqLock.Lock()
+	defer e.seqLock.Unlock()
+	if e.seqStopped {
+		return common.Hash{}, errors.New("sequencer already stopped")
+	}
+	if err := e.Pause(ctx); err != nil {
+		return common.Hash{}, err
+	}
+	e.seqStopped = true
+	head := e.L2Head()
+	e.Log.Info("Stopped sequencer", "l2_head", head)
+	return head.Hash, nil
+}
+
+// StartSequencer starts sequencing new L2 blocks after StopSequencer, on top of the unsafe L2 head.
+// The unsafe L2 head must be the handover point of the previous sequencer, to not fork the unsafe L2 chain.
+func (e *EngineDriver) StartSequencer(ctx context.Context, unsafeHead common.Hash) error {
+	if e.Sequencer == nil {
+		return errors.New("engine driver is not sequencing")
+	}
+	e.seqLock.Lock()
+	defer e.seqLock.Unlock()
+	if !e.seqStopped {
+		return errors.New("sequencer already started")
+	}
+	// no unsafe payloads may be imported past the handover point while starting
+	e.stepLock.Lock()
+	defer e.stepLock.Unlock()
+	if head := e.L2Head(); head.Hash != unsafeHead {
+		return fmt.Errorf("unsafe L2 head %s does not match handover point %s", head, unsafeHead)
+	}
+	if err := e.Resume(ctx); err != nil {
+		return err
+	}
+	e.seqStopped = false
+	e.Log.Info("Started sequencer", "l2_head", unsafeHead)
+	return nil
+}
+
+// Sequencing returns true if the driver is sequencing, and not stopped
+func (e *EngineDriver) Sequencing() bool {
+	e.seqLock.Lock()
+	defer e.seqLock.Unlock()
+	return e.Sequencer != nil && !e.seqStopped
+}
+
+// SyncStartup anchors the driver state on the L2 head of the engine, or, if the L1 origin of the L2 head is not canonical anymore,
+// on the latest L2 block that was built on the canonical L1 chain. Derivation then resumes from the anchor,
+// which replaces the non-canonical L2 blocks. The anchor is the unsafe L2 head only, when sequencing.
+func (e *EngineDriver) SyncStartup(ctx context.Context) error {
+	_, engineL2, err := e.requestEngineHead(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch engine head: %w", err)
+	}
+	refL1, refL2, err := FindL2Anchor(ctx, e.SyncRef, &e.Genesis)
+	if err != nil {
+		return err
+	}
+	if engineL2 != refL2 {
+		e.Log.Warn("L1 origin of L2 head is not canonical, resuming from common ancestor", "l2_head", engineL2, "l2_anchor", refL2, "l1_origin", refL1)
+	} else {
+		e.Log.Info("Found L2 anchor to resume from", "l2_anchor", refL2, "l1_origin", refL1)
+	}
+	if e.Sequencer != nil {
+		e.UpdateUnsafeHead(refL1, refL2)
+	} else {
+		e.UpdateHead(refL1, refL2)
+	}
+	return nil
+}
+
+// RestoreSafeHeads restores the persisted safe and finalized L2 heads after SyncStartup,
+// if they are still canonical in the engine. Heads that were reorged out, or that are beyond the anchor, are ignored.
+func (e *EngineDriver) RestoreSafeHeads(ctx context.Context, safe eth.BlockID, finalized eth.BlockID) error {
+	safeL1, safeL2, _, err := e.SyncRef.RefByL2Num(ctx, new(big.Int).SetUint64(safe.Number), &e.Genesis)
+	if err != nil {
+		return fmt.Errorf("failed to fetch safe L2 block %s: %w", safe, err)
+	}
+	if safeL2 != safe {
+		return fmt.Errorf("safe L2 block %s is no longer canonical, got %s", safe, safeL2)
+	}
+	_, finalizedL2, _, err := e.SyncRef.RefByL2Num(ctx, new(big.Int).SetUint64(finalized.Number), &e.Genesis)
+	if err != nil {
+		return fmt.Errorf("failed to fetch finalized L2 block %s: %w", finalized, err)
+	}
+	if finalizedL2 != finalized {
+		return fmt.Errorf("finalized L2 block %s is no longer canonical, got %s", finalized, finalizedL2)
+	}
+	e.restoreSafeHeads(safeL1, safe, finalized)
+	return nil
+}
+
 func (e *EngineDriver) requestEngineHead(ctx context.Context) (refL1 eth.BlockID, refL2 eth.BlockID, err error) {
 	refL1, refL2, _, err = e.SyncRef.RefByL2Num(ctx, nil, &e.Genesis)
 	return
@@ -62,8 +234,247 @@ func (e *EngineDriver) findSyncStart(ctx context.Context) (nextRefL1 eth.BlockID
 	return FindSyncStart(ctx, e.SyncRef, &e.Genesis)
 }
 
-func (e *EngineDriver) driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l2ID eth.BlockID, err error) {
-	return DriverStep(ctx, e.Log, e.RPC, e.DL, nextRefL1, refL2, finalized.Hash)
+// SubscribeReorgs subscribes to the ReorgEvent of every driver step that replaces previously derived L2 blocks,
+// after a L1 reorg, so consumers can roll back the state they derived from the invalidated L2 blocks.
+func (e *EngineDriver) SubscribeReorgs(ch chan<- ReorgEvent) ethereum.Subscription {
+	return e.reorgFeed.Subscribe(ch)
+}
+
+func (e *EngineDriver) driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l1Origin eth.BlockID, l2ID eth.BlockID, err error) {
+	e.stepLock.Lock()
+	defer e.stepLock.Unlock()
+	if e.EngineSync != nil && !e.EngineSync.CheckSynced(ctx) {
+		return eth.BlockID{}, eth.BlockID{}, fmt.Errorf("skipping derivation of L1 block %s: %w", nextRefL1, EngineSyncingErr)
+	}
+	// the step replaces the derived L2 blocks after the L2 block it builds on, if it is older than the safe L2 head:
+	// find the invalidated L2 blocks before the engine drops them.
+	var reorg *ReorgEvent
+	if head := e.derivedHead(); head != (eth.BlockID{}) && head.Number >= refL2.Number && head != refL2 {
+		ev, err := FindInvalidatedL2(ctx, e.SyncRef, &e.Genesis, refL2, head)
+		if err != nil {
+			return eth.BlockID{}, eth.BlockID{}, fmt.Errorf("failed to find the L2 blocks invalidated by the L1 reorg: %w", err)
+		}
+		reorg = &ev
+	}
+	attrs, l1Origin, err := e.deriveNext(ctx, nextRefL1, refL2)
+	if err != nil {
+		return eth.BlockID{}, eth.BlockID{}, err
+	}
+	e.Events.Publish(AttributesDerivedEvent{L1: l1Origin, L2Parent: refL2, Attributes: attrs})
+	// the parent was derived from L1 as well, and the new block is safe as soon as it is derived, unless the L1 block is not safe yet
+	heads := L2Heads{Unsafe: refL2, Safe: refL2, Finalized: finalized}
+	safe := e.isL1Safe(l1Origin)
+	if !safe {
+		// keep the safe L2 head, unless the step replaces it after a L1 reorg
+		heads.Safe = e.L2Heads().Safe
+		if heads.Safe == (eth.BlockID{}) {
+			heads.Safe = e.Genesis.L2
+		} else if heads.Safe.Number >= refL2.Number {
+			heads.Safe = refL2
+		}
+	}
+	l2ID, err = DriverStep(ctx, e.Log, e.RPC, e.Events, l1Origin, attrs, heads, safe)
+	if errors.Is(err, InvalidPayloadErr) {
+		unwindInvalid(e.Log, &e.EngineDriverState, e.Events, l1Origin, err)
+	}
+	if err != nil {
+		// the derived block inputs were not built into a L2 block, derive them again with the next step
+		e.pipelineL2 = eth.BlockID{}
+		return eth.BlockID{}, eth.BlockID{}, err
+	}
+	e.pipelineL2 = l2ID
+	// the batch of the next L2 block must build on the L2 block
+	e.pipeline.SetL2Parent(l2ID.Hash)
+	if e.PipelineStore != nil {
+		if err := e.PipelineStore.StorePipeline(e.pipeline.State()); err != nil {
+			e.Log.Error("Failed to persist derivation pipeline", "l2", l2ID, "err", err)
+		}
+	}
+	if reorg != nil {
+		e.reorgFeed.Send(*reorg)
+	}
+	return l1Origin, l2ID, nil
+}
+
+// deriveNext derives the block inputs of the next L2 block with the DerivationPipeline, to build on top of the L2 block,
+// and returns them with the L1 origin of the next L2 block. The pipeline is reset if its last derived block inputs
+// were not built into the L2 block: on the first step, after a failed step, or after a L1 reorg.
+// Without a L2 block time, every L1 block derives a single L2 block, and the L1 origin must be the next L1 block.
+func (e *EngineDriver) deriveNext(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID) (*PayloadAttributes, eth.BlockID, error) {
+	if e.pipeline == nil && e.PipelineStore != nil {
+		e.restorePipeline(refL2)
+	}
+	if e.pipeline == nil || e.pipelineL2 != refL2 {
+		if err := e.resetPipeline(ctx, nextRefL1, refL2); err != nil {
+			return nil, eth.BlockID{}, err
+		}
+		e.pipelineL2 = refL2
+	}
+	attrs, l1Origin, err := e.pipeline.Step(ctx)
+	if err != nil {
+		// the pipeline keeps its state while it waits for the next L1 block, other failures reset it
+		if !errors.Is(err, ethereum.NotFound) {
+			e.pipelineL2 = eth.BlockID{}
+		}
+		return nil, eth.BlockID{}, fmt.Errorf("failed to derive block inputs of the L2 block after %s: %w", refL2, err)
+	}
+	if e.Config.BlockTime == 0 && l1Origin != nextRefL1 {
+		e.pipelineL2 = eth.BlockID{}
+		return nil, eth.BlockID{}, fmt.Errorf("derived L1 block %s, but expected %s: %w", l1Origin, nextRefL1, ReorgErr)
+	}
+	return attrs, l1Origin, nil
+}
+
+// restorePipeline creates the pipeline from the persisted pipeline state, if the state was persisted after building
+// the given L2 block. The pipeline is reset instead if the state is missing, or does not match the L2 block.
+func (e *EngineDriver) restorePipeline(refL2 eth.BlockID) {
+	state, ok, err := e.PipelineStore.LoadPipeline()
+	if err != nil {
+		e.Log.Warn("Failed to load derivation pipeline, resetting it", "err", err)
+		return
+	}
+	if !ok {
+		return
+	}
+	if state.L2Parent != refL2.Hash {
+		e.Log.Info("Persisted derivation pipeline does not build on the L2 block, resetting it", "l2", refL2, "pipeline_l2", state.L2Parent)
+		return
+	}
+	dp, err := RestoreDerivationPipeline(&e.Config, e.L1, e.DL, state)
+	if err != nil {
+		e.Log.Warn("Failed to restore derivation pipeline, resetting it", "err", err)
+		return
+	}
+	dp.Metrics = e.Metrics
+	dp.L1Logs = e.L1Logs
+	e.pipeline = dp
+	e.pipelineL2 = refL2
+	e.Log.Info("Restored derivation pipeline", "l2", refL2, "l1_base", state.L1Base)
+}
+
+// resetPipeline resets the pipeline, or creates it on the first step, to derive the L2 block after the given L2 block.
+// Without a L2 block time, the pipeline is reset onto the L1 parent of the next L1 block.
+// With a L2 block time, the pipeline is reset to the epoch of the L2 block, to derive the rest of the epoch first.
+func (e *EngineDriver) resetPipeline(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID) error {
+	var l1Base eth.BlockID
+	var epoch *EpochState
+	if e.Config.BlockTime == 0 {
+		self, parent, err := e.SyncRef.RefByL1Num(ctx, nextRefL1.Number)
+		if err != nil {
+			return fmt.Errorf("failed to lookup L1 parent of %s: %w", nextRefL1, err)
+		}
+		if self != nextRefL1 {
+			return fmt.Errorf("L1 block %s is no longer canonical, got %s: %w", nextRefL1, self, ReorgErr)
+		}
+		l1Base = parent
+	} else {
+		l2Block, err := e.RPC.BlockByHash(ctx, refL2.Hash)
+		if err != nil {
+			return fmt.Errorf("failed to fetch L2 block %s: %w", refL2, err)
+		}
+		origin, seqNumber, err := ParseBlockEpoch(l2Block, &e.Genesis)
+		if err != nil {
+			return fmt.Errorf("failed to parse epoch of L2 block %s: %w", refL2, err)
+		}
+		header, err := e.L1.HeaderByNumber(ctx, new(big.Int).SetUint64(origin.Number))
+		if err != nil {
+			return fmt.Errorf("failed to fetch L1 origin %s of L2 block %s: %w", origin, refL2, err)
+		}
+		if header.Hash() != origin.Hash {
+			return fmt.Errorf("L1 origin %s of L2 block %s is no longer canonical, got %s: %w", origin, refL2, header.Hash(), ReorgErr)
+		}
+		epoch = &EpochState{Origin: header, SeqNumber: seqNumber, Timestamp: l2Block.Time()}
+		l1Base = origin
+	}
+	if e.pipeline == nil {
+		e.pipeline = NewDerivationPipeline(&e.Config, e.L1, e.DL, l1Base)
+		e.pipeline.Metrics = e.Metrics
+		e.pipeline.L1Logs = e.L1Logs
+		e.pipeline.DataSource = e.DataSource
+	}
+	if epoch != nil {
+		e.pipeline.ResetToEpoch(epoch)
+	} else {
+		e.pipeline.Reset(l1Base)
+	}
+	e.pipeline.SetL2Parent(refL2.Hash)
+	return nil
+}
+
+// ImportUnsafePayload inserts a L2 block that was sequenced elsewhere, e.g. received through gossip,
+// as the new unsafe L2 head, ahead of its confirmation on L1. The payload must build on the current unsafe L2 head.
+// The payload must continue the epoch of its parent, or start the epoch of the next L1 block.
+// The safe L2 head is unchanged: derivation from L1 replaces the unsafe block if the L1 batches disagree with it.
+func (e *EngineDriver) ImportUnsafePayload(ctx context.Context, payload *ExecutionPayload) error {
+	if e.Sequencing() {
+		return errors.New("cannot import unsafe payloads while sequencing")
+	}
+	e.stepLock.Lock()
+	defer e.stepLock.Unlock()
+
+	heads := e.L2Heads()
+	if payload.ParentHash != heads.Unsafe.Hash || uint64(payload.BlockNumber) != heads.Unsafe.Number+1 {
+		return fmt.Errorf("payload %s does not build on unsafe L2 head %s", payload.ID(), heads.Unsafe)
+	}
+	l1Origin, originTime, seqNumber, err := payloadL1Origin(payload)
+	if err != nil {
+		return fmt.Errorf("failed to parse L1 origin of payload %s: %v", payload.ID(), err)
+	}
+	parent, err := e.RPC.BlockByHash(ctx, payload.ParentHash)
+	if err != nil {
+		return fmt.Errorf("failed to fetch parent of payload %s: %v", payload.ID(), err)
+	}
+	parentOrigin, parentSeqNumber, err := ParseBlockEpoch(parent, &e.Genesis)
+	if err != nil {
+		return fmt.Errorf("failed to parse epoch of parent of payload %s: %v", payload.ID(), err)
+	}
+	if err := CheckBlockEpoch(parentOrigin, parentSeqNumber, l1Origin, seqNumber); err != nil {
+		return fmt.Errorf("rejected unsafe payload %s: %w", payload.ID(), err)
+	}
+	if err := CheckBlockTimestamp(&e.Config, parent.Time(), originTime, uint64(payload.Timestamp)); err != nil {
+		return fmt.Errorf("rejected unsafe payload %s: %w", payload.ID(), err)
+	}
+	if err := Execute(ctx, e.RPC, payload); err != nil {
+		return fmt.Errorf("failed to apply unsafe payload: %w", err)
+	}
+	e.Events.Publish(PayloadInsertedEvent{L1: l1Origin, Payload: payload})
+
+	heads.Unsafe = payload.ID()
+	if heads.Safe == (eth.BlockID{}) {
+		heads.Safe = e.Genesis.L2
+	}
+	if err := ForkchoiceUpdate(ctx, e.RPC, heads); err != nil {
+		return fmt.Errorf("failed to persist unsafe payload: %w", err)
+	}
+	e.UpdateUnsafeHead(l1Origin, heads.Unsafe)
+	e.Log.Info("Imported unsafe L2 block", "l2", heads.Unsafe, "l1_origin", l1Origin, "seq_number", seqNumber)
+	e.Events.Publish(ForkchoiceUpdatedEvent{Heads: heads})
+	return nil
+}
+
+// payloadL1Origin parses the L1 origin of a L2 block, its timestamp, and the sequence number of the L2 block within the epoch,
+// from its L1 info deposit, the first transaction of the payload.
+func payloadL1Origin(payload *ExecutionPayload) (eth.BlockID, uint64, uint64, error) {
+	if len(payload.Transactions) == 0 {
+		return eth.BlockID{}, 0, 0, errors.New("missing L1 info deposit")
+	}
+	var tx types.Transaction
+	if err := tx.UnmarshalBinary(payload.Transactions[0]); err != nil {
+		return eth.BlockID{}, 0, 0, fmt.Errorf("failed to decode L1 info deposit: %v", err)
+	}
+	nr, time, _, hash, seqNumber, _, _, _, err := ParseL1InfoDepositTxData(tx.Data())
+	if err != nil {
+		return eth.BlockID{}, 0, 0, err
+	}
+	return eth.BlockID{Hash: hash, Number: nr}, time, seqNumber, nil
+}
+
+// unwindInvalid recovers from a L2 block that the engine rejected as invalid:
+// the unsafe L2 head is unwound to the safe L2 head, to derive again from there, and the incident is published.
+func unwindInvalid(log log.Logger, state *EngineDriverState, bus *events.Bus, l1 eth.BlockID, err error) {
+	unwound, l1Head, safe := state.UnwindToSafe()
+	log.Error("Engine rejected L2 block as invalid, unwinding to safe L2 head", "l1", l1, "unwound", unwound, "safe", safe, "l1_head", l1Head, "err", err)
+	bus.Publish(InvalidPayloadEvent{L1: l1, Unwound: unwound, Safe: safe, L1Head: l1Head, Err: err})
 }
 
 func (e *EngineDriver) Close() {
@@ -17,7 +17,10 @@ const cold = time.Second * 8
 // at least try every minute to sync, even if things are going well
 const max = time.Minute
 
-func NewDriverLoop(ctx context.Context, state StateMachine, log log.Logger, l1Heads <-chan eth.HeadSignal, driver Driver) func(quit <-chan struct{}) error {
+// NewDriverLoop creates the loop that drives the given Driver with the StateMachine.
+// Sending true to pause stops the loop from deriving, until false is sent to resume.
+// L1 heads received while paused only update the sync target, the skipped L1 blocks are derived after resuming.
+func NewDriverLoop(ctx context.Context, state StateMachine, log log.Logger, l1Heads <-chan eth.HeadSignal, pause <-chan bool, driver Driver) func(quit <-chan struct{}) error {
 
 	backoff := cold
 	syncTicker := time.NewTicker(cold)
@@ -44,13 +47,30 @@ func NewDriverLoop(ctx context.Context, state StateMachine, log log.Logger, l1He
 		defer syncTicker.Stop()
 		defer l2HeadPoll.Stop()
 
+		paused := false
 		for {
 			select {
 			case <-ctx.Done():
 				return ctx.Err()
 			case <-quit:
 				return nil
+			case p := <-pause:
+				if p == paused {
+					continue
+				}
+				paused = p
+				if paused {
+					log.Info("Paused derivation")
+				} else {
+					log.Info("Resumed derivation")
+					// catch up with the L1 heads we skipped while paused
+					syncQuickly()
+				}
+				continue
 			case <-l2HeadPoll.C:
+				if paused {
+					continue
+				}
 				ctx, cancel := context.WithTimeout(ctx, time.Second*4)
 				if state.RequestUpdate(ctx, log, driver) {
 					onL2Update()
@@ -58,11 +78,20 @@ func NewDriverLoop(ctx context.Context, state StateMachine, log log.Logger, l1He
 				cancel()
 				continue
 			case l1HeadSig := <-l1Heads:
-				if state.NotifyL1Head(ctx, log, l1HeadSig, driver) {
-					syncQuickly()
+				if paused {
+					state.NotifyL1Target(log, l1HeadSig)
+					continue
 				}
+				// Whether the head was derived right away, or L1 is ahead of the engine (or reorged),
+				// walk the engine towards the new L1 head without waiting for the regular sync interval.
+				// This is cheap when already synced.
+				state.NotifyL1Head(ctx, log, l1HeadSig, driver)
+				syncQuickly()
 				continue
 			case <-syncTicker.C:
+				if paused {
+					continue
+				}
 				// If already synced, or in case of failure, we slow down
 				syncBackoff()
 				if state.RequestSync(ctx, log, driver) {
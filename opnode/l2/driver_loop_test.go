@@ -0,0 +1,99 @@
+package l2
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testlog"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func headSig(parent testID, self testID) eth.HeadSignal {
+	return eth.HeadSignal{Parent: parent.ID(), Self: self.ID()}
+}
+
+func TestDriverLoop_PauseResume(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	driver := new(mockDriver)
+	ctx := context.Background()
+
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "A:0",
+		l2Finalized: "A:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "A:0",
+	})
+
+	l1Heads := make(chan eth.HeadSignal)
+	pause := make(chan bool)
+	sub := event.NewSubscription(NewDriverLoop(ctx, state, log, l1Heads, pause, driver))
+	defer sub.Unsubscribe()
+
+	// simple extension before pausing
+	driver.On("driverStep", mock.Anything, testID("b:1").ID(), testID("A:0").ID(), testID("A:0").ID()).Return(testID("b:1").ID(), testID("B:1").ID(), nil).Once()
+	l1Heads <- headSig("a:0", "b:1")
+
+	pause <- true
+	assert.Equal(t, testID("b:1").ID(), state.L1Head())
+	assert.Equal(t, testID("B:1").ID(), state.L2Head())
+
+	// L1 advances during the pause, the loop must not derive anything
+	l1Heads <- headSig("b:1", "c:2")
+	l1Heads <- headSig("c:2", "d:3")
+	time.Sleep(hot * 3)
+	driver.AssertNumberOfCalls(t, "driverStep", 1)
+	assert.Equal(t, testID("b:1").ID(), state.L1Head())
+
+	// after resuming, sync picks up from the engine head and derives the skipped blocks
+	driver.On("findSyncStart", mock.Anything).Return(testID("c:2").ID(), testID("B:1").ID(), nil).Once()
+	driver.On("driverStep", mock.Anything, testID("c:2").ID(), testID("B:1").ID(), testID("A:0").ID()).Return(testID("c:2").ID(), testID("C:2").ID(), nil).Once()
+	driver.On("findSyncStart", mock.Anything).Return(testID("d:3").ID(), testID("C:2").ID(), nil).Once()
+	driver.On("driverStep", mock.Anything, testID("d:3").ID(), testID("C:2").ID(), testID("A:0").ID()).Return(testID("d:3").ID(), testID("D:3").ID(), nil).Once()
+	pause <- false
+
+	assert.Eventually(t, func() bool {
+		return state.L2Head() == testID("D:3").ID()
+	}, time.Second, hot)
+	assert.Equal(t, testID("d:3").ID(), state.L1Head())
+	driver.AssertExpectations(t)
+}
+
+func TestDriverLoop_CatchUp(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	driver := new(mockDriver)
+	ctx := context.Background()
+
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "A:0",
+		l2Finalized: "A:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "A:0",
+	})
+
+	l1Heads := make(chan eth.HeadSignal)
+	sub := event.NewSubscription(NewDriverLoop(ctx, state, log, l1Heads, make(chan bool), driver))
+	defer sub.Unsubscribe()
+
+	// the new L1 head does not extend the engine L1 head: the missing L1 blocks are walked through right away,
+	// without waiting for the regular sync interval
+	driver.On("findSyncStart", mock.Anything).Return(testID("b:1").ID(), testID("A:0").ID(), nil).Once()
+	driver.On("driverStep", mock.Anything, testID("b:1").ID(), testID("A:0").ID(), testID("A:0").ID()).Return(testID("b:1").ID(), testID("B:1").ID(), nil).Once()
+	driver.On("findSyncStart", mock.Anything).Return(testID("c:2").ID(), testID("B:1").ID(), nil).Once()
+	driver.On("driverStep", mock.Anything, testID("c:2").ID(), testID("B:1").ID(), testID("A:0").ID()).Return(testID("c:2").ID(), testID("C:2").ID(), nil).Once()
+	l1Heads <- headSig("b:1", "c:2")
+
+	assert.Eventually(t, func() bool {
+		return state.L2Head() == testID("C:2").ID()
+	}, cold/2, hot)
+	assert.Equal(t, testID("c:2").ID(), state.L1Head())
+	driver.AssertExpectations(t)
+}
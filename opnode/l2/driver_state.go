@@ -2,6 +2,7 @@ package l2
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
@@ -20,6 +21,8 @@ type StateMachine interface {
 	// and attempts to sync the driver if the update extends the previous head.
 	// Returns true if the driver successfully derived and synced the L2 block to match L1. False otherwise.
 	NotifyL1Head(ctx context.Context, log log.Logger, l1HeadSig eth.HeadSignal, driver Driver) (l2Updated bool)
+	// NotifyL1Target updates the sync target of the state-machine with the L1 signal, without syncing the driver.
+	NotifyL1Target(log log.Logger, l1HeadSig eth.HeadSignal)
 }
 
 type EngineDriverState struct {
@@ -29,18 +32,32 @@ type EngineDriverState struct {
 	// l1Head tracks the L1 block corresponding to the l2Head
 	l1Head eth.BlockID
 
-	// l2Head tracks the head-block of the engine
+	// l2Head tracks the head-block of the engine, the unsafe L2 head
 	l2Head eth.BlockID
 
-	// l2Finalized tracks the block the engine can safely regard as irreversible
-	// (a week for disputes, or maybe shorter if we see L1 finalize and take the derived L2 chain up till there)
+	// l2Safe tracks the last L2 block derived from L1
+	l2Safe eth.BlockID
+
+	// l1Safe tracks the safe L1 head, to only regard the L2 blocks derived from it or its ancestors as safe.
+	// Zero if the safe L1 head is not known: every L2 block is safe as soon as it is derived.
+	l1Safe eth.BlockID
+
+	// l2Finalized tracks the block the engine can safely regard as irreversible:
+	// the last L2 block derived from a L1 block that is finalized.
 	l2Finalized eth.BlockID
 
+	// safeOrigins tracks the recent safe L2 blocks with their L1 origins, in order, to finalize them along with L1
+	safeOrigins []safeOrigin
+
 	// The L1 block we are syncing towards, may be ahead of l1Head
 	l1Target eth.BlockID
 
 	// Genesis starting point
 	Genesis Genesis
+
+	// L1Recent is optional, to locate the divergence point of L1 reorgs from the recent L1 heads,
+	// without RPC round trips. It must be fed the L1 head signals before they are passed to the state-machine.
+	L1Recent *eth.ChainTracker
 }
 
 // L1Head returns the block-id (hash and number) of the last L1 block that was derived into the L2 block
@@ -63,11 +80,136 @@ func (e *EngineDriverState) Head() (l1Head eth.BlockID, l2Head eth.BlockID) {
 	return e.l1Head, e.l2Head
 }
 
+// L2Heads returns the unsafe, safe and finalized L2 heads
+func (e *EngineDriverState) L2Heads() L2Heads {
+	e.headLock.RLock()
+	defer e.headLock.RUnlock()
+	return L2Heads{Unsafe: e.l2Head, Safe: e.safeHead(), Finalized: e.l2Finalized}
+}
+
+// safeHead returns the last derived L2 block, or, if the safe L1 head is known,
+// the last L2 block that was derived from the safe L1 head or one of its ancestors.
+func (e *EngineDriverState) safeHead() eth.BlockID {
+	if e.l1Safe == (eth.BlockID{}) {
+		return e.l2Safe
+	}
+	for i := len(e.safeOrigins) - 1; i >= 0; i-- {
+		if e.safeOrigins[i].l1.Number <= e.l1Safe.Number {
+			return e.safeOrigins[i].l2
+		}
+	}
+	return e.l2Finalized
+}
+
+// derivedHead returns the last L2 block that was derived from L1, which may be ahead of the safe L2 head
+func (e *EngineDriverState) derivedHead() eth.BlockID {
+	e.headLock.RLock()
+	defer e.headLock.RUnlock()
+	return e.l2Safe
+}
+
+// isL1Safe returns true if the L2 blocks derived from the given L1 block are safe right away
+func (e *EngineDriverState) isL1Safe(l1 eth.BlockID) bool {
+	e.headLock.RLock()
+	defer e.headLock.RUnlock()
+	return e.l1Safe == (eth.BlockID{}) || l1.Number <= e.l1Safe.Number
+}
+
+// UpdateHead updates the L2 head with a L2 block that was derived from the given L1 block:
+// the L2 block becomes both the unsafe and the safe L2 head.
 func (e *EngineDriverState) UpdateHead(l1Head eth.BlockID, l2Head eth.BlockID) {
 	e.headLock.Lock()
 	defer e.headLock.Unlock()
 	e.l1Head = l1Head
 	e.l2Head = l2Head
+	e.updateSafe(l1Head, l2Head)
+}
+
+// UpdateUnsafeHead updates the L2 head with a L2 block that was not derived from L1, e.g. a sequenced block,
+// with the given L1 origin. The safe L2 head is unchanged.
+func (e *EngineDriverState) UpdateUnsafeHead(l1Origin eth.BlockID, l2Head eth.BlockID) {
+	e.headLock.Lock()
+	defer e.headLock.Unlock()
+	e.l1Head = l1Origin
+	e.l2Head = l2Head
+}
+
+func (e *EngineDriverState) updateSafe(l1 eth.BlockID, l2 eth.BlockID) {
+	e.l2Safe = l2
+	// forget the safe blocks that were replaced, e.g. after a L1 reorg
+	i := len(e.safeOrigins)
+	for i > 0 && e.safeOrigins[i-1].l2.Number >= l2.Number {
+		i--
+	}
+	e.safeOrigins = append(e.safeOrigins[:i], safeOrigin{l1: l1, l2: l2})
+	if len(e.safeOrigins) > maxSafeOrigins {
+		e.safeOrigins = e.safeOrigins[len(e.safeOrigins)-maxSafeOrigins:]
+	}
+}
+
+// restoreSafeHeads restores the safe L2 head, derived from the given L1 block, and the finalized L2 head, e.g. after a restart.
+// The heads only move forward, and never past the unsafe L2 head. The blocks must be canonical in the engine.
+func (e *EngineDriverState) restoreSafeHeads(safeL1 eth.BlockID, safe eth.BlockID, finalized eth.BlockID) {
+	e.headLock.Lock()
+	defer e.headLock.Unlock()
+	if safe.Number <= e.l2Head.Number && (e.l2Safe == (eth.BlockID{}) || safe.Number > e.l2Safe.Number) {
+		e.updateSafe(safeL1, safe)
+	}
+	if finalized.Number <= e.l2Safe.Number && (e.l2Finalized == (eth.BlockID{}) || finalized.Number > e.l2Finalized.Number) {
+		e.l2Finalized = finalized
+	}
+}
+
+// UnwindToSafe resets the unsafe L2 head to the safe L2 head, and the L1 head to the L1 block that the safe L2 head was derived from,
+// to derive again from there, e.g. after the engine rejected a L2 block as invalid.
+// It returns the unsafe L2 head before unwinding, and the L1 and L2 heads after unwinding.
+func (e *EngineDriverState) UnwindToSafe() (unwound eth.BlockID, l1Head eth.BlockID, l2Head eth.BlockID) {
+	e.headLock.Lock()
+	defer e.headLock.Unlock()
+	unwound = e.l2Head
+	l1Head, l2Head = e.Genesis.L1, e.Genesis.L2
+	if n := len(e.safeOrigins); n > 0 {
+		l1Head, l2Head = e.safeOrigins[n-1].l1, e.safeOrigins[n-1].l2
+	}
+	e.l1Head = l1Head
+	e.l2Head = l2Head
+	e.l2Safe = l2Head
+	return
+}
+
+// NotifyL1Safe updates the safe L1 head: only the L2 blocks derived from it or one of its ancestors are safe,
+// the L2 blocks derived from later L1 blocks are still regarded as unsafe, as L1 may reorg them.
+// The safe L2 head is sent to the engine with the next forkchoice update.
+func (e *EngineDriverState) NotifyL1Safe(log log.Logger, l1Safe eth.BlockID) {
+	e.headLock.Lock()
+	defer e.headLock.Unlock()
+	prev := e.safeHead()
+	e.l1Safe = l1Safe
+	if safe := e.safeHead(); safe != prev {
+		log.Info("Updated safe L2 head", "l2", safe, "l1_safe", l1Safe)
+	}
+}
+
+// NotifyL1Finalized finalizes the last safe L2 block that was derived from the finalized L1 block or one of its ancestors.
+// The finalized L2 head never moves back, and is sent to the engine with the next forkchoice update.
+func (e *EngineDriverState) NotifyL1Finalized(log log.Logger, l1Finalized eth.BlockID) {
+	e.headLock.Lock()
+	defer e.headLock.Unlock()
+	i := 0
+	for i < len(e.safeOrigins) && e.safeOrigins[i].l1.Number <= l1Finalized.Number {
+		i++
+	}
+	if i == 0 {
+		return
+	}
+	finalized := e.safeOrigins[i-1]
+	// keep the last finalized entry, to finalize it again after the newer safe blocks are reorged out
+	e.safeOrigins = e.safeOrigins[i-1:]
+	if finalized.l2.Number <= e.l2Finalized.Number && e.l2Finalized != (eth.BlockID{}) {
+		return
+	}
+	log.Info("Finalized L2 block", "l2", finalized.l2, "l1_origin", finalized.l1, "l1_finalized", l1Finalized)
+	e.l2Finalized = finalized.l2
 }
 
 func (e *EngineDriverState) RequestUpdate(ctx context.Context, log log.Logger, driver Driver) (l2Updated bool) {
@@ -79,9 +221,12 @@ func (e *EngineDriverState) RequestUpdate(ctx context.Context, log log.Logger, d
 	e.headLock.Lock()
 	defer e.headLock.Unlock()
 
+	updated := e.l1Head != refL1 || e.l2Head != refL2
 	e.l1Head = refL1
 	e.l2Head = refL2
-	return e.l1Head != refL1 || e.l2Head != refL2
+	// the engine only holds L2 blocks derived from L1 when the driver is not sequencing
+	e.updateSafe(refL1, refL2)
+	return updated
 }
 
 func (e *EngineDriverState) RequestSync(ctx context.Context, log log.Logger, driver Driver) (l2Updated bool) {
@@ -101,11 +246,11 @@ func (e *EngineDriverState) RequestSync(ctx context.Context, log log.Logger, dri
 		log.Debug("Engine is already synced, aborting sync", "l1_head", e.l1Head, "l2_head", e.l2Head)
 		return false
 	}
-	if l2ID, err := driver.driverStep(ctx, nextRefL1, refL2, e.l2Finalized); err != nil {
-		log.Error("Failed to sync L2 chain with new L1 block", "l1", nextRefL1, "onto_l2", refL2, "err", err)
+	if l1Origin, l2ID, err := driver.driverStep(ctx, nextRefL1, refL2, e.L2Heads().Finalized); err != nil {
+		logStepErr(log, "Failed to sync L2 chain with new L1 block", err, "l1", nextRefL1, "onto_l2", refL2)
 		return false
 	} else {
-		e.UpdateHead(nextRefL1, l2ID) // l2ID is derived from the nextRefL1
+		e.UpdateHead(l1Origin, l2ID) // l2ID is derived from the nextRefL1, or continues the epoch of refL2
 	}
 	return e.l1Head != e.l1Target
 }
@@ -117,16 +262,30 @@ func (e *EngineDriverState) NotifyL1Head(ctx context.Context, log log.Logger, l1
 	}
 	if e.l1Head == l1HeadSig.Parent {
 		// Simple extend, a linear life is easy
-		if l2ID, err := driver.driverStep(ctx, l1HeadSig.Self, e.l2Head, e.l2Finalized); err != nil {
-			log.Error("Failed to extend L2 chain with new L1 block", "l1", l1HeadSig.Self, "l2", e.l2Head, "err", err)
+		if l1Origin, l2ID, err := driver.driverStep(ctx, l1HeadSig.Self, e.l2Head, e.L2Heads().Finalized); err != nil {
+			logStepErr(log, "Failed to extend L2 chain with new L1 block", err, "l1", l1HeadSig.Self, "l2", e.l2Head)
 			// Retry sync later
 			e.l1Target = l1HeadSig.Self
 			return false
 		} else {
-			e.UpdateHead(l1HeadSig.Self, l2ID)
+			e.UpdateHead(l1Origin, l2ID)
+			e.l1Target = l1HeadSig.Self
 			return true
 		}
 	}
+	if e.L1Recent != nil {
+		if ancestor, newChain, ok := e.L1Recent.FindDivergence(e.l1Head, l1HeadSig.Self); ok {
+			if ancestor == e.l1Head {
+				log.Debug("Received new L1 head, engine is out of sync, cannot immediately process",
+					"l1", l1HeadSig.Self, "l2", e.l2Head, "missing", len(newChain))
+			} else {
+				log.Warn("Received a L1 reorg, syncing new alternative chain", "l1", l1HeadSig.Self, "l2", e.l2Head,
+					"common_ancestor", ancestor, "depth", e.l1Head.Number-ancestor.Number)
+			}
+			e.l1Target = l1HeadSig.Self
+			return false
+		}
+	}
 	if e.l1Head.Number < l1HeadSig.Parent.Number {
 		log.Debug("Received new L1 head, engine is out of sync, cannot immediately process", "l1", l1HeadSig.Self, "l2", e.l2Head)
 	} else {
@@ -136,3 +295,19 @@ func (e *EngineDriverState) NotifyL1Head(ctx context.Context, log log.Logger, l1
 	e.l1Target = l1HeadSig.Self
 	return false
 }
+
+func (e *EngineDriverState) NotifyL1Target(log log.Logger, l1HeadSig eth.HeadSignal) {
+	log.Debug("Received L1 head signal, updating sync target", "l1", l1HeadSig.Self, "l1_head", e.l1Head)
+	e.l1Target = l1HeadSig.Self
+}
+
+// logStepErr logs a failed driver step: failures to fetch L1 data that may succeed when retried later,
+// e.g. a L1 block that is not available yet, are logged as warnings, any other failures as errors.
+func logStepErr(log log.Logger, msg string, err error, ctx ...interface{}) {
+	ctx = append(ctx, "err", err)
+	if eth.IsRetryable(err) || errors.Is(err, EngineSyncingErr) {
+		log.Warn(msg, ctx...)
+	} else {
+		log.Error(msg, ctx...)
+	}
+}
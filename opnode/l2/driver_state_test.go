@@ -66,7 +66,7 @@ func (m *mockDriver) requestEngineHead(ctx context.Context) (refL1 eth.BlockID,
 	returnArgs := m.Called(ctx)
 	refL1 = returnArgs.Get(0).(eth.BlockID)
 	refL2 = returnArgs.Get(1).(eth.BlockID)
-	err = returnArgs.Get(2).(error)
+	err, _ = returnArgs.Get(2).(error)
 	return
 }
 
@@ -78,10 +78,11 @@ func (m *mockDriver) findSyncStart(ctx context.Context) (nextRefL1 eth.BlockID,
 	return
 }
 
-func (m *mockDriver) driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l2ID eth.BlockID, err error) {
+func (m *mockDriver) driverStep(ctx context.Context, nextRefL1 eth.BlockID, refL2 eth.BlockID, finalized eth.BlockID) (l1Origin eth.BlockID, l2ID eth.BlockID, err error) {
 	returnArgs := m.Called(ctx, nextRefL1, refL2, finalized)
-	l2ID = returnArgs.Get(0).(eth.BlockID)
-	err, _ = returnArgs.Get(1).(error)
+	l1Origin = returnArgs.Get(0).(eth.BlockID)
+	l2ID = returnArgs.Get(1).(eth.BlockID)
+	err, _ = returnArgs.Get(2).(error)
 	return
 }
 
@@ -101,7 +102,7 @@ func TestEngineDriverState_RequestSync(t *testing.T) {
 		genesisL2:   "b:0",
 	})
 	driver.On("findSyncStart", ctx).Return(testID("d:3").ID(), testID("C:2").ID(), nil)
-	driver.On("driverStep", ctx, testID("d:3").ID(), testID("C:2").ID(), testID("B:1").ID()).Return(testID("D:3").ID(), nil)
+	driver.On("driverStep", ctx, testID("d:3").ID(), testID("C:2").ID(), testID("B:1").ID()).Return(testID("d:3").ID(), testID("D:3").ID(), nil)
 
 	l2Updated := state.RequestSync(ctx, log, driver)
 
@@ -109,3 +110,162 @@ func TestEngineDriverState_RequestSync(t *testing.T) {
 	assert.Equal(t, state.l2Head, testID("D:3").ID())
 	assert.True(t, l2Updated)
 }
+
+func TestEngineDriverState_RequestUpdate(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	driver := new(mockDriver)
+	ctx := context.Background()
+
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "b:0",
+		l2Finalized: "b:0",
+		l1Target:    "c:2",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	driver.On("requestEngineHead", ctx).Return(testID("c:2").ID(), testID("C:2").ID(), nil)
+
+	assert.True(t, state.RequestUpdate(ctx, log, driver), "the engine head changed")
+	assert.Equal(t, testID("c:2").ID(), state.l1Head)
+	assert.Equal(t, testID("C:2").ID(), state.l2Head)
+	assert.False(t, state.RequestUpdate(ctx, log, driver), "the engine head is unchanged")
+}
+
+func TestEngineDriverState_NotifyL1HeadReorg(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	driver := new(mockDriver)
+	ctx := context.Background()
+
+	state := makeState(testState{
+		l1Head:      "c:2",
+		l2Head:      "C:2",
+		l2Finalized: "B:1",
+		l1Target:    "c:2",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	state.L1Recent = eth.NewChainTracker(nil, nil, 10)
+	state.L1Recent.AddHead(eth.HeadSignal{Parent: testID("b:1").ID(), Self: testID("c:2").ID()})
+	state.L1Recent.AddHead(eth.HeadSignal{Parent: testID("b:1").ID(), Self: testID("x:2").ID()})
+	reorgSig := eth.HeadSignal{Parent: testID("x:2").ID(), Self: testID("y:3").ID()}
+	state.L1Recent.AddHead(reorgSig)
+
+	// the new head does not extend the current L1 head: no driver step, but sync towards the new head
+	l2Updated := state.NotifyL1Head(ctx, log, reorgSig, driver)
+
+	assert.False(t, l2Updated)
+	assert.Equal(t, testID("y:3").ID(), state.l1Target)
+	driver.AssertNotCalled(t, "driverStep", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestEngineDriverState_NotifyL1Finalized(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "b:0",
+		l2Finalized: "b:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	state.UpdateHead(testID("b:1").ID(), testID("B:1").ID())
+	state.UpdateHead(testID("c:2").ID(), testID("C:2").ID())
+	state.UpdateUnsafeHead(testID("c:2").ID(), testID("X:3").ID())
+
+	heads := state.L2Heads()
+	assert.Equal(t, testID("X:3").ID(), heads.Unsafe)
+	assert.Equal(t, testID("C:2").ID(), heads.Safe, "unsafe blocks do not advance the safe head")
+
+	state.NotifyL1Finalized(log, testID("b:1").ID())
+	assert.Equal(t, testID("B:1").ID(), state.L2Heads().Finalized)
+
+	// L1 reorgs after the finalized block, and the safe head is replaced
+	state.UpdateHead(testID("d:2").ID(), testID("D:2").ID())
+	state.NotifyL1Finalized(log, testID("a:0").ID())
+	assert.Equal(t, testID("B:1").ID(), state.L2Heads().Finalized, "finalized head does not move back")
+	state.NotifyL1Finalized(log, testID("d:2").ID())
+	assert.Equal(t, testID("D:2").ID(), state.L2Heads().Finalized)
+}
+
+func TestEngineDriverState_NotifyL1Safe(t *testing.T) {
+	log := testlog.Logger(t, log.LvlTrace)
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "b:0",
+		l2Finalized: "b:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	state.UpdateHead(testID("b:1").ID(), testID("B:1").ID())
+	state.UpdateHead(testID("c:2").ID(), testID("C:2").ID())
+	assert.Equal(t, testID("C:2").ID(), state.L2Heads().Safe, "without safe L1 head, derived blocks are safe")
+	assert.True(t, state.isL1Safe(testID("d:3").ID()))
+
+	state.NotifyL1Safe(log, testID("b:1").ID())
+	assert.Equal(t, testID("B:1").ID(), state.L2Heads().Safe, "blocks derived after the safe L1 head are not safe")
+	assert.Equal(t, testID("C:2").ID(), state.derivedHead())
+	assert.False(t, state.isL1Safe(testID("c:2").ID()))
+
+	state.UpdateHead(testID("d:3").ID(), testID("D:3").ID())
+	assert.Equal(t, testID("B:1").ID(), state.L2Heads().Safe)
+	state.NotifyL1Safe(log, testID("d:3").ID())
+	assert.Equal(t, testID("D:3").ID(), state.L2Heads().Safe)
+
+	state.NotifyL1Finalized(log, testID("c:2").ID())
+	state.NotifyL1Safe(log, testID("a:0").ID())
+	assert.Equal(t, testID("C:2").ID(), state.L2Heads().Safe, "the safe head does not fall behind the finalized head")
+}
+
+func TestEngineDriverState_UnwindToSafe(t *testing.T) {
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "b:0",
+		l2Finalized: "b:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	unwound, l1Head, l2Head := state.UnwindToSafe()
+	assert.Equal(t, testID("b:0").ID(), unwound)
+	assert.Equal(t, testID("a:0").ID(), l1Head, "unwind to genesis without safe blocks")
+	assert.Equal(t, testID("b:0").ID(), l2Head)
+
+	state.UpdateHead(testID("b:1").ID(), testID("B:1").ID())
+	state.UpdateUnsafeHead(testID("c:2").ID(), testID("X:2").ID())
+	state.UpdateUnsafeHead(testID("c:2").ID(), testID("X:3").ID())
+	unwound, l1Head, l2Head = state.UnwindToSafe()
+	assert.Equal(t, testID("X:3").ID(), unwound)
+	assert.Equal(t, testID("b:1").ID(), l1Head)
+	assert.Equal(t, testID("B:1").ID(), l2Head)
+	assert.Equal(t, testID("B:1").ID(), state.L2Heads().Unsafe)
+	assert.Equal(t, testID("b:1").ID(), state.L1Head())
+}
+
+func TestEngineDriverState_RestoreSafeHeads(t *testing.T) {
+	state := makeState(testState{
+		l1Head:      "a:0",
+		l2Head:      "b:0",
+		l2Finalized: "b:0",
+		l1Target:    "a:0",
+		genesisL1:   "a:0",
+		genesisL2:   "b:0",
+	})
+	state.UpdateUnsafeHead(testID("c:2").ID(), testID("X:3").ID())
+	state.restoreSafeHeads(testID("b:1").ID(), testID("X:4").ID(), testID("X:4").ID())
+	assert.Equal(t, L2Heads{Unsafe: testID("X:3").ID(), Finalized: testID("b:0").ID()}, state.L2Heads(), "heads beyond the unsafe head are ignored")
+
+	state.restoreSafeHeads(testID("c:2").ID(), testID("X:2").ID(), testID("X:1").ID())
+	heads := state.L2Heads()
+	assert.Equal(t, testID("X:2").ID(), heads.Safe)
+	assert.Equal(t, testID("X:1").ID(), heads.Finalized)
+
+	state.restoreSafeHeads(testID("b:1").ID(), testID("X:1").ID(), testID("b:0").ID())
+	assert.Equal(t, heads, state.L2Heads(), "restored heads do not move back")
+
+	// the restored safe block is finalized along with its L1 origin
+	state.UpdateUnsafeHead(testID("c:2").ID(), testID("X:3").ID())
+	state.NotifyL1Finalized(testlog.Logger(t, log.LvlTrace), testID("c:2").ID())
+	assert.Equal(t, testID("X:2").ID(), state.L2Heads().Finalized)
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/ethereum/go-ethereum/core/types"
 
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/events"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/log"
 )
@@ -20,42 +21,47 @@ type DriverAPI interface {
 func Execute(ctx context.Context, rpc DriverAPI, payload *ExecutionPayload) error {
 	execCtx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
-	execRes, err := rpc.ExecutePayload(execCtx, payload)
+	execRes, err := rpc.NewPayload(execCtx, payload)
 	if err != nil {
-		return fmt.Errorf("failed to execute payload: %v", err)
+		return fmt.Errorf("failed to execute payload: %w", err)
 	}
 	switch execRes.Status {
 	case ExecutionValid:
 		return nil
-	case ExecutionSyncing:
-		return fmt.Errorf("failed to execute payload %s, node is syncing, latest valid hash is %s", payload.ID(), execRes.LatestValidHash)
-	case ExecutionInvalid:
-		return fmt.Errorf("execution payload %s was INVALID! Latest valid hash is %s, ignoring bad block: %q", payload.ID(), execRes.LatestValidHash, execRes.ValidationError)
+	case ExecutionSyncing, ExecutionAccepted:
+		return fmt.Errorf("failed to execute payload %s, status is %s: %w", payload.ID(), execRes.Status, EngineSyncingErr)
+	case ExecutionInvalid, ExecutionInvalidBlockHash, ExecutionInvalidTerminalBlock:
+		var latestValid common.Hash
+		if execRes.LatestValidHash != nil {
+			latestValid = *execRes.LatestValidHash
+		}
+		var validationErr string
+		if execRes.ValidationError != nil {
+			validationErr = *execRes.ValidationError
+		}
+		return fmt.Errorf("execution payload %s was %s! Latest valid hash is %s, ignoring bad block: %q: %w", payload.ID(), execRes.Status, latestValid, validationErr, InvalidPayloadErr)
 	default:
 		return fmt.Errorf("unknown execution status on %s: %q, ", payload.ID(), string(execRes.Status))
 	}
 }
 
-func ForkchoiceUpdate(ctx context.Context, rpc DriverAPI, l2BlockHash common.Hash, l2Finalized common.Hash) error {
-	postState := &ForkchoiceState{
-		HeadBlockHash:      l2BlockHash, // no difference yet between Head and Safe, no data ahead of L1 yet.
-		SafeBlockHash:      l2BlockHash,
-		FinalizedBlockHash: l2Finalized,
-	}
-
+// ForkchoiceUpdate updates the forkchoice of the engine to the L2 heads
+func ForkchoiceUpdate(ctx context.Context, rpc DriverAPI, heads L2Heads) error {
 	fcCtx, cancel := context.WithTimeout(ctx, time.Second*5)
 	defer cancel()
-	fcRes, err := rpc.ForkchoiceUpdated(fcCtx, postState, nil)
+	fcRes, err := rpc.ForkchoiceUpdated(fcCtx, heads.ForkchoiceState(), nil)
 	if err != nil {
-		return fmt.Errorf("failed to update forkchoice: %v", err)
+		return fmt.Errorf("failed to update forkchoice: %w", err)
 	}
-	switch fcRes.Status {
-	case UpdateSyncing:
-		return fmt.Errorf("updated forkchoice, but node is syncing: %v", err)
-	case UpdateSuccess:
+	switch fcRes.PayloadStatus.Status {
+	case ExecutionSyncing:
+		return fmt.Errorf("updated forkchoice to %s: %w", heads.Unsafe, EngineSyncingErr)
+	case ExecutionValid:
 		return nil
+	case ExecutionInvalid, ExecutionInvalidBlockHash, ExecutionInvalidTerminalBlock:
+		return fmt.Errorf("forkchoice head %s was rejected as %s: %w", heads.Unsafe, fcRes.PayloadStatus.Status, InvalidPayloadErr)
 	default:
-		return fmt.Errorf("unknown forkchoice status on %s: %q, ", l2BlockHash, string(fcRes.Status))
+		return fmt.Errorf("unknown forkchoice status on %s: %q, ", heads.Unsafe, string(fcRes.PayloadStatus.Status))
 	}
 }
 
@@ -63,28 +69,31 @@ type Downloader interface {
 	Fetch(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error)
 }
 
-func DriverStep(ctx context.Context, log log.Logger, rpc DriverAPI,
-	dl Downloader, l1Input eth.BlockID, l2Parent eth.BlockID, l2Finalized common.Hash) (out eth.BlockID, err error) {
+// DriverStep builds the L2 block with the block inputs that were derived from the L1 block, on top of the unsafe L2 head,
+// and applies it to the engine as the new head. The new block becomes the safe L2 head if safe is true,
+// otherwise the safe L2 head is kept until the L1 block is safe.
+func DriverStep(ctx context.Context, log log.Logger, rpc DriverAPI, bus *events.Bus,
+	l1Input eth.BlockID, attrs *PayloadAttributes, heads L2Heads, safe bool) (out eth.BlockID, err error) {
 
-	logger := log.New("input_l1", l1Input, "input_l2_parent", l2Parent, "finalized_l2", l2Finalized)
+	logger := log.New("input_l1", l1Input, "input_l2_parent", heads.Unsafe, "safe_l2", heads.Safe, "finalized_l2", heads.Finalized)
 
-	fetchCtx, cancel := context.WithTimeout(ctx, time.Second*20)
-	defer cancel()
-	bl, receipts, err := dl.Fetch(fetchCtx, l1Input)
+	heads, err = InsertBlock(ctx, logger, rpc, bus, l1Input, heads, safe, attrs, 0)
 	if err != nil {
-		return eth.BlockID{}, fmt.Errorf("failed to fetch block with receipts: %v", err)
+		return eth.BlockID{}, err
 	}
-	logger.Debug("fetched L1 data for driver")
+	return heads.Unsafe, nil
+}
 
-	attrs, err := DeriveBlockInputs(bl, receipts)
-	if err != nil {
-		return eth.BlockID{}, fmt.Errorf("failed to derive execution payload inputs: %v", err)
-	}
-	logger.Debug("derived L2 block inputs")
+// InsertBlock has the engine build the L2 block with the attributes on top of the unsafe L2 head, within the build time,
+// executes it, and updates the forkchoice of the engine to make it the new unsafe head, and safe head if it is derived from L1.
+// The L1 block is the origin of the L2 block, as reported in the events. The updated L2 heads are returned.
+func InsertBlock(ctx context.Context, logger log.Logger, rpc DriverAPI, bus *events.Bus,
+	l1Origin eth.BlockID, heads L2Heads, safe bool, attrs *PayloadAttributes, buildTime time.Duration) (out L2Heads, err error) {
 
-	payload, err := DeriveBlockOutputs(ctx, rpc, l2Parent.Hash, l2Finalized, attrs)
+	builder := &PayloadBuilder{Engine: rpc, Log: logger, BuildTime: buildTime}
+	payload, err := builder.Build(ctx, heads, attrs)
 	if err != nil {
-		return eth.BlockID{}, fmt.Errorf("failed to derive execution payload: %v", err)
+		return L2Heads{}, fmt.Errorf("failed to derive execution payload: %w", err)
 	}
 
 	logger = logger.New("derived_l2", payload.ID())
@@ -92,15 +101,21 @@ func DriverStep(ctx context.Context, log log.Logger, rpc DriverAPI,
 
 	err = Execute(ctx, rpc, payload)
 	if err != nil {
-		return eth.BlockID{}, fmt.Errorf("failed to apply execution payload: %v", err)
+		return L2Heads{}, fmt.Errorf("failed to apply execution payload: %w", err)
 	}
 	logger.Info("executed block")
+	bus.Publish(PayloadInsertedEvent{L1: l1Origin, Payload: payload})
 
-	err = ForkchoiceUpdate(ctx, rpc, payload.BlockHash, l2Finalized)
+	heads.Unsafe = payload.ID()
+	if safe {
+		heads.Safe = payload.ID()
+	}
+	err = ForkchoiceUpdate(ctx, rpc, heads)
 	if err != nil {
-		return eth.BlockID{}, fmt.Errorf("failed to persist execution payload: %v", err)
+		return L2Heads{}, fmt.Errorf("failed to persist execution payload: %w", err)
 	}
-	logger.Info("updated fork-choice with block")
+	logger.Info("updated fork-choice with block", "safe", safe)
+	bus.Publish(ForkchoiceUpdatedEvent{Heads: heads})
 
-	return payload.ID(), nil
+	return heads, nil
 }
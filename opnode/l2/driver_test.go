@@ -0,0 +1,467 @@
+package l2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/events"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testlog"
+)
+
+// fakeEngine is a DriverAPI that builds L2 blocks from the payload attributes without executing them,
+// and remembers the attributes of every built L2 block.
+type fakeEngine struct {
+	numbers  map[common.Hash]uint64
+	payloads map[common.Hash]*ExecutionPayload
+	attrs    map[common.Hash]*PayloadAttributes
+	// safe L2 block of the last forkchoice update
+	safe common.Hash
+}
+
+func newFakeEngine(genesis eth.BlockID) *fakeEngine {
+	return &fakeEngine{
+		numbers:  map[common.Hash]uint64{genesis.Hash: genesis.Number},
+		payloads: make(map[common.Hash]*ExecutionPayload),
+		attrs:    make(map[common.Hash]*PayloadAttributes),
+	}
+}
+
+func (f *fakeEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	num, ok := f.numbers[state.HeadBlockHash]
+	if !ok {
+		return ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionSyncing}}, nil
+	}
+	if attr == nil {
+		f.safe = state.SafeBlockHash
+		return ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionValid}}, nil
+	}
+	payload := &ExecutionPayload{
+		ParentHash:   state.HeadBlockHash,
+		BlockNumber:  Uint64Quantity(num + 1),
+		Timestamp:    attr.Timestamp,
+		Transactions: attr.Transactions,
+	}
+	// blocks with the same parent and inputs are the same block
+	h := crypto.NewKeccakState()
+	h.Write(state.HeadBlockHash[:])
+	for _, tx := range attr.Transactions {
+		h.Write(tx)
+	}
+	h.Read(payload.BlockHash[:])
+	f.payloads[payload.BlockHash] = payload
+	f.attrs[payload.BlockHash] = attr
+	var id PayloadID
+	copy(id[:], payload.BlockHash[:8])
+	return ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionValid}, PayloadID: &id}, nil
+}
+
+func (f *fakeEngine) GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error) {
+	for h, payload := range f.payloads {
+		var id PayloadID
+		copy(id[:], h[:8])
+		if id == payloadId {
+			return payload, nil
+		}
+	}
+	return nil, errors.New("unknown payload")
+}
+
+func (f *fakeEngine) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	f.numbers[payload.BlockHash] = uint64(payload.BlockNumber)
+	return &PayloadStatusV1{Status: ExecutionValid}, nil
+}
+
+func (f *fakeEngine) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeEngine) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeEngine) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeEngine) Close() {}
+
+var _ DriverAPI = (*fakeEngine)(nil)
+
+func TestEngineDriver_DerivationPipeline(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+		ChannelTimeout: 3,
+	}
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	frames := testFrames(1, chData, 2)
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[0])}, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[1])}, 0)
+	chain.add(nil, 0)
+	l1 := func(n uint64) eth.BlockID {
+		return eth.BlockID{Hash: chain.blocks[n].Hash(), Number: n}
+	}
+	l1Refs := &mockSyncReference{}
+	for i := range chain.blocks {
+		l1Refs.L1 = append(l1Refs.L1, l1(uint64(i)))
+	}
+
+	genesis := Genesis{L1: l1(0), L2: eth.BlockID{Hash: common.Hash{0x42}, Number: 0}}
+	engine := newFakeEngine(genesis.L2)
+	driver := &EngineDriver{
+		Log:               testlog.Logger(t, log.LvlError),
+		Config:            cfg,
+		RPC:               engine,
+		L1:                chain,
+		DL:                chain,
+		SyncRef:           l1Refs,
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	ctx := context.Background()
+
+	// the first step creates the pipeline on top of the L2 genesis
+	_, l2a, err := driver.driverStep(ctx, l1(1), genesis.L2, eth.BlockID{})
+	require.NoError(t, err)
+	require.Len(t, engine.attrs[l2a.Hash].Transactions, 1, "only the L1 info deposit, the channel is not complete")
+
+	// the channel bank of the pipeline completes the channel with the frame of the next L1 block
+	_, l2b, err := driver.driverStep(ctx, l1(2), l2a, eth.BlockID{})
+	require.NoError(t, err)
+	require.Equal(t, []Data{seqTx}, engine.attrs[l2b.Hash].Transactions[1:], "L1 info deposit, followed by the sequenced tx")
+
+	// building on an older L2 block resets the pipeline, which replays the frame of L1 block 1 to derive the same block
+	_, replaced, err := driver.driverStep(ctx, l1(2), l2a, eth.BlockID{})
+	require.NoError(t, err)
+	require.Equal(t, l2b, replaced)
+	require.Equal(t, l2b.Hash, engine.safe, "without safe L1 head, derived blocks are safe right away")
+
+	// blocks derived from L1 blocks after the safe L1 head are not safe yet
+	driver.UpdateHead(l1(2), l2b)
+	driver.NotifyL1Safe(driver.Log, l1(2))
+	_, l2c, err := driver.driverStep(ctx, l1(3), l2b, eth.BlockID{})
+	require.NoError(t, err)
+	require.Len(t, engine.attrs[l2c.Hash].Transactions, 1)
+	require.Equal(t, l2b.Hash, engine.safe)
+
+	// the L1 block to derive must be the next L1 block of the pipeline
+	_, _, err = driver.driverStep(ctx, eth.BlockID{Hash: common.Hash{0xba, 0xd}, Number: 3}, l2b, eth.BlockID{})
+	require.ErrorIs(t, err, ReorgErr)
+}
+
+// memPipelineStore persists the pipeline state as JSON in memory
+type memPipelineStore struct {
+	data []byte
+}
+
+func (m *memPipelineStore) LoadPipeline() (state PipelineState, ok bool, err error) {
+	if m.data == nil {
+		return PipelineState{}, false, nil
+	}
+	err = json.Unmarshal(m.data, &state)
+	return state, err == nil, err
+}
+
+func (m *memPipelineStore) StorePipeline(state PipelineState) (err error) {
+	m.data, err = json.Marshal(state)
+	return err
+}
+
+func TestEngineDriver_RestorePipeline(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+		ChannelTimeout: 3,
+	}
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	frames := testFrames(1, chData, 2)
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[0])}, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[1])}, 0)
+	l1 := func(n uint64) eth.BlockID {
+		return eth.BlockID{Hash: chain.blocks[n].Hash(), Number: n}
+	}
+
+	genesis := Genesis{L1: l1(0), L2: eth.BlockID{Hash: common.Hash{0x42}, Number: 0}}
+	engine := newFakeEngine(genesis.L2)
+	store := new(memPipelineStore)
+	newDriver := func(syncRef SyncReference) *EngineDriver {
+		return &EngineDriver{
+			Log:               testlog.Logger(t, log.LvlError),
+			Config:            cfg,
+			RPC:               engine,
+			L1:                chain,
+			DL:                chain,
+			SyncRef:           syncRef,
+			PipelineStore:     store,
+			EngineDriverState: EngineDriverState{Genesis: genesis},
+		}
+	}
+	ctx := context.Background()
+
+	_, l2a, err := newDriver(&mockSyncReference{L1: []eth.BlockID{l1(0), l1(1)}}).driverStep(ctx, l1(1), genesis.L2, eth.BlockID{})
+	require.NoError(t, err)
+	state, ok, err := store.LoadPipeline()
+	require.NoError(t, err)
+	require.True(t, ok, "the pipeline is persisted after the step")
+	require.Equal(t, l2a.Hash, state.L2Parent)
+	require.Len(t, state.Bank.Channels, 1, "the first frame is pending")
+
+	// after a restart, the driver resumes from the persisted pipeline, without a reset to replay the first frame
+	_, l2b, err := newDriver(&mockSyncReference{}).driverStep(ctx, l1(2), l2a, eth.BlockID{})
+	require.NoError(t, err)
+	require.Equal(t, []Data{seqTx}, engine.attrs[l2b.Hash].Transactions[1:], "the restored channel bank completes the channel")
+
+	// the persisted pipeline does not build on an older L2 block, and is not restored for it
+	_, _, err = newDriver(&mockSyncReference{}).driverStep(ctx, l1(2), l2a, eth.BlockID{})
+	require.ErrorIs(t, err, ethereum.NotFound, "reset instead of restore")
+}
+
+func TestEngineDriver_Epochs(t *testing.T) {
+	cfg := Config{BlockTime: 2, MaxSequencerDrift: 600}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	l1 := func(n uint64) eth.BlockID {
+		return eth.BlockID{Hash: chain.blocks[n].Hash(), Number: n}
+	}
+	// the L2 genesis block starts the epoch of the L1 genesis block
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: chain.blocks[0].Time()})
+	genesis := Genesis{L1: l1(0), L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+	engine := newFakeSeqEngine(l2Genesis)
+	driver := &EngineDriver{
+		Log:               testlog.Logger(t, log.LvlError),
+		Config:            cfg,
+		RPC:               engine,
+		L1:                chain,
+		DL:                chain,
+		SyncRef:           &mockSyncReference{L1: []eth.BlockID{l1(0), l1(1)}},
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	ctx := context.Background()
+
+	checkEpoch := func(l2 eth.BlockID, expectedOrigin eth.BlockID, expectedSeqNumber uint64, expectedTimestamp uint64) {
+		bl, err := engine.BlockByHash(ctx, l2.Hash)
+		require.NoError(t, err)
+		origin, seqNumber, err := ParseBlockEpoch(bl, &genesis)
+		require.NoError(t, err)
+		require.Equal(t, expectedOrigin, origin)
+		require.Equal(t, expectedSeqNumber, seqNumber)
+		require.Equal(t, expectedTimestamp, bl.Time())
+	}
+
+	// every step derives the next L2 block of the epoch, while the state machine asks for the next L1 block
+	refL2 := genesis.L2
+	for i := uint64(1); i < 6; i++ {
+		origin, l2ID, err := driver.driverStep(ctx, l1(1), refL2, eth.BlockID{})
+		require.NoError(t, err)
+		require.Equal(t, l1(0), origin, "the L1 origin is taken from the derived L2 block")
+		checkEpoch(l2ID, l1(0), i, 2*i)
+		refL2 = l2ID
+	}
+	// the L2 block at the time of the next L1 block starts its epoch
+	origin, epochStart, err := driver.driverStep(ctx, l1(1), refL2, eth.BlockID{})
+	require.NoError(t, err)
+	require.Equal(t, l1(1), origin)
+	checkEpoch(epochStart, l1(1), 0, 12)
+
+	// the rest of the epoch needs the next L1 block
+	_, _, err = driver.driverStep(ctx, l1(1), epochStart, eth.BlockID{})
+	require.ErrorIs(t, err, ethereum.NotFound)
+
+	// building on an older L2 block resets the pipeline to the epoch of that L2 block
+	origin, l2ID, err := driver.driverStep(ctx, l1(1), refL2, eth.BlockID{})
+	require.NoError(t, err)
+	require.Equal(t, l1(1), origin)
+	require.Equal(t, epochStart, l2ID)
+}
+
+func TestEngineDriver_BatchParent(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := Config{
+		BatcherAddr:       crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr:    common.Address{0xff, 0x01},
+		ChannelTimeout:    3,
+		BlockTime:         2,
+		MaxSequencerDrift: 600,
+	}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	l1Genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: chain.blocks[0].Time()})
+	genesis := Genesis{L1: l1Genesis, L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+
+	// the batch of the second L2 block builds on the L2 genesis block, instead of the first L2 block
+	txA, txB := testL2Tx(t, 0), testL2Tx(t, 1)
+	chData, err := EncodeChannel([]*BatchData{
+		{ParentHash: genesis.L2.Hash, EpochNum: 0, EpochHash: l1Genesis.Hash, Timestamp: 2, Transactions: []Data{txA}},
+		{ParentHash: genesis.L2.Hash, EpochNum: 0, EpochHash: l1Genesis.Hash, Timestamp: 4, Transactions: []Data{txB}},
+	}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, testFrames(1, chData, 1)...)}, 0)
+
+	engine := newFakeSeqEngine(l2Genesis)
+	m := new(testMetrics)
+	driver := &EngineDriver{
+		Log:               testlog.Logger(t, log.LvlError),
+		Config:            cfg,
+		RPC:               engine,
+		L1:                chain,
+		DL:                chain,
+		Metrics:           m,
+		SyncRef:           &mockSyncReference{L1: []eth.BlockID{l1Genesis, {Hash: chain.blocks[1].Hash(), Number: 1}}},
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	ctx := context.Background()
+	next := eth.BlockID{Hash: chain.blocks[1].Hash(), Number: 1}
+
+	_, l2a, err := driver.driverStep(ctx, next, genesis.L2, eth.BlockID{})
+	require.NoError(t, err)
+	bl, err := engine.BlockByHash(ctx, l2a.Hash)
+	require.NoError(t, err)
+	require.Len(t, bl.Transactions(), 2, "the batch builds on the L2 genesis block")
+
+	_, l2b, err := driver.driverStep(ctx, next, l2a, eth.BlockID{})
+	require.NoError(t, err)
+	bl, err = engine.BlockByHash(ctx, l2b.Hash)
+	require.NoError(t, err)
+	require.Len(t, bl.Transactions(), 1, "the batch does not build on the previous L2 block")
+	require.Equal(t, 1, m.droppedBatches[string(BatchWrongParent)])
+}
+
+func TestEngineDriver_InvalidPayload(t *testing.T) {
+	l1 := new(fakeSeqL1)
+	l1.add(1000)
+	l1.add(1012)
+	l1.add(1024)
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesis := Genesis{L1: l1.id(0), L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+	engine := &rejectingEngine{newFakeSeqEngine(l2Genesis)}
+
+	bus := events.NewBus()
+	invalid := make(chan InvalidPayloadEvent, 1)
+	sub := bus.Subscribe(invalid)
+	defer sub.Unsubscribe()
+
+	driver := &EngineDriver{
+		Log:               testlog.Logger(t, log.LvlCrit),
+		RPC:               engine,
+		L1:                l1,
+		DL:                l1,
+		SyncRef:           &mockSyncReference{L1: []eth.BlockID{l1.id(0), l1.id(1), l1.id(2)}},
+		Events:            bus,
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	driver.UpdateHead(l1.id(1), eth.BlockID{Hash: l2Genesis.Hash(), Number: 0})
+	driver.UpdateUnsafeHead(l1.id(1), eth.BlockID{Number: 1})
+
+	_, _, err := driver.driverStep(context.Background(), l1.id(2), genesis.L2, genesis.L2)
+	require.ErrorIs(t, err, InvalidPayloadErr)
+
+	ev := <-invalid
+	require.Equal(t, l1.id(2), ev.L1)
+	require.Equal(t, eth.BlockID{Number: 1}, ev.Unwound)
+	require.Equal(t, genesis.L2, ev.Safe)
+	require.Equal(t, l1.id(1), ev.L1Head, "derive again from the L1 origin of the safe head")
+	require.ErrorIs(t, ev.Err, InvalidPayloadErr)
+	require.Equal(t, genesis.L2, driver.L2Head())
+}
+
+func TestEngineDriver_ImportUnsafePayload(t *testing.T) {
+	l1 := new(fakeSeqL1)
+	l1.add(1000)
+	l1.add(1012)
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesis := Genesis{L1: l1.id(0), L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+	engine := newFakeSeqEngine(l2Genesis)
+
+	driver := &EngineDriver{
+		Log:               log.New(),
+		Config:            Config{BlockTime: 2, MaxSequencerDrift: 20},
+		RPC:               engine,
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	driver.UpdateHead(genesis.L1, genesis.L2)
+
+	// the sequencer built the block with the same engine, the fake engine only executes the payloads it built
+	attrs, err := SequencerBlockInputs(&Config{}, BlockInputFromHeader((*l1)[0].Header()), 1, nil, 1002)
+	require.NoError(t, err)
+	payload, err := (&PayloadBuilder{Engine: engine}).Build(context.Background(), driver.L2Heads(), attrs)
+	require.NoError(t, err)
+
+	orphan := *payload
+	orphan.ParentHash = common.Hash{0xaa}
+	require.Error(t, driver.ImportUnsafePayload(context.Background(), &orphan), "must build on the unsafe head")
+	misaligned := *payload
+	misaligned.Timestamp += 1
+	require.ErrorIs(t, driver.ImportUnsafePayload(context.Background(), &misaligned), InvalidTimestampErr)
+	skipAttrs, err := SequencerBlockInputs(&Config{}, BlockInputFromHeader((*l1)[0].Header()), 2, nil, 1002)
+	require.NoError(t, err)
+	skipped, err := (&PayloadBuilder{Engine: engine}).Build(context.Background(), driver.L2Heads(), skipAttrs)
+	require.NoError(t, err)
+	require.ErrorIs(t, driver.ImportUnsafePayload(context.Background(), skipped), InvalidEpochErr, "must continue the epoch of the parent")
+
+	require.NoError(t, driver.ImportUnsafePayload(context.Background(), payload))
+	require.Equal(t, payload.ID(), driver.L2Head())
+	require.Equal(t, genesis.L1, driver.L1Head(), "L1 origin is parsed from the L1 info deposit")
+	require.Equal(t, genesis.L2, driver.L2Heads().Safe, "unsafe payloads do not advance the safe head")
+}
+
+func TestEngineDriver_SequencerHandover(t *testing.T) {
+	l1 := new(fakeSeqL1)
+	l1.add(1000)
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesis := Genesis{L1: l1.id(0), L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+	engine := newFakeSeqEngine(l2Genesis)
+
+	driver := &EngineDriver{
+		Log: log.New(),
+		RPC: engine,
+		Sequencer: &Sequencer{
+			Log:     log.New(),
+			Config:  &Config{BlockTime: 100, MaxSequencerDrift: 1000},
+			Genesis: &genesis,
+			RPC:     engine,
+			L1:      l1,
+			DL:      l1,
+		},
+		EngineDriverState: EngineDriverState{Genesis: genesis},
+	}
+	driver.UpdateUnsafeHead(genesis.L1, genesis.L2)
+
+	ctx := context.Background()
+	require.Error(t, driver.StartSequencer(ctx, genesis.L2.Hash), "not stopped")
+	sub := driver.Drive(ctx, make(chan eth.HeadSignal))
+	defer sub.Unsubscribe()
+
+	handover, err := driver.StopSequencer(ctx)
+	require.NoError(t, err)
+	require.Equal(t, driver.L2Head().Hash, handover)
+	_, err = driver.StopSequencer(ctx)
+	require.Error(t, err, "already stopped")
+
+	require.Error(t, driver.StartSequencer(ctx, common.Hash{0xaa}), "must start at the handover point")
+	require.NoError(t, driver.StartSequencer(ctx, handover))
+	require.Error(t, driver.StartSequencer(ctx, handover), "already started")
+}
@@ -0,0 +1,151 @@
+package l2
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// JWTClockSkewTolerance is the maximum difference between the issued-at time of a token and the clock of the engine,
+// within which the engine accepts the token.
+const JWTClockSkewTolerance = time.Second * 5
+
+// JWTSecret is the secret shared with the engine, to authenticate engine API requests with
+type JWTSecret [32]byte
+
+// LoadJWTSecret reads the JWT secret from a file with the hex-encoded secret, optionally 0x-prefixed,
+// like the secret files of the execution engines.
+func LoadJWTSecret(path string) (JWTSecret, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return JWTSecret{}, fmt.Errorf("failed to read JWT secret file %q: %v", path, err)
+	}
+	text := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(text, "0x") {
+		text = "0x" + text
+	}
+	raw, err := hexutil.Decode(text)
+	if err != nil {
+		return JWTSecret{}, fmt.Errorf("failed to decode JWT secret file %q: %v", path, err)
+	}
+	var secret JWTSecret
+	if len(raw) != len(secret) {
+		return JWTSecret{}, fmt.Errorf("JWT secret in %q must be %d bytes, got %d", path, len(secret), len(raw))
+	}
+	copy(secret[:], raw)
+	return secret, nil
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// Token creates a HS256 JWT with the given issued-at time as only claim
+func (s JWTSecret) Token(iat time.Time) (string, error) {
+	claims, err := json.Marshal(struct {
+		IssuedAt int64 `json:"iat"`
+	}{IssuedAt: iat.Unix()})
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(claims)
+	mac := hmac.New(sha256.New, s[:])
+	mac.Write([]byte(unsigned))
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+// JWTTransport authenticates every HTTP request with a new bearer token, issued at the time of the request.
+//
+// If the engine rejects a request as unauthorized, while its clock is off by more than JWTClockSkewTolerance,
+// the transport adopts the clock offset of the engine for the issued-at times, and retries the request once.
+type JWTTransport struct {
+	Secret JWTSecret
+	// Base is optional, the transport to send the authenticated requests with. Defaults to http.DefaultTransport.
+	Base http.RoundTripper
+	// Now is optional, the local clock. Defaults to time.Now.
+	Now func() time.Time
+
+	// offset of the engine clock relative to the local clock, in nanoseconds (atomic)
+	skew int64
+}
+
+func (t *JWTTransport) now() time.Time {
+	if t.Now != nil {
+		return t.Now()
+	}
+	return time.Now()
+}
+
+func (t *JWTTransport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+func (t *JWTTransport) send(req *http.Request) (*http.Response, error) {
+	token, err := t.Secret.Token(t.now().Add(time.Duration(atomic.LoadInt64(&t.skew))))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create JWT: %v", err)
+	}
+	// a RoundTripper must not modify the original request
+	authReq := req.Clone(req.Context())
+	authReq.Header.Set("Authorization", "Bearer "+token)
+	return t.base().RoundTrip(authReq)
+}
+
+func (t *JWTTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.send(req)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized || req.GetBody == nil {
+		return resp, err
+	}
+	engineTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		return resp, nil
+	}
+	skew := engineTime.Sub(t.now())
+	if skew < JWTClockSkewTolerance && skew > -JWTClockSkewTolerance {
+		return resp, nil // unauthorized for other reasons, e.g. a wrong secret
+	}
+	atomic.StoreInt64(&t.skew, int64(skew))
+	resp.Body.Close()
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	retry := req.Clone(req.Context())
+	retry.Body = body
+	return t.send(retry)
+}
+
+// DialEngine connects to the engine API at the given address. With a JWT secret the requests are authenticated,
+// which is supported over HTTP. IPC connections are local and are not authenticated.
+func DialEngine(ctx context.Context, addr string, secret *JWTSecret) (*rpc.Client, error) {
+	if secret == nil {
+		return rpc.DialContext(ctx, addr)
+	}
+	transport, err := eth.DetectTransport(addr)
+	if err != nil {
+		return nil, err
+	}
+	switch transport {
+	case eth.HTTPTransport:
+		return rpc.DialHTTPWithClient(addr, &http.Client{Transport: &JWTTransport{Secret: *secret}})
+	case eth.IPCTransport:
+		return rpc.DialContext(ctx, addr)
+	default:
+		return nil, errors.New("JWT authentication of the engine API is only supported over HTTP and IPC")
+	}
+}
@@ -0,0 +1,105 @@
+package l2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// checkJWT verifies the bearer token of the request, and returns its issued-at time
+func checkJWT(t *testing.T, secret JWTSecret, r *http.Request) time.Time {
+	auth := r.Header.Get("Authorization")
+	require.True(t, strings.HasPrefix(auth, "Bearer "))
+	parts := strings.Split(strings.TrimPrefix(auth, "Bearer "), ".")
+	require.Len(t, parts, 3)
+
+	mac := hmac.New(sha256.New, secret[:])
+	mac.Write([]byte(parts[0] + "." + parts[1]))
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	require.NoError(t, err)
+	require.True(t, hmac.Equal(mac.Sum(nil), sig), "valid signature")
+
+	header, err := base64.RawURLEncoding.DecodeString(parts[0])
+	require.NoError(t, err)
+	require.JSONEq(t, `{"alg":"HS256","typ":"JWT"}`, string(header))
+	claims, err := base64.RawURLEncoding.DecodeString(parts[1])
+	require.NoError(t, err)
+	var c struct {
+		IssuedAt int64 `json:"iat"`
+	}
+	require.NoError(t, json.Unmarshal(claims, &c))
+	return time.Unix(c.IssuedAt, 0)
+}
+
+func TestLoadJWTSecret(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "jwt.hex")
+	require.NoError(t, os.WriteFile(path, []byte("0x"+strings.Repeat("ab", 32)+"\n"), 0600))
+	secret, err := LoadJWTSecret(path)
+	require.NoError(t, err)
+	require.Equal(t, byte(0xab), secret[31])
+
+	require.NoError(t, os.WriteFile(path, []byte(strings.Repeat("cd", 32)), 0600))
+	secret, err = LoadJWTSecret(path)
+	require.NoError(t, err, "prefix is optional")
+	require.Equal(t, byte(0xcd), secret[0])
+
+	require.NoError(t, os.WriteFile(path, []byte("0x1234"), 0600))
+	_, err = LoadJWTSecret(path)
+	require.Error(t, err, "secret too short")
+}
+
+func TestJWTTransport(t *testing.T) {
+	secret := JWTSecret{1, 2, 3}
+	engineSkew := time.Minute * 2
+	var iats []time.Time
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iat := checkJWT(t, secret, r)
+		iats = append(iats, iat)
+		engineNow := time.Now().Add(engineSkew)
+		w.Header().Set("Date", engineNow.UTC().Format(http.TimeFormat))
+		if d := engineNow.Sub(iat); d > JWTClockSkewTolerance || d < -JWTClockSkewTolerance {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &JWTTransport{Secret: secret}}
+	resp, err := client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode, "retried with the clock of the engine")
+	require.Len(t, iats, 2)
+
+	resp, err = client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Len(t, iats, 3, "the clock offset is remembered")
+
+	// a wrong secret is not retried
+	iats = nil
+	engineSkew = 0
+	client = &http.Client{Transport: &JWTTransport{Secret: JWTSecret{4}}}
+	srv.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		iats = append(iats, time.Now())
+		w.WriteHeader(http.StatusUnauthorized)
+	})
+	resp, err = client.Post(srv.URL, "application/json", strings.NewReader(`{}`))
+	require.NoError(t, err)
+	resp.Body.Close()
+	require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	require.Len(t, iats, 1)
+}
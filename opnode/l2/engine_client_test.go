@@ -0,0 +1,117 @@
+package l2
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testlog"
+)
+
+type testRPCErr struct {
+	code int
+	msg  string
+}
+
+func (e *testRPCErr) Error() string  { return e.msg }
+func (e *testRPCErr) ErrorCode() int { return e.code }
+
+// testEngineRPC is a RPCBackend that serves JSON results, or errors, by method
+type testEngineRPC struct {
+	results map[string]string
+	errs    map[string]error
+	calls   []string
+}
+
+func (r *testEngineRPC) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	r.calls = append(r.calls, method)
+	if err, ok := r.errs[method]; ok {
+		return err
+	}
+	return json.Unmarshal([]byte(r.results[method]), result)
+}
+
+func (r *testEngineRPC) Close() {}
+
+func TestPayloadIDJSON(t *testing.T) {
+	id := PayloadID{1, 2, 3, 4, 5, 6, 7, 8}
+	data, err := json.Marshal(id)
+	require.NoError(t, err)
+	assert.Equal(t, `"0x0102030405060708"`, string(data))
+	var out PayloadID
+	require.NoError(t, json.Unmarshal(data, &out))
+	assert.Equal(t, id, out)
+	assert.Error(t, json.Unmarshal([]byte(`"0x01020304"`), &out), "payload IDs are 8 bytes")
+}
+
+func TestForkchoiceUpdatedResultJSON(t *testing.T) {
+	var res ForkchoiceUpdatedResult
+	require.NoError(t, json.Unmarshal([]byte(`{"payloadStatus":{"status":"SYNCING","latestValidHash":null,"validationError":null},"payloadId":null}`), &res))
+	assert.Equal(t, ExecutionSyncing, res.PayloadStatus.Status)
+	assert.Nil(t, res.PayloadID)
+
+	// pre-release engine API versions
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"SUCCESS","payloadId":"0x0000000000000001"}`), &res))
+	assert.Equal(t, ExecutionValid, res.PayloadStatus.Status)
+	require.NotNil(t, res.PayloadID)
+	assert.Equal(t, PayloadID{7: 1}, *res.PayloadID)
+}
+
+func TestClassifyEngineErr(t *testing.T) {
+	assert.NoError(t, ClassifyEngineErr(nil))
+	assert.ErrorIs(t, ClassifyEngineErr(&testRPCErr{code: int(UnknownPayload)}), UnknownPayloadErr)
+	assert.ErrorIs(t, ClassifyEngineErr(&testRPCErr{code: int(UnavailablePayload)}), UnknownPayloadErr)
+	assert.ErrorIs(t, ClassifyEngineErr(&testRPCErr{code: int(InvalidForkchoiceState)}), InvalidForkchoiceStateErr)
+	assert.ErrorIs(t, ClassifyEngineErr(&testRPCErr{code: int(InvalidPayloadAttributes)}), InvalidPayloadAttributesErr)
+	assert.ErrorIs(t, ClassifyEngineErr(&testRPCErr{code: int(InvalidParams)}), EngineRequestErr)
+
+	netErr := errors.New("connection refused")
+	err := ClassifyEngineErr(netErr)
+	assert.ErrorIs(t, err, eth.TemporaryRPCErr)
+	assert.ErrorIs(t, err, netErr, "the original error is preserved")
+	assert.Equal(t, err, ClassifyEngineErr(err), "already classified errors are not classified again")
+	assert.Equal(t, context.Canceled, ClassifyEngineErr(context.Canceled))
+}
+
+func TestEngineClient_NewPayload(t *testing.T) {
+	ctx := context.Background()
+	rpc := &testEngineRPC{results: map[string]string{
+		"engine_newPayloadV1": `{"status":"VALID","latestValidHash":"0x0100000000000000000000000000000000000000000000000000000000000000"}`,
+	}}
+	client := &EngineClient{RPCBackend: rpc, Log: testlog.Logger(t, log.LvlTrace)}
+	res, err := client.NewPayload(ctx, &ExecutionPayload{})
+	require.NoError(t, err)
+	assert.Equal(t, ExecutionValid, res.Status)
+	require.NotNil(t, res.LatestValidHash)
+	assert.Equal(t, common.Hash{1}, *res.LatestValidHash)
+}
+
+func TestEngineClient_NewPayloadLegacy(t *testing.T) {
+	ctx := context.Background()
+	rpc := &testEngineRPC{
+		results: map[string]string{"engine_executePayloadV1": `{"status":"SYNCING"}`},
+		errs:    map[string]error{"engine_newPayloadV1": &testRPCErr{code: int(MethodNotFound), msg: "method not found"}},
+	}
+	client := &EngineClient{RPCBackend: rpc, Log: testlog.Logger(t, log.LvlTrace)}
+	for i := 0; i < 2; i++ {
+		res, err := client.NewPayload(ctx, &ExecutionPayload{})
+		require.NoError(t, err)
+		assert.Equal(t, ExecutionSyncing, res.Status)
+	}
+	assert.Equal(t, []string{"engine_newPayloadV1", "engine_executePayloadV1", "engine_executePayloadV1"}, rpc.calls,
+		"the legacy method is remembered")
+}
+
+func TestEngineClient_GetPayloadErr(t *testing.T) {
+	rpc := &testEngineRPC{errs: map[string]error{"engine_getPayloadV1": &testRPCErr{code: int(UnknownPayload), msg: "unknown payload"}}}
+	client := &EngineClient{RPCBackend: rpc, Log: testlog.Logger(t, log.LvlTrace)}
+	_, err := client.GetPayload(context.Background(), PayloadID{})
+	assert.ErrorIs(t, err, UnknownPayloadErr)
+}
@@ -0,0 +1,73 @@
+package l2
+
+import (
+	"context"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// Engine API calls fail with one of these errors (wrapped), or with eth.TemporaryRPCErr if the call itself failed,
+// e.g. a network failure or timeout, so the caller can decide how to proceed.
+var (
+	// UnknownPayloadErr is returned when the engine does not know the requested payload, e.g. it was already evicted.
+	UnknownPayloadErr = errors.New("unknown payload")
+	// InvalidForkchoiceStateErr is returned when the forkchoice state is inconsistent, e.g. the head is not a descendant of the safe block.
+	InvalidForkchoiceStateErr = errors.New("invalid forkchoice state")
+	// InvalidPayloadAttributesErr is returned when the payload attributes are invalid, e.g. a timestamp before the parent.
+	InvalidPayloadAttributesErr = errors.New("invalid payload attributes")
+	// EngineRequestErr is returned when the engine rejected the request itself, e.g. an unsupported method or invalid params.
+	EngineRequestErr = errors.New("engine rejected request")
+)
+
+// InvalidPayloadErr is returned when the engine rejects a payload, or the head of a forkchoice update, as invalid.
+var InvalidPayloadErr = errors.New("invalid payload")
+
+// engineErr classifies an error as one of the engine API errors, while preserving the original error.
+type engineErr struct {
+	kind error
+	err  error
+}
+
+func (e *engineErr) Error() string {
+	return e.kind.Error() + ": " + e.err.Error()
+}
+
+func (e *engineErr) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *engineErr) Unwrap() error {
+	return e.err
+}
+
+// ClassifyEngineErr classifies an error returned by the engine API by its JSON-RPC error code:
+// the engine API error codes as UnknownPayloadErr, InvalidForkchoiceStateErr and InvalidPayloadAttributesErr,
+// other JSON-RPC errors as EngineRequestErr, and errors without code as eth.TemporaryRPCErr.
+// Nil, errors that are already classified, and context errors of the caller are returned as-is.
+func ClassifyEngineErr(err error) error {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return err
+	}
+	for _, kind := range []error{UnknownPayloadErr, InvalidForkchoiceStateErr, InvalidPayloadAttributesErr, EngineRequestErr, eth.TemporaryRPCErr} {
+		if errors.Is(err, kind) {
+			return err
+		}
+	}
+	var rpcErr rpc.Error
+	if !errors.As(err, &rpcErr) {
+		return &engineErr{kind: eth.TemporaryRPCErr, err: err}
+	}
+	switch ErrorCode(rpcErr.ErrorCode()) {
+	case UnknownPayload, UnavailablePayload:
+		return &engineErr{kind: UnknownPayloadErr, err: err}
+	case InvalidForkchoiceState:
+		return &engineErr{kind: InvalidForkchoiceStateErr, err: err}
+	case InvalidPayloadAttributes:
+		return &engineErr{kind: InvalidPayloadAttributesErr, err: err}
+	default:
+		return &engineErr{kind: EngineRequestErr, err: err}
+	}
+}
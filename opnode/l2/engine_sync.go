@@ -0,0 +1,116 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxQueuedPayloads bounds the number of payloads that are queued while the engine is syncing
+const maxQueuedPayloads = 256
+
+// EngineSyncingErr is returned when the engine is syncing, e.g. snap-syncing, and cannot build or validate payloads yet.
+var EngineSyncingErr = errors.New("engine is syncing")
+
+// EngineSync coordinates the driver with an engine that is still syncing, e.g. an execution client that is snap-syncing.
+// It wraps the engine API of the engine:
+//   - Payloads that the engine cannot validate yet (SYNCING or ACCEPTED) are queued, to send them again once the engine caught up.
+//   - While the engine is syncing, payload attributes are not sent to the engine: the last forkchoice is sent again instead,
+//     and only once the engine reports it caught up, the payload building continues. Otherwise EngineSyncingErr is returned.
+//
+// The driver retries its steps periodically, which re-sends the forkchoice until the engine caught up.
+type EngineSync struct {
+	DriverAPI
+	Log log.Logger
+
+	mu      sync.Mutex
+	syncing bool
+	// last forkchoice state sent to the engine, to send again while syncing
+	forkchoice *ForkchoiceState
+	queue      []*ExecutionPayload
+}
+
+// Syncing returns true if the engine reported that it is syncing, and has not caught up since
+func (s *EngineSync) Syncing() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.syncing
+}
+
+func (s *EngineSync) setSyncing(status ExecutePayloadStatus) {
+	if !s.syncing {
+		s.Log.Warn("Engine is syncing, pausing payload building until it caught up", "status", status)
+	}
+	s.syncing = true
+}
+
+// CheckSynced sends the last forkchoice to the engine again if it is syncing, and returns true if the engine caught up.
+// Once caught up, the queued payloads are sent to the engine, in order.
+func (s *EngineSync) CheckSynced(ctx context.Context) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.checkSynced(ctx)
+}
+
+func (s *EngineSync) checkSynced(ctx context.Context) bool {
+	if !s.syncing {
+		return true
+	}
+	for len(s.queue) > 0 {
+		res, err := s.DriverAPI.NewPayload(ctx, s.queue[0])
+		if err != nil || res.Status == ExecutionSyncing || res.Status == ExecutionAccepted {
+			return false
+		}
+		if res.Status != ExecutionValid {
+			s.Log.Warn("Engine rejected queued payload", "payload", s.queue[0].ID(), "status", res.Status)
+		}
+		s.queue = s.queue[1:]
+	}
+	if s.forkchoice != nil {
+		res, err := s.DriverAPI.ForkchoiceUpdated(ctx, s.forkchoice, nil)
+		if err != nil || res.PayloadStatus.Status == ExecutionSyncing {
+			return false
+		}
+	}
+	s.Log.Info("Engine caught up, resuming payload building")
+	s.syncing = false
+	return true
+}
+
+func (s *EngineSync) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	res, err := s.DriverAPI.NewPayload(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	if res.Status == ExecutionSyncing || res.Status == ExecutionAccepted {
+		s.mu.Lock()
+		s.setSyncing(res.Status)
+		if len(s.queue) < maxQueuedPayloads {
+			s.queue = append(s.queue, payload)
+		} else {
+			s.Log.Warn("Payload queue is full, dropping payload", "payload", payload.ID())
+		}
+		s.mu.Unlock()
+	}
+	return res, nil
+}
+
+func (s *EngineSync) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if attr != nil && !s.checkSynced(ctx) {
+		return ForkchoiceUpdatedResult{}, fmt.Errorf("cannot build payload on %s: %w", state.HeadBlockHash, EngineSyncingErr)
+	}
+	res, err := s.DriverAPI.ForkchoiceUpdated(ctx, state, attr)
+	if err != nil {
+		return res, err
+	}
+	s.forkchoice = state
+	if res.PayloadStatus.Status == ExecutionSyncing {
+		s.setSyncing(res.PayloadStatus.Status)
+	}
+	return res, nil
+}
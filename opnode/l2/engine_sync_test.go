@@ -0,0 +1,83 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// snapSyncingEngine reports SYNCING for all payloads and forkchoice updates while it is syncing
+type snapSyncingEngine struct {
+	*fakeSeqEngine
+	syncing bool
+	calls   []string
+}
+
+func (s *snapSyncingEngine) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	s.calls = append(s.calls, "newPayload")
+	if s.syncing {
+		return &PayloadStatusV1{Status: ExecutionSyncing}, nil
+	}
+	return s.fakeSeqEngine.NewPayload(ctx, payload)
+}
+
+func (s *snapSyncingEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	if attr != nil {
+		s.calls = append(s.calls, "forkchoiceUpdated+attributes")
+	} else {
+		s.calls = append(s.calls, "forkchoiceUpdated")
+	}
+	if s.syncing {
+		return ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionSyncing}}, nil
+	}
+	return s.fakeSeqEngine.ForkchoiceUpdated(ctx, state, attr)
+}
+
+func TestEngineSync(t *testing.T) {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesisID := eth.BlockID{Hash: genesis.Hash(), Number: 0}
+	heads := L2Heads{Unsafe: genesisID, Safe: genesisID, Finalized: genesisID}
+	attrs := &PayloadAttributes{Timestamp: 1002, Random: Bytes32(common.Hash{1})}
+	ctx := context.Background()
+
+	engine := &snapSyncingEngine{fakeSeqEngine: newFakeSeqEngine(genesis)}
+	// build a payload while the engine is synced, and then let the engine fall behind
+	payload, err := DeriveBlockOutputs(ctx, engine, heads, attrs)
+	require.NoError(t, err)
+	engine.syncing = true
+	engine.calls = nil
+
+	es := &EngineSync{DriverAPI: engine, Log: log.New()}
+	require.True(t, es.CheckSynced(ctx))
+	err = Execute(ctx, es, payload)
+	require.ErrorIs(t, err, EngineSyncingErr)
+	require.True(t, es.Syncing())
+
+	_, err = DeriveBlockOutputs(ctx, es, heads, attrs)
+	require.ErrorIs(t, err, EngineSyncingErr)
+	require.False(t, es.CheckSynced(ctx))
+	require.Equal(t, []string{"newPayload", "newPayload", "newPayload"}, engine.calls,
+		"queued payload is sent again, attributes are not sent while syncing")
+
+	engine.syncing = false
+	engine.calls = nil
+	require.True(t, es.CheckSynced(ctx))
+	require.False(t, es.Syncing())
+	require.Equal(t, []string{"newPayload"}, engine.calls, "the queued payload is sent once the engine caught up")
+	require.Contains(t, engine.blocks, payload.BlockHash)
+
+	require.NoError(t, ForkchoiceUpdate(ctx, es, L2Heads{Unsafe: payload.ID(), Safe: payload.ID(), Finalized: genesisID}))
+	engine.syncing = true
+	require.ErrorIs(t, ForkchoiceUpdate(ctx, es, heads), EngineSyncingErr)
+	engine.syncing = false
+	engine.calls = nil
+	require.True(t, es.CheckSynced(ctx))
+	require.Equal(t, []string{"forkchoiceUpdated"}, engine.calls, "the last forkchoice is sent again")
+}
@@ -0,0 +1,34 @@
+package l2
+
+import (
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DeriveEpoch derives the block inputs of all the L2 blocks of the epoch of the L1 origin, in order,
+// from the receipts of the origin and the batches that were included within the sequencing window of the epoch.
+//
+// Every L1 block is the origin of a single L2 block, with the timestamp of the origin:
+// the L1 info deposit and user deposits of the origin, followed by the transactions of the batch
+// that matches the origin and timestamp. Batches of other epochs or L2 blocks are ignored.
+// Without such a batch, the L2 block only includes the deposits.
+func DeriveEpoch(origin BlockInput, originReceipts []*types.Receipt, window []BatchData, cfg *Config) ([]*PayloadAttributes, error) {
+	// the batches of the epoch are read from the sequencing window, not from the origin block itself
+	depositsCfg := *cfg
+	depositsCfg.BatchInboxAddr = common.Address{}
+	attrs, err := DeriveBlockInputs(&depositsCfg, origin, originReceipts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive block inputs from L1 block %s: %v", origin.Hash(), err)
+	}
+	for i := range window {
+		batch := &window[i]
+		if batch.EpochNum == origin.NumberU64() && batch.EpochHash == origin.Hash() && batch.Timestamp == origin.Time() {
+			// sequenced transactions follow after the deposits
+			attrs.Transactions = append(attrs.Transactions, batch.Transactions...)
+			break
+		}
+	}
+	return []*PayloadAttributes{attrs}, nil
+}
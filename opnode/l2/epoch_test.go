@@ -0,0 +1,51 @@
+package l2
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeriveEpoch(t *testing.T) {
+	cfg := &Config{}
+	origin := BlockInputFromHeader(&types.Header{Number: big.NewInt(7), Time: 1000, ReceiptHash: types.EmptyRootHash, BaseFee: big.NewInt(7)})
+	batch := func(epochNum uint64, timestamp uint64) BatchData {
+		tx, err := types.NewTx(&types.DynamicFeeTx{Nonce: timestamp, Gas: 21000, To: &common.Address{0x42}}).MarshalBinary()
+		require.NoError(t, err)
+		return BatchData{EpochNum: epochNum, EpochHash: origin.Hash(), Timestamp: timestamp, Transactions: []Data{tx}}
+	}
+	deposits, err := DeriveBlockInputs(cfg, origin, nil)
+	require.NoError(t, err)
+
+	t.Run("full", func(t *testing.T) {
+		b := batch(7, 1000)
+		attrs, err := DeriveEpoch(origin, nil, []BatchData{b}, cfg)
+		require.NoError(t, err)
+		require.Len(t, attrs, 1, "every L1 block is the origin of a single L2 block")
+		require.Equal(t, Uint64Quantity(1000), attrs[0].Timestamp)
+		require.Equal(t, append(deposits.Transactions, b.Transactions...), attrs[0].Transactions)
+	})
+	t.Run("partial", func(t *testing.T) {
+		b := batch(7, 1000)
+		window := []BatchData{batch(8, 1000), batch(7, 1002), b, batch(7, 1000)}
+		attrs, err := DeriveEpoch(origin, nil, window, cfg)
+		require.NoError(t, err)
+		require.Len(t, attrs, 1)
+		require.Equal(t, append(deposits.Transactions, b.Transactions...), attrs[0].Transactions,
+			"batches of other epochs and L2 blocks are ignored, and only the first matching batch is included")
+	})
+	t.Run("empty", func(t *testing.T) {
+		attrs, err := DeriveEpoch(origin, nil, nil, cfg)
+		require.NoError(t, err)
+		require.Len(t, attrs, 1)
+		require.Equal(t, deposits, attrs[0], "without batch, the L2 block only includes the deposits")
+	})
+	t.Run("invalid", func(t *testing.T) {
+		invalid := BlockInputFromHeader(&types.Header{Number: big.NewInt(7), Time: 1000, ReceiptHash: common.Hash{0x01}, BaseFee: big.NewInt(7)})
+		_, err := DeriveEpoch(invalid, nil, nil, cfg)
+		require.Error(t, err, "receipts must match the origin")
+	})
+}
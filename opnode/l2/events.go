@@ -0,0 +1,36 @@
+package l2
+
+import (
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// AttributesDerivedEvent is published when the payload attributes of a L2 block are derived from a L1 block
+type AttributesDerivedEvent struct {
+	L1         eth.BlockID
+	L2Parent   eth.BlockID
+	Attributes *PayloadAttributes
+}
+
+// PayloadInsertedEvent is published when a derived payload is executed by the engine
+type PayloadInsertedEvent struct {
+	L1      eth.BlockID
+	Payload *ExecutionPayload
+}
+
+// InvalidPayloadEvent is published when the engine rejects a L2 block as invalid,
+// after the driver unwound the unsafe L2 head back to the safe L2 head, to derive again from there.
+type InvalidPayloadEvent struct {
+	// L1 is the L1 block that the invalid L2 block was derived from, or the L1 origin of the invalid sequenced block
+	L1 eth.BlockID
+	// Unwound is the unsafe L2 head before unwinding, and Safe the L2 head after unwinding
+	Unwound eth.BlockID
+	Safe    eth.BlockID
+	// L1Head is the L1 block that the safe L2 head was derived from, to derive again from
+	L1Head eth.BlockID
+	Err    error
+}
+
+// ForkchoiceUpdatedEvent is published when the forkchoice of the engine is updated
+type ForkchoiceUpdatedEvent struct {
+	Heads L2Heads
+}
@@ -0,0 +1,36 @@
+package l2
+
+import (
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// maxSafeOrigins bounds the number of derived L2 blocks that are remembered to finalize once their L1 origin is final
+const maxSafeOrigins = 1024
+
+// L2Heads is the forkchoice of the L2 chain, as maintained by the driver and sent to the engine:
+//   - Unsafe is the head of the L2 chain. It may be a sequenced block that is not derived from L1 yet.
+//   - Safe is the last L2 block that was derived from L1, from the safe L1 head or its ancestors if the safe L1 head is known.
+//     It only reorgs if L1 reorgs.
+//   - Finalized is the last L2 block that was derived from a finalized L1 block. It never reorgs.
+//
+// The safe block is always the unsafe head or an ancestor of it, and the finalized block is the safe block or an ancestor of it.
+type L2Heads struct {
+	Unsafe    eth.BlockID
+	Safe      eth.BlockID
+	Finalized eth.BlockID
+}
+
+// ForkchoiceState is the engine API representation of the L2 heads
+func (h L2Heads) ForkchoiceState() *ForkchoiceState {
+	return &ForkchoiceState{
+		HeadBlockHash:      h.Unsafe.Hash,
+		SafeBlockHash:      h.Safe.Hash,
+		FinalizedBlockHash: h.Finalized.Hash,
+	}
+}
+
+// safeOrigin is a safe L2 block with the L1 block it was derived from
+type safeOrigin struct {
+	l1 eth.BlockID
+	l2 eth.BlockID
+}
@@ -0,0 +1,129 @@
+//go:build go1.18
+// +build go1.18
+
+package l2
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+// Native fuzz targets, run with e.g.: go test ./l2 -run=^$ -fuzz=FuzzUnmarshalLogEvent -fuzztime=1m
+// Without -fuzz the seed corpus runs as regular tests.
+
+// fuzzLogTopics splits the fuzz input into 32-byte topics, ignoring any remainder.
+func fuzzLogTopics(topics []byte) []common.Hash {
+	out := make([]common.Hash, 0, len(topics)/32)
+	for i := 0; i+32 <= len(topics); i += 32 {
+		out = append(out, common.BytesToHash(topics[i:i+32]))
+	}
+	return out
+}
+
+func joinTopics(topics []common.Hash) []byte {
+	out := make([]byte, 0, len(topics)*32)
+	for _, topic := range topics {
+		out = append(out, topic[:]...)
+	}
+	return out
+}
+
+func FuzzUnmarshalLogEvent(f *testing.F) {
+	// seeded with the deposit logs of the L1 receipts fixture, and generated deposits
+	receiptsJSON, err := os.ReadFile("testdata/l1_receipts.json")
+	require.NoError(f, err)
+	var receipts []*types.Receipt
+	require.NoError(f, json.Unmarshal(receiptsJSON, &receipts))
+	for _, rec := range receipts {
+		for _, ev := range rec.Logs {
+			if ev.Address == DepositContractAddr {
+				f.Add(joinTopics(ev.Topics), ev.Data)
+			}
+		}
+	}
+	rng := rand.New(rand.NewSource(1234))
+	for i := 0; i < 10; i++ {
+		ev := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(1, 1, rng))
+		f.Add(joinTopics(ev.Topics), ev.Data)
+	}
+
+	f.Fuzz(func(t *testing.T, topics []byte, data []byte) {
+		ev := testutil.GenerateLog(DepositContractAddr, fuzzLogTopics(topics), data)
+		dep, err := UnmarshalLogEvent(1, 1, ev)
+		if err != nil {
+			return
+		}
+		// decoding is strict: an accepted log is the canonical encoding of the deposit
+		reEncoded := MarshalDepositLogEvent(DepositContractAddr, dep)
+		assert.Equal(t, ev.Topics, reEncoded.Topics, "topics must round-trip")
+		assert.Equal(t, ev.Data, reEncoded.Data, "data must round-trip")
+	})
+}
+
+func FuzzDecodeBatch(f *testing.F) {
+	for _, batch := range []*BatchData{{}, {Transactions: []Data{testL2Tx(f, 0), testL2Tx(f, 1)}}} {
+		data, err := EncodeBatch(batch)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		batch, err := DecodeBatch(data)
+		if err != nil {
+			return
+		}
+		reEncoded, err := EncodeBatch(batch)
+		require.NoError(t, err)
+		assert.Equal(t, data, reEncoded, "batch must round-trip")
+	})
+}
+
+func FuzzDecodeFrames(f *testing.F) {
+	for _, frames := range [][]Frame{testFrames(1, []byte("hello world"), 3), testFrames(2, nil, 1)} {
+		data, err := EncodeFrames(frames)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		frames, err := DecodeFrames(data)
+		if err != nil {
+			return
+		}
+		reEncoded, err := EncodeFrames(frames)
+		require.NoError(t, err)
+		assert.Equal(t, data, reEncoded, "frames must round-trip")
+	})
+}
+
+func FuzzDecodeChannel(f *testing.F) {
+	batches := []*BatchData{{Transactions: []Data{testL2Tx(f, 0)}}, {}}
+	for _, algo := range []CompressionAlgo{NoCompression, ZlibCompression} {
+		data, err := EncodeChannel(batches, algo)
+		require.NoError(f, err)
+		f.Add(data)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		batches, err := DecodeChannel(data)
+		if err != nil {
+			return
+		}
+		// compression is not canonical, but the decoded batches must survive re-encoding
+		reEncoded, err := EncodeChannel(batches, NoCompression)
+		require.NoError(t, err)
+		decoded, err := DecodeChannel(reEncoded)
+		require.NoError(t, err)
+		if len(batches) == 0 {
+			assert.Empty(t, decoded)
+		} else {
+			assert.Equal(t, batches, decoded)
+		}
+	})
+}
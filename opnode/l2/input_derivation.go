@@ -2,21 +2,30 @@ package l2
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"math/big"
 
+	"github.com/ethereum-optimism/optimistic-specs/opnode/contracts/deposit"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/trie"
-	"github.com/holiman/uint256"
 )
 
+var (
+	DepositGasLimitErr   = errors.New("deposit gas limit exceeded")
+	DepositCountLimitErr = errors.New("deposit count limit exceeded")
+)
+
+// L1InfoDepositIndex is the transaction index of the L1 info deposit, reserved in every L2 block.
+const L1InfoDepositIndex = 0
+
 var (
 	DepositEventABI     = "TransactionDeposited(address,address,uint256,uint256,uint256,bool,bytes)"
 	DepositEventABIHash = crypto.Keccak256Hash([]byte(DepositEventABI))
 	DepositContractAddr = common.HexToAddress("0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001")
-	L1InfoFuncSignature = "setL1BlockValues(uint256 _number, uint256 _timestamp, uint256 _basefee, bytes32 _hash)"
+	L1InfoFuncSignature = "setL1BlockValues(uint256,uint256,uint256,bytes32,uint64,bytes32,uint256,uint256)"
 	L1InfoFuncBytes4    = crypto.Keccak256([]byte(L1InfoFuncSignature))[:4]
 	L1InfoPredeployAddr = common.HexToAddress("0x4242424242424242424242424242424242424242")
 )
@@ -34,79 +43,94 @@ var (
 //    	 data data
 //     );
 //
+// The log is decoded with the deposit contract ABI, see unpackEventStrict for the strictness of the decoding.
+//
 // Deposits additionally get:
 //  - blockNum matching the L1 block height
 //  - txIndex: matching the deposit index, not L1 transaction index, since there can be multiple deposits per L1 tx
 func UnmarshalLogEvent(blockNum uint64, txIndex uint64, ev *types.Log) (*types.DepositTx, error) {
-	if len(ev.Topics) != 3 {
-		return nil, fmt.Errorf("expected 3 event topics (event identity, indexed from, indexed to)")
-	}
-	if ev.Topics[0] != DepositEventABIHash {
-		return nil, fmt.Errorf("invalid deposit event selector: %s, expected %s", ev.Topics[0], DepositEventABIHash)
-	}
-	if len(ev.Data) < 6*32 {
-		return nil, fmt.Errorf("deposit event data too small (%d bytes): %x", len(ev.Data), ev.Data)
+	var event deposit.DepositTransactionDeposited
+	if err := unpackEventStrict(DepositContractABI, "TransactionDeposited", ev, &event); err != nil {
+		return nil, err
 	}
 
 	var dep types.DepositTx
 
+	// TODO: set the UserDepositSource hash instead, once the DepositTx of the geth dependency has a SourceHash field
 	dep.BlockHeight = blockNum
 	dep.TransactionIndex = txIndex
+	dep.From = event.From
 
-	// indexed 0
-	dep.From = common.BytesToAddress(ev.Topics[1][12:])
-	// indexed 1
-	to := common.BytesToAddress(ev.Topics[2][12:])
-
-	// unindexed data
-	offset := uint64(0)
-	dep.Value = new(big.Int).SetBytes(ev.Data[offset : offset+32])
-	offset += 32
-
-	dep.Mint = new(big.Int).SetBytes(ev.Data[offset : offset+32])
+	dep.Mint = event.Mint
 	// 0 mint is represented as nil to skip minting code
-	if dep.Mint.Cmp(new(big.Int)) == 0 {
+	if dep.Mint.Sign() == 0 {
 		dep.Mint = nil
 	}
-	offset += 32
+	// 0 value is kept as-is, only the mint is normalized
+	dep.Value = event.Value
 
-	gas := new(big.Int).SetBytes(ev.Data[offset : offset+32])
-	if !gas.IsUint64() {
-		return nil, fmt.Errorf("bad gas value: %x", ev.Data[offset:offset+32])
+	if !event.GasLimit.IsUint64() {
+		return nil, fmt.Errorf("bad gas value: %s", event.GasLimit)
 	}
-	offset += 32
-	dep.Gas = gas.Uint64()
-	// isCreation: If the boolean byte is 1 then dep.To will stay nil,
+	dep.Gas = event.GasLimit.Uint64()
+	// isCreation: If the boolean is true then dep.To will stay nil,
 	// and it will create a contract using L2 account nonce to determine the created address.
-	if ev.Data[offset+31] == 0 {
+	// The deposit contract rejects a creation with a non-zero to address, such a log is not canonical.
+	if event.IsCreation && event.To != (common.Address{}) {
+		return nil, fmt.Errorf("contract creation with non-zero to address: %s", event.To)
+	}
+	if !event.IsCreation {
+		to := event.To
 		dep.To = &to
 	}
-	offset += 32
-	var dataOffset uint256.Int
-	dataOffset.SetBytes(ev.Data[offset : offset+32])
-	offset += 32
-	if dataOffset.Eq(uint256.NewInt(128)) {
-		return nil, fmt.Errorf("incorrect data offset: %v", dataOffset[0])
+	// a zero length results in empty (non-nil) data
+	dep.Data = event.Data
+
+	return &dep, nil
+}
+
+// MarshalDepositLogEvent encodes a deposit as the TransactionDeposited event log the deposit contract at
+// depositContractAddr emits for it, the inverse of UnmarshalLogEvent. The L1 block and index are not part of the log.
+func MarshalDepositLogEvent(depositContractAddr common.Address, deposit *types.DepositTx) *types.Log {
+	toBytes := common.Hash{}
+	if deposit.To != nil {
+		toBytes = deposit.To.Hash()
+	}
+	topics := []common.Hash{
+		DepositEventABIHash,
+		deposit.From.Hash(),
+		toBytes,
 	}
 
-	var dataLen uint256.Int
-	dataLen.SetBytes(ev.Data[offset : offset+32])
+	data := make([]byte, 6*32)
+	offset := 0
+	if deposit.Mint != nil {
+		deposit.Mint.FillBytes(data[offset : offset+32])
+	}
 	offset += 32
 
-	if !dataLen.IsUint64() {
-		return nil, fmt.Errorf("data too large: %s", dataLen.String())
+	deposit.Value.FillBytes(data[offset : offset+32])
+	offset += 32
+
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], deposit.Gas)
+	offset += 32
+	if deposit.To == nil { // isCreation
+		data[offset+31] = 1
 	}
-	// The data may be padded to a multiple of 32 bytes
-	maxExpectedLen := uint64(len(ev.Data)) - offset
-	dataLenU64 := dataLen.Uint64()
-	if dataLenU64 > maxExpectedLen {
-		return nil, fmt.Errorf("data length too long: %d, expected max %d", dataLenU64, maxExpectedLen)
+	offset += 32
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], 5*32)
+	offset += 32
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], uint64(len(deposit.Data)))
+	data = append(data, deposit.Data...)
+	if len(data)%32 != 0 { // pad to multiple of 32
+		data = append(data, make([]byte, 32-(len(data)%32))...)
 	}
 
-	// remaining bytes fill the data
-	dep.Data = ev.Data[offset : offset+dataLenU64]
-
-	return &dep, nil
+	return &types.Log{
+		Address: depositContractAddr,
+		Topics:  topics,
+		Data:    data,
+	}
 }
 
 type L1Info interface {
@@ -116,31 +140,73 @@ type L1Info interface {
 	BaseFee() *big.Int
 }
 
-func DeriveL1InfoDeposit(block L1Info) *types.DepositTx {
-	data := make([]byte, 4+8+8+32+32)
+// L1InfoDepositGas is the gas limit of the L1 info deposit
+const L1InfoDepositGas = 99_999_999
+
+// L1InfoDataLen is the length of the L1 info deposit calldata: the selector and 8 ABI-encoded arguments
+const L1InfoDataLen = 4 + 8*32
+
+// EncodeL1InfoData encodes the calldata of the L1 info deposit: the ABI-encoded setL1BlockValues call with the L1 block info,
+// the sequence number of the L2 block within the epoch of the L1 block,
+// the batcher hash, to verify the provenance of batches with on L2,
+// and the L1 fee overhead and scalar, for the L2 gas price oracle to compute the L1 data cost of transactions with.
+func EncodeL1InfoData(block L1Info, seqNumber uint64, batcherHash common.Hash, l1FeeOverhead common.Hash, l1FeeScalar common.Hash) ([]byte, error) {
+	baseFee := block.BaseFee()
+	if baseFee == nil {
+		return nil, errors.New("missing base fee")
+	}
+	if baseFee.Sign() < 0 || baseFee.BitLen() > 256 {
+		return nil, fmt.Errorf("base fee does not fit in 32 bytes: %s", baseFee)
+	}
+	data := make([]byte, L1InfoDataLen)
 	offset := 0
 	copy(data[offset:4], L1InfoFuncBytes4)
 	offset += 4
-	binary.BigEndian.PutUint64(data[offset:offset+8], block.NumberU64())
-	offset += 8
-	binary.BigEndian.PutUint64(data[offset:offset+8], block.Time())
-	offset += 8
-	block.BaseFee().FillBytes(data[offset : offset+32])
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], block.NumberU64())
+	offset += 32
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], block.Time())
+	offset += 32
+	baseFee.FillBytes(data[offset : offset+32])
 	offset += 32
 	copy(data[offset:offset+32], block.Hash().Bytes())
+	offset += 32
+	binary.BigEndian.PutUint64(data[offset+24:offset+32], seqNumber)
+	offset += 32
+	copy(data[offset:offset+32], batcherHash.Bytes())
+	offset += 32
+	copy(data[offset:offset+32], l1FeeOverhead.Bytes())
+	offset += 32
+	copy(data[offset:offset+32], l1FeeScalar.Bytes())
+	return data, nil
+}
 
+// L1InfoDepositTx wraps the L1 info calldata of the given L1 block height in a deposit transaction.
+func L1InfoDepositTx(cfg *Config, blockHeight uint64, data []byte, gas uint64) *types.DepositTx {
+	to := cfg.L1InfoPredeploy()
+	// TODO: set the L1InfoDepositSource hash instead, once the DepositTx of the geth dependency has a SourceHash field
 	return &types.DepositTx{
-		BlockHeight:      block.NumberU64(),
-		TransactionIndex: 0, // always the first transaction
-		From:             DepositContractAddr,
-		To:               &L1InfoPredeployAddr,
+		BlockHeight:      blockHeight,
+		TransactionIndex: L1InfoDepositIndex, // always the first transaction
+		From:             cfg.DepositContract(),
+		To:               &to,
 		Mint:             nil,
 		Value:            big.NewInt(0),
-		Gas:              99_999_999,
+		Gas:              gas,
 		Data:             data,
 	}
 }
 
+// DeriveL1InfoDeposit derives the L1 info deposit, the first transaction of the L2 block derived from the L1 block.
+// The sequence number is the index of the L2 block within the epoch of the L1 block.
+// The batcher hash and L1 fee scalars are those of the system config.
+func DeriveL1InfoDeposit(cfg *Config, block L1Info, seqNumber uint64) (*types.DepositTx, error) {
+	data, err := EncodeL1InfoData(block, seqNumber, cfg.BatcherHash(), cfg.SystemConfig.Overhead, cfg.SystemConfig.Scalar)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode L1 info: %v", err)
+	}
+	return L1InfoDepositTx(cfg, block.NumberU64(), data, L1InfoDepositGas), nil
+}
+
 type ReceiptHash interface {
 	ReceiptHash() common.Hash
 }
@@ -152,65 +218,244 @@ func CheckReceipts(block ReceiptHash, receipts []*types.Receipt) bool {
 	return block.ReceiptHash() == computed
 }
 
-// DeriveL2Transactions transforms a L1 block and corresponding receipts into the transaction inputs for a full L2 block
-func DeriveUserDeposits(height uint64, receipts []*types.Receipt) ([]*types.DepositTx, error) {
+type LogsBloom interface {
+	Bloom() types.Bloom
+}
+
+// CheckLogsBloom sanity checks that the logs bloom of the block matches the combined logs bloom of the receipts.
+func CheckLogsBloom(block LogsBloom, receipts []*types.Receipt) bool {
+	var combined types.Bloom
+	for _, rec := range receipts {
+		for i := range combined {
+			combined[i] |= rec.Bloom[i]
+		}
+	}
+	return block.Bloom() == combined
+}
+
+// TransactionsRoot computes the transactions root of the L2 block built with exactly the attributes transactions,
+// matching the transactionsRoot of the header produced by the execution engine.
+func (attrs *PayloadAttributes) TransactionsRoot() (common.Hash, error) {
+	txs := make(types.Transactions, 0, len(attrs.Transactions))
+	for i, opaqueTx := range attrs.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(opaqueTx); err != nil {
+			return common.Hash{}, fmt.Errorf("failed to decode tx %d: %v", i, err)
+		}
+		txs = append(txs, &tx)
+	}
+	hasher := trie.NewStackTrie(nil)
+	return types.DeriveSha(txs, hasher), nil
+}
+
+// UserDepositIndex returns the transaction index of the n-th (0-based) user deposit in a L2 block.
+// The user deposits follow the L1 info deposit, and never collide with its reserved index.
+// An error wrapping DepositCountLimitErr is returned if the configured MaxDeposits would be exceeded.
+func UserDepositIndex(cfg *Config, n uint64) (uint64, error) {
+	if cfg.MaxDeposits != 0 && n >= cfg.MaxDeposits {
+		return 0, fmt.Errorf("deposit %d is more than max %d deposits: %w", n, cfg.MaxDeposits, DepositCountLimitErr)
+	}
+	index := n + L1InfoDepositIndex + 1
+	if index <= L1InfoDepositIndex {
+		return 0, fmt.Errorf("deposit %d transaction index overflows: %w", n, DepositCountLimitErr)
+	}
+	return index, nil
+}
+
+// DeriveUserDeposits derives the user deposits from the logs of the deposit contracts in the receipts.
+// The deposits are ordered like the logs in the L1 block, regardless of which deposit contract emitted them.
+func DeriveUserDeposits(cfg *Config, height uint64, receipts []*types.Receipt) ([]*types.DepositTx, error) {
+	return deriveUserDeposits(cfg, height, depositLogs(cfg, receipts))
+}
+
+// deriveUserDeposits derives the user deposits from the deposit logs of a L1 block, in the given order.
+func deriveUserDeposits(cfg *Config, height uint64, logs []*types.Log) ([]*types.DepositTx, error) {
 	var out []*types.DepositTx
+	for _, log := range logs {
+		txIndex, err := UserDepositIndex(cfg, uint64(len(out)))
+		if err != nil {
+			return nil, err
+		}
+		dep, err := cfg.DepositDecoder(log.Address)(height, txIndex, log)
+		if err != nil {
+			return nil, fmt.Errorf("malformatted L1 deposit log: %w", err)
+		}
+		out = append(out, dep)
+	}
+	return out, nil
+}
 
+// depositLogs returns the deposit event logs of the deposit contracts in the receipts of successful transactions,
+// in block order. Other events of the deposit contracts are ignored.
+func depositLogs(cfg *Config, receipts []*types.Receipt) []*types.Log {
+	var out []*types.Log
 	for _, rec := range receipts {
 		if rec.Status != types.ReceiptStatusSuccessful {
 			continue
 		}
 		for _, log := range rec.Logs {
-			if log.Address == DepositContractAddr {
-				// offset transaction index by 1, the first is the l1-info tx
-				dep, err := UnmarshalLogEvent(height, uint64(len(out))+1, log)
-				if err != nil {
-					return nil, fmt.Errorf("malformatted L1 deposit log: %v", err)
-				}
-				out = append(out, dep)
+			if isDepositLog(cfg, log) {
+				out = append(out, log)
 			}
 		}
 	}
-	return out, nil
+	return out
+}
+
+// isDepositLog returns true if the log is a deposit event of a deposit contract
+func isDepositLog(cfg *Config, log *types.Log) bool {
+	return cfg.IsDepositContract(log.Address) && len(log.Topics) > 0 && log.Topics[0] == DepositEventABIHash
 }
 
 type BlockInput interface {
 	ReceiptHash
+	LogsBloom
 	L1Info
 	MixDigest() common.Hash
 }
 
-func DeriveBlockInputs(block BlockInput, receipts []*types.Receipt) (*PayloadAttributes, error) {
+// CheckDepositGas checks that the combined gas of the L1 info deposit and user deposits fits within
+// the configured MaxTotalDepositGas. An error wrapping DepositGasLimitErr is returned if it does not.
+func CheckDepositGas(cfg *Config, l1Info *types.DepositTx, userDeposits []*types.DepositTx) error {
+	if cfg.MaxTotalDepositGas == 0 {
+		return nil
+	}
+	if l1Info.Gas > cfg.MaxTotalDepositGas {
+		return fmt.Errorf("L1 info deposit gas %d is more than max %d: %w", l1Info.Gas, cfg.MaxTotalDepositGas, DepositGasLimitErr)
+	}
+	total := l1Info.Gas
+	for i, dep := range userDeposits {
+		// check before adding, to not overflow
+		if dep.Gas > cfg.MaxTotalDepositGas-total {
+			return fmt.Errorf("deposit %d with %d gas does not fit in remaining %d deposit gas: %w", i, dep.Gas, cfg.MaxTotalDepositGas-total, DepositGasLimitErr)
+		}
+		total += dep.Gas
+	}
+	return nil
+}
+
+// headerBlockInput implements BlockInput with a header
+type headerBlockInput struct {
+	header *types.Header
+}
+
+// BlockInputFromHeader adapts a header to a BlockInput, to derive from when there is no full block.
+// A nil base fee (pre-London header) is presented as zero.
+func BlockInputFromHeader(h *types.Header) BlockInput {
+	return headerBlockInput{header: h}
+}
+
+// BlockInputFromBlock adapts a block to a BlockInput, which also implements BlockTransactions.
+// A nil base fee (pre-London block) is presented as zero.
+func BlockInputFromBlock(bl *types.Block) BlockInput {
+	return blockInput{headerBlockInput: headerBlockInput{header: bl.Header()}, txs: bl.Transactions()}
+}
+
+type blockInput struct {
+	headerBlockInput
+	txs types.Transactions
+}
+
+func (b blockInput) Transactions() types.Transactions {
+	return b.txs
+}
+
+func (h headerBlockInput) NumberU64() uint64 {
+	return h.header.Number.Uint64()
+}
+
+func (h headerBlockInput) Time() uint64 {
+	return h.header.Time
+}
+
+func (h headerBlockInput) Hash() common.Hash {
+	return h.header.Hash()
+}
+
+func (h headerBlockInput) BaseFee() *big.Int {
+	if h.header.BaseFee == nil {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(h.header.BaseFee)
+}
+
+func (h headerBlockInput) ReceiptHash() common.Hash {
+	return h.header.ReceiptHash
+}
+
+func (h headerBlockInput) Bloom() types.Bloom {
+	return h.header.Bloom
+}
+
+func (h headerBlockInput) MixDigest() common.Hash {
+	return h.header.MixDigest
+}
+
+func DeriveBlockInputs(cfg *Config, block BlockInput, receipts []*types.Receipt) (*PayloadAttributes, error) {
 	if !CheckReceipts(block, receipts) {
 		return nil, fmt.Errorf("receipts are not consistent with the block's receipts root: %s", block.ReceiptHash())
 	}
-
-	l1Tx := types.NewTx(DeriveL1InfoDeposit(block))
-	opaqueL1Tx, err := l1Tx.MarshalBinary()
+	if cfg.VerifyLogsBloom && !CheckLogsBloom(block, receipts) {
+		return nil, fmt.Errorf("receipts are not consistent with the block's logs bloom")
+	}
+	userDeposits, err := DeriveUserDeposits(cfg, block.NumberU64(), receipts)
 	if err != nil {
-		return nil, fmt.Errorf("failed to encode L1 info tx")
+		return nil, fmt.Errorf("failed to derive user deposits: %v", err)
 	}
+	return deriveBlockInputs(cfg, block, userDeposits)
+}
 
-	userDeposits, err := DeriveUserDeposits(block.NumberU64(), receipts)
+// deriveBlockInputs derives the block inputs of the L2 block of a L1 block, with the user deposits of the L1 block.
+func deriveBlockInputs(cfg *Config, block BlockInput, userDeposits []*types.DepositTx) (*PayloadAttributes, error) {
+	// every L1 block derives a single L2 block, the first and only of the epoch
+	l1Info, err := DeriveL1InfoDeposit(cfg, block, 0)
 	if err != nil {
-		return nil, fmt.Errorf("failed to derive user deposits: %v", err)
+		return nil, err
+	}
+	opaqueL1Tx, err := EncodeDeposit(l1Info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode L1 info tx: %v", err)
+	}
+
+	if err := CheckDepositGas(cfg, l1Info, userDeposits); err != nil {
+		return nil, err
 	}
 
-	encodedTxs := make([]Data, 0, len(userDeposits)+1)
+	var batchTxs []Data
+	if cfg.BatchInboxAddr != (common.Address{}) {
+		blockTxs, ok := block.(BlockTransactions)
+		if !ok {
+			return nil, errors.New("batch inbox is configured, but the block input has no transactions to derive batches from")
+		}
+		batchTxs, err = DeriveBatches(cfg, blockTxs.Transactions())
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive batches: %v", err)
+		}
+	}
+
+	encodedTxs := make([]Data, 0, len(userDeposits)+1+len(batchTxs))
 	encodedTxs = append(encodedTxs, opaqueL1Tx)
 
 	for i, tx := range userDeposits {
-		opaqueTx, err := types.NewTx(tx).MarshalBinary()
+		opaqueTx, err := EncodeDeposit(tx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to encode user tx %d", i)
+			return nil, fmt.Errorf("failed to encode user tx %d: %v", i, err)
 		}
 		encodedTxs = append(encodedTxs, opaqueTx)
 	}
+	// sequenced transactions follow after the deposits
+	encodedTxs = append(encodedTxs, batchTxs...)
 
-	return &PayloadAttributes{
+	attrs := &PayloadAttributes{
 		Timestamp:             Uint64Quantity(block.Time()),
 		Random:                Bytes32(block.MixDigest()),
 		SuggestedFeeRecipient: common.Address{}, // nobody gets tx fees for deposits
 		Transactions:          encodedTxs,
-	}, nil
+		NoTxPool:              true,
+	}
+	if gasLimit := cfg.SystemConfig.GasLimit; gasLimit != 0 {
+		attrs.GasLimit = (*Uint64Quantity)(&gasLimit)
+	}
+	cfg.SetPayloadVersion(attrs)
+	return attrs, nil
 }
@@ -0,0 +1,50 @@
+package l2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// DeriveBlockInputsFromJSON runs DeriveBlockInputs on the JSON-RPC representations of a L1 block and its receipts:
+// the result of eth_getBlockByHash (transactions may be hashes or full objects, they are not used),
+// and a JSON list of the eth_getTransactionReceipt results, in transaction order.
+func DeriveBlockInputsFromJSON(cfg *Config, blockJSON, receiptsJSON []byte) (*PayloadAttributes, error) {
+	var header types.Header
+	if err := json.Unmarshal(blockJSON, &header); err != nil {
+		return nil, fmt.Errorf("failed to decode L1 block JSON: %v", err)
+	}
+	if header.BaseFee == nil {
+		return nil, errors.New("L1 block JSON is missing baseFeePerGas, pre-London blocks are not supported")
+	}
+	if !header.Number.IsUint64() {
+		return nil, fmt.Errorf("L1 block number too large: %s", header.Number)
+	}
+	var id struct {
+		Hash *common.Hash `json:"hash"`
+	}
+	if err := json.Unmarshal(blockJSON, &id); err != nil {
+		return nil, fmt.Errorf("failed to decode L1 block hash JSON: %v", err)
+	}
+	if id.Hash == nil {
+		return nil, errors.New("L1 block JSON is missing the block hash")
+	}
+	if computed := header.Hash(); computed != *id.Hash {
+		return nil, fmt.Errorf("L1 block JSON hash %s does not match computed header hash %s", *id.Hash, computed)
+	}
+
+	var receipts []*types.Receipt
+	if err := json.Unmarshal(receiptsJSON, &receipts); err != nil {
+		return nil, fmt.Errorf("failed to decode L1 receipts JSON: %v", err)
+	}
+	for i, rec := range receipts {
+		if rec == nil {
+			return nil, fmt.Errorf("L1 receipts JSON has null receipt %d", i)
+		}
+	}
+
+	return DeriveBlockInputs(cfg, BlockInputFromHeader(&header), receipts)
+}
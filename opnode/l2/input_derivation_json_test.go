@@ -0,0 +1,108 @@
+package l2
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadJSONFixtures(t *testing.T) (blockJSON []byte, receiptsJSON []byte) {
+	blockJSON, err := os.ReadFile("testdata/l1_block.json")
+	require.NoError(t, err)
+	receiptsJSON, err = os.ReadFile("testdata/l1_receipts.json")
+	require.NoError(t, err)
+	return
+}
+
+// editBlockJSON changes a field of the block JSON, or removes it if the value is nil
+func editBlockJSON(t *testing.T, blockJSON []byte, field string, value interface{}) []byte {
+	var m map[string]interface{}
+	require.NoError(t, json.Unmarshal(blockJSON, &m))
+	if value == nil {
+		delete(m, field)
+	} else {
+		m[field] = value
+	}
+	out, err := json.Marshal(m)
+	require.NoError(t, err)
+	return out
+}
+
+func TestDeriveBlockInputsFromJSON(t *testing.T) {
+	blockJSON, receiptsJSON := loadJSONFixtures(t)
+
+	attrs, err := DeriveBlockInputsFromJSON(&Config{}, blockJSON, receiptsJSON)
+	require.NoError(t, err)
+
+	var header types.Header
+	require.NoError(t, json.Unmarshal(blockJSON, &header))
+	assert.Equal(t, Uint64Quantity(header.Time), attrs.Timestamp)
+	assert.Equal(t, Bytes32(header.MixDigest), attrs.Random)
+	assert.Equal(t, common.Address{}, attrs.SuggestedFeeRecipient)
+	// the L1 info tx, followed by the 2 deposits in the fixture receipts
+	require.Len(t, attrs.Transactions, 3)
+
+	var l1InfoTx types.Transaction
+	require.NoError(t, l1InfoTx.UnmarshalBinary(attrs.Transactions[0]))
+	nr, time, baseFee, h, seqNumber, batcherHash, l1FeeOverhead, l1FeeScalar, err := ParseL1InfoDepositTxData(l1InfoTx.Data())
+	require.NoError(t, err)
+	assert.Equal(t, header.Number.Uint64(), nr)
+	assert.Equal(t, header.Time, time)
+	assert.Equal(t, header.BaseFee, baseFee)
+	assert.Equal(t, header.Hash(), h)
+	assert.Equal(t, uint64(0), seqNumber)
+	assert.Equal(t, common.Hash{}, batcherHash)
+	assert.Equal(t, common.Hash{}, l1FeeOverhead)
+	assert.Equal(t, common.Hash{}, l1FeeScalar)
+
+	for i, opaqueTx := range attrs.Transactions[1:] {
+		var tx types.Transaction
+		require.NoError(t, tx.UnmarshalBinary(opaqueTx))
+		assert.Equal(t, uint8(types.DepositTxType), tx.Type(), "deposit %d", i)
+	}
+}
+
+func TestDeriveBlockInputsFromJSONErrors(t *testing.T) {
+	blockJSON, receiptsJSON := loadJSONFixtures(t)
+
+	t.Run("malformed block", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, blockJSON[:len(blockJSON)/2], receiptsJSON)
+		assert.Error(t, err)
+	})
+	t.Run("malformed receipts", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, blockJSON, receiptsJSON[:len(receiptsJSON)/2])
+		assert.Error(t, err)
+	})
+	t.Run("missing base fee", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, editBlockJSON(t, blockJSON, "baseFeePerGas", nil), receiptsJSON)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "baseFeePerGas")
+	})
+	t.Run("missing required header field", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, editBlockJSON(t, blockJSON, "receiptsRoot", nil), receiptsJSON)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "receiptsRoot")
+	})
+	t.Run("missing hash", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, editBlockJSON(t, blockJSON, "hash", nil), receiptsJSON)
+		assert.Error(t, err)
+	})
+	t.Run("wrong hash", func(t *testing.T) {
+		_, err := DeriveBlockInputsFromJSON(&Config{}, editBlockJSON(t, blockJSON, "hash", common.Hash{1}), receiptsJSON)
+		assert.Error(t, err)
+	})
+	t.Run("missing receipt", func(t *testing.T) {
+		var receipts []json.RawMessage
+		require.NoError(t, json.Unmarshal(receiptsJSON, &receipts))
+		partial, err := json.Marshal(receipts[:len(receipts)-1])
+		require.NoError(t, err)
+		_, err = DeriveBlockInputsFromJSON(&Config{}, blockJSON, partial)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "receipts root")
+	})
+}
@@ -1,129 +1,140 @@
 package l2
 
 import (
-	"encoding/binary"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 	"math/rand"
+	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
 )
 
-func GenerateAddress(rng *rand.Rand) (out common.Address) {
-	rng.Read(out[:])
-	return
+func TestUnmarshalLogEvent(t *testing.T) {
+	for i := int64(0); i < 100; i++ {
+		t.Run(fmt.Sprintf("random_deposit_%d", i), func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1234 + i))
+			blockNum := rng.Uint64()
+			txIndex := uint64(rng.Intn(10000))
+			depInput := testutil.GenerateDeposit(blockNum, txIndex, rng)
+			log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+			depOutput, err := UnmarshalLogEvent(blockNum, txIndex, log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, depInput, depOutput)
+		})
+	}
 }
 
-func RandETH(rng *rand.Rand, max int64) *big.Int {
-	x := big.NewInt(rng.Int63n(max))
-	x = new(big.Int).Mul(x, big.NewInt(1e18))
-	return x
-}
+func TestUnmarshalLogEventMintValue(t *testing.T) {
+	cases := []struct {
+		name  string
+		mint  *big.Int
+		value *big.Int
+	}{
+		{"zero mint, zero value", nil, big.NewInt(0)},
+		{"nonzero mint, zero value", big.NewInt(123), big.NewInt(0)},
+		{"zero mint, nonzero value", nil, big.NewInt(456)},
+		{"nonzero mint, nonzero value", big.NewInt(123), big.NewInt(456)},
+	}
+	for i, testCase := range cases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rng := rand.New(rand.NewSource(1234 + int64(i)))
+			depInput := testutil.GenerateDeposit(100, 1, rng)
+			depInput.Mint = testCase.mint
+			depInput.Value = testCase.value
+			log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+			// the contract emits the mint before the value
+			assert.Equal(t, common.BigToHash(testCase.value).Bytes(), log.Data[32:64], "value is the second data word")
 
-// Returns a DepositEvent customized on the basis of the id parameter.
-func GenerateDeposit(blockNum uint64, txIndex uint64, rng *rand.Rand) *types.DepositTx {
-	dataLen := rng.Int63n(10_000)
-	data := make([]byte, dataLen)
-	rng.Read(data)
-
-	var to *common.Address
-	if rng.Intn(2) == 0 {
-		x := GenerateAddress(rng)
-		to = &x
-	}
-	var mint *big.Int
-	if rng.Intn(2) == 0 {
-		mint = RandETH(rng, 200)
-	}
-
-	dep := &types.DepositTx{
-		BlockHeight:      blockNum,
-		TransactionIndex: txIndex,
-		From:             GenerateAddress(rng),
-		To:               to,
-		Value:            RandETH(rng, 200),
-		Gas:              uint64(rng.Int63n(10 * 1e6)), // 10 M gas max
-		Data:             data,
-		Mint:             mint,
-	}
-	return dep
+			depOutput, err := UnmarshalLogEvent(100, 1, log)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, testCase.mint, depOutput.Mint)
+			assert.NotNil(t, depOutput.Value, "zero value must not be normalized to nil")
+			assert.Equal(t, 0, testCase.value.Cmp(depOutput.Value))
+			assert.Equal(t, depInput.Data, depOutput.Data)
+		})
+	}
 }
 
-// Generates an EVM log entry that encodes a TransactionDeposited event from the deposit contract.
-// Calls GenerateDeposit with random number generator to generate the deposit.
-func GenerateDepositLog(deposit *types.DepositTx) *types.Log {
+func TestUnmarshalLogEventEmptyData(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	depInput := testutil.GenerateDeposit(100, 1, rng)
+	depInput.Data = []byte{}
+	log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+	// the zero length is the last word, no data words follow
+	assert.Equal(t, 6*32, len(log.Data))
 
-	toBytes := common.Hash{}
-	if deposit.To != nil {
-		toBytes = deposit.To.Hash()
+	depOutput, err := UnmarshalLogEvent(100, 1, log)
+	if err != nil {
+		t.Fatal(err)
 	}
-	topics := []common.Hash{
-		DepositEventABIHash,
-		deposit.From.Hash(),
-		toBytes,
-	}
-
-	data := make([]byte, 6*32)
-	offset := 0
-	deposit.Value.FillBytes(data[offset : offset+32])
-	offset += 32
+	assert.NotNil(t, depOutput.Data)
+	assert.Len(t, depOutput.Data, 0)
+	assert.Equal(t, depInput, depOutput)
 
-	if deposit.Mint != nil {
-		deposit.Mint.FillBytes(data[offset : offset+32])
-	}
-	offset += 32
+	t.Run("data length past end", func(t *testing.T) {
+		badLog := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		badLog.Data[6*32-1] = 1
+		_, err := UnmarshalLogEvent(100, 1, badLog)
+		assert.Error(t, err)
+	})
+	t.Run("truncated", func(t *testing.T) {
+		badLog := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		badLog.Data = badLog.Data[:6*32-1]
+		_, err := UnmarshalLogEvent(100, 1, badLog)
+		assert.Error(t, err)
+	})
+	t.Run("bad data offset", func(t *testing.T) {
+		badLog := MarshalDepositLogEvent(DepositContractAddr, depInput)
+		badLog.Data[5*32-1] = 4 * 32
+		_, err := UnmarshalLogEvent(100, 1, badLog)
+		assert.Error(t, err)
+	})
+}
 
-	binary.BigEndian.PutUint64(data[offset+24:offset+32], deposit.Gas)
-	offset += 32
-	if deposit.To == nil { // isCreation
-		data[offset+31] = 1
-	}
-	offset += 32
-	binary.BigEndian.PutUint64(data[offset+24:offset+32], 5*32)
-	offset += 32
-	binary.BigEndian.PutUint64(data[offset+24:offset+32], uint64(len(deposit.Data)))
-	data = append(data, deposit.Data...)
-	if len(data)%32 != 0 { // pad to multiple of 32
-		data = append(data, make([]byte, 32-(len(data)%32))...)
-	}
+func TestUnmarshalLogEventStrict(t *testing.T) {
+	assert.Equal(t, DepositEventABIHash, DepositContractABI.Events["TransactionDeposited"].ID)
 
-	return GenerateLog(DepositContractAddr, topics, data)
-}
+	rng := rand.New(rand.NewSource(1234))
+	depInput := testutil.GenerateDeposit(100, 1, rng)
+	depInput.Data = []byte{1, 2, 3}
+	_, err := UnmarshalLogEvent(100, 1, MarshalDepositLogEvent(DepositContractAddr, depInput))
+	require.NoError(t, err)
 
-// Generates an EVM log entry with the given topics and data.
-func GenerateLog(addr common.Address, topics []common.Hash, data []byte) *types.Log {
-	return &types.Log{
-		Address: addr,
-		Topics:  topics,
-		Data:    data,
-		Removed: false,
-
-		// ignored (zeroed):
-		BlockNumber: 0,
-		TxHash:      common.Hash{},
-		TxIndex:     0,
-		BlockHash:   common.Hash{},
-		Index:       0,
+	testCases := []struct {
+		name   string
+		mutate func(log *types.Log)
+	}{
+		{"dirty address padding", func(log *types.Log) { log.Topics[1][0] = 1 }},
+		{"missing topic", func(log *types.Log) { log.Topics = log.Topics[:2] }},
+		{"non-boolean isCreation", func(log *types.Log) { log.Data[4*32-1] = 2 }},
+		{"creation with to address", func(log *types.Log) {
+			log.Topics[2] = common.Address{0x42}.Hash()
+			log.Data[4*32-1] = 1
+		}},
+		{"dirty data padding", func(log *types.Log) { log.Data[len(log.Data)-1] = 1 }},
+		{"trailing bytes", func(log *types.Log) { log.Data = append(log.Data, make([]byte, 32)...) }},
+		{"unpadded data", func(log *types.Log) { log.Data = log.Data[:6*32+3] }},
 	}
-}
-
-func TestUnmarshalLogEvent(t *testing.T) {
-	for i := int64(0); i < 100; i++ {
-		t.Run(fmt.Sprintf("random_deposit_%d", i), func(t *testing.T) {
-			rng := rand.New(rand.NewSource(1234 + i))
-			blockNum := rng.Uint64()
-			txIndex := uint64(rng.Intn(10000))
-			depInput := GenerateDeposit(blockNum, txIndex, rng)
-			log := GenerateDepositLog(depInput)
-			depOutput, err := UnmarshalLogEvent(blockNum, txIndex, log)
-			if err != nil {
-				t.Fatal(err)
-			}
-			assert.Equal(t, depInput, depOutput)
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			log := MarshalDepositLogEvent(DepositContractAddr, depInput)
+			testCase.mutate(log)
+			_, err := UnmarshalLogEvent(100, 1, log)
+			assert.Error(t, err)
 		})
 	}
 }
@@ -170,13 +181,13 @@ func TestDeriveUserDeposits(t *testing.T) {
 				}
 				for _, isDeposit := range rData.DepositLogs {
 					if isDeposit {
-						dep := GenerateDeposit(testCase.height, uint64(1+len(expectedDeposits)), rng)
+						dep := testutil.GenerateDeposit(testCase.height, uint64(1+len(expectedDeposits)), rng)
 						if status == types.ReceiptStatusSuccessful {
 							expectedDeposits = append(expectedDeposits, dep)
 						}
-						logs = append(logs, GenerateDepositLog(dep))
+						logs = append(logs, MarshalDepositLogEvent(DepositContractAddr, dep))
 					} else {
-						logs = append(logs, GenerateLog(GenerateAddress(rng), nil, nil))
+						logs = append(logs, testutil.GenerateLog(testutil.GenerateAddress(rng), nil, nil))
 					}
 				}
 
@@ -186,7 +197,7 @@ func TestDeriveUserDeposits(t *testing.T) {
 					Logs:   logs,
 				})
 			}
-			got, err := DeriveUserDeposits(testCase.height, receipts)
+			got, err := DeriveUserDeposits(&Config{}, testCase.height, receipts)
 			assert.NoError(t, err)
 			assert.Equal(t, len(got), len(expectedDeposits))
 			for d, depTx := range got {
@@ -196,3 +207,218 @@ func TestDeriveUserDeposits(t *testing.T) {
 		})
 	}
 }
+
+func TestPayloadAttributesTransactionsRoot(t *testing.T) {
+	// testdata/l2_block.json is a L2 block that the engine built from payload attributes with the L1 info deposit,
+	// user deposits and sequenced txs, with the encoded transactions of the execution payload.
+	data, err := os.ReadFile("testdata/l2_block.json")
+	require.NoError(t, err)
+	var block struct {
+		Header       types.Header `json:"header"`
+		Transactions []Data       `json:"transactions"`
+	}
+	require.NoError(t, json.Unmarshal(data, &block))
+	require.Equal(t, common.HexToHash("0x45177ba83ff4fdefeecefc5c4cc4509eb9690f93c591cf77de295126057331e2"), block.Header.Hash())
+	require.Equal(t, common.HexToHash("0x26b1f85115e0a9be00d7d2e05917fa97a9425da07f202c42315e52d00aeec1fd"), block.Header.TxHash)
+
+	attrs := &PayloadAttributes{Timestamp: Uint64Quantity(block.Header.Time), Transactions: block.Transactions}
+	root, err := attrs.TransactionsRoot()
+	assert.NoError(t, err)
+	assert.Equal(t, block.Header.TxHash, root)
+
+	// the root commits to the order of the transactions
+	attrs.Transactions[1], attrs.Transactions[2] = attrs.Transactions[2], attrs.Transactions[1]
+	reordered, err := attrs.TransactionsRoot()
+	assert.NoError(t, err)
+	assert.NotEqual(t, root, reordered)
+
+	t.Run("empty", func(t *testing.T) {
+		root, err := (&PayloadAttributes{}).TransactionsRoot()
+		assert.NoError(t, err)
+		assert.Equal(t, types.EmptyRootHash, root)
+	})
+	t.Run("malformed tx", func(t *testing.T) {
+		_, err := (&PayloadAttributes{Transactions: []Data{{0x7e, 0x01}}}).TransactionsRoot()
+		assert.Error(t, err)
+	})
+}
+
+func TestCheckDepositGas(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	l1Info, err := DeriveL1InfoDeposit(&Config{}, randomL1Info(rng), 0)
+	assert.NoError(t, err)
+	var deposits []*types.DepositTx
+	for i := 0; i < 3; i++ {
+		dep := testutil.GenerateDeposit(100, uint64(1+i), rng)
+		dep.Gas = 1_000_000
+		deposits = append(deposits, dep)
+	}
+	total := l1Info.Gas + 3*1_000_000
+
+	t.Run("unlimited", func(t *testing.T) {
+		assert.NoError(t, CheckDepositGas(&Config{}, l1Info, deposits))
+	})
+	t.Run("exact", func(t *testing.T) {
+		assert.NoError(t, CheckDepositGas(&Config{MaxTotalDepositGas: total}, l1Info, deposits))
+	})
+	t.Run("just under", func(t *testing.T) {
+		assert.NoError(t, CheckDepositGas(&Config{MaxTotalDepositGas: total + 1}, l1Info, deposits))
+	})
+	t.Run("just over", func(t *testing.T) {
+		err := CheckDepositGas(&Config{MaxTotalDepositGas: total - 1}, l1Info, deposits)
+		assert.True(t, errors.Is(err, DepositGasLimitErr))
+	})
+	t.Run("l1 info over", func(t *testing.T) {
+		err := CheckDepositGas(&Config{MaxTotalDepositGas: l1Info.Gas - 1}, l1Info, deposits)
+		assert.True(t, errors.Is(err, DepositGasLimitErr))
+	})
+	t.Run("overflow", func(t *testing.T) {
+		deposits[1].Gas = ^uint64(0)
+		err := CheckDepositGas(&Config{MaxTotalDepositGas: total}, l1Info, deposits)
+		assert.True(t, errors.Is(err, DepositGasLimitErr))
+	})
+}
+
+func TestCheckLogsBloom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	var receipts []*types.Receipt
+	for i := 0; i < 3; i++ {
+		rec := &types.Receipt{
+			Type:   types.DynamicFeeTxType,
+			Status: types.ReceiptStatusSuccessful,
+			Logs:   []*types.Log{MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, uint64(1+i), rng))},
+		}
+		rec.Bloom = types.CreateBloom(types.Receipts{rec})
+		receipts = append(receipts, rec)
+	}
+	header := &types.Header{
+		Number:      big.NewInt(100),
+		ReceiptHash: types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil)),
+		Bloom:       types.CreateBloom(receipts),
+		BaseFee:     big.NewInt(7),
+	}
+	assert.True(t, CheckLogsBloom(BlockInputFromHeader(header), receipts))
+
+	_, err := DeriveBlockInputs(&Config{VerifyLogsBloom: true}, BlockInputFromHeader(header), receipts)
+	assert.NoError(t, err)
+
+	// the receipts still match the receipts root, but not the header bloom
+	header.Bloom[0] ^= 0xff
+	assert.False(t, CheckLogsBloom(BlockInputFromHeader(header), receipts))
+
+	_, err = DeriveBlockInputs(&Config{VerifyLogsBloom: true}, BlockInputFromHeader(header), receipts)
+	assert.Error(t, err)
+	_, err = DeriveBlockInputs(&Config{VerifyLogsBloom: false}, BlockInputFromHeader(header), receipts)
+	assert.NoError(t, err, "logs bloom check is optional")
+}
+
+func TestBlockInputFromHeader(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	header := &types.Header{
+		ParentHash:  randomHash(rng),
+		Number:      big.NewInt(1234),
+		Time:        5678,
+		ReceiptHash: types.EmptyRootHash,
+		Bloom:       types.Bloom{},
+		MixDigest:   randomHash(rng),
+		BaseFee:     big.NewInt(42),
+		Difficulty:  big.NewInt(0),
+	}
+	for _, input := range []BlockInput{BlockInputFromHeader(header), BlockInputFromBlock(types.NewBlockWithHeader(header))} {
+		assert.Equal(t, uint64(1234), input.NumberU64())
+		assert.Equal(t, uint64(5678), input.Time())
+		assert.Equal(t, header.Hash(), input.Hash())
+		assert.Equal(t, big.NewInt(42), input.BaseFee())
+		assert.Equal(t, types.EmptyRootHash, input.ReceiptHash())
+		assert.Equal(t, header.MixDigest, input.MixDigest())
+
+		attrs, err := DeriveBlockInputs(&Config{}, input, nil)
+		assert.NoError(t, err)
+		assert.Equal(t, Uint64Quantity(5678), attrs.Timestamp)
+		assert.Equal(t, Bytes32(header.MixDigest), attrs.Random)
+		assert.Len(t, attrs.Transactions, 1)
+	}
+
+	t.Run("gas limit", func(t *testing.T) {
+		input := BlockInputFromHeader(header)
+		attrs, err := DeriveBlockInputs(&Config{}, input, nil)
+		require.NoError(t, err)
+		assert.Nil(t, attrs.GasLimit)
+		data, err := json.Marshal(attrs)
+		require.NoError(t, err)
+		assert.NotContains(t, string(data), "gasLimit")
+
+		attrs, err = DeriveBlockInputs(&Config{SystemConfig: SystemConfig{GasLimit: 30_000_000}}, input, nil)
+		require.NoError(t, err)
+		require.NotNil(t, attrs.GasLimit)
+		assert.Equal(t, Uint64Quantity(30_000_000), *attrs.GasLimit)
+		data, err = json.Marshal(attrs)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"gasLimit":"0x1c9c380"`)
+	})
+	t.Run("nil base fee", func(t *testing.T) {
+		legacy := types.CopyHeader(header)
+		legacy.BaseFee = nil
+		input := BlockInputFromHeader(legacy)
+		assert.Equal(t, 0, input.BaseFee().Sign())
+		_, err := DeriveBlockInputs(&Config{}, input, nil)
+		assert.NoError(t, err)
+	})
+}
+
+func TestDeriveUserDepositsConfig(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	customAddr := common.Address{0xaa}
+	defaultLog := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, 1, rng))
+	customLog := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, 2, rng))
+	customLog.Address = customAddr
+	receipts := []*types.Receipt{{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{defaultLog, customLog}}}
+
+	deps, err := DeriveUserDeposits(&Config{}, 100, receipts)
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.Equal(t, uint64(1), deps[0].TransactionIndex)
+
+	// only logs of the configured deposit contract are derived from
+	deps, err = DeriveUserDeposits(&Config{DepositContractAddr: customAddr}, 100, receipts)
+	require.NoError(t, err)
+	require.Len(t, deps, 1)
+	assert.Equal(t, customLog.Topics[1][12:], deps[0].From.Bytes())
+
+	// other events of the deposit contracts are ignored
+	otherLog := testutil.GenerateLog(customAddr, []common.Hash{{0x01}}, nil)
+	receipts[0].Logs = append(receipts[0].Logs, otherLog)
+	deps, err = DeriveUserDeposits(&Config{ExtraDepositContractAddrs: []common.Address{customAddr}}, 100, receipts)
+	require.NoError(t, err)
+	require.Len(t, deps, 2)
+}
+
+func TestUserDepositIndex(t *testing.T) {
+	index, err := UserDepositIndex(&Config{}, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), index, "first user deposit follows the L1 info deposit")
+
+	_, err = UserDepositIndex(&Config{}, ^uint64(0))
+	assert.True(t, errors.Is(err, DepositCountLimitErr), "index must not wrap to the L1 info index")
+
+	cfg := &Config{MaxDeposits: 3}
+	index, err = UserDepositIndex(cfg, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), index)
+	_, err = UserDepositIndex(cfg, 3)
+	assert.True(t, errors.Is(err, DepositCountLimitErr))
+
+	rng := rand.New(rand.NewSource(1234))
+	var logs []*types.Log
+	for i := 0; i < 4; i++ {
+		logs = append(logs, MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, uint64(1+i), rng)))
+	}
+	receipt := func(logs []*types.Log) []*types.Receipt {
+		return []*types.Receipt{{Type: types.DynamicFeeTxType, Status: types.ReceiptStatusSuccessful, Logs: logs}}
+	}
+	deposits, err := DeriveUserDeposits(cfg, 100, receipt(logs[:3]))
+	assert.NoError(t, err)
+	assert.Len(t, deposits, 3)
+	_, err = DeriveUserDeposits(cfg, 100, receipt(logs))
+	assert.True(t, errors.Is(err, DepositCountLimitErr))
+}
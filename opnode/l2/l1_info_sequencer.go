@@ -0,0 +1,65 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var L1InfoOutOfOrderErr = errors.New("l1 info out of order")
+
+// L1InfoSequencer checks that successive L1 origins move strictly forward in both block number and timestamp.
+// A L1 origin that does not move forward indicates a reorg or bad data, and must not silently feed into the L2 timestamps.
+//
+// After a reorg the sequencer must be Reset explicitly, the next L1 info is then accepted unconditionally.
+type L1InfoSequencer struct {
+	mu sync.Mutex
+
+	// false until the first L1 info is accepted, or after a Reset
+	started  bool
+	lastNum  uint64
+	lastTime uint64
+}
+
+// Next checks the L1 info against the previously accepted L1 info, and accepts it if it moves forward.
+// An error wrapping L1InfoOutOfOrderErr is returned otherwise, and the previous L1 info is retained.
+func (s *L1InfoSequencer) Next(info L1Info) error {
+	return s.next(info.NumberU64(), info.Time())
+}
+
+// NextDeposit is like Next, but parses the L1 info from the L1 info deposit of a derived L2 block.
+func (s *L1InfoSequencer) NextDeposit(dep *types.DepositTx) error {
+	nr, time, _, _, _, _, _, _, err := ParseL1InfoDepositTxData(dep.Data)
+	if err != nil {
+		return fmt.Errorf("failed to parse L1 info deposit: %v", err)
+	}
+	return s.next(nr, time)
+}
+
+func (s *L1InfoSequencer) next(nr uint64, time uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started {
+		if nr <= s.lastNum {
+			return fmt.Errorf("l1 block number %d does not increase, previous was %d: %w", nr, s.lastNum, L1InfoOutOfOrderErr)
+		}
+		if time <= s.lastTime {
+			return fmt.Errorf("l1 block %d timestamp %d does not increase, previous was %d: %w", nr, time, s.lastTime, L1InfoOutOfOrderErr)
+		}
+	}
+	s.started = true
+	s.lastNum = nr
+	s.lastTime = time
+	return nil
+}
+
+// Reset forgets the previously accepted L1 info, e.g. after a L1 reorg.
+func (s *L1InfoSequencer) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.started = false
+	s.lastNum = 0
+	s.lastTime = 0
+}
@@ -0,0 +1,53 @@
+package l2
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func seqInfo(num uint64, time uint64) *l1MockInfo {
+	return &l1MockInfo{num: num, time: time, baseFee: big.NewInt(7)}
+}
+
+func TestL1InfoSequencer_OutOfOrder(t *testing.T) {
+	var seq L1InfoSequencer
+	assert.NoError(t, seq.Next(seqInfo(10, 1000)))
+	assert.NoError(t, seq.Next(seqInfo(11, 1012)))
+
+	err := seq.Next(seqInfo(12, 1011))
+	assert.True(t, errors.Is(err, L1InfoOutOfOrderErr), "timestamp going backwards must be rejected")
+	err = seq.Next(seqInfo(12, 1012))
+	assert.True(t, errors.Is(err, L1InfoOutOfOrderErr), "equal timestamp must be rejected")
+	err = seq.Next(seqInfo(11, 1024))
+	assert.True(t, errors.Is(err, L1InfoOutOfOrderErr), "repeated block number must be rejected")
+
+	// rejected infos are not retained
+	assert.NoError(t, seq.Next(seqInfo(12, 1024)))
+}
+
+func TestL1InfoSequencer_Deposit(t *testing.T) {
+	var seq L1InfoSequencer
+	dep, err := DeriveL1InfoDeposit(&Config{}, seqInfo(10, 1000), 0)
+	assert.NoError(t, err)
+	assert.NoError(t, seq.NextDeposit(dep))
+	dep, err = DeriveL1InfoDeposit(&Config{}, seqInfo(11, 999), 0)
+	assert.NoError(t, err)
+	err = seq.NextDeposit(dep)
+	assert.True(t, errors.Is(err, L1InfoOutOfOrderErr))
+}
+
+func TestL1InfoSequencer_Reset(t *testing.T) {
+	var seq L1InfoSequencer
+	assert.NoError(t, seq.Next(seqInfo(10, 1000)))
+	assert.NoError(t, seq.Next(seqInfo(11, 1012)))
+
+	// reorg back to an alternative block 11, with a different timestamp
+	seq.Reset()
+	assert.NoError(t, seq.Next(seqInfo(11, 1010)))
+	assert.NoError(t, seq.Next(seqInfo(12, 1022)))
+	err := seq.Next(seqInfo(13, 1020))
+	assert.True(t, errors.Is(err, L1InfoOutOfOrderErr))
+}
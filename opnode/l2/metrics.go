@@ -0,0 +1,97 @@
+package l2
+
+import (
+	"time"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// Metrics is the interface the derivation reports to, to monitor the derivation health.
+type Metrics interface {
+	// RecordDeposits records the number of user deposits derived for a L2 block
+	RecordDeposits(count int)
+	// RecordDerivationTime records how long it took to derive the inputs of a L2 block, once the L1 data was fetched
+	RecordDerivationTime(d time.Duration)
+	// RecordFetchTime records how long it took to fetch a L1 block with receipts
+	RecordFetchTime(d time.Duration)
+	// RecordDecodeFailure records L1 data that is ignored because it failed to decode. E.g. "frames" or "channel".
+	RecordDecodeFailure(kind string)
+	// RecordDroppedBatch records a decoded batch that is dropped because it is invalid, by BatchDropReason.
+	RecordDroppedBatch(reason string)
+}
+
+type noopMetrics struct{}
+
+func (noopMetrics) RecordDeposits(count int)             {}
+func (noopMetrics) RecordDerivationTime(d time.Duration) {}
+func (noopMetrics) RecordFetchTime(d time.Duration)      {}
+func (noopMetrics) RecordDecodeFailure(kind string)      {}
+func (noopMetrics) RecordDroppedBatch(reason string)     {}
+
+// NoopMetrics discards all metrics
+var NoopMetrics Metrics = noopMetrics{}
+
+// metricsOrNoop returns m, or NoopMetrics if m is nil, so that metrics can be left unconfigured.
+func metricsOrNoop(m Metrics) Metrics {
+	if m == nil {
+		return NoopMetrics
+	}
+	return m
+}
+
+// GethMetrics implements Metrics with the go-ethereum metrics library.
+// Like the go-ethereum metrics, it is a no-op unless metrics.Enabled is set before creating it.
+type GethMetrics struct {
+	registry metrics.Registry
+
+	deposits       metrics.Histogram
+	derivationTime metrics.Timer
+	fetchTime      metrics.Timer
+}
+
+var _ Metrics = (*GethMetrics)(nil)
+
+// NewGethMetrics registers the derivation metrics in the given registry, e.g. metrics.DefaultRegistry.
+func NewGethMetrics(r metrics.Registry) *GethMetrics {
+	return &GethMetrics{
+		registry:       r,
+		deposits:       metrics.NewRegisteredHistogram("opnode/derivation/deposits", r, metrics.NewExpDecaySample(1028, 0.015)),
+		derivationTime: metrics.NewRegisteredTimer("opnode/derivation/time", r),
+		fetchTime:      metrics.NewRegisteredTimer("opnode/derivation/fetch", r),
+	}
+}
+
+func (m *GethMetrics) RecordDeposits(count int) {
+	m.deposits.Update(int64(count))
+}
+
+func (m *GethMetrics) RecordDerivationTime(d time.Duration) {
+	m.derivationTime.Update(d)
+}
+
+func (m *GethMetrics) RecordFetchTime(d time.Duration) {
+	m.fetchTime.Update(d)
+}
+
+func (m *GethMetrics) RecordDecodeFailure(kind string) {
+	metrics.GetOrRegisterCounter("opnode/derivation/decode_failures/"+kind, m.registry).Inc(1)
+}
+
+func (m *GethMetrics) RecordDroppedBatch(reason string) {
+	metrics.GetOrRegisterCounter("opnode/derivation/dropped_batches/"+reason, m.registry).Inc(1)
+}
+
+// userDepositsCount counts the user deposits in the attributes: all deposit transactions but the L1 info deposit.
+func userDepositsCount(attrs *PayloadAttributes) int {
+	count := 0
+	for _, tx := range attrs.Transactions {
+		if len(tx) > 0 && tx[0] == types.DepositTxType {
+			count += 1
+		}
+	}
+	if count > 0 {
+		count -= 1
+	}
+	return count
+}
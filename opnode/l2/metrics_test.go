@@ -0,0 +1,153 @@
+package l2
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testMetrics records all metrics, for tests to inspect
+type testMetrics struct {
+	mu             sync.Mutex
+	deposits       []int
+	derivations    int
+	fetches        int
+	decodeFailures map[string]int
+	droppedBatches map[string]int
+}
+
+func (m *testMetrics) RecordDeposits(count int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deposits = append(m.deposits, count)
+}
+
+func (m *testMetrics) RecordDerivationTime(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.derivations += 1
+}
+
+func (m *testMetrics) RecordFetchTime(d time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.fetches += 1
+}
+
+func (m *testMetrics) RecordDecodeFailure(kind string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.decodeFailures == nil {
+		m.decodeFailures = make(map[string]int)
+	}
+	m.decodeFailures[kind] += 1
+}
+
+func (m *testMetrics) RecordDroppedBatch(reason string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.droppedBatches == nil {
+		m.droppedBatches = make(map[string]int)
+	}
+	m.droppedBatches[reason] += 1
+}
+
+func TestUserDepositsCount(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	l1Info, err := DeriveL1InfoDeposit(&Config{}, seqInfo(10, 1000), 0)
+	require.NoError(t, err)
+	attrs := &PayloadAttributes{}
+	assert.Equal(t, 0, userDepositsCount(attrs))
+	attrs.Transactions = append(attrs.Transactions, mustMarshalTx(t, types.NewTx(l1Info)))
+	assert.Equal(t, 0, userDepositsCount(attrs))
+	for i := uint64(0); i < 3; i++ {
+		attrs.Transactions = append(attrs.Transactions, mustMarshalTx(t, types.NewTx(testutil.GenerateDeposit(10, i+1, rng))))
+	}
+	attrs.Transactions = append(attrs.Transactions, testL2Tx(t, 0))
+	assert.Equal(t, 3, userDepositsCount(attrs), "sequenced transactions are not deposits")
+}
+
+func mustMarshalTx(t *testing.T, tx *types.Transaction) Data {
+	data, err := tx.MarshalBinary()
+	require.NoError(t, err)
+	return data
+}
+
+func TestDerivationPipelineMetrics(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+	}
+	to := cfg.BatchInboxAddr
+	invalid := types.MustSignNewTx(batcherKey, types.LatestSignerForChainID(testL1ChainID),
+		&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &to, Data: []byte{0xba, 0xd}})
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(types.Transactions{invalid}, 0)
+
+	m := new(testMetrics)
+	dp := NewDerivationPipeline(cfg, chain, chain, eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	dp.Metrics = m
+	_, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+
+	assert.Equal(t, []int{0}, m.deposits)
+	assert.Equal(t, 1, m.derivations)
+	assert.Equal(t, 1, m.fetches)
+	assert.Equal(t, map[string]int{"frames": 1}, m.decodeFailures)
+}
+
+func TestGethMetrics(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	r := metrics.NewRegistry()
+	m := NewGethMetrics(r)
+	m.RecordDeposits(3)
+	m.RecordDeposits(5)
+	m.RecordDerivationTime(time.Millisecond)
+	m.RecordFetchTime(time.Millisecond)
+	m.RecordDecodeFailure("channel")
+	m.RecordDecodeFailure("channel")
+
+	assert.Equal(t, int64(8), r.Get("opnode/derivation/deposits").(metrics.Histogram).Sum())
+	assert.Equal(t, int64(1), r.Get("opnode/derivation/time").(metrics.Timer).Count())
+	assert.Equal(t, int64(1), r.Get("opnode/derivation/fetch").(metrics.Timer).Count())
+	assert.Equal(t, int64(2), r.Get("opnode/derivation/decode_failures/channel").(metrics.Counter).Count())
+}
+
+func TestMeteredRPC(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	r := metrics.NewRegistry()
+	backend := &testEngineRPC{
+		results: map[string]string{"engine_getPayloadV1": `{}`},
+		errs:    map[string]error{"engine_newPayloadV1": &testRPCErr{code: -32000, msg: "failed"}},
+	}
+	rpc := NewMeteredRPC(backend, r, "opnode/engine/0")
+	var res ExecutionPayload
+	require.NoError(t, rpc.CallContext(context.Background(), &res, "engine_getPayloadV1"))
+	require.Error(t, rpc.CallContext(context.Background(), &res, "engine_newPayloadV1"))
+	require.Error(t, rpc.CallContext(context.Background(), &res, "engine_newPayloadV1"))
+
+	assert.Equal(t, int64(1), r.Get("opnode/engine/0/rpc/engine_getPayloadV1").(metrics.Timer).Count())
+	assert.Equal(t, int64(2), r.Get("opnode/engine/0/rpc/engine_newPayloadV1").(metrics.Timer).Count())
+	assert.Nil(t, r.Get("opnode/engine/0/rpc_errors/engine_getPayloadV1"))
+	assert.Equal(t, int64(2), r.Get("opnode/engine/0/rpc_errors/engine_newPayloadV1").(metrics.Counter).Count())
+}
@@ -0,0 +1,141 @@
+package l2
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// maxTrackedPayloads bounds the number of payloads that are being built, for which the secondary payload IDs are remembered
+const maxTrackedPayloads = 64
+
+// Divergence describes a secondary engine that disagreed with the primary engine
+type Divergence struct {
+	// Method is the engine API method that the engines disagreed on
+	Method string
+	// Secondary is the index of the diverging secondary engine
+	Secondary int
+	// Expected is the result of the primary engine, Got the result of the secondary engine
+	Expected string
+	Got      string
+	// Err is the error of the secondary engine, if it failed where the primary engine did not
+	Err error
+}
+
+func (d Divergence) String() string {
+	if d.Err != nil {
+		return fmt.Sprintf("%s: secondary engine %d failed: %v", d.Method, d.Secondary, d.Err)
+	}
+	return fmt.Sprintf("%s: secondary engine %d returned %s, expected %s", d.Method, d.Secondary, d.Got, d.Expected)
+}
+
+// DivergenceFn is called for every result of a secondary engine that differs from the primary engine
+type DivergenceFn func(d Divergence)
+
+// MultiEngine drives several execution engines with the same engine API calls, to test different engine implementations
+// against each other. The primary engine leads: its results are returned to the driver, and it serves the eth API.
+// The results of the secondary engines are reconciled with those of the primary engine, and any divergence is flagged,
+// but does not affect the driver.
+//
+// Each engine builds its own payload from the same attributes: the payloads are expected to have the same block hash.
+// The primary payload is then executed by all engines.
+type MultiEngine struct {
+	// Primary is the leading engine
+	DriverAPI
+	Secondaries []DriverAPI
+
+	Log log.Logger
+	// OnDivergence is optional, to observe diverging secondary engines
+	OnDivergence DivergenceFn
+
+	mu sync.Mutex
+	// primary payload ID -> payload IDs of the secondary engines, nil if the secondary did not start building
+	payloads map[PayloadID][]*PayloadID
+}
+
+func (m *MultiEngine) diverged(d Divergence) {
+	m.Log.Warn("Secondary engine diverged from primary engine", "method", d.Method, "secondary", d.Secondary,
+		"expected", d.Expected, "got", d.Got, "err", d.Err)
+	if m.OnDivergence != nil {
+		m.OnDivergence(d)
+	}
+}
+
+func (m *MultiEngine) GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error) {
+	payload, err := m.DriverAPI.GetPayload(ctx, payloadId)
+	m.mu.Lock()
+	secondaryIDs := m.payloads[payloadId]
+	delete(m.payloads, payloadId)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range secondaryIDs {
+		if id == nil {
+			continue
+		}
+		res, err := m.Secondaries[i].GetPayload(ctx, *id)
+		if err != nil {
+			m.diverged(Divergence{Method: "getPayload", Secondary: i, Err: err})
+		} else if res.BlockHash != payload.BlockHash {
+			m.diverged(Divergence{Method: "getPayload", Secondary: i, Expected: payload.ID().String(), Got: res.ID().String()})
+		}
+	}
+	return payload, nil
+}
+
+func (m *MultiEngine) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	status, err := m.DriverAPI.NewPayload(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	for i, secondary := range m.Secondaries {
+		res, err := secondary.NewPayload(ctx, payload)
+		if err != nil {
+			m.diverged(Divergence{Method: "newPayload", Secondary: i, Err: err})
+		} else if res.Status != status.Status {
+			m.diverged(Divergence{Method: "newPayload", Secondary: i, Expected: string(status.Status), Got: string(res.Status)})
+		}
+	}
+	return status, nil
+}
+
+func (m *MultiEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	result, err := m.DriverAPI.ForkchoiceUpdated(ctx, state, attr)
+	if err != nil {
+		return ForkchoiceUpdatedResult{}, err
+	}
+	secondaryIDs := make([]*PayloadID, len(m.Secondaries))
+	for i, secondary := range m.Secondaries {
+		res, err := secondary.ForkchoiceUpdated(ctx, state, attr)
+		if err != nil {
+			m.diverged(Divergence{Method: "forkchoiceUpdated", Secondary: i, Err: err})
+			continue
+		}
+		if res.PayloadStatus.Status != result.PayloadStatus.Status {
+			m.diverged(Divergence{Method: "forkchoiceUpdated", Secondary: i,
+				Expected: string(result.PayloadStatus.Status), Got: string(res.PayloadStatus.Status)})
+		}
+		secondaryIDs[i] = res.PayloadID
+	}
+	if result.PayloadID != nil {
+		m.mu.Lock()
+		if m.payloads == nil || len(m.payloads) >= maxTrackedPayloads {
+			// payloads that were never retrieved are forgotten
+			m.payloads = make(map[PayloadID][]*PayloadID)
+		}
+		m.payloads[*result.PayloadID] = secondaryIDs
+		m.mu.Unlock()
+	}
+	return result, nil
+}
+
+// Close closes the primary and all secondary engines
+func (m *MultiEngine) Close() {
+	m.DriverAPI.Close()
+	for _, secondary := range m.Secondaries {
+		secondary.Close()
+	}
+}
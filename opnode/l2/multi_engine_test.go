@@ -0,0 +1,77 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// skewedEngine builds payloads with a different timestamp than requested
+type skewedEngine struct {
+	*fakeSeqEngine
+}
+
+func (s skewedEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	if attr != nil {
+		skewed := *attr
+		skewed.Timestamp += 1
+		attr = &skewed
+	}
+	return s.fakeSeqEngine.ForkchoiceUpdated(ctx, state, attr)
+}
+
+// rejectingEngine regards every new payload as invalid
+type rejectingEngine struct {
+	*fakeSeqEngine
+}
+
+func (r rejectingEngine) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	return &PayloadStatusV1{Status: ExecutionInvalid}, nil
+}
+
+func TestMultiEngine(t *testing.T) {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	var divergences []Divergence
+	multi := &MultiEngine{
+		DriverAPI: newFakeSeqEngine(genesis),
+		Secondaries: []DriverAPI{
+			newFakeSeqEngine(genesis),
+			skewedEngine{newFakeSeqEngine(genesis)},
+			rejectingEngine{newFakeSeqEngine(genesis)},
+		},
+		Log: log.New(),
+		OnDivergence: func(d Divergence) {
+			divergences = append(divergences, d)
+		},
+	}
+	genesisID := eth.BlockID{Hash: genesis.Hash(), Number: 0}
+	heads := L2Heads{Unsafe: genesisID, Safe: genesisID, Finalized: genesisID}
+	attrs := &PayloadAttributes{Timestamp: 1002, Random: Bytes32(common.Hash{1})}
+
+	out, err := InsertBlock(context.Background(), log.New(), multi, nil, eth.BlockID{}, heads, true, attrs, 0)
+	require.NoError(t, err, "the primary engine leads")
+	require.Equal(t, uint64(1), out.Unsafe.Number)
+	type diverged struct {
+		method    string
+		secondary int
+	}
+	var got []diverged
+	for _, d := range divergences {
+		got = append(got, diverged{d.Method, d.Secondary})
+	}
+	require.Equal(t, []diverged{
+		{"getPayload", 1},        // built a different block
+		{"newPayload", 1},        // only accepts the payloads it built itself
+		{"newPayload", 2},        // rejected the payload
+		{"forkchoiceUpdated", 1}, // missing the new head, as it did not accept the primary payload
+		{"forkchoiceUpdated", 2},
+	}, got, "the first secondary engine agrees with the primary engine")
+	require.Equal(t, string(ExecutionInvalid), divergences[2].Got)
+}
@@ -2,9 +2,6 @@ package l2
 
 import (
 	"context"
-	"fmt"
-
-	"github.com/ethereum/go-ethereum/common"
 )
 
 type BlockPreparer interface {
@@ -12,24 +9,10 @@ type BlockPreparer interface {
 	ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error)
 }
 
-// DeriveBlockOutputs uses the engine API to derive a full L2 block from the block inputs.
-// The fcState does not affect the block production, but may inform the engine of finality and head changes to sync towards before block computation.
-func DeriveBlockOutputs(ctx context.Context, engine BlockPreparer, l2Parent common.Hash, l2Finalized common.Hash, attributes *PayloadAttributes) (*ExecutionPayload, error) {
-	fcState := &ForkchoiceState{
-		HeadBlockHash:      l2Parent, // no difference yet between Head and Safe, no data ahead of L1 yet.
-		SafeBlockHash:      l2Parent,
-		FinalizedBlockHash: l2Finalized,
-	}
-	fcResult, err := engine.ForkchoiceUpdated(ctx, fcState, attributes)
-	if err != nil {
-		return nil, fmt.Errorf("engine failed to process forkchoice update for block derivation: %v", err)
-	} else if fcResult.Status != UpdateSuccess {
-		return nil, fmt.Errorf("engine not in sync, failed to derive block, status: %s", fcResult.Status)
-	}
-
-	payload, err := engine.GetPayload(ctx, *fcResult.PayloadID)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get payload: %v", err)
-	}
-	return payload, nil
+// DeriveBlockOutputs uses the engine API to derive a full L2 block from the block inputs, on top of the unsafe L2 head.
+// The safe and finalized L2 heads do not affect the block production, but inform the engine of the L2 forkchoice before block computation.
+// The payload is retrieved as soon as the engine started building it: derived blocks only consist of the given transactions.
+func DeriveBlockOutputs(ctx context.Context, engine BlockPreparer, heads L2Heads, attributes *PayloadAttributes) (*ExecutionPayload, error) {
+	builder := &PayloadBuilder{Engine: engine}
+	return builder.Build(ctx, heads, attributes)
 }
@@ -0,0 +1,21 @@
+package l2
+
+// L2OutputOracleABI is the ABI of the L2 output oracle on L1, that the outputs of the L2 chain are proposed to
+var L2OutputOracleABI = mustParseABI(`[
+	{"inputs":[],"name":"latestBlockNumber","outputs":[{"internalType":"uint256","name":"","type":"uint256"}],"stateMutability":"view","type":"function"},
+	{"inputs":[
+		{"internalType":"bytes32","name":"_l2Output","type":"bytes32"},
+		{"internalType":"uint256","name":"_l2BlockNumber","type":"uint256"},
+		{"internalType":"bytes32","name":"_l1Blockhash","type":"bytes32"},
+		{"internalType":"uint256","name":"_l1BlockNumber","type":"uint256"}
+	],"name":"appendL2Output","outputs":[],"stateMutability":"payable","type":"function"},
+	{"inputs":[{"internalType":"bytes32","name":"_l2Output","type":"bytes32"}],"name":"deleteL2Output","outputs":[],"stateMutability":"nonpayable","type":"function"},
+	{"anonymous":false,"inputs":[
+		{"indexed":true,"internalType":"bytes32","name":"_l2Output","type":"bytes32"},
+		{"indexed":true,"internalType":"uint256","name":"_l1Timestamp","type":"uint256"},
+		{"indexed":true,"internalType":"uint256","name":"_l2BlockNumber","type":"uint256"}
+	],"name":"L2OutputAppended","type":"event"}
+]`)
+
+// L2OutputAppendedEventABIHash is the topic of L2OutputAppended events, emitted for every proposed output
+var L2OutputAppendedEventABIHash = L2OutputOracleABI.Events["L2OutputAppended"].ID
@@ -0,0 +1,75 @@
+package l2
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// OutputVersionV0 is the version of the output root format
+var OutputVersionV0 = common.Hash{}
+
+// WithdrawalContractAddr is the L2 address of the withdrawal contract, whose storage commits to the withdrawals of the L2 chain.
+var WithdrawalContractAddr = common.HexToAddress("0x4200000000000000000000000000000000000016")
+
+// ProofSource fetches the account and storage proofs of the L2 state, as served by eth_getProof
+type ProofSource interface {
+	GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error)
+}
+
+// Output is the output of a L2 block: the commitment to the L2 state that is proposed to L1, with its components.
+type Output struct {
+	Version        common.Hash `json:"version"`
+	OutputRoot     common.Hash `json:"outputRoot"`
+	StateRoot      common.Hash `json:"stateRoot"`
+	WithdrawalRoot common.Hash `json:"withdrawalStorageRoot"`
+	Block          eth.BlockID `json:"block"`
+}
+
+// ComputeOutputRoot computes the output root of a L2 block, as verified by the L1 contracts:
+// keccak256(version ‖ stateRoot ‖ withdrawalStorageRoot ‖ blockHash)
+func ComputeOutputRoot(stateRoot common.Hash, withdrawalRoot common.Hash, blockHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(OutputVersionV0[:], stateRoot[:], withdrawalRoot[:], blockHash[:])
+}
+
+// OutputAtBlock queries the engine for the state root of the L2 block with the given number,
+// and the storage root of the withdrawal contract at that block, to compute the output root of the block.
+func OutputAtBlock(ctx context.Context, cfg *Config, blocks eth.BlockByNumberSource, proofs ProofSource, number uint64) (*Output, error) {
+	out, _, _, err := outputAtBlock(ctx, cfg, blocks, proofs, number, nil)
+	return out, err
+}
+
+// outputAtBlock computes the output root of the L2 block with the given number,
+// and returns the block and the withdrawal contract proof of the given storage keys that the output commits to.
+func outputAtBlock(ctx context.Context, cfg *Config, blocks eth.BlockByNumberSource, proofs ProofSource, number uint64, keys []string) (*Output, *types.Block, *gethclient.AccountResult, error) {
+	block, err := blocks.BlockByNumber(ctx, new(big.Int).SetUint64(number))
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch L2 block %d: %w", number, err)
+	}
+	// query by hash is not supported by eth_getProof, the number may point to a different block after a reorg
+	account, err := proofs.GetProof(ctx, cfg.WithdrawalContract(), keys, block.Number())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch withdrawal contract proof at L2 block %d: %w", number, err)
+	}
+	after, err := blocks.BlockByNumber(ctx, block.Number())
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch L2 block %d: %w", number, err)
+	}
+	if after.Hash() != block.Hash() {
+		return nil, nil, nil, fmt.Errorf("L2 block %d changed from %s to %s while computing its output root", number, block.Hash(), after.Hash())
+	}
+	return &Output{
+		Version:        OutputVersionV0,
+		OutputRoot:     ComputeOutputRoot(block.Root(), account.StorageHash, block.Hash()),
+		StateRoot:      block.Root(),
+		WithdrawalRoot: account.StorageHash,
+		Block:          eth.BlockID{Hash: block.Hash(), Number: block.NumberU64()},
+	}, block, account, nil
+}
@@ -0,0 +1,64 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeOutputRoot(t *testing.T) {
+	stateRoot := common.Hash{0x01}
+	withdrawalRoot := common.Hash{0x02}
+	blockHash := common.Hash{0x03}
+	data := make([]byte, 128)
+	copy(data[32:], stateRoot[:])
+	copy(data[64:], withdrawalRoot[:])
+	copy(data[96:], blockHash[:])
+	require.Equal(t, crypto.Keccak256Hash(data), ComputeOutputRoot(stateRoot, withdrawalRoot, blockHash))
+	require.NotEqual(t, ComputeOutputRoot(stateRoot, withdrawalRoot, blockHash), ComputeOutputRoot(withdrawalRoot, stateRoot, blockHash))
+}
+
+// fakeOutputSource serves a L2 chain, of which the block at the reorg number is replaced after the first query of it.
+type fakeOutputSource struct {
+	blocks      map[uint64]*types.Block
+	storageRoot common.Hash
+	account     common.Address
+	reorg       map[uint64]*types.Block
+}
+
+func (f *fakeOutputSource) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	bl := f.blocks[number.Uint64()]
+	if re, ok := f.reorg[number.Uint64()]; ok {
+		f.blocks[number.Uint64()] = re
+		delete(f.reorg, number.Uint64())
+	}
+	return bl, nil
+}
+
+func (f *fakeOutputSource) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	f.account = account
+	return &gethclient.AccountResult{Address: account, StorageHash: f.storageRoot}, nil
+}
+
+func TestOutputAtBlock(t *testing.T) {
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7), Root: common.Hash{0xaa}})
+	src := &fakeOutputSource{blocks: map[uint64]*types.Block{7: block}, storageRoot: common.Hash{0xbb}}
+
+	out, err := OutputAtBlock(context.Background(), &Config{}, src, src, 7)
+	require.NoError(t, err)
+	require.Equal(t, WithdrawalContractAddr, src.account)
+	require.Equal(t, common.Hash{0xaa}, out.StateRoot)
+	require.Equal(t, common.Hash{0xbb}, out.WithdrawalRoot)
+	require.Equal(t, block.Hash(), out.Block.Hash)
+	require.Equal(t, ComputeOutputRoot(common.Hash{0xaa}, common.Hash{0xbb}, block.Hash()), out.OutputRoot)
+
+	src.reorg = map[uint64]*types.Block{7: types.NewBlockWithHeader(&types.Header{Number: big.NewInt(7), Root: common.Hash{0xcc}})}
+	_, err = OutputAtBlock(context.Background(), &Config{}, src, src, 7)
+	require.Error(t, err, "block changed while computing the output root")
+}
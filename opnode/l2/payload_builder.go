@@ -0,0 +1,144 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+const (
+	// DefaultPayloadAttempts is the default number of times a payload is built before giving up
+	DefaultPayloadAttempts = 3
+	// payloadCallTimeout limits the duration of each engine API call of the payload building
+	payloadCallTimeout = time.Second * 5
+)
+
+// PayloadBuildState is the state of a PayloadBuilder
+type PayloadBuildState uint8
+
+const (
+	// PayloadIdle is the state without a payload being built
+	PayloadIdle PayloadBuildState = iota
+	// PayloadBuilding is the state after the engine started building a payload, until it is retrieved
+	PayloadBuilding
+)
+
+func (s PayloadBuildState) String() string {
+	switch s {
+	case PayloadIdle:
+		return "idle"
+	case PayloadBuilding:
+		return "building"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+// PayloadBuilder manages the lifecycle of building a payload with the engine:
+// a forkchoice update with payload attributes starts the building of a payload,
+// which is retrieved with getPayload after the build time.
+//
+// If the engine does not know the payload anymore, e.g. after it restarted, or retrieving the payload times out,
+// the building is restarted with a fresh forkchoice update, up to MaxAttempts times in total.
+type PayloadBuilder struct {
+	Engine BlockPreparer
+	// Log is optional, to log restarts of the payload building
+	Log log.Logger
+	// BuildTime is the time the engine is given to build the payload, e.g. to include transactions from its tx pool
+	BuildTime time.Duration
+	// MaxAttempts is the number of times the payload is built before giving up. Defaults to DefaultPayloadAttempts.
+	MaxAttempts int
+
+	state   PayloadBuildState
+	id      PayloadID
+	started time.Time
+	heads   L2Heads
+	attrs   *PayloadAttributes
+}
+
+// State returns the build state, and the ID of the payload that is being built, if any
+func (b *PayloadBuilder) State() (PayloadBuildState, PayloadID) {
+	return b.state, b.id
+}
+
+// Start has the engine start building a payload with the attributes on top of the unsafe L2 head.
+// The safe and finalized L2 heads inform the engine of the L2 forkchoice.
+// Any payload that is still being built is abandoned.
+func (b *PayloadBuilder) Start(ctx context.Context, heads L2Heads, attrs *PayloadAttributes) error {
+	b.state = PayloadIdle
+	b.heads, b.attrs = heads, attrs
+	fcCtx, cancel := context.WithTimeout(ctx, payloadCallTimeout)
+	defer cancel()
+	fcResult, err := b.Engine.ForkchoiceUpdated(fcCtx, heads.ForkchoiceState(), attrs)
+	if err != nil {
+		return fmt.Errorf("engine failed to process forkchoice update for block derivation: %w", err)
+	} else if fcResult.PayloadStatus.Status.Invalid() {
+		return fmt.Errorf("engine rejected the forkchoice head %s as %s: %w", heads.Unsafe, fcResult.PayloadStatus.Status, InvalidPayloadErr)
+	} else if fcResult.PayloadStatus.Status == ExecutionSyncing {
+		return fmt.Errorf("failed to derive block: %w", EngineSyncingErr)
+	} else if fcResult.PayloadStatus.Status != ExecutionValid {
+		return fmt.Errorf("engine not in sync, failed to derive block, status: %s", fcResult.PayloadStatus.Status)
+	} else if fcResult.PayloadID == nil {
+		return fmt.Errorf("engine did not start building a payload")
+	}
+	b.state = PayloadBuilding
+	b.id = *fcResult.PayloadID
+	b.started = time.Now()
+	return nil
+}
+
+// Get waits for the remainder of the build time, and then retrieves the payload that is being built.
+// The building is restarted if the payload is unknown to the engine or cannot be retrieved in time.
+func (b *PayloadBuilder) Get(ctx context.Context) (*ExecutionPayload, error) {
+	maxAttempts := b.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultPayloadAttempts
+	}
+	for attempt := 1; ; attempt++ {
+		if b.state != PayloadBuilding {
+			return nil, errors.New("no payload is being built")
+		}
+		if wait := time.Until(b.started.Add(b.BuildTime)); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		getCtx, cancel := context.WithTimeout(ctx, payloadCallTimeout)
+		payload, err := b.Engine.GetPayload(getCtx, b.id)
+		cancel()
+		if err == nil {
+			b.state = PayloadIdle
+			return payload, nil
+		}
+		retryable := errors.Is(err, UnknownPayloadErr) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, eth.TemporaryRPCErr)
+		if ctx.Err() != nil || !retryable {
+			b.state = PayloadIdle
+			return nil, fmt.Errorf("failed to get payload: %w", err)
+		}
+		if attempt >= maxAttempts {
+			b.state = PayloadIdle
+			return nil, fmt.Errorf("failed to get payload after %d attempts: %w", attempt, err)
+		}
+		if b.Log != nil {
+			b.Log.Warn("Failed to get payload, restarting payload building", "payload_id", b.id, "attempt", attempt, "err", err)
+		}
+		if err := b.Start(ctx, b.heads, b.attrs); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Build starts building a payload, and retrieves it after the build time
+func (b *PayloadBuilder) Build(ctx context.Context, heads L2Heads, attrs *PayloadAttributes) (*ExecutionPayload, error) {
+	if err := b.Start(ctx, heads, attrs); err != nil {
+		return nil, err
+	}
+	return b.Get(ctx)
+}
@@ -0,0 +1,75 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// forgetfulEngine forgets the payloads it builds, as many times as configured
+type forgetfulEngine struct {
+	*fakeSeqEngine
+	forget int
+	starts int
+	gets   []time.Time
+}
+
+func (f *forgetfulEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	f.starts++
+	return f.fakeSeqEngine.ForkchoiceUpdated(ctx, state, attr)
+}
+
+func (f *forgetfulEngine) GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error) {
+	f.gets = append(f.gets, time.Now())
+	if f.forget > 0 {
+		f.forget--
+		return nil, UnknownPayloadErr
+	}
+	return f.fakeSeqEngine.GetPayload(ctx, payloadId)
+}
+
+func TestPayloadBuilder(t *testing.T) {
+	genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesisID := eth.BlockID{Hash: genesis.Hash(), Number: 0}
+	heads := L2Heads{Unsafe: genesisID, Safe: genesisID, Finalized: genesisID}
+	attrs := &PayloadAttributes{Timestamp: 1002, Random: Bytes32(common.Hash{1})}
+
+	t.Run("build time", func(t *testing.T) {
+		engine := &forgetfulEngine{fakeSeqEngine: newFakeSeqEngine(genesis)}
+		builder := &PayloadBuilder{Engine: engine, BuildTime: time.Millisecond * 50}
+		start := time.Now()
+		require.NoError(t, builder.Start(context.Background(), heads, attrs))
+		state, _ := builder.State()
+		require.Equal(t, PayloadBuilding, state)
+		payload, err := builder.Get(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, uint64(1), uint64(payload.BlockNumber))
+		require.GreaterOrEqual(t, engine.gets[0].Sub(start), builder.BuildTime, "payload is retrieved after the build time")
+		state, _ = builder.State()
+		require.Equal(t, PayloadIdle, state)
+	})
+	t.Run("restart unknown payload", func(t *testing.T) {
+		engine := &forgetfulEngine{fakeSeqEngine: newFakeSeqEngine(genesis), forget: 2}
+		builder := &PayloadBuilder{Engine: engine}
+		payload, err := builder.Build(context.Background(), heads, attrs)
+		require.NoError(t, err)
+		require.Equal(t, uint64(1002), uint64(payload.Timestamp))
+		require.Equal(t, 3, engine.starts, "fresh forkchoice update for each attempt")
+	})
+	t.Run("give up", func(t *testing.T) {
+		engine := &forgetfulEngine{fakeSeqEngine: newFakeSeqEngine(genesis), forget: 10}
+		builder := &PayloadBuilder{Engine: engine, MaxAttempts: 2}
+		_, err := builder.Build(context.Background(), heads, attrs)
+		require.ErrorIs(t, err, UnknownPayloadErr)
+		require.Equal(t, 2, engine.starts)
+		state, _ := builder.State()
+		require.Equal(t, PayloadIdle, state)
+	})
+}
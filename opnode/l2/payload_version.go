@@ -0,0 +1,83 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// UnsupportedPayloadVersionErr is returned when payload attributes do not match their version
+var UnsupportedPayloadVersionErr = errors.New("unsupported payload version")
+
+// PayloadVersion is the version of the payload attributes, and of the engine API methods they are sent with.
+// New versions are introduced with hardforks, and are activated by L2 timestamp: see Config.PayloadVersion.
+type PayloadVersion uint8
+
+const (
+	// PayloadV1 is the initial version of the payload attributes
+	PayloadV1 PayloadVersion = 1
+	// PayloadV2 adds the withdrawals to the payload attributes
+	PayloadV2 PayloadVersion = 2
+)
+
+func (v PayloadVersion) String() string {
+	return fmt.Sprintf("V%d", uint8(v))
+}
+
+// Withdrawal is a withdrawal of the beacon chain, as included in the payload attributes from PayloadV2 on.
+// The rollup has no beacon chain, and L2 blocks have no withdrawals: the list is empty, but present.
+type Withdrawal struct {
+	Index     Uint64Quantity `json:"index"`
+	Validator Uint64Quantity `json:"validatorIndex"`
+	Address   common.Address `json:"address"`
+	Amount    Uint64Quantity `json:"amount"`
+}
+
+// PayloadVersion returns the version of the payload attributes of a L2 block with the given timestamp
+func (cfg *Config) PayloadVersion(timestamp uint64) PayloadVersion {
+	if cfg.PayloadV2Time != 0 && timestamp >= cfg.PayloadV2Time {
+		return PayloadV2
+	}
+	return PayloadV1
+}
+
+// SetPayloadVersion sets the version of the payload attributes by their timestamp,
+// and fills in the fields that the version requires.
+func (cfg *Config) SetPayloadVersion(attrs *PayloadAttributes) {
+	attrs.Version = cfg.PayloadVersion(uint64(attrs.Timestamp))
+	switch attrs.Version {
+	case PayloadV1:
+		attrs.Withdrawals = nil
+	default:
+		if attrs.Withdrawals == nil {
+			attrs.Withdrawals = &[]Withdrawal{}
+		}
+	}
+}
+
+// CheckVersion checks that the payload attributes have the fields of their version, and no others.
+// Attributes without version are PayloadV1.
+func (attrs *PayloadAttributes) CheckVersion() error {
+	switch attrs.Version {
+	case 0, PayloadV1:
+		if attrs.Withdrawals != nil {
+			return fmt.Errorf("withdrawals are not supported by %s payload attributes: %w", PayloadV1, UnsupportedPayloadVersionErr)
+		}
+	case PayloadV2:
+		if attrs.Withdrawals == nil {
+			return fmt.Errorf("withdrawals are required by %s payload attributes: %w", PayloadV2, UnsupportedPayloadVersionErr)
+		}
+	default:
+		return fmt.Errorf("unknown payload version %s: %w", attrs.Version, UnsupportedPayloadVersionErr)
+	}
+	return nil
+}
+
+// forkchoiceUpdatedMethod returns the engine API method to send a forkchoice update with the payload attributes with.
+func forkchoiceUpdatedMethod(attrs *PayloadAttributes) string {
+	if attrs == nil || attrs.Version <= PayloadV1 {
+		return "engine_forkchoiceUpdatedV1"
+	}
+	return fmt.Sprintf("engine_forkchoiceUpdatedV%d", uint8(attrs.Version))
+}
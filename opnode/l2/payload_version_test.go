@@ -0,0 +1,63 @@
+package l2
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetPayloadVersion(t *testing.T) {
+	cfg := &Config{PayloadV2Time: 1000}
+	attrs := &PayloadAttributes{Timestamp: 998}
+	cfg.SetPayloadVersion(attrs)
+	assert.Equal(t, PayloadV1, attrs.Version)
+	assert.Nil(t, attrs.Withdrawals)
+	require.NoError(t, attrs.CheckVersion())
+	data, err := json.Marshal(attrs)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "withdrawals", "V1 attributes are unchanged")
+
+	attrs.Timestamp = 1000
+	cfg.SetPayloadVersion(attrs)
+	assert.Equal(t, PayloadV2, attrs.Version)
+	require.NotNil(t, attrs.Withdrawals)
+	require.NoError(t, attrs.CheckVersion())
+	data, err = json.Marshal(attrs)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"withdrawals":[]`)
+
+	assert.Equal(t, PayloadV1, (&Config{}).PayloadVersion(1<<40), "V2 is not scheduled")
+
+	assert.ErrorIs(t, (&PayloadAttributes{Version: PayloadV1, Withdrawals: &[]Withdrawal{}}).CheckVersion(), UnsupportedPayloadVersionErr)
+	assert.ErrorIs(t, (&PayloadAttributes{Version: PayloadV2}).CheckVersion(), UnsupportedPayloadVersionErr)
+	assert.ErrorIs(t, (&PayloadAttributes{Version: 3}).CheckVersion(), UnsupportedPayloadVersionErr)
+	assert.NoError(t, (&PayloadAttributes{}).CheckVersion(), "attributes without version are V1")
+}
+
+func TestEngineClient_ForkchoiceUpdatedVersion(t *testing.T) {
+	result := `{"payloadStatus":{"status":"VALID"},"payloadId":"0x0000000000000001"}`
+	rpc := &testEngineRPC{results: map[string]string{
+		"engine_forkchoiceUpdatedV1": result,
+		"engine_forkchoiceUpdatedV2": result,
+	}}
+	client := &EngineClient{RPCBackend: rpc, Log: log.New()}
+	ctx := context.Background()
+	cfg := &Config{PayloadV2Time: 1000}
+	for _, timestamp := range []uint64{998, 1000} {
+		attrs := &PayloadAttributes{Timestamp: Uint64Quantity(timestamp)}
+		cfg.SetPayloadVersion(attrs)
+		_, err := client.ForkchoiceUpdated(ctx, &ForkchoiceState{}, attrs)
+		require.NoError(t, err)
+	}
+	_, err := client.ForkchoiceUpdated(ctx, &ForkchoiceState{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"engine_forkchoiceUpdatedV1", "engine_forkchoiceUpdatedV2", "engine_forkchoiceUpdatedV1"}, rpc.calls)
+
+	_, err = client.ForkchoiceUpdated(ctx, &ForkchoiceState{}, &PayloadAttributes{Version: PayloadV2})
+	assert.ErrorIs(t, err, UnsupportedPayloadVersionErr)
+	assert.Len(t, rpc.calls, 3, "invalid attributes are not sent")
+}
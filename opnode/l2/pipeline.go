@@ -0,0 +1,512 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+var ReorgErr = errors.New("L1 reorg detected")
+
+// l1FetchTimeout bounds the time to fetch a L1 block with its receipts
+const l1FetchTimeout = time.Second * 20
+
+// ResettableStage is a stage of the derivation pipeline.
+// Reset unwinds the stage to the given L1 block, after which derivation continues from the next L1 block.
+type ResettableStage interface {
+	Reset(l1Base eth.BlockID)
+}
+
+// L1Traversal walks the L1 chain block by block, and detects when the traversed chain was reorged.
+type L1Traversal struct {
+	src eth.HeaderByNumberSource
+	// last traversed block. The hash is zero if the block is not known, and the next block is not checked against it.
+	current eth.BlockID
+	// header of the last traversed block, nil if no block was traversed since the last reset
+	currentHeader *types.Header
+}
+
+var _ ResettableStage = (*L1Traversal)(nil)
+
+func NewL1Traversal(src eth.HeaderByNumberSource, l1Base eth.BlockID) *L1Traversal {
+	return &L1Traversal{src: src, current: l1Base}
+}
+
+// Next returns the next L1 block, or an error wrapping ReorgErr if it does not build on the last traversed block.
+// The ethereum.NotFound error is returned as-is if the next block does not exist yet.
+// A header that is not the requested block, or does not follow the last traversed header,
+// is rejected with an error wrapping eth.InvalidResponseErr, before it is used in derivation.
+func (t *L1Traversal) Next(ctx context.Context) (eth.BlockID, error) {
+	num := t.current.Number + 1
+	header, err := t.src.HeaderByNumber(ctx, new(big.Int).SetUint64(num))
+	if err != nil {
+		return eth.BlockID{}, err
+	}
+	if header.Number == nil || header.Number.Uint64() != num {
+		return eth.BlockID{}, fmt.Errorf("requested L1 block %d, but got block %v: %w", num, header.Number, eth.InvalidResponseErr)
+	}
+	if t.current.Hash != (common.Hash{}) && header.ParentHash != t.current.Hash {
+		return eth.BlockID{}, fmt.Errorf("L1 block %d has parent %s, but traversed %s: %w", header.Number, header.ParentHash, t.current, ReorgErr)
+	}
+	if t.currentHeader != nil {
+		if err := eth.VerifyHeaderLink(t.currentHeader, header); err != nil {
+			return eth.BlockID{}, err
+		}
+	}
+	t.current = eth.BlockID{Hash: header.Hash(), Number: num}
+	t.currentHeader = header
+	return t.current, nil
+}
+
+func (t *L1Traversal) Reset(l1Base eth.BlockID) {
+	t.current = l1Base
+	t.currentHeader = nil
+}
+
+// BatchQueue buffers the batches read from complete channels, until they are included in a L2 block.
+type BatchQueue struct {
+	batches []*BatchData
+}
+
+func (bq *BatchQueue) Push(batches ...*BatchData) {
+	bq.batches = append(bq.batches, batches...)
+}
+
+func (bq *BatchQueue) remove(batch *BatchData) {
+	for i, b := range bq.batches {
+		if b == batch {
+			bq.batches = append(bq.batches[:i], bq.batches[i+1:]...)
+			return
+		}
+	}
+}
+
+// PopAll returns the sequenced transactions of all queued batches, in order, and empties the queue.
+func (bq *BatchQueue) PopAll() []Data {
+	var out []Data
+	for _, batch := range bq.batches {
+		out = append(out, batch.Transactions...)
+	}
+	bq.batches = nil
+	return out
+}
+
+// Reset drops all queued batches.
+func (bq *BatchQueue) Reset() {
+	bq.batches = nil
+}
+
+// DerivationPipeline derives the L2 block inputs from L1 in stages:
+// L1 traversal → block and receipts retrieval → channel bank → batch queue → attributes.
+//
+// The pipeline can be reset to a L1 base block, so an L1 reorg unwinds the derivation deterministically:
+// on Reset the channel bank and batch queue are emptied, and the pipeline replays the ChannelTimeout L1 blocks up to and including the base block,
+// to rebuild the channels that were pending at the base block, without deriving attributes for the replayed blocks.
+// The base block is then checked to still be canonical. Without a L2 block time, the batches of the replayed blocks
+// were included in L2 blocks before the reset, and are dropped. With a L2 block time, they are kept:
+// the batches of the L2 blocks before the reset are dropped as outdated, the others are still to be included.
+// ResetToEpoch resets the pipeline within an epoch, to continue the epoch of a L2 block that was derived before.
+//
+// With a L2 block time, every L1 block starts an epoch of L2 blocks: the first L2 block of the epoch
+// includes the L1 deposits, the other L2 blocks only the L1 info deposit with their sequence number.
+// Each L2 block includes the queued batch that matches its parent, L1 origin and timestamp: see CheckBatch.
+// Invalid batches are dropped, and batches of later L2 blocks are kept until then.
+// The epoch ends when the next L2 block is due at or after the timestamp of the next L1 block,
+// or would exceed the max sequencer drift of the L1 origin.
+// If no batch is queued, the L2 block is derived empty once the sequencing window of its epoch elapsed,
+// so the L2 chain progresses without the sequencer.
+// Without a L2 block time, every L1 block derives a single L2 block, with the L1 timestamp and all queued batches.
+//
+// The ConfigUpdate events of the SystemConfig contract update the system config that L2 blocks are built with,
+// from their L1 origin on, and the batcher that batches are accepted from, from the next L1 block on.
+// The SystemConfig of the rollup config is the system config at the initial L1 base block.
+//
+// The DerivationPipeline is not safe for concurrent use.
+type DerivationPipeline struct {
+	cfg *Config
+
+	// Metrics is optional, to monitor the derivation
+	Metrics Metrics
+	// L1Logs is optional, to fetch the deposit and ConfigUpdate logs of L1 blocks instead of all their receipts.
+	// The receipts are still fetched if the logs bloom indicates that logs may be missing, see LogsTrustStrict.
+	L1Logs *L1LogsFetcher
+	// DataSource is optional, to retrieve the batch submission data of L1 blocks from.
+	// Defaults to the calldata of the batcher transactions, see CalldataSource.
+	DataSource DataSource
+
+	traversal *L1Traversal
+	dl        Downloader
+	bank      *ChannelBank
+	queue     *BatchQueue
+
+	// L1 block up to which blocks are replayed after a reset, to rebuild the channel bank
+	replayUntil eth.BlockID
+
+	// epoch of the last derived L2 block, nil if no L2 block was derived since the last reset
+	epoch *EpochState
+	// traversed L1 blocks, starting at the L1 origin of the last derived L2 block, to derive the next epochs from
+	origins []*types.Header
+	// hash of the last L2 block built from the derived block inputs, zero if unknown
+	l2Parent common.Hash
+	// changes of the system config by the traversed L1 blocks, in order. Before the first change,
+	// the system config is the SystemConfig of the rollup config.
+	sysCfgChanges []SystemConfigChange
+}
+
+// SystemConfigChange is the system config from a L1 block on, after the ConfigUpdate events in the block.
+type SystemConfigChange struct {
+	L1Number uint64       `json:"l1Number"`
+	Config   SystemConfig `json:"config"`
+}
+
+// EpochState is the L1 origin of the last derived L2 block, and the sequence number and timestamp of the L2 block.
+type EpochState struct {
+	Origin    *types.Header `json:"origin"`
+	SeqNumber uint64        `json:"seqNumber"`
+	Timestamp uint64        `json:"timestamp"`
+}
+
+// ID returns the block-id of the L1 origin
+func (e *EpochState) ID() eth.BlockID {
+	return eth.BlockID{Hash: e.Origin.Hash(), Number: e.Origin.Number.Uint64()}
+}
+
+func NewDerivationPipeline(cfg *Config, l1 eth.HeaderByNumberSource, dl Downloader, l1Base eth.BlockID) *DerivationPipeline {
+	dp := &DerivationPipeline{
+		cfg:       cfg,
+		traversal: NewL1Traversal(l1, l1Base),
+		dl:        dl,
+		bank:      NewChannelBank(cfg),
+		queue:     new(BatchQueue),
+	}
+	dp.Reset(l1Base)
+	return dp
+}
+
+var _ ResettableStage = (*DerivationPipeline)(nil)
+
+// Reset resets all the stages to the given L1 base block. The next Step derives the block inputs of the block after.
+func (dp *DerivationPipeline) Reset(l1Base eth.BlockID) {
+	dp.bank.Reset()
+	dp.queue.Reset()
+	dp.replayUntil = l1Base
+	dp.epoch = nil
+	dp.origins = nil
+	dp.l2Parent = common.Hash{}
+	// the changes up to the base block are canonical, and not replayed
+	i := len(dp.sysCfgChanges)
+	for i > 0 && dp.sysCfgChanges[i-1].L1Number > l1Base.Number {
+		i--
+	}
+	dp.sysCfgChanges = dp.sysCfgChanges[:i]
+	replay := dp.cfg.ChannelTimeout
+	if replay > l1Base.Number {
+		replay = l1Base.Number
+	}
+	if replay == 0 {
+		dp.traversal.Reset(l1Base)
+	} else {
+		// the block before the first replayed block, the hash is unknown until the replay checks the base block
+		dp.traversal.Reset(eth.BlockID{Number: l1Base.Number - replay})
+	}
+}
+
+// ResetToEpoch resets all the stages to the L1 origin of the epoch, to continue the epoch after the L2 block
+// with the sequence number and timestamp of the epoch. The next Step derives the L2 block after it.
+func (dp *DerivationPipeline) ResetToEpoch(epoch *EpochState) {
+	dp.Reset(epoch.ID())
+	dp.epoch = epoch.copy()
+	dp.origins = []*types.Header{types.CopyHeader(epoch.Origin)}
+}
+
+// SetL2Parent sets the hash of the L2 block that was built from the last derived block inputs,
+// to check that the batch of the next L2 block builds on it. The parent is not checked if it is unknown,
+// e.g. after a reset, until it is set again.
+func (dp *DerivationPipeline) SetL2Parent(hash common.Hash) {
+	dp.l2Parent = hash
+}
+
+// Step derives the block inputs of the next L2 block, and returns them with the L1 origin of the L2 block.
+// An error wrapping ReorgErr is returned if the traversed L1 chain was reorged, and the pipeline must be Reset.
+// The ethereum.NotFound error is returned as-is if more L1 blocks are needed to derive the next L2 block.
+func (dp *DerivationPipeline) Step(ctx context.Context) (*PayloadAttributes, eth.BlockID, error) {
+	if dp.cfg.BlockTime == 0 {
+		return dp.stepL1Block(ctx)
+	}
+	for {
+		attrs, origin, err := dp.nextInEpochs(ctx)
+		if err != nil {
+			return nil, eth.BlockID{}, err
+		}
+		if attrs != nil {
+			return attrs, origin, nil
+		}
+		if _, err := dp.traverse(ctx); err != nil {
+			return nil, eth.BlockID{}, err
+		}
+	}
+}
+
+// stepL1Block derives a single L2 block from the next L1 block, with all queued batches.
+func (dp *DerivationPipeline) stepL1Block(ctx context.Context) (*PayloadAttributes, eth.BlockID, error) {
+	for {
+		bl, err := dp.traverse(ctx)
+		if err != nil {
+			return nil, eth.BlockID{}, err
+		}
+		if bl == nil {
+			continue
+		}
+		id := eth.BlockID{Hash: bl.Hash(), Number: bl.NumberU64()}
+		m := metricsOrNoop(dp.Metrics)
+		start := time.Now()
+		attrs, err := bl.blockInputs(dp.cfg.WithSystemConfig(dp.systemConfigAt(id.Number)))
+		if err != nil {
+			return nil, eth.BlockID{}, fmt.Errorf("failed to derive block inputs from L1 block %s: %v", id, err)
+		}
+		attrs.Transactions = append(attrs.Transactions, dp.queue.PopAll()...)
+		m.RecordDerivationTime(time.Since(start))
+		m.RecordDeposits(userDepositsCount(attrs))
+		return attrs, id, nil
+	}
+}
+
+// traverse retrieves the next L1 block, and queues the batches of the channels it completes.
+// Replayed blocks are checked and nil is returned for them.
+// Other blocks are returned with their receipts or logs, and added to the L1 origins to derive epochs from.
+func (dp *DerivationPipeline) traverse(ctx context.Context) (*l1Block, error) {
+	prev := *dp.traversal
+	id, err := dp.traversal.Next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	m := metricsOrNoop(dp.Metrics)
+	start := time.Now()
+	bl, err := dp.fetch(ctx, id)
+	if err != nil {
+		// traverse the block again with the next step
+		*dp.traversal = prev
+		return nil, fmt.Errorf("failed to fetch L1 block %s with receipts: %w", id, err)
+	}
+	m.RecordFetchTime(time.Since(start))
+	// batches are authenticated with the batcher of the system config before the updates of this block
+	if err := dp.ingestFrames(ctx, dp.cfg.WithSystemConfig(dp.systemConfigAt(id.Number-1)), id, bl.Transactions()); err != nil {
+		*dp.traversal = prev
+		return nil, err
+	}
+	batches, errs := dp.bank.ReadBatches(id.Number) // invalid channels are ignored
+	for range errs {
+		m.RecordDecodeFailure("channel")
+	}
+	for _, batch := range batches {
+		if dp.cfg.BlockTime != 0 && !InSeqWindow(dp.cfg, batch, id.Number) {
+			m.RecordDroppedBatch(string(BatchPastWindow))
+			continue
+		}
+		dp.queue.Push(batch)
+	}
+
+	if id.Number <= dp.replayUntil.Number {
+		if id.Number == dp.replayUntil.Number && id.Hash != dp.replayUntil.Hash {
+			return nil, fmt.Errorf("L1 base block %s is no longer canonical, got %s: %w", dp.replayUntil, id, ReorgErr)
+		}
+		if dp.cfg.BlockTime == 0 {
+			dp.queue.PopAll()
+		}
+		return nil, nil
+	}
+	sysCfg := dp.systemConfigAt(id.Number)
+	for range bl.updateSystemConfig(dp.cfg, &sysCfg) { // invalid updates are ignored
+		m.RecordDecodeFailure("system_config")
+	}
+	if sysCfg != dp.systemConfigAt(id.Number) {
+		dp.sysCfgChanges = append(dp.sysCfgChanges, SystemConfigChange{L1Number: id.Number, Config: sysCfg})
+	}
+	dp.origins = append(dp.origins, bl.Header())
+	return bl, nil
+}
+
+// nextInEpochs derives the block inputs of the next L2 block, and returns them with the L1 origin of the L2 block.
+// Nil is returned if more L1 blocks have to be traversed first: to decide on the L1 origin of the next L2 block,
+// or to wait for its batch until the sequencing window of its epoch elapsed.
+func (dp *DerivationPipeline) nextInEpochs(ctx context.Context) (*PayloadAttributes, eth.BlockID, error) {
+	if len(dp.origins) == 0 {
+		return nil, eth.BlockID{}, nil
+	}
+	next := EpochState{Origin: dp.origins[0], SeqNumber: 0, Timestamp: dp.origins[0].Time}
+	newEpoch := dp.epoch == nil
+	if !newEpoch {
+		// the epoch continues until the next L2 block is due at the time of the next L1 block, or exceeds the drift
+		if len(dp.origins) < 2 {
+			return nil, eth.BlockID{}, nil
+		}
+		next = EpochState{Origin: dp.epoch.Origin, SeqNumber: dp.epoch.SeqNumber + 1, Timestamp: dp.epoch.Timestamp + dp.cfg.BlockTime}
+		if nextOrigin := dp.origins[1]; nextOrigin.Time <= next.Timestamp || next.Timestamp > dp.epoch.Origin.Time+dp.cfg.MaxSequencerDrift {
+			newEpoch = true
+			next.Origin = nextOrigin
+			next.SeqNumber = 0
+			// the L2 chain continues at the block time, unless the L1 origin is ahead of it
+			if nextOrigin.Time > next.Timestamp {
+				next.Timestamp = nextOrigin.Time
+			}
+		}
+	}
+	originID := next.ID()
+	batch := dp.nextBatch(NextL2Block{ParentHash: dp.l2Parent, Epoch: originID, Timestamp: next.Timestamp})
+	if batch == nil && dp.cfg.SeqWindowSize != 0 && dp.traversal.current.Number+1 < originID.Number+dp.cfg.SeqWindowSize {
+		// the batch may still be included within the sequencing window of the epoch
+		return nil, eth.BlockID{}, nil
+	}
+
+	// L2 blocks are built with the system config of their L1 origin
+	epochCfg := dp.cfg.WithSystemConfig(dp.systemConfigAt(originID.Number))
+	var attrs *PayloadAttributes
+	if next.SeqNumber == 0 {
+		m := metricsOrNoop(dp.Metrics)
+		bl, err := dp.fetch(ctx, originID)
+		if err != nil {
+			return nil, eth.BlockID{}, fmt.Errorf("failed to fetch L1 origin %s with receipts: %w", originID, err)
+		}
+		start := time.Now()
+		attrs, err = bl.blockInputs(epochCfg)
+		if err != nil {
+			return nil, eth.BlockID{}, fmt.Errorf("failed to derive block inputs from L1 block %s: %v", originID, err)
+		}
+		attrs.Timestamp = Uint64Quantity(next.Timestamp)
+		dp.cfg.SetPayloadVersion(attrs)
+		m.RecordDerivationTime(time.Since(start))
+		m.RecordDeposits(userDepositsCount(attrs))
+	} else {
+		var err error
+		attrs, err = SequencerBlockInputs(epochCfg, BlockInputFromHeader(next.Origin), next.SeqNumber, nil, next.Timestamp)
+		if err != nil {
+			return nil, eth.BlockID{}, fmt.Errorf("failed to derive block inputs %d of epoch %s: %v", next.SeqNumber, originID, err)
+		}
+		attrs.NoTxPool = true
+	}
+	// without batch, the L2 block is empty after the sequencing window elapsed, and nobody gets tx fees
+	if batch != nil {
+		dp.queue.remove(batch)
+		attrs.Transactions = append(attrs.Transactions, batch.Transactions...)
+		attrs.SuggestedFeeRecipient = epochCfg.FeeRecipient()
+	} else {
+		attrs.SuggestedFeeRecipient = common.Address{}
+	}
+
+	if newEpoch && dp.epoch != nil {
+		dp.origins = dp.origins[1:]
+	}
+	dp.epoch = &next
+	return attrs, originID, nil
+}
+
+// nextBatch drops the invalid batches from the queue, and returns the queued batch of the next L2 block, if any.
+func (dp *DerivationPipeline) nextBatch(next NextL2Block) *BatchData {
+	m := metricsOrNoop(dp.Metrics)
+	var out *BatchData
+	kept := dp.queue.batches[:0]
+	for _, batch := range dp.queue.batches {
+		if out != nil {
+			kept = append(kept, batch)
+			continue
+		}
+		check, reason := CheckBatch(dp.cfg, batch, next)
+		switch check {
+		case BatchAccept:
+			out = batch
+			kept = append(kept, batch)
+		case BatchFuture:
+			kept = append(kept, batch)
+		default:
+			m.RecordDroppedBatch(string(reason))
+		}
+	}
+	dp.queue.batches = kept
+	return out
+}
+
+// l1Block is a fetched L1 block, with either all its receipts, or its deposit and ConfigUpdate logs.
+type l1Block struct {
+	*types.Block
+	receipts []*types.Receipt
+	// logs are checked with the trust level, and only used if fromLogs is set
+	logs     []types.Log
+	trust    LogsTrustLevel
+	fromLogs bool
+}
+
+// blockInputs derives the block inputs of the L2 block of the L1 block.
+func (bl *l1Block) blockInputs(cfg *Config) (*PayloadAttributes, error) {
+	if bl.fromLogs {
+		return DeriveBlockInputsFromLogs(cfg, bl.trust, BlockInputFromBlock(bl.Block), bl.logs)
+	}
+	return DeriveBlockInputs(cfg, BlockInputFromBlock(bl.Block), bl.receipts)
+}
+
+// updateSystemConfig applies the ConfigUpdate events of the L1 block to the system config.
+func (bl *l1Block) updateSystemConfig(cfg *Config, sc *SystemConfig) []error {
+	if bl.fromLogs {
+		return UpdateSystemConfigFromLogs(cfg, sc, sortLogs(bl.logs))
+	}
+	return UpdateSystemConfig(cfg, sc, bl.receipts)
+}
+
+// fetch fetches the L1 block with its logs if L1Logs is set, or else with its receipts, within l1FetchTimeout.
+// The receipts are fetched instead of the logs if logs may be missing.
+func (dp *DerivationPipeline) fetch(ctx context.Context, id eth.BlockID) (*l1Block, error) {
+	ctx, cancel := context.WithTimeout(ctx, l1FetchTimeout)
+	defer cancel()
+	if dp.L1Logs != nil {
+		bl, logs, err := dp.L1Logs.Fetch(ctx, dp.cfg, id)
+		if err == nil {
+			return &l1Block{Block: bl, logs: logs, trust: dp.L1Logs.Trust, fromLogs: true}, nil
+		}
+		if !errors.Is(err, PossiblyMissingLogsErr) {
+			return nil, err
+		}
+	}
+	bl, receipts, err := dp.dl.Fetch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return &l1Block{Block: bl, receipts: receipts}, nil
+}
+
+// systemConfigAt returns the system config after the ConfigUpdate events of the given L1 block.
+func (dp *DerivationPipeline) systemConfigAt(l1Num uint64) SystemConfig {
+	for i := len(dp.sysCfgChanges) - 1; i >= 0; i-- {
+		if dp.sysCfgChanges[i].L1Number <= l1Num {
+			return dp.sysCfgChanges[i].Config
+		}
+	}
+	return dp.cfg.SystemConfig
+}
+
+func (dp *DerivationPipeline) ingestFrames(ctx context.Context, cfg *Config, id eth.BlockID, txs types.Transactions) error {
+	if cfg.BatchInboxAddr == (common.Address{}) {
+		return nil
+	}
+	src := dp.DataSource
+	if src == nil {
+		src = CalldataSource{}
+	}
+	datas, err := src.BatchData(ctx, cfg, id, txs)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve batch data of L1 block %s: %v", id, err)
+	}
+	for _, data := range datas {
+		frames, err := DecodeFrames(data)
+		if err != nil {
+			metricsOrNoop(dp.Metrics).RecordDecodeFailure("frames")
+			continue // not a frames submission, or invalid frames
+		}
+		dp.bank.IngestFrames(id.Number, frames)
+	}
+	return nil
+}
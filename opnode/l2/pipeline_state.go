@@ -0,0 +1,196 @@
+package l2
+
+import (
+	"fmt"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+func (id ChannelID) MarshalText() ([]byte, error) {
+	return hexutil.Bytes(id[:]).MarshalText()
+}
+
+func (id *ChannelID) UnmarshalText(text []byte) error {
+	return hexutil.UnmarshalFixedText("ChannelID", text, id[:])
+}
+
+// ChannelState is the state of a pending channel, to persist it with
+type ChannelState struct {
+	ID ChannelID `json:"id"`
+	// L1 block number the first frame of the channel was included in
+	OpenBlock uint64 `json:"openBlock"`
+	// frame number -> frame data
+	Frames map[uint16]hexutil.Bytes `json:"frames"`
+	// LastFrame is nil until the last frame is ingested
+	LastFrame *uint16 `json:"lastFrame,omitempty"`
+}
+
+// ChannelBankState is the state of a ChannelBank, to persist it with and restore it from after a restart,
+// instead of replaying the frames of the last ChannelTimeout L1 blocks.
+type ChannelBankState struct {
+	// pending channels, in order of opening
+	Channels []ChannelState `json:"channels"`
+	// channels that were read or timed out, by the L1 block they were opened in
+	Closed map[ChannelID]uint64 `json:"closed"`
+}
+
+// State returns a copy of the pending and closed channels.
+func (cb *ChannelBank) State() ChannelBankState {
+	state := ChannelBankState{
+		Channels: make([]ChannelState, 0, len(cb.queue)),
+		Closed:   make(map[ChannelID]uint64, len(cb.closed)),
+	}
+	for _, id := range cb.queue {
+		ch := cb.channels[id]
+		frames := make(map[uint16]hexutil.Bytes, len(ch.frames))
+		for nr, data := range ch.frames {
+			frames[nr] = data
+		}
+		var lastFrame *uint16
+		if ch.lastFrame != nil {
+			last := *ch.lastFrame
+			lastFrame = &last
+		}
+		state.Channels = append(state.Channels, ChannelState{ID: ch.id, OpenBlock: ch.openBlock, Frames: frames, LastFrame: lastFrame})
+	}
+	for id, openBlock := range cb.closed {
+		state.Closed[id] = openBlock
+	}
+	return state
+}
+
+// Restore replaces the channels of the bank with the given state.
+func (cb *ChannelBank) Restore(state ChannelBankState) error {
+	channels := make(map[ChannelID]*channel, len(state.Channels))
+	queue := make([]ChannelID, 0, len(state.Channels))
+	size := uint64(0)
+	for _, chState := range state.Channels {
+		if _, ok := channels[chState.ID]; ok {
+			return fmt.Errorf("duplicate channel %s", chState.ID)
+		}
+		ch := &channel{id: chState.ID, openBlock: chState.OpenBlock, frames: make(map[uint16][]byte, len(chState.Frames))}
+		if chState.LastFrame != nil {
+			last := *chState.LastFrame
+			ch.lastFrame = &last
+		}
+		for nr, data := range chState.Frames {
+			if ch.lastFrame != nil && nr > *ch.lastFrame {
+				return fmt.Errorf("channel %s has frame %d past the last frame %d", ch.id, nr, *ch.lastFrame)
+			}
+			ch.frames[nr] = data
+			ch.size += uint64(len(data))
+		}
+		channels[ch.id] = ch
+		queue = append(queue, ch.id)
+		size += ch.size
+	}
+	closed := make(map[ChannelID]uint64, len(state.Closed))
+	for id, openBlock := range state.Closed {
+		if _, ok := channels[id]; ok {
+			return fmt.Errorf("channel %s is both pending and closed", id)
+		}
+		closed[id] = openBlock
+	}
+	cb.channels = channels
+	cb.queue = queue
+	cb.closed = closed
+	cb.size = size
+	return nil
+}
+
+// PipelineState is the state of a DerivationPipeline, to persist it with and resume derivation from after a restart.
+type PipelineState struct {
+	// L1Base is the last traversed L1 block. Derivation resumes with the next L1 block.
+	L1Base eth.BlockID `json:"l1Base"`
+	// ReplayUntil is the L1 block up to which blocks are replayed after a reset, if any are left to replay
+	ReplayUntil eth.BlockID      `json:"replayUntil"`
+	Bank        ChannelBankState `json:"channelBank"`
+	// Batches are the batches that are queued, but not included in a L2 block yet
+	Batches []*BatchData `json:"batches"`
+	// Epoch is the epoch of the last derived L2 block, if any was derived since the last reset
+	Epoch *EpochState `json:"epoch,omitempty"`
+	// Origins are the traversed L1 blocks, starting at the L1 origin of the epoch, to derive the next epochs from
+	Origins []*types.Header `json:"origins"`
+	// SystemConfigChanges are the changes of the system config by the traversed L1 blocks
+	SystemConfigChanges []SystemConfigChange `json:"systemConfigChanges"`
+	// L2Parent is the hash of the last L2 block built from the derived block inputs, zero if unknown
+	L2Parent common.Hash `json:"l2Parent"`
+}
+
+// PipelineStore persists the state of a DerivationPipeline across restarts.
+type PipelineStore interface {
+	// LoadPipeline loads the persisted pipeline state, or returns false if no state was persisted yet.
+	LoadPipeline() (PipelineState, bool, error)
+	// StorePipeline persists the pipeline state, replacing the previous state.
+	StorePipeline(state PipelineState) error
+}
+
+// State returns the state of the pipeline. Derivation can be resumed from the state with RestoreDerivationPipeline.
+func (dp *DerivationPipeline) State() PipelineState {
+	return PipelineState{
+		L1Base:      dp.traversal.current,
+		ReplayUntil: dp.replayUntil,
+		Bank:        dp.bank.State(),
+		Batches:     append([]*BatchData(nil), dp.queue.batches...),
+		Epoch:       dp.epoch.copy(),
+		Origins:     copyHeaders(dp.origins),
+
+		SystemConfigChanges: append([]SystemConfigChange(nil), dp.sysCfgChanges...),
+		L2Parent:            dp.l2Parent,
+	}
+}
+
+func copyHeaders(headers []*types.Header) []*types.Header {
+	out := make([]*types.Header, 0, len(headers))
+	for _, h := range headers {
+		out = append(out, types.CopyHeader(h))
+	}
+	return out
+}
+
+func (e *EpochState) copy() *EpochState {
+	if e == nil {
+		return nil
+	}
+	out := *e
+	out.Origin = types.CopyHeader(e.Origin)
+	return &out
+}
+
+// RestoreDerivationPipeline creates a DerivationPipeline that resumes from the given state,
+// without replaying the L1 blocks that the channel bank was built from.
+// The L1 base block of the state is checked to still be canonical by the next Step.
+func RestoreDerivationPipeline(cfg *Config, l1 eth.HeaderByNumberSource, dl Downloader, state PipelineState) (*DerivationPipeline, error) {
+	// the hash is unknown only before the first replayed block
+	if state.L1Base.Hash == (common.Hash{}) && state.L1Base.Number >= state.ReplayUntil.Number {
+		return nil, fmt.Errorf("unknown L1 base block %s", state.L1Base)
+	}
+	if state.Epoch != nil && state.Epoch.Origin == nil {
+		return nil, fmt.Errorf("epoch of the last derived L2 block is missing its L1 origin")
+	}
+	for i, h := range state.Origins {
+		if h == nil {
+			return nil, fmt.Errorf("missing L1 origin %d", i)
+		}
+	}
+	bank := NewChannelBank(cfg)
+	if err := bank.Restore(state.Bank); err != nil {
+		return nil, fmt.Errorf("invalid channel bank state: %v", err)
+	}
+	return &DerivationPipeline{
+		cfg:         cfg,
+		traversal:   NewL1Traversal(l1, state.L1Base),
+		dl:          dl,
+		bank:        bank,
+		queue:       &BatchQueue{batches: append([]*BatchData(nil), state.Batches...)},
+		replayUntil: state.ReplayUntil,
+		epoch:       state.Epoch.copy(),
+		origins:     copyHeaders(state.Origins),
+
+		l2Parent:      state.L2Parent,
+		sysCfgChanges: append([]SystemConfigChange(nil), state.SystemConfigChanges...),
+	}, nil
+}
@@ -0,0 +1,59 @@
+package l2
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDerivationPipeline_RestoreState(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+		ChannelTimeout: 3,
+	}
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	frames := testFrames(1, chData, 2)
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[0])}, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[1])}, 0)
+
+	genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+	dp := NewDerivationPipeline(cfg, chain, chain, genesis)
+	_, _, err = dp.Step(context.Background())
+	require.NoError(t, err)
+
+	// the first frame is pending in the channel bank, and survives a JSON round trip
+	data, err := json.Marshal(dp.State())
+	require.NoError(t, err)
+	var state PipelineState
+	require.NoError(t, json.Unmarshal(data, &state))
+	assert.Equal(t, eth.BlockID{Hash: chain.blocks[1].Hash(), Number: 1}, state.L1Base)
+	require.Len(t, state.Bank.Channels, 1)
+
+	restored, err := RestoreDerivationPipeline(cfg, chain, chain, state)
+	require.NoError(t, err)
+	expected, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	attrs, id, err := restored.Step(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), id.Number)
+	require.Len(t, attrs.Transactions, 2, "the channel completes without replaying the first frame")
+	assert.Equal(t, expected, attrs)
+	assert.Empty(t, restored.State().Bank.Channels)
+
+	_, err = RestoreDerivationPipeline(cfg, chain, chain, PipelineState{L1Base: eth.BlockID{Number: 1}})
+	assert.Error(t, err, "the L1 base block must be known")
+}
@@ -0,0 +1,525 @@
+package l2
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"math/big"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testL1Chain is a L1 chain of blocks by number, serving headers and blocks with receipts
+type testL1Chain struct {
+	blocks   []*types.Block
+	receipts map[common.Hash][]*types.Receipt
+}
+
+func (c *testL1Chain) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if !number.IsUint64() || number.Uint64() >= uint64(len(c.blocks)) {
+		return nil, ethereum.NotFound
+	}
+	return c.blocks[number.Uint64()].Header(), nil
+}
+
+func (c *testL1Chain) Fetch(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+	for _, bl := range c.blocks {
+		if bl.Hash() == id.Hash {
+			return bl, c.receipts[id.Hash], nil
+		}
+	}
+	return nil, nil, ethereum.NotFound
+}
+
+func (c *testL1Chain) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	bl, _, err := c.Fetch(ctx, eth.BlockID{Hash: hash})
+	return bl, err
+}
+
+// FilterLogs returns the logs of the successful transactions of the queried block, like eth_getLogs
+func (c *testL1Chain) FilterLogs(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+	bl, receipts, err := c.Fetch(ctx, eth.BlockID{Hash: *q.BlockHash})
+	if err != nil {
+		return nil, err
+	}
+	var out []types.Log
+	index := uint(0)
+	for _, rec := range receipts {
+		for _, log := range rec.Logs {
+			out = append(out, types.Log{Address: log.Address, Topics: log.Topics, Data: log.Data, BlockNumber: bl.NumberU64(), BlockHash: bl.Hash(), Index: index})
+			index += 1
+		}
+	}
+	var matched []types.Log
+	for _, log := range out {
+		addrOk := len(q.Addresses) == 0
+		for _, addr := range q.Addresses {
+			addrOk = addrOk || log.Address == addr
+		}
+		topicOk := len(q.Topics) == 0
+		for _, topic := range q.Topics[0] {
+			topicOk = topicOk || (len(log.Topics) > 0 && log.Topics[0] == topic)
+		}
+		if addrOk && topicOk {
+			matched = append(matched, log)
+		}
+	}
+	return matched, nil
+}
+
+func (c *testL1Chain) SubscribeFilterLogs(ctx context.Context, q ethereum.FilterQuery, ch chan<- types.Log) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+// add appends a block with the given transactions, each with a successful receipt without logs.
+// Blocks are 12 seconds apart.
+func (c *testL1Chain) add(txs types.Transactions, extra byte) {
+	var receipts []*types.Receipt
+	for i := range txs {
+		receipts = append(receipts, &types.Receipt{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(21000 * (i + 1)), Logs: []*types.Log{}})
+	}
+	c.addWithReceipts(txs, receipts, extra)
+}
+
+// addWithReceipts appends a block with the given transactions and their receipts.
+func (c *testL1Chain) addWithReceipts(txs types.Transactions, receipts []*types.Receipt, extra byte) {
+	header := &types.Header{Number: big.NewInt(int64(len(c.blocks))), Time: 12 * uint64(len(c.blocks)), Difficulty: common.Big0, BaseFee: big.NewInt(7), Extra: []byte{extra}}
+	if len(c.blocks) > 0 {
+		header.ParentHash = c.blocks[len(c.blocks)-1].Hash()
+	}
+	bl := types.NewBlock(header, txs, nil, receipts, trie.NewStackTrie(nil))
+	c.blocks = append(c.blocks, bl)
+	if c.receipts == nil {
+		c.receipts = make(map[common.Hash][]*types.Receipt)
+	}
+	c.receipts[bl.Hash()] = receipts
+}
+
+func testFramesSubmission(t *testing.T, key *ecdsa.PrivateKey, to common.Address, frames ...Frame) *types.Transaction {
+	data, err := EncodeFrames(frames)
+	require.NoError(t, err)
+	return types.MustSignNewTx(key, types.LatestSignerForChainID(testL1ChainID),
+		&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &to, Data: data})
+}
+
+func TestL1Traversal(t *testing.T) {
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	id := func(n uint64) eth.BlockID {
+		return eth.BlockID{Hash: chain.blocks[n].Hash(), Number: n}
+	}
+	ctx := context.Background()
+	tr := NewL1Traversal(chain, id(0))
+	next, err := tr.Next(ctx)
+	require.NoError(t, err)
+	require.Equal(t, id(1), next)
+
+	// the header of the next block does not follow the traversed header
+	bad := types.CopyHeader(chain.blocks[2].Header())
+	bad.Time = chain.blocks[1].Time()
+	badSrc := eth.HeaderByNumberFn(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+		return bad, nil
+	})
+	tr.src = badSrc
+	_, err = tr.Next(ctx)
+	require.ErrorIs(t, err, eth.InvalidResponseErr)
+	require.Equal(t, id(1), tr.current, "the invalid header is not traversed")
+
+	// the header is not the requested block
+	tr.src = eth.HeaderByNumberFn(func(ctx context.Context, number *big.Int) (*types.Header, error) {
+		return chain.blocks[1].Header(), nil
+	})
+	_, err = tr.Next(ctx)
+	require.ErrorIs(t, err, eth.InvalidResponseErr)
+
+	tr.src = chain
+	_, err = tr.Next(ctx)
+	require.NoError(t, err)
+	_, err = tr.Next(ctx)
+	require.ErrorIs(t, err, ethereum.NotFound)
+
+	// a block that does not build on the traversed block is a reorg
+	tr.Reset(eth.BlockID{Hash: common.Hash{0xba, 0xd}, Number: 1})
+	_, err = tr.Next(ctx)
+	require.ErrorIs(t, err, ReorgErr)
+}
+
+func TestDerivationPipeline(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:    crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr: common.Address{0xff, 0x01},
+		ChannelTimeout: 3,
+	}
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	frames := testFrames(1, chData, 2)
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[0])}, 0)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, frames[1])}, 0)
+	chain.add(nil, 0)
+
+	genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+	dp := NewDerivationPipeline(cfg, chain, chain, genesis)
+
+	step := func(expectedNum uint64) *PayloadAttributes {
+		attrs, id, err := dp.Step(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, chain.blocks[expectedNum].Hash(), id.Hash)
+		assert.Equal(t, expectedNum, id.Number)
+		return attrs
+	}
+	assert.Len(t, step(1).Transactions, 1)
+	assert.Len(t, step(2).Transactions, 1)
+	attrs := step(3)
+	require.Len(t, attrs.Transactions, 2, "L1 info deposit and the sequenced tx of the completed channel")
+	assert.Equal(t, seqTx, attrs.Transactions[1])
+	assert.Len(t, step(4).Transactions, 1)
+	_, _, err = dp.Step(context.Background())
+	assert.True(t, errors.Is(err, ethereum.NotFound))
+
+	// resetting to block 2 replays the frame of block 2, and derives block 3 again in the same way
+	dp.Reset(eth.BlockID{Hash: chain.blocks[2].Hash(), Number: 2})
+	assert.Equal(t, attrs, step(3))
+
+	// resetting to a block that is no longer canonical
+	dp.Reset(eth.BlockID{Hash: common.Hash{0xba, 0xd}, Number: 2})
+	_, _, err = dp.Step(context.Background())
+	assert.True(t, errors.Is(err, ReorgErr))
+
+	// reorg the chain, replacing the base block after the reset
+	dp.Reset(eth.BlockID{Hash: chain.blocks[3].Hash(), Number: 3})
+	chain.blocks = chain.blocks[:3]
+	chain.add(nil, 1)
+	chain.add(nil, 1)
+	_, _, err = dp.Step(context.Background())
+	assert.True(t, errors.Is(err, ReorgErr))
+}
+
+func TestDerivationPipeline_Epochs(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:       crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr:    common.Address{0xff, 0x01},
+		ChannelTimeout:    3,
+		BlockTime:         2,
+		MaxSequencerDrift: 600,
+		SeqWindowSize:     2,
+	}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+
+	// the batches of the first two L2 blocks of the epoch of L1 block 1 are included in L1 block 2
+	epoch := chain.blocks[1]
+	seqTxA, seqTxB := testL2Tx(t, 0), testL2Tx(t, 1)
+	chData, err := EncodeChannel([]*BatchData{
+		{EpochNum: 1, EpochHash: epoch.Hash(), Timestamp: 12, Transactions: []Data{seqTxA}},
+		{EpochNum: 1, EpochHash: epoch.Hash(), Timestamp: 14, Transactions: []Data{seqTxB}},
+	}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, testFrames(1, chData, 1)...)}, 0)
+
+	dp := NewDerivationPipeline(cfg, chain, chain, eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	step := func(expectedOrigin uint64, expectedSeqNumber uint64, expectedTimestamp uint64) *PayloadAttributes {
+		attrs, id, err := dp.Step(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, chain.blocks[expectedOrigin].Hash(), id.Hash)
+		require.Equal(t, expectedTimestamp, uint64(attrs.Timestamp))
+		var l1Info types.Transaction
+		require.NoError(t, l1Info.UnmarshalBinary(attrs.Transactions[0]))
+		l1Num, _, _, _, seqNumber, _, _, _, err := ParseL1InfoDepositTxData(l1Info.Data())
+		require.NoError(t, err)
+		require.Equal(t, expectedOrigin, l1Num)
+		require.Equal(t, expectedSeqNumber, seqNumber)
+		return attrs
+	}
+	require.Equal(t, []Data{seqTxA}, step(1, 0, 12).Transactions[1:])
+	require.Equal(t, []Data{seqTxB}, step(1, 1, 14).Transactions[1:])
+	// the remaining L2 blocks of the epoch are empty, until the L2 block at the time of the next L1 block
+	for i := uint64(2); i < 6; i++ {
+		require.Len(t, step(1, i, 12+2*i).Transactions, 1)
+	}
+	// the sequencing window of the epoch of L1 block 2 is still open
+	_, _, err = dp.Step(context.Background())
+	require.True(t, errors.Is(err, ethereum.NotFound))
+	chain.add(nil, 0)
+	require.Len(t, step(2, 0, 24).Transactions, 1)
+	step(2, 1, 26)
+
+	// the epoch ends at the max sequencer drift, and the L2 chain continues at the time of the next L1 origin
+	cfg.MaxSequencerDrift = 4
+	dp.Reset(eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	step(1, 0, 12)
+	step(1, 1, 14)
+	step(1, 2, 16)
+	step(2, 0, 24)
+
+	// the epoch is persisted with the pipeline state
+	restored, err := RestoreDerivationPipeline(cfg, chain, chain, dp.State())
+	require.NoError(t, err)
+	dp = restored
+	step(2, 1, 26)
+}
+
+func TestDerivationPipeline_SeqWindow(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:           crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr:        common.Address{0xff, 0x01},
+		ChannelTimeout:        3,
+		BlockTime:             12,
+		MaxSequencerDrift:     600,
+		SeqWindowSize:         2,
+		SequencerFeeRecipient: common.Address{0xfe},
+	}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{EpochNum: 1, EpochHash: chain.blocks[1].Hash(), Timestamp: 12, Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, testFrames(1, chData, 1)...)}, 0)
+
+	dp := NewDerivationPipeline(cfg, chain, chain, eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	// the batch of the epoch of L1 block 1 is included within its sequencing window, in L1 block 2
+	attrs, origin, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), origin.Number)
+	require.Equal(t, []Data{seqTx}, attrs.Transactions[1:])
+
+	// no batch for the epoch of L1 block 2 yet, the window is still open
+	_, _, err = dp.Step(context.Background())
+	require.True(t, errors.Is(err, ethereum.NotFound))
+
+	// once the window elapsed without batch, the L2 block is derived empty
+	chain.add(nil, 0)
+	attrs, origin, err = dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), origin.Number)
+	require.Equal(t, uint64(24), uint64(attrs.Timestamp))
+	require.Len(t, attrs.Transactions, 1, "only the L1 info deposit")
+	require.Equal(t, common.Address{}, attrs.SuggestedFeeRecipient, "nobody gets tx fees for L2 blocks without batch")
+}
+
+func TestDerivationPipeline_InvalidBatches(t *testing.T) {
+	batcherKey, _ := crypto.GenerateKey()
+	cfg := &Config{
+		BatcherAddr:       crypto.PubkeyToAddress(batcherKey.PublicKey),
+		BatchInboxAddr:    common.Address{0xff, 0x01},
+		ChannelTimeout:    3,
+		BlockTime:         12,
+		MaxSequencerDrift: 600,
+		SeqWindowSize:     2,
+	}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	epoch := chain.blocks[1].Hash()
+	validTx, futureTx := testL2Tx(t, 0), testL2Tx(t, 1)
+	chData, err := EncodeChannel([]*BatchData{
+		{EpochNum: 2, EpochHash: common.Hash{0xaa}, Timestamp: 24, Transactions: []Data{futureTx}},
+		{EpochNum: 1, EpochHash: epoch, Timestamp: 13, Transactions: []Data{testL2Tx(t, 2)}},
+		{EpochNum: 1, EpochHash: common.Hash{0xbb}, Timestamp: 12, Transactions: []Data{testL2Tx(t, 3)}},
+		{EpochNum: 1, EpochHash: epoch, ParentHash: common.Hash{0xcc}, Timestamp: 12, Transactions: []Data{testL2Tx(t, 4)}},
+		{EpochNum: 1, EpochHash: epoch, ParentHash: common.Hash{0x01}, Timestamp: 12, Transactions: []Data{validTx}},
+	}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, testFrames(1, chData, 1)...)}, 0)
+
+	m := new(testMetrics)
+	dp := NewDerivationPipeline(cfg, chain, chain, eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	dp.Metrics = m
+	dp.SetL2Parent(common.Hash{0x01})
+	attrs, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []Data{validTx}, attrs.Transactions[1:], "invalid batches before the valid batch are dropped")
+	require.Equal(t, map[string]int{string(BatchMisaligned): 1, string(BatchWrongEpoch): 1, string(BatchWrongParent): 1}, m.droppedBatches)
+	require.Len(t, dp.State().Batches, 1, "the future batch is kept")
+
+	// the future batch has a different L1 origin than the L2 block at its timestamp
+	chain.add(nil, 0)
+	attrs, _, err = dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Len(t, attrs.Transactions, 1)
+	require.Equal(t, 2, m.droppedBatches[string(BatchWrongEpoch)])
+
+	// batches included after the sequencing window of their epoch are dropped when they are read
+	late, err := EncodeChannel([]*BatchData{{EpochNum: 2, EpochHash: chain.blocks[2].Hash(), Timestamp: 36}}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{testFramesSubmission(t, batcherKey, cfg.BatchInboxAddr, testFrames(2, late, 1)...)}, 0)
+	chain.add(nil, 0)
+	_, _, err = dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, m.droppedBatches[string(BatchPastWindow)])
+}
+
+func TestDerivationPipeline_SystemConfig(t *testing.T) {
+	oldBatcherKey, _ := crypto.GenerateKey()
+	newBatcherKey, _ := crypto.GenerateKey()
+	newBatcher := crypto.PubkeyToAddress(newBatcherKey.PublicKey)
+	cfg := &Config{
+		BatcherAddr:           crypto.PubkeyToAddress(oldBatcherKey.PublicKey),
+		BatchInboxAddr:        common.Address{0xff, 0x01},
+		SystemConfigAddr:      testSystemConfigAddr,
+		SequencerFeeRecipient: common.Address{0xfe},
+		ChannelTimeout:        3,
+		BlockTime:             12,
+		MaxSequencerDrift:     600,
+		SeqWindowSize:         2,
+	}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	// L1 block 1 rotates the batcher and updates the gas limit
+	updateTx := types.MustSignNewTx(oldBatcherKey, types.LatestSignerForChainID(testL1ChainID),
+		&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &testSystemConfigAddr})
+	updates := []*types.Log{
+		configUpdateLog(t, 0, SystemConfigUpdateBatcher, common.BytesToHash(newBatcher[:]).Bytes()),
+		configUpdateLog(t, 0, SystemConfigUpdateGasLimit, common.BigToHash(big.NewInt(30_000_000)).Bytes()),
+	}
+	chain.addWithReceipts(types.Transactions{updateTx}, []*types.Receipt{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: updates}}, 0)
+
+	epoch := chain.blocks[1].Hash()
+	oldTx, newTx := testL2Tx(t, 0), testL2Tx(t, 1)
+	oldData, err := EncodeChannel([]*BatchData{{EpochNum: 1, EpochHash: epoch, Timestamp: 12, Transactions: []Data{oldTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	newData, err := EncodeChannel([]*BatchData{{EpochNum: 1, EpochHash: epoch, Timestamp: 12, Transactions: []Data{newTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	chain.add(types.Transactions{
+		testFramesSubmission(t, oldBatcherKey, cfg.BatchInboxAddr, testFrames(1, oldData, 1)...),
+		testFramesSubmission(t, newBatcherKey, cfg.BatchInboxAddr, testFrames(2, newData, 1)...),
+	}, 0)
+
+	dp := NewDerivationPipeline(cfg, chain, chain, eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0})
+	attrs, origin, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), origin.Number)
+	require.Equal(t, []Data{newTx}, attrs.Transactions[1:], "only batches of the updated batcher are accepted")
+	require.NotNil(t, attrs.GasLimit)
+	require.Equal(t, uint64(30_000_000), uint64(*attrs.GasLimit))
+	require.Equal(t, common.Address{0xfe}, attrs.SuggestedFeeRecipient, "sequenced L2 blocks pay the fee recipient")
+
+	state := dp.State()
+	require.Len(t, state.SystemConfigChanges, 1)
+	require.Equal(t, SystemConfig{BatcherAddr: newBatcher, GasLimit: 30_000_000}, state.SystemConfigChanges[0].Config)
+}
+
+func TestDerivationPipeline_FetchTimeout(t *testing.T) {
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+	var deadline time.Time
+	dl := eth.FetchFn(func(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+		deadline, _ = ctx.Deadline()
+		return chain.Fetch(ctx, id)
+	})
+	dp := NewDerivationPipeline(&Config{}, chain, dl, genesis)
+	start := time.Now()
+	_, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.False(t, deadline.IsZero(), "fetching is bounded by a timeout")
+	require.WithinDuration(t, start.Add(l1FetchTimeout), deadline, time.Second)
+
+	// the step context is passed on, to abort fetching with the step
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	dp = NewDerivationPipeline(&Config{}, chain, eth.FetchFn(func(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+		return nil, nil, ctx.Err()
+	}), genesis)
+	_, _, err = dp.Step(ctx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestDerivationPipeline_L1Logs(t *testing.T) {
+	cfg := &Config{SystemConfigAddr: testSystemConfigAddr}
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	key, _ := crypto.GenerateKey()
+	depTx := types.MustSignNewTx(key, types.LatestSignerForChainID(testL1ChainID),
+		&types.DynamicFeeTx{ChainID: testL1ChainID, Gas: 100_000, GasTipCap: common.Big1, GasFeeCap: common.Big2, To: &DepositContractAddr})
+	deposit := MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(1, 0, rand.New(rand.NewSource(1234))))
+	update := configUpdateLog(t, 0, SystemConfigUpdateGasLimit, common.BigToHash(big.NewInt(30_000_000)).Bytes())
+	chain.addWithReceipts(types.Transactions{depTx}, []*types.Receipt{{Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: 21000, Logs: []*types.Log{deposit, update}}}, 0)
+	genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+
+	noReceipts := eth.FetchFn(func(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+		return nil, nil, errors.New("receipts are not fetched")
+	})
+	dp := NewDerivationPipeline(cfg, chain, noReceipts, genesis)
+	dp.L1Logs = &L1LogsFetcher{Blocks: chain, Logs: chain}
+	fromLogs, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+
+	dp = NewDerivationPipeline(cfg, chain, chain, genesis)
+	fromReceipts, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, fromReceipts, fromLogs, "logs derive the same deposits and system config as the receipts")
+	require.Equal(t, 1, userDepositsCount(fromLogs))
+	require.Equal(t, uint64(30_000_000), uint64(*fromLogs.GasLimit))
+
+	// withheld logs are detected with the logs bloom, and the receipts are fetched instead
+	withheld := filterLogsFn(func(ctx context.Context, q ethereum.FilterQuery) ([]types.Log, error) {
+		return nil, nil
+	})
+	dp = NewDerivationPipeline(cfg, chain, chain, genesis)
+	dp.L1Logs = &L1LogsFetcher{Blocks: chain, Logs: withheld}
+	fallback, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, fromReceipts, fallback)
+}
+
+type dataSourceFn func(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error)
+
+func (fn dataSourceFn) BatchData(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error) {
+	return fn(ctx, cfg, block, txs)
+}
+
+func TestDerivationPipeline_DataSource(t *testing.T) {
+	cfg := &Config{BatchInboxAddr: common.Address{0xff, 0x01}, ChannelTimeout: 3}
+	seqTx := testL2Tx(t, 0)
+	chData, err := EncodeChannel([]*BatchData{{Transactions: []Data{seqTx}}}, ZlibCompression)
+	require.NoError(t, err)
+	data, err := EncodeFrames(testFrames(1, chData, 1))
+	require.NoError(t, err)
+
+	chain := new(testL1Chain)
+	chain.add(nil, 0)
+	chain.add(nil, 0)
+	genesis := eth.BlockID{Hash: chain.blocks[0].Hash(), Number: 0}
+
+	dp := NewDerivationPipeline(cfg, chain, chain, genesis)
+	dp.DataSource = dataSourceFn(func(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error) {
+		require.Equal(t, chain.blocks[1].Hash(), block.Hash)
+		require.Empty(t, txs, "the batch data is not in the calldata of the L1 block")
+		return [][]byte{data}, nil
+	})
+	attrs, _, err := dp.Step(context.Background())
+	require.NoError(t, err)
+	require.Len(t, attrs.Transactions, 2, "L1 info deposit and the sequenced tx of the data source")
+	require.Equal(t, seqTx, attrs.Transactions[1])
+
+	dp = NewDerivationPipeline(cfg, chain, chain, genesis)
+	dp.DataSource = dataSourceFn(func(ctx context.Context, cfg *Config, block eth.BlockID, txs types.Transactions) ([][]byte, error) {
+		return nil, errors.New("data not available")
+	})
+	_, _, err = dp.Step(context.Background())
+	require.Error(t, err)
+}
@@ -11,22 +11,49 @@ import (
 )
 
 // ParseL1InfoDepositTxData is the inverse of DeriveL1InfoDeposit, to see where the L2 chain is derived from
-func ParseL1InfoDepositTxData(data []byte) (nr uint64, time uint64, baseFee *big.Int, blockHash common.Hash, err error) {
-	if len(data) != 4+8+8+32+32 {
+func ParseL1InfoDepositTxData(data []byte) (nr uint64, time uint64, baseFee *big.Int, blockHash common.Hash, seqNumber uint64, batcherHash common.Hash, l1FeeOverhead common.Hash, l1FeeScalar common.Hash, err error) {
+	if len(data) != L1InfoDataLen {
 		err = fmt.Errorf("data is unexpected length: %d", len(data))
 		return
 	}
 	offset := 4
-	nr = binary.BigEndian.Uint64(data[offset : offset+8])
-	offset += 8
-	time = binary.BigEndian.Uint64(data[offset : offset+8])
-	offset += 8
+	if nr, err = readUint64Word(data[offset : offset+32]); err != nil {
+		err = fmt.Errorf("invalid number: %v", err)
+		return
+	}
+	offset += 32
+	if time, err = readUint64Word(data[offset : offset+32]); err != nil {
+		err = fmt.Errorf("invalid timestamp: %v", err)
+		return
+	}
+	offset += 32
 	baseFee = new(big.Int).SetBytes(data[offset : offset+32])
 	offset += 32
 	blockHash.SetBytes(data[offset : offset+32])
+	offset += 32
+	if seqNumber, err = readUint64Word(data[offset : offset+32]); err != nil {
+		err = fmt.Errorf("invalid sequence number: %v", err)
+		return
+	}
+	offset += 32
+	batcherHash.SetBytes(data[offset : offset+32])
+	offset += 32
+	l1FeeOverhead.SetBytes(data[offset : offset+32])
+	offset += 32
+	l1FeeScalar.SetBytes(data[offset : offset+32])
 	return
 }
 
+// readUint64Word reads an ABI-encoded uint64 from a 32-byte word
+func readUint64Word(word []byte) (uint64, error) {
+	for _, b := range word[:24] {
+		if b != 0 {
+			return 0, fmt.Errorf("value does not fit in 64 bits: %x", word)
+		}
+	}
+	return binary.BigEndian.Uint64(word[24:]), nil
+}
+
 type Block interface {
 	Hash() common.Hash
 	NumberU64() uint64
@@ -39,6 +66,22 @@ type Genesis struct {
 	L2 eth.BlockID
 }
 
+// ParseBlockEpoch takes a L2 block and determines its L1 origin, and its sequence number within the epoch of the L1 origin.
+// The L2 genesis block starts the epoch of the L1 genesis block.
+func ParseBlockEpoch(l2Block Block, genesis *Genesis) (l1Origin eth.BlockID, seqNumber uint64, err error) {
+	l1Origin, refL2, _, err := ParseBlockReferences(l2Block, genesis)
+	if err != nil {
+		return eth.BlockID{}, 0, err
+	}
+	if refL2.Number > genesis.L2.Number {
+		_, _, _, _, seqNumber, _, _, _, err = ParseL1InfoDepositTxData(l2Block.Transactions()[0].Data())
+		if err != nil {
+			return eth.BlockID{}, 0, fmt.Errorf("failed to parse L1 info deposit tx from L2 block: %v", err)
+		}
+	}
+	return l1Origin, seqNumber, nil
+}
+
 // ParseBlockReferences takes a L2 block and determines which L1 block it was derived from, and the L2 self and parent id.
 func ParseBlockReferences(refL2Block Block, genesis *Genesis) (refL1 eth.BlockID, refL2 eth.BlockID, parentL2 common.Hash, err error) {
 	refL2 = eth.BlockID{Hash: refL2Block.Hash(), Number: refL2Block.NumberU64()}
@@ -59,7 +102,7 @@ func ParseBlockReferences(refL2Block Block, genesis *Genesis) (refL1 eth.BlockID
 		err = fmt.Errorf("l2 block is missing L1 info deposit tx, block hash: %s", refL2Block.Hash())
 		return
 	}
-	refL1Nr, _, _, refL1Hash, err := ParseL1InfoDepositTxData(txs[0].Data())
+	refL1Nr, _, _, refL1Hash, _, _, _, _, err := ParseL1InfoDepositTxData(txs[0].Data())
 	if err != nil {
 		err = fmt.Errorf("failed to parse L1 info deposit tx from L2 block: %v", err)
 		return
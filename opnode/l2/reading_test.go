@@ -1,12 +1,15 @@
 package l2
 
 import (
+	"encoding/hex"
 	"math/big"
 	"math/rand"
 	"testing"
 
+	"github.com/ethereum-optimism/optimistic-specs/opnode/contracts/l1block"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 type l1MockInfo struct {
@@ -83,26 +86,133 @@ func TestParseL1InfoDepositTxData(t *testing.T) {
 	for i, testCase := range cases {
 		t.Run(testCase.name, func(t *testing.T) {
 			info := testCase.mkInfo(rand.New(rand.NewSource(int64(1234 + i))))
-			depTx := DeriveL1InfoDeposit(info)
-			nr, time, baseFee, h, err := ParseL1InfoDepositTxData(depTx.Data)
+			cfg := &Config{BatcherAddr: common.Address{0xba}, SystemConfig: SystemConfig{Overhead: common.Hash{31: 0x0b}, Scalar: common.Hash{31: 0x5c}}}
+			depTx, err := DeriveL1InfoDeposit(cfg, info, uint64(i))
+			assert.NoError(t, err)
+			nr, time, baseFee, h, seqNumber, batcherHash, l1FeeOverhead, l1FeeScalar, err := ParseL1InfoDepositTxData(depTx.Data)
 			assert.NoError(t, err, "expected valid deposit info")
 			assert.Equal(t, nr, info.num)
 			assert.Equal(t, time, info.time)
 			assert.True(t, baseFee.Sign() >= 0)
 			assert.Equal(t, baseFee.Bytes(), info.baseFee.Bytes())
 			assert.Equal(t, h, info.hash)
+			assert.Equal(t, seqNumber, uint64(i))
+			assert.Equal(t, batcherHash, cfg.BatcherHash())
+			assert.Equal(t, l1FeeOverhead, cfg.SystemConfig.Overhead)
+			assert.Equal(t, l1FeeScalar, cfg.SystemConfig.Scalar)
 		})
 	}
 	t.Run("no data", func(t *testing.T) {
-		_, _, _, _, err := ParseL1InfoDepositTxData(nil)
+		_, _, _, _, _, _, _, _, err := ParseL1InfoDepositTxData(nil)
 		assert.Error(t, err)
 	})
 	t.Run("not enough data", func(t *testing.T) {
-		_, _, _, _, err := ParseL1InfoDepositTxData([]byte{1, 2, 3, 4})
+		_, _, _, _, _, _, _, _, err := ParseL1InfoDepositTxData([]byte{1, 2, 3, 4})
 		assert.Error(t, err)
 	})
 	t.Run("too much data", func(t *testing.T) {
-		_, _, _, _, err := ParseL1InfoDepositTxData(make([]byte, 4+8+8+32+32+1))
+		_, _, _, _, _, _, _, _, err := ParseL1InfoDepositTxData(make([]byte, L1InfoDataLen+1))
 		assert.Error(t, err)
 	})
+	t.Run("number does not fit in 64 bits", func(t *testing.T) {
+		data := make([]byte, L1InfoDataLen)
+		data[4+23] = 1
+		_, _, _, _, _, _, _, _, err := ParseL1InfoDepositTxData(data)
+		assert.Error(t, err)
+	})
+}
+
+func TestEncodeL1InfoData(t *testing.T) {
+	info := &l1MockInfo{
+		num:     0x1234,
+		time:    0x5678,
+		baseFee: big.NewInt(7_000_000_000),
+		hash:    common.HexToHash("0x1111111111111111111111111111111111111111111111111111111111111111"),
+	}
+	expected, _ := hex.DecodeString("1549528e" + // setL1BlockValues selector
+		"0000000000000000000000000000000000000000000000000000000000001234" + // number
+		"0000000000000000000000000000000000000000000000000000000000005678" + // timestamp
+		"00000000000000000000000000000000000000000000000000000001a13b8600" + // basefee
+		"1111111111111111111111111111111111111111111111111111111111111111" + // hash
+		"0000000000000000000000000000000000000000000000000000000000000003" + // sequence number
+		"0000000000000000000000002222222222222222222222222222222222222222" + // batcher hash
+		"0000000000000000000000000000000000000000000000000000000000000834" + // L1 fee overhead
+		"00000000000000000000000000000000000000000000000000000000000f4240") // L1 fee scalar
+	batcherHash := (&Config{BatcherAddr: common.HexToAddress("0x2222222222222222222222222222222222222222")}).BatcherHash()
+	data, err := EncodeL1InfoData(info, 3, batcherHash, common.BigToHash(big.NewInt(2100)), common.BigToHash(big.NewInt(1_000_000)))
+	assert.NoError(t, err)
+	assert.Equal(t, expected, data)
+
+	t.Run("L1Block ABI", func(t *testing.T) {
+		l1BlockABI, err := l1block.L1blockMetaData.GetAbi()
+		require.NoError(t, err)
+		packed, err := l1BlockABI.Pack("setL1BlockValues", big.NewInt(0x1234), big.NewInt(0x5678), info.baseFee, info.hash,
+			uint64(3), batcherHash, big.NewInt(2100), big.NewInt(1_000_000))
+		require.NoError(t, err)
+		assert.Equal(t, packed, data, "calldata must match the setL1BlockValues call of the L1Block contract")
+	})
+	t.Run("nil base fee", func(t *testing.T) {
+		_, err := EncodeL1InfoData(&l1MockInfo{}, 0, common.Hash{}, common.Hash{}, common.Hash{})
+		assert.Error(t, err)
+	})
+	t.Run("base fee too large", func(t *testing.T) {
+		_, err := EncodeL1InfoData(&l1MockInfo{baseFee: new(big.Int).Lsh(big.NewInt(1), 256)}, 0, common.Hash{}, common.Hash{}, common.Hash{})
+		assert.Error(t, err)
+	})
+}
+
+func TestL1InfoDepositTx(t *testing.T) {
+	data := []byte{1, 2, 3}
+	dep := L1InfoDepositTx(&Config{}, 1234, data, 42)
+	assert.Equal(t, uint64(1234), dep.BlockHeight)
+	assert.Equal(t, uint64(L1InfoDepositIndex), dep.TransactionIndex)
+	assert.Equal(t, DepositContractAddr, dep.From)
+	assert.Equal(t, &L1InfoPredeployAddr, dep.To)
+	assert.Nil(t, dep.Mint)
+	assert.Equal(t, 0, dep.Value.Sign())
+	assert.Equal(t, uint64(42), dep.Gas)
+	assert.Equal(t, data, dep.Data)
+}
+
+func TestL1InfoDepositTxConfig(t *testing.T) {
+	cfg := &Config{
+		DepositContractAddr: common.Address{1},
+		L1InfoPredeployAddr: common.Address{2},
+	}
+	dep := L1InfoDepositTx(cfg, 1234, nil, 42)
+	assert.Equal(t, common.Address{1}, dep.From)
+	assert.Equal(t, &common.Address{2}, dep.To)
+}
+
+func TestDeriveL1InfoDeposit(t *testing.T) {
+	info := randomL1Info(rand.New(rand.NewSource(1234)))
+	cfg := &Config{
+		BatcherAddr:         common.Address{0xba},
+		DepositContractAddr: common.Address{0xdc},
+		L1InfoPredeployAddr: common.Address{0x4e},
+		SystemConfig:        SystemConfig{Overhead: common.Hash{0x0a}, Scalar: common.Hash{0x5c}},
+	}
+	dep, err := DeriveL1InfoDeposit(cfg, info, 3)
+	assert.NoError(t, err)
+
+	// the deposit is the first tx of the L2 block, from the deposit contract to the L1 info predeploy
+	assert.Equal(t, info.num, dep.BlockHeight)
+	assert.Equal(t, uint64(0), dep.TransactionIndex)
+	assert.Equal(t, common.Address{0xdc}, dep.From)
+	assert.Equal(t, &common.Address{0x4e}, dep.To)
+	assert.Nil(t, dep.Mint)
+	assert.Equal(t, 0, dep.Value.Sign())
+	assert.Equal(t, uint64(99_999_999), dep.Gas)
+
+	// the calldata carries the L1 block info, the sequence number, and the system config
+	nr, time, baseFee, h, seqNumber, batcherHash, overhead, scalar, err := ParseL1InfoDepositTxData(dep.Data)
+	assert.NoError(t, err)
+	assert.Equal(t, info.num, nr)
+	assert.Equal(t, info.time, time)
+	assert.Equal(t, info.baseFee, baseFee)
+	assert.Equal(t, info.hash, h)
+	assert.Equal(t, uint64(3), seqNumber)
+	assert.Equal(t, common.BytesToHash(common.Address{0xba}.Bytes()), batcherHash)
+	assert.Equal(t, common.Hash{0x0a}, overhead)
+	assert.Equal(t, common.Hash{0x5c}, scalar)
 }
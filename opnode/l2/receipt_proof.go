@@ -0,0 +1,80 @@
+package l2
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+)
+
+// ReceiptProof is a Merkle-Patricia-Trie proof of a receipt in the receipts trie of a block:
+// the trie nodes on the path from the root to the receipt.
+type ReceiptProof [][]byte
+
+// Put implements ethdb.KeyValueWriter, to collect the proof nodes with Trie.Prove
+func (p *ReceiptProof) Put(key []byte, value []byte) error {
+	*p = append(*p, common.CopyBytes(value))
+	return nil
+}
+
+// Delete implements ethdb.KeyValueWriter
+func (p *ReceiptProof) Delete(key []byte) error {
+	return nil
+}
+
+func receiptKey(txIndex uint64) []byte {
+	return rlp.AppendUint64(nil, txIndex)
+}
+
+func encodeReceipt(receipt *types.Receipt) []byte {
+	var buf bytes.Buffer
+	types.Receipts{receipt}.EncodeIndex(0, &buf)
+	return buf.Bytes()
+}
+
+// BuildReceiptProof builds the proof of the receipt at txIndex, in the receipts trie of the given block receipts.
+func BuildReceiptProof(receipts []*types.Receipt, txIndex uint64) (ReceiptProof, error) {
+	if txIndex >= uint64(len(receipts)) {
+		return nil, fmt.Errorf("receipt index %d out of range, only %d receipts", txIndex, len(receipts))
+	}
+	tr, err := trie.New(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create receipts trie: %v", err)
+	}
+	for i, rec := range receipts {
+		tr.Update(receiptKey(uint64(i)), encodeReceipt(rec))
+	}
+	var proof ReceiptProof
+	if err := tr.Prove(receiptKey(txIndex), 0, &proof); err != nil {
+		return nil, fmt.Errorf("failed to prove receipt %d: %v", txIndex, err)
+	}
+	return proof, nil
+}
+
+// VerifyReceiptProof checks that the receipt is included at txIndex in the receipts trie with the given root.
+// Unlike CheckReceipts, this only requires the receipt itself, e.g. a receipt with deposit logs,
+// and not all the receipts of the block.
+func VerifyReceiptProof(receiptsRoot common.Hash, txIndex uint64, receipt *types.Receipt, proof ReceiptProof) error {
+	db := memorydb.New()
+	for _, node := range proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+	value, err := trie.VerifyProof(receiptsRoot, receiptKey(txIndex), db)
+	if err != nil {
+		return fmt.Errorf("invalid receipt proof: %v", err)
+	}
+	if value == nil {
+		return fmt.Errorf("no receipt at index %d in receipts root %s", txIndex, receiptsRoot)
+	}
+	if !bytes.Equal(value, encodeReceipt(receipt)) {
+		return fmt.Errorf("receipt does not match the proven receipt at index %d", txIndex)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package l2
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func TestReceiptProof(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	var receipts []*types.Receipt
+	for i := 0; i < 200; i++ {
+		rec := &types.Receipt{Type: uint8(i % 3), Status: types.ReceiptStatusSuccessful, CumulativeGasUsed: uint64(21000 * (i + 1))}
+		if i%7 == 0 {
+			rec.Logs = []*types.Log{MarshalDepositLogEvent(DepositContractAddr, testutil.GenerateDeposit(100, 1, rng))}
+		}
+		rec.Bloom = types.CreateBloom(types.Receipts{rec})
+		receipts = append(receipts, rec)
+	}
+	root := types.DeriveSha(types.Receipts(receipts), trie.NewStackTrie(nil))
+
+	for _, i := range []uint64{0, 1, 7, 127, 128, 199} {
+		proof, err := BuildReceiptProof(receipts, i)
+		require.NoError(t, err)
+		assert.NoError(t, VerifyReceiptProof(root, i, receipts[i], proof), "receipt %d", i)
+
+		// the proof does not hold for another receipt, index or root
+		assert.Error(t, VerifyReceiptProof(root, i, receipts[(i+1)%200], proof))
+		assert.Error(t, VerifyReceiptProof(root, (i+1)%200, receipts[i], proof))
+		assert.Error(t, VerifyReceiptProof(types.EmptyRootHash, i, receipts[i], proof))
+	}
+
+	_, err := BuildReceiptProof(receipts, 200)
+	assert.Error(t, err)
+}
@@ -0,0 +1,44 @@
+package l2
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// ReorgEvent is sent when derivation continues on an older L2 block after a L1 reorg,
+// with the previously derived L2 blocks that are no longer valid.
+type ReorgEvent struct {
+	// CommonAncestor is the L1 block that the L2 block that derivation continues on was derived from
+	CommonAncestor eth.BlockID
+	// InvalidatedL2 are the L2 blocks after the L2 block that derivation continues on, in order
+	InvalidatedL2 []eth.BlockID
+}
+
+// FindInvalidatedL2 walks back the L2 chain from the head to refL2, to find the L2 blocks that are invalidated
+// when derivation continues on refL2, and the L1 block that refL2 was derived from.
+// The walk is only as deep as the reorg. WrongChainErr is returned if refL2 is not an ancestor of the head.
+func FindInvalidatedL2(ctx context.Context, reference SyncReference, genesis *Genesis, refL2 eth.BlockID, head eth.BlockID) (ReorgEvent, error) {
+	refL1, cur, parentL2, err := reference.RefByL2Hash(ctx, head.Hash, genesis)
+	if err != nil {
+		return ReorgEvent{}, fmt.Errorf("failed to lookup L2 head %s: %w", head, err)
+	}
+	var invalidated []eth.BlockID
+	for cur.Number > refL2.Number {
+		invalidated = append(invalidated, cur)
+		prev := cur
+		refL1, cur, parentL2, err = reference.RefByL2Hash(ctx, parentL2, genesis)
+		if err != nil {
+			return ReorgEvent{}, fmt.Errorf("failed to lookup parent of L2 block %s: %w", prev, err)
+		}
+	}
+	if cur != refL2 {
+		return ReorgEvent{}, fmt.Errorf("%w: L2 block %s is not an ancestor of the L2 head %s", WrongChainErr, refL2, head)
+	}
+	// reverse, to list the invalidated blocks in order
+	for i, j := 0, len(invalidated)-1; i < j; i, j = i+1, j-1 {
+		invalidated[i], invalidated[j] = invalidated[j], invalidated[i]
+	}
+	return ReorgEvent{CommonAncestor: refL1, InvalidatedL2: invalidated}, nil
+}
@@ -0,0 +1,39 @@
+package l2
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindInvalidatedL2(t *testing.T) {
+	// the engine derived L2 blocks A-F from L1 blocks a-f
+	engL1 := chainL1(0, "abcdef")
+	msr := &mockSyncReference{L2: chainL2(engL1, "ABCDEF"), L1: chainL1(0, "abcxyz")}
+	genesis := &Genesis{L1: mockID('a', 0), L2: mockID('A', 0)}
+	head := mockID('F', 5)
+
+	// L1 reorgs after c, derivation continues on C
+	ev, err := FindInvalidatedL2(context.Background(), msr, genesis, mockID('C', 2), head)
+	require.NoError(t, err)
+	require.Equal(t, ReorgEvent{
+		CommonAncestor: mockID('c', 2),
+		InvalidatedL2:  []eth.BlockID{mockID('D', 3), mockID('E', 4), mockID('F', 5)},
+	}, ev)
+
+	// derivation continues on the parent of the head
+	ev, err = FindInvalidatedL2(context.Background(), msr, genesis, mockID('E', 4), head)
+	require.NoError(t, err)
+	require.Equal(t, ReorgEvent{CommonAncestor: mockID('e', 4), InvalidatedL2: []eth.BlockID{head}}, ev)
+
+	// nothing is invalidated when derivation continues on the head
+	ev, err = FindInvalidatedL2(context.Background(), msr, genesis, head, head)
+	require.NoError(t, err)
+	require.Empty(t, ev.InvalidatedL2)
+
+	// the L2 block must be an ancestor of the head
+	_, err = FindInvalidatedL2(context.Background(), msr, genesis, mockID('X', 2), head)
+	require.ErrorIs(t, err, WrongChainErr)
+}
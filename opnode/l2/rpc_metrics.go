@@ -0,0 +1,36 @@
+package l2
+
+import (
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/metrics"
+)
+
+// MeteredRPC wraps a RPCBackend to record the latency of every call, and the number of failed calls, by method:
+// <prefix>/rpc/<method> timers and <prefix>/rpc_errors/<method> counters.
+// Like the go-ethereum metrics, it records nothing unless metrics.Enabled is set.
+type MeteredRPC struct {
+	RPCBackend
+
+	registry metrics.Registry
+	prefix   string
+}
+
+var _ RPCBackend = (*MeteredRPC)(nil)
+
+// NewMeteredRPC records the calls to the backend in the given registry, e.g. metrics.DefaultRegistry,
+// with the given metric name prefix, e.g. "opnode/engine/0".
+func NewMeteredRPC(backend RPCBackend, r metrics.Registry, prefix string) *MeteredRPC {
+	return &MeteredRPC{RPCBackend: backend, registry: r, prefix: prefix}
+}
+
+func (m *MeteredRPC) CallContext(ctx context.Context, result interface{}, method string, args ...interface{}) error {
+	start := time.Now()
+	err := m.RPCBackend.CallContext(ctx, result, method, args...)
+	metrics.GetOrRegisterTimer(m.prefix+"/rpc/"+method, m.registry).UpdateSince(start)
+	if err != nil {
+		metrics.GetOrRegisterCounter(m.prefix+"/rpc_errors/"+method, m.registry).Inc(1)
+	}
+	return err
+}
@@ -0,0 +1,217 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/events"
+)
+
+const (
+	// DefaultBlockTime is the default number of seconds between sequenced L2 blocks
+	DefaultBlockTime = 2
+	// DefaultMaxSequencerDrift is the default number of seconds that the timestamp of a sequenced L2 block
+	// may be ahead of the timestamp of its L1 origin
+	DefaultMaxSequencerDrift = 600
+	// DefaultSeqWindowSize is the default number of L1 blocks within which the batches of an epoch must be included on L1
+	DefaultSeqWindowSize = 64
+	// DefaultSequencerBuildTime is the default time the engine is given to build a sequenced L2 block
+	DefaultSequencerBuildTime = time.Millisecond * 500
+)
+
+var (
+	// SequencerAheadErr is returned when the next L2 block would have a timestamp in the future: retry later.
+	SequencerAheadErr = errors.New("next L2 block is not due yet")
+	// SequencerDriftErr is returned when the next L2 block would drift too far ahead of its L1 origin,
+	// and the next L1 origin is not available yet: retry after L1 progressed.
+	SequencerDriftErr = errors.New("sequencer drift exceeded")
+)
+
+// SequencerConfig configures the sequencing of L2 blocks.
+// The block time and sequencer drift are part of the rollup configuration, as verifiers enforce them.
+type SequencerConfig struct {
+	// BuildTime is the time the engine is given to build each L2 block, to fill it with transactions from its tx pool.
+	// It must be shorter than the block time.
+	BuildTime time.Duration
+}
+
+// SequencerL1 is the L1 source to find the L1 origins of sequenced L2 blocks with
+type SequencerL1 interface {
+	eth.HeaderByHashSource
+	eth.HeaderByNumberSource
+}
+
+// Sequencer builds a L2 block every block time, instead of deriving L2 blocks from batches on L1:
+// every L2 block has a L1 origin, and the first L2 block of each L1 origin (the epoch) includes its deposits.
+// The remaining block space is filled with transactions from the tx pool of the engine.
+//
+// The L1 origin advances to the next L1 block once the L2 timestamp reaches the timestamp of that L1 block,
+// and must advance when the L2 timestamp would otherwise drift more than MaxSequencerDrift ahead of the L1 origin.
+type Sequencer struct {
+	Log       log.Logger
+	Config    *Config
+	SeqConfig SequencerConfig
+	Genesis   *Genesis
+
+	RPC DriverAPI
+	L1  SequencerL1
+	DL  Downloader
+	// Events is optional, to publish the sequencing events to
+	Events *events.Bus
+}
+
+// sequencerOrigin is the L1 origin of a L2 block, and the index of the L2 block within the epoch of the L1 origin
+type sequencerOrigin struct {
+	header    *types.Header
+	seqNumber uint64
+}
+
+// origin finds the L1 origin of the L2 block
+func (s *Sequencer) origin(ctx context.Context, l2Block *types.Block) (sequencerOrigin, error) {
+	refL1, seqNumber, err := ParseBlockEpoch(l2Block, s.Genesis)
+	if err != nil {
+		return sequencerOrigin{}, err
+	}
+	header, err := s.L1.HeaderByHash(ctx, refL1.Hash)
+	if err != nil {
+		return sequencerOrigin{}, fmt.Errorf("failed to fetch L1 origin %s: %w", refL1, eth.ClassifyFetchErr(err))
+	}
+	return sequencerOrigin{header: header, seqNumber: seqNumber}, nil
+}
+
+// nextOrigin returns the L1 block after the L1 origin, or nil if it is not available yet.
+func (s *Sequencer) nextOrigin(ctx context.Context, origin *types.Header) (*types.Header, error) {
+	next, err := s.L1.HeaderByNumber(ctx, new(big.Int).Add(origin.Number, common.Big1))
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch next L1 origin: %w", eth.ClassifyFetchErr(err))
+	}
+	if next.ParentHash != origin.Hash() {
+		return nil, fmt.Errorf("next L1 origin %s does not build on L1 origin %s, L1 reorged", next.Hash(), origin.Hash())
+	}
+	return next, nil
+}
+
+// Step builds the next L2 block on top of the unsafe L2 head, and returns the updated L2 heads and the L1 origin of the new block.
+// If the engine fails to insert the new block, the L1 origin of the block is returned with the error.
+// Sequenced blocks are not derived from L1, and only advance the unsafe L2 head.
+// It fails with SequencerAheadErr if the next L2 block is not due yet at the given unix time,
+// and with SequencerDriftErr if the next L2 block cannot be built without exceeding the sequencer drift.
+// When the drift would be exceeded, the next L2 block adopts the next L1 origin, with the timestamp of that L1 block if it is later.
+func (s *Sequencer) Step(ctx context.Context, heads L2Heads, now uint64) (out L2Heads, l1Origin eth.BlockID, err error) {
+	l2Head := heads.Unsafe
+	parent, err := s.RPC.BlockByHash(ctx, l2Head.Hash)
+	if err != nil {
+		return L2Heads{}, eth.BlockID{}, fmt.Errorf("failed to fetch L2 head %s: %v", l2Head, err)
+	}
+	timestamp := parent.Time() + s.Config.BlockTime
+	if timestamp > now {
+		return L2Heads{}, eth.BlockID{}, SequencerAheadErr
+	}
+
+	origin, err := s.origin(ctx, parent)
+	if err != nil {
+		return L2Heads{}, eth.BlockID{}, err
+	}
+	next, err := s.nextOrigin(ctx, origin.header)
+	if err != nil {
+		return L2Heads{}, eth.BlockID{}, err
+	}
+	var receipts []*types.Receipt
+	var block BlockInput
+	drift := timestamp > origin.header.Time+s.Config.MaxSequencerDrift
+	if next != nil && (next.Time <= timestamp || drift) {
+		// the L2 chain continues at the block time, unless the next L1 origin is ahead of it
+		if next.Time > timestamp {
+			timestamp = next.Time
+			if timestamp > now {
+				return L2Heads{}, eth.BlockID{}, SequencerAheadErr
+			}
+		}
+		// start the epoch of the next L1 origin, with its deposits
+		bl, rs, err := s.DL.Fetch(ctx, eth.BlockID{Hash: next.Hash(), Number: next.Number.Uint64()})
+		if err != nil {
+			return L2Heads{}, eth.BlockID{}, fmt.Errorf("failed to fetch L1 origin with receipts: %w", err)
+		}
+		block, receipts = BlockInputFromBlock(bl), rs
+		origin = sequencerOrigin{header: next, seqNumber: 0}
+	} else if drift {
+		return L2Heads{}, eth.BlockID{}, fmt.Errorf("L2 timestamp %d is more than %d seconds ahead of L1 origin %d at %d: %w",
+			timestamp, s.Config.MaxSequencerDrift, origin.header.Number, origin.header.Time, SequencerDriftErr)
+	} else {
+		block = BlockInputFromHeader(origin.header)
+		origin.seqNumber += 1
+	}
+	l1Origin = eth.BlockID{Hash: origin.header.Hash(), Number: origin.header.Number.Uint64()}
+
+	attrs, err := SequencerBlockInputs(s.Config, block, origin.seqNumber, receipts, timestamp)
+	if err != nil {
+		return L2Heads{}, eth.BlockID{}, fmt.Errorf("failed to prepare sequenced block inputs: %w", err)
+	}
+	logger := s.Log.New("l1_origin", l1Origin, "seq_number", origin.seqNumber, "l2_parent", l2Head, "timestamp", timestamp)
+	out, err = InsertBlock(ctx, logger, s.RPC, s.Events, l1Origin, heads, false, attrs, s.SeqConfig.BuildTime)
+	if err != nil {
+		return L2Heads{}, l1Origin, err
+	}
+	return out, l1Origin, nil
+}
+
+// SequencerBlockInputs prepares the attributes of a sequenced L2 block with the given timestamp:
+// the L1 info deposit of the L1 origin, and, for the first L2 block of the epoch (with receipts),
+// the user deposits of the L1 origin. The engine fills the rest of the block from its tx pool,
+// and the transaction fees go to the configured fee recipient.
+func SequencerBlockInputs(cfg *Config, origin BlockInput, seqNumber uint64, receipts []*types.Receipt, timestamp uint64) (*PayloadAttributes, error) {
+	l1Info, err := DeriveL1InfoDeposit(cfg, origin, seqNumber)
+	if err != nil {
+		return nil, err
+	}
+	opaqueL1Tx, err := EncodeDeposit(l1Info)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode L1 info tx: %v", err)
+	}
+	encodedTxs := []Data{opaqueL1Tx}
+
+	if seqNumber == 0 {
+		if !CheckReceipts(origin, receipts) {
+			return nil, fmt.Errorf("receipts are not consistent with the block's receipts root: %s", origin.ReceiptHash())
+		}
+		userDeposits, err := DeriveUserDeposits(cfg, origin.NumberU64(), receipts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive user deposits: %v", err)
+		}
+		if err := CheckDepositGas(cfg, l1Info, userDeposits); err != nil {
+			return nil, err
+		}
+		for i, tx := range userDeposits {
+			opaqueTx, err := EncodeDeposit(tx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode user tx %d: %v", i, err)
+			}
+			encodedTxs = append(encodedTxs, opaqueTx)
+		}
+	}
+
+	attrs := &PayloadAttributes{
+		Timestamp:             Uint64Quantity(timestamp),
+		Random:                Bytes32(origin.MixDigest()),
+		SuggestedFeeRecipient: cfg.FeeRecipient(),
+		Transactions:          encodedTxs,
+		NoTxPool:              false,
+	}
+	if gasLimit := cfg.SystemConfig.GasLimit; gasLimit != 0 {
+		attrs.GasLimit = (*Uint64Quantity)(&gasLimit)
+	}
+	cfg.SetPayloadVersion(attrs)
+	return attrs, nil
+}
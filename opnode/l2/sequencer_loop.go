@@ -0,0 +1,86 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// NewSequencerLoop creates the loop that sequences a new L2 block with the Sequencer every block time,
+// on top of the L2 head of the state, instead of deriving L2 blocks from L1.
+// L1 heads only update the sync target: the sequencer picks up new L1 origins by itself.
+// Sending true to pause stops the loop from sequencing, until false is sent to resume.
+func NewSequencerLoop(ctx context.Context, state *EngineDriverState, log log.Logger, l1Heads <-chan eth.HeadSignal, pause <-chan bool, seq *Sequencer, driver Driver) func(quit <-chan struct{}) error {
+	blockTime := time.Duration(seq.Config.BlockTime) * time.Second
+	seqTicker := time.NewTicker(hot)
+
+	return func(quit <-chan struct{}) error {
+		defer seqTicker.Stop()
+
+		paused := false
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			case p := <-pause:
+				if p == paused {
+					continue
+				}
+				paused = p
+				if paused {
+					log.Info("Paused sequencing")
+				} else {
+					log.Info("Resumed sequencing")
+					seqTicker.Reset(hot)
+				}
+			case l1HeadSig := <-l1Heads:
+				state.NotifyL1Target(log, l1HeadSig)
+			case <-seqTicker.C:
+				if paused {
+					continue
+				}
+				seqTicker.Reset(blockTime)
+				heads := state.L2Heads()
+				if heads.Unsafe == (eth.BlockID{}) {
+					// learn the engine head before building on top of it
+					refL1, refL2, err := driver.requestEngineHead(ctx)
+					if err != nil {
+						log.Error("failed to request engine head", "err", err)
+					} else {
+						state.UpdateUnsafeHead(refL1, refL2)
+					}
+					seqTicker.Reset(hot)
+					continue
+				}
+				if heads.Safe == (eth.BlockID{}) {
+					// nothing is derived from L1 while sequencing, the genesis block is safe
+					heads.Safe = state.Genesis.L2
+				}
+
+				stepCtx, cancel := context.WithTimeout(ctx, blockTime+time.Second*10)
+				now := uint64(time.Now().Unix())
+				next, l1Origin, err := seq.Step(stepCtx, heads, now)
+				cancel()
+				if errors.Is(err, SequencerAheadErr) {
+					continue
+				}
+				if errors.Is(err, InvalidPayloadErr) {
+					unwindInvalid(log, state, seq.Events, l1Origin, err)
+					continue
+				}
+				if err != nil {
+					logStepErr(log, "Failed to sequence L2 block", err, "l2_head", heads.Unsafe)
+					continue
+				}
+				log.Info("Sequenced L2 block", "l1_origin", l1Origin, "l2", next.Unsafe)
+				state.UpdateUnsafeHead(l1Origin, next.Unsafe)
+			}
+		}
+	}
+}
@@ -0,0 +1,230 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// fakeSeqEngine builds L2 blocks from the forced transactions of the payload attributes, without executing them.
+type fakeSeqEngine struct {
+	blocks   map[common.Hash]*types.Block
+	building map[PayloadID]*types.Block
+	nextID   uint64
+}
+
+func newFakeSeqEngine(genesis *types.Block) *fakeSeqEngine {
+	return &fakeSeqEngine{
+		blocks:   map[common.Hash]*types.Block{genesis.Hash(): genesis},
+		building: make(map[PayloadID]*types.Block),
+	}
+}
+
+func (f *fakeSeqEngine) GetPayload(ctx context.Context, payloadId PayloadID) (*ExecutionPayload, error) {
+	bl, ok := f.building[payloadId]
+	if !ok {
+		return nil, UnknownPayloadErr
+	}
+	txs := make([]Data, 0, len(bl.Transactions()))
+	for _, tx := range bl.Transactions() {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		txs = append(txs, data)
+	}
+	return &ExecutionPayload{
+		ParentHash:   bl.ParentHash(),
+		Random:       Bytes32(bl.MixDigest()),
+		BlockNumber:  Uint64Quantity(bl.NumberU64()),
+		Timestamp:    Uint64Quantity(bl.Time()),
+		BlockHash:    bl.Hash(),
+		Transactions: txs,
+	}, nil
+}
+
+func (f *fakeSeqEngine) NewPayload(ctx context.Context, payload *ExecutionPayload) (*PayloadStatusV1, error) {
+	for _, bl := range f.building {
+		if bl.Hash() == payload.BlockHash {
+			f.blocks[bl.Hash()] = bl
+			return &PayloadStatusV1{Status: ExecutionValid}, nil
+		}
+	}
+	return &PayloadStatusV1{Status: ExecutionInvalid}, nil
+}
+
+func (f *fakeSeqEngine) ForkchoiceUpdated(ctx context.Context, state *ForkchoiceState, attr *PayloadAttributes) (ForkchoiceUpdatedResult, error) {
+	parent, ok := f.blocks[state.HeadBlockHash]
+	if !ok {
+		return ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionSyncing}}, nil
+	}
+	res := ForkchoiceUpdatedResult{PayloadStatus: PayloadStatusV1{Status: ExecutionValid}}
+	if attr == nil {
+		return res, nil
+	}
+	var txs types.Transactions
+	for _, data := range attr.Transactions {
+		var tx types.Transaction
+		if err := tx.UnmarshalBinary(data); err != nil {
+			return ForkchoiceUpdatedResult{}, err
+		}
+		txs = append(txs, &tx)
+	}
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		Time:       uint64(attr.Timestamp),
+		MixDigest:  common.Hash(attr.Random),
+	}
+	f.nextID++
+	var id PayloadID
+	new(big.Int).SetUint64(f.nextID).FillBytes(id[:])
+	f.building[id] = types.NewBlockWithHeader(header).WithBody(txs, nil)
+	res.PayloadID = &id
+	return res, nil
+}
+
+func (f *fakeSeqEngine) Close() {}
+
+func (f *fakeSeqEngine) BlockByHash(ctx context.Context, hash common.Hash) (*types.Block, error) {
+	if bl, ok := f.blocks[hash]; ok {
+		return bl, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeSeqEngine) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return nil, errors.New("not supported")
+}
+
+func (f *fakeSeqEngine) SubscribeNewHead(ctx context.Context, ch chan<- *types.Header) (ethereum.Subscription, error) {
+	return nil, errors.New("not supported")
+}
+
+// fakeSeqL1 is a L1 chain of blocks without transactions
+type fakeSeqL1 []*types.Block
+
+func (f *fakeSeqL1) add(time uint64) {
+	header := &types.Header{Number: big.NewInt(int64(len(*f))), Time: time, ReceiptHash: types.EmptyRootHash, BaseFee: big.NewInt(7)}
+	if len(*f) > 0 {
+		header.ParentHash = (*f)[len(*f)-1].Hash()
+	}
+	*f = append(*f, types.NewBlockWithHeader(header))
+}
+
+func (f *fakeSeqL1) id(n uint64) eth.BlockID {
+	return eth.BlockID{Hash: (*f)[n].Hash(), Number: n}
+}
+
+func (f *fakeSeqL1) HeaderByHash(ctx context.Context, hash common.Hash) (*types.Header, error) {
+	for _, bl := range *f {
+		if bl.Hash() == hash {
+			return bl.Header(), nil
+		}
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeSeqL1) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if n := number.Uint64(); n < uint64(len(*f)) {
+		return (*f)[n].Header(), nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeSeqL1) Fetch(ctx context.Context, id eth.BlockID) (*types.Block, []*types.Receipt, error) {
+	for _, bl := range *f {
+		if bl.Hash() == id.Hash {
+			return bl, nil, nil
+		}
+	}
+	return nil, nil, ethereum.NotFound
+}
+
+func TestSequencer_Step(t *testing.T) {
+	l1 := new(fakeSeqL1)
+	l1.add(1000)
+	l1.add(1012)
+	l2Genesis := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(0), Time: 1000})
+	genesis := &Genesis{L1: l1.id(0), L2: eth.BlockID{Hash: l2Genesis.Hash(), Number: 0}}
+	engine := newFakeSeqEngine(l2Genesis)
+	seq := &Sequencer{
+		Log:     log.New(),
+		Config:  &Config{BlockTime: 2, MaxSequencerDrift: 20},
+		Genesis: genesis,
+		RPC:     engine,
+		L1:      l1,
+		DL:      l1,
+	}
+	ctx := context.Background()
+
+	heads := L2Heads{Unsafe: genesis.L2, Safe: genesis.L2, Finalized: genesis.L2}
+	_, _, err := seq.Step(ctx, heads, 1001)
+	require.ErrorIs(t, err, SequencerAheadErr, "first block is due at 1002")
+
+	step := func(expectedOrigin eth.BlockID, expectedSeqNumber uint64) {
+		next, l1Origin, err := seq.Step(ctx, heads, 2000)
+		require.NoError(t, err)
+		require.Equal(t, expectedOrigin, l1Origin)
+		require.Equal(t, heads.Unsafe.Number+1, next.Unsafe.Number)
+		require.Equal(t, genesis.L2, next.Safe, "sequenced blocks are not safe")
+		require.Equal(t, genesis.L2, next.Finalized)
+		bl := engine.blocks[next.Unsafe.Hash]
+		require.NotNil(t, bl)
+		l1Num, _, _, l1Hash, seqNumber, _, _, _, err := ParseL1InfoDepositTxData(bl.Transactions()[0].Data())
+		require.NoError(t, err)
+		require.Equal(t, expectedOrigin, eth.BlockID{Hash: l1Hash, Number: l1Num})
+		require.Equal(t, expectedSeqNumber, seqNumber)
+		heads = next
+	}
+	// L2 blocks at 1002 ... 1010 keep the L1 origin 0
+	for i := uint64(1); i <= 5; i++ {
+		step(l1.id(0), i)
+	}
+	// at 1012, the L2 block adopts L1 block 1 as origin and starts a new epoch
+	step(l1.id(1), 0)
+	// L2 blocks at 1014 ... 1032 stay within the drift of L1 block 1
+	for i := uint64(1); i <= 10; i++ {
+		step(l1.id(1), i)
+	}
+	// at 1034, the L2 block would exceed the drift, but there is no next L1 block yet
+	_, _, err = seq.Step(ctx, heads, 2000)
+	require.ErrorIs(t, err, SequencerDriftErr)
+
+	// once L1 progresses, the L2 block adopts the next L1 origin
+	l1.add(1024)
+	step(l1.id(2), 0)
+	// L2 blocks at 1036 ... 1044 stay within the drift of L1 block 2
+	for i := uint64(1); i <= 5; i++ {
+		step(l1.id(2), i)
+	}
+	// at 1046, the L2 block would exceed the drift, and the next L1 block is ahead of it:
+	// the L2 block adopts it as origin, with its timestamp
+	l1.add(1050)
+	_, _, err = seq.Step(ctx, heads, 1049)
+	require.ErrorIs(t, err, SequencerAheadErr, "the L2 block is raised to the time of its L1 origin")
+	step(l1.id(3), 0)
+	require.Equal(t, uint64(1050), engine.blocks[heads.Unsafe.Hash].Time())
+}
+
+func TestSequencerBlockInputs_FeeRecipient(t *testing.T) {
+	origin := BlockInputFromHeader(&types.Header{Number: big.NewInt(1), Time: 1000, ReceiptHash: types.EmptyRootHash, BaseFee: big.NewInt(7)})
+	cfg := &Config{SequencerFeeRecipient: common.Address{0xfe}}
+	attrs, err := SequencerBlockInputs(cfg, origin, 1, nil, 1002)
+	require.NoError(t, err)
+	require.Equal(t, common.Address{0xfe}, attrs.SuggestedFeeRecipient)
+
+	// the system config overrides the configured fee recipient
+	attrs, err = SequencerBlockInputs(cfg.WithSystemConfig(SystemConfig{FeeRecipient: common.Address{0xff}}), origin, 1, nil, 1002)
+	require.NoError(t, err)
+	require.Equal(t, common.Address{0xff}, attrs.SuggestedFeeRecipient)
+}
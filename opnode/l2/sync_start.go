@@ -107,3 +107,43 @@ func FindSyncStart(ctx context.Context, reference SyncReference, genesis *Genesi
 	// we got the correct genesis, all good, but a lot to sync!
 	return
 }
+
+// FindL2Anchor finds the L2 block to resume from on startup: the L2 head of the engine if its L1 origin is canonical,
+// or otherwise the latest ancestor of the L2 head with a canonical L1 origin, if L1 reorged while the driver was offline.
+// The L1 origin of each L2 block is read from its L1 info deposit. The anchor is returned along with its L1 origin.
+//
+// L2 blocks with a L1 origin that the L1 source does not have yet, e.g. when the L1 source is behind, are not regarded as canonical.
+// A WrongChainErr is returned if the walk back reaches a genesis block that does not match the given genesis.
+func FindL2Anchor(ctx context.Context, reference SyncReference, genesis *Genesis) (refL1, refL2 eth.BlockID, err error) {
+	var parentL2 common.Hash
+	refL1, refL2, parentL2, err = reference.RefByL2Num(ctx, nil, genesis)
+	if err != nil {
+		err = fmt.Errorf("failed to fetch L2 head: %v", err)
+		return
+	}
+	for {
+		var canonicalL1 eth.BlockID
+		canonicalL1, _, err = reference.RefByL1Num(ctx, refL1.Number)
+		if err != nil && !errors.Is(err, ethereum.NotFound) {
+			err = fmt.Errorf("failed to lookup block %d in L1: %w", refL1.Number, err)
+			return
+		}
+		err = nil
+		if refL2.Number <= genesis.L2.Number {
+			if refL2 != genesis.L2 {
+				err = fmt.Errorf("unexpected L2 genesis block: %s, expected %s, %w", refL2, genesis.L2, WrongChainErr)
+			} else if canonicalL1 != genesis.L1 {
+				err = fmt.Errorf("unexpected L1 anchor block: %s, expected %s, %w", canonicalL1, genesis.L1, WrongChainErr)
+			}
+			return
+		}
+		if canonicalL1 == refL1 {
+			return
+		}
+		refL1, refL2, parentL2, err = reference.RefByL2Hash(ctx, parentL2, genesis)
+		if err != nil {
+			err = fmt.Errorf("failed to lookup block %s in L2: %w", parentL2, err)
+			return
+		}
+	}
+}
@@ -3,6 +3,7 @@ package l2
 import (
 	"context"
 	"math/big"
+	"strings"
 	"testing"
 
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
@@ -314,3 +315,41 @@ func TestFindSyncStart(t *testing.T) {
 		t.Run(testCase.Name, testCase.Run)
 	}
 }
+
+func TestFindL2Anchor(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		EngineL1 string
+		EngineL2 string
+		ActualL1 string
+
+		ExpectedRefL2 rune
+		ExpectedErr   error
+	}{
+		{Name: "canonical head", EngineL1: "abc", EngineL2: "ABC", ActualL1: "abcd", ExpectedRefL2: 'C'},
+		{Name: "genesis", EngineL1: "a", EngineL2: "A", ActualL1: "ab", ExpectedRefL2: 'A'},
+		{Name: "reorg two steps back", EngineL1: "abc", EngineL2: "ABC", ActualL1: "axy", ExpectedRefL2: 'A'},
+		{Name: "orphan block", EngineL1: "abcd", EngineL2: "ABCD", ActualL1: "abcx", ExpectedRefL2: 'C'},
+		{Name: "L1 behind", EngineL1: "abcde", EngineL2: "ABCDE", ActualL1: "abc", ExpectedRefL2: 'C'},
+		{Name: "unexpected L1 chain", EngineL1: "abc", EngineL2: "ABC", ActualL1: "xyz", ExpectedErr: WrongChainErr},
+	}
+	for _, c := range testCases {
+		t.Run(c.Name, func(t *testing.T) {
+			engL1 := chainL1(0, c.EngineL1)
+			msr := &mockSyncReference{
+				L2: chainL2(engL1, c.EngineL2),
+				L1: chainL1(0, c.ActualL1),
+			}
+			genesis := &Genesis{L1: mockID('a', 0), L2: mockID('A', 0)}
+			refL1, refL2, err := FindL2Anchor(context.Background(), msr, genesis)
+			if c.ExpectedErr != nil {
+				assert.ErrorIs(t, err, c.ExpectedErr)
+				return
+			}
+			assert.NoError(t, err)
+			i := strings.IndexRune(c.EngineL2, c.ExpectedRefL2)
+			assert.Equal(t, msr.L2[i].Self, refL2)
+			assert.Equal(t, msr.L2[i].FromL1, refL1)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package l2
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// SystemConfigUpdateType is the type of a ConfigUpdate event of the SystemConfig contract on L1
+type SystemConfigUpdateType uint8
+
+const (
+	// SystemConfigUpdateBatcher updates the batcher address: abi.encode(address)
+	SystemConfigUpdateBatcher SystemConfigUpdateType = 0
+	// SystemConfigUpdateGasConfig updates the fee scalars: abi.encode(uint256 overhead, uint256 scalar)
+	SystemConfigUpdateGasConfig SystemConfigUpdateType = 1
+	// SystemConfigUpdateGasLimit updates the L2 block gas limit: abi.encode(uint64)
+	SystemConfigUpdateGasLimit SystemConfigUpdateType = 2
+	// SystemConfigUpdateFeeRecipient updates the fee recipient of sequenced L2 blocks: abi.encode(address)
+	SystemConfigUpdateFeeRecipient SystemConfigUpdateType = 3
+)
+
+// SystemConfigVersion0 is the only supported version of ConfigUpdate events
+const SystemConfigVersion0 = 0
+
+// SystemConfigContractABI is the ABI of the ConfigUpdate event of the SystemConfig contract
+var SystemConfigContractABI = mustParseABI(`[{"anonymous":false,"inputs":[
+	{"indexed":true,"internalType":"uint256","name":"version","type":"uint256"},
+	{"indexed":true,"internalType":"uint8","name":"updateType","type":"uint8"},
+	{"indexed":false,"internalType":"bytes","name":"data","type":"bytes"}
+],"name":"ConfigUpdate","type":"event"}]`)
+
+// ConfigUpdateEventABIHash is the topic of ConfigUpdate events
+var ConfigUpdateEventABIHash = SystemConfigContractABI.Events["ConfigUpdate"].ID
+
+// InvalidSystemConfigUpdateErr is returned when a ConfigUpdate event cannot be applied
+var InvalidSystemConfigUpdateErr = errors.New("invalid system config update")
+
+// configUpdateEvent holds the decoded ConfigUpdate event.
+// Data must be the first field: the single non-indexed argument is copied into the first field.
+type configUpdateEvent struct {
+	Data       []byte
+	Version    *big.Int
+	UpdateType uint8
+}
+
+// ProcessLog applies a ConfigUpdate event of the SystemConfig contract to the system config.
+func (sc *SystemConfig) ProcessLog(log *types.Log) error {
+	var ev configUpdateEvent
+	if err := unpackEventStrict(SystemConfigContractABI, "ConfigUpdate", log, &ev); err != nil {
+		return fmt.Errorf("%v: %w", err, InvalidSystemConfigUpdateErr)
+	}
+	if ev.Version.Sign() != SystemConfigVersion0 {
+		return fmt.Errorf("unsupported ConfigUpdate version %d: %w", ev.Version, InvalidSystemConfigUpdateErr)
+	}
+	words := func(n int) ([]common.Hash, error) {
+		if len(ev.Data) != n*32 {
+			return nil, fmt.Errorf("expected %d bytes of update type %d, got %d: %w", n*32, ev.UpdateType, len(ev.Data), InvalidSystemConfigUpdateErr)
+		}
+		out := make([]common.Hash, n)
+		for i := range out {
+			out[i] = common.BytesToHash(ev.Data[i*32 : (i+1)*32])
+		}
+		return out, nil
+	}
+	address := func() (common.Address, error) {
+		w, err := words(1)
+		if err != nil {
+			return common.Address{}, err
+		}
+		if !isZero(w[0][:common.HashLength-common.AddressLength]) {
+			return common.Address{}, fmt.Errorf("address of update type %d has dirty padding: %s: %w", ev.UpdateType, w[0], InvalidSystemConfigUpdateErr)
+		}
+		return common.BytesToAddress(w[0][:]), nil
+	}
+	switch SystemConfigUpdateType(ev.UpdateType) {
+	case SystemConfigUpdateBatcher:
+		addr, err := address()
+		if err != nil {
+			return err
+		}
+		sc.BatcherAddr = addr
+	case SystemConfigUpdateGasConfig:
+		w, err := words(2)
+		if err != nil {
+			return err
+		}
+		sc.Overhead, sc.Scalar = w[0], w[1]
+	case SystemConfigUpdateGasLimit:
+		w, err := words(1)
+		if err != nil {
+			return err
+		}
+		gasLimit := new(big.Int).SetBytes(w[0][:])
+		if !gasLimit.IsUint64() {
+			return fmt.Errorf("gas limit %d overflows: %w", gasLimit, InvalidSystemConfigUpdateErr)
+		}
+		sc.GasLimit = gasLimit.Uint64()
+	case SystemConfigUpdateFeeRecipient:
+		addr, err := address()
+		if err != nil {
+			return err
+		}
+		sc.FeeRecipient = addr
+	default:
+		return fmt.Errorf("unknown update type %d: %w", ev.UpdateType, InvalidSystemConfigUpdateErr)
+	}
+	return nil
+}
+
+// UpdateSystemConfig applies the ConfigUpdate events of the SystemConfig contract in the receipts of a L1 block,
+// in order, to the system config. Events of reverted transactions are ignored.
+// Invalid events are skipped, and returned as errors: the update is applied on L1 regardless,
+// and must not halt the derivation. Tracking is disabled if no SystemConfig contract is configured.
+func UpdateSystemConfig(cfg *Config, sc *SystemConfig, receipts []*types.Receipt) (errs []error) {
+	if cfg.SystemConfigAddr == (common.Address{}) {
+		return nil
+	}
+	var logs []*types.Log
+	for _, rec := range receipts {
+		if rec.Status == types.ReceiptStatusSuccessful {
+			logs = append(logs, rec.Logs...)
+		}
+	}
+	return UpdateSystemConfigFromLogs(cfg, sc, logs)
+}
+
+// UpdateSystemConfigFromLogs applies the ConfigUpdate events of the SystemConfig contract in the logs of a L1 block,
+// in order, to the system config, like UpdateSystemConfig. Other logs are ignored.
+func UpdateSystemConfigFromLogs(cfg *Config, sc *SystemConfig, logs []*types.Log) (errs []error) {
+	if cfg.SystemConfigAddr == (common.Address{}) {
+		return nil
+	}
+	for _, log := range logs {
+		if !isConfigUpdateLog(cfg, log) {
+			continue
+		}
+		if err := sc.ProcessLog(log); err != nil {
+			errs = append(errs, fmt.Errorf("skipped ConfigUpdate log %d of tx %s: %w", log.Index, log.TxHash, err))
+		}
+	}
+	return errs
+}
+
+// isConfigUpdateLog returns true if the log is a ConfigUpdate event of the SystemConfig contract
+func isConfigUpdateLog(cfg *Config, log *types.Log) bool {
+	return log.Address == cfg.SystemConfigAddr && len(log.Topics) > 0 && log.Topics[0] == ConfigUpdateEventABIHash
+}
+
+// WithSystemConfig returns a copy of the rollup config, to derive L2 blocks with the given system config.
+func (cfg *Config) WithSystemConfig(sc SystemConfig) *Config {
+	out := *cfg
+	out.SystemConfig = sc
+	return &out
+}
@@ -0,0 +1,92 @@
+package l2
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+var testSystemConfigAddr = common.HexToAddress("0x5c")
+
+var maxWord = common.HexToHash("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff").Bytes()
+
+func configUpdateLog(t *testing.T, version uint64, updateType SystemConfigUpdateType, data []byte) *types.Log {
+	packed, err := SystemConfigContractABI.Events["ConfigUpdate"].Inputs.NonIndexed().Pack(data)
+	require.NoError(t, err)
+	topics := []common.Hash{
+		ConfigUpdateEventABIHash,
+		common.BigToHash(new(big.Int).SetUint64(version)),
+		common.BigToHash(big.NewInt(int64(updateType))),
+	}
+	return testutil.GenerateLog(testSystemConfigAddr, topics, packed)
+}
+
+func TestSystemConfig_ProcessLog(t *testing.T) {
+	batcher := common.HexToAddress("0xba")
+	feeRecipient := common.HexToAddress("0xfe")
+	overhead := common.BigToHash(big.NewInt(2100))
+	scalar := common.BigToHash(big.NewInt(1000000))
+
+	var sc SystemConfig
+	require.NoError(t, sc.ProcessLog(configUpdateLog(t, 0, SystemConfigUpdateBatcher, common.BytesToHash(batcher[:]).Bytes())))
+	require.NoError(t, sc.ProcessLog(configUpdateLog(t, 0, SystemConfigUpdateGasConfig, append(overhead.Bytes(), scalar.Bytes()...))))
+	require.NoError(t, sc.ProcessLog(configUpdateLog(t, 0, SystemConfigUpdateGasLimit, common.BigToHash(big.NewInt(30_000_000)).Bytes())))
+	require.NoError(t, sc.ProcessLog(configUpdateLog(t, 0, SystemConfigUpdateFeeRecipient, common.BytesToHash(feeRecipient[:]).Bytes())))
+	require.Equal(t, SystemConfig{BatcherAddr: batcher, Overhead: overhead, Scalar: scalar, GasLimit: 30_000_000, FeeRecipient: feeRecipient}, sc)
+
+	invalid := []struct {
+		name string
+		log  *types.Log
+	}{
+		{"version", configUpdateLog(t, 1, SystemConfigUpdateGasLimit, common.BigToHash(big.NewInt(1)).Bytes())},
+		{"type", configUpdateLog(t, 0, 4, common.BigToHash(big.NewInt(1)).Bytes())},
+		{"length", configUpdateLog(t, 0, SystemConfigUpdateGasConfig, overhead.Bytes())},
+		{"padding", configUpdateLog(t, 0, SystemConfigUpdateBatcher, maxWord)},
+		{"overflow", configUpdateLog(t, 0, SystemConfigUpdateGasLimit, maxWord)},
+	}
+	for _, tc := range invalid {
+		t.Run(tc.name, func(t *testing.T) {
+			before := sc
+			require.ErrorIs(t, sc.ProcessLog(tc.log), InvalidSystemConfigUpdateErr)
+			require.Equal(t, before, sc, "invalid updates must not change the system config")
+		})
+	}
+}
+
+func TestUpdateSystemConfig(t *testing.T) {
+	cfg := &Config{SystemConfigAddr: testSystemConfigAddr}
+	gasLimit := func(v int64) *types.Log {
+		return configUpdateLog(t, 0, SystemConfigUpdateGasLimit, common.BigToHash(big.NewInt(v)).Bytes())
+	}
+	other := gasLimit(1)
+	other.Address = common.HexToAddress("0x0e")
+	receipts := []*types.Receipt{
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{gasLimit(10), other}},
+		{Status: types.ReceiptStatusFailed, Logs: []*types.Log{gasLimit(20)}},
+		{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{configUpdateLog(t, 1, SystemConfigUpdateGasLimit, nil), gasLimit(30)}},
+	}
+
+	var sc SystemConfig
+	errs := UpdateSystemConfig(cfg, &sc, receipts)
+	require.Len(t, errs, 1)
+	require.ErrorIs(t, errs[0], InvalidSystemConfigUpdateErr)
+	require.Equal(t, uint64(30), sc.GasLimit)
+
+	var untracked SystemConfig
+	require.Empty(t, UpdateSystemConfig(&Config{}, &untracked, receipts))
+	require.Equal(t, SystemConfig{}, untracked)
+}
+
+func TestConfig_Batcher(t *testing.T) {
+	cfg := &Config{BatcherAddr: common.HexToAddress("0xb1")}
+	require.Equal(t, cfg.BatcherAddr, cfg.Batcher())
+	updated := cfg.WithSystemConfig(SystemConfig{BatcherAddr: common.HexToAddress("0xb2")})
+	require.Equal(t, common.HexToAddress("0xb2"), updated.Batcher())
+	require.Equal(t, common.BytesToHash(updated.Batcher().Bytes()), updated.BatcherHash())
+	require.Equal(t, common.HexToAddress("0xb1"), cfg.Batcher(), "the original config must be unchanged")
+}
@@ -0,0 +1,112 @@
+package l2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// TestVectors are canonical derivation test vectors, for other client implementations to validate against.
+type TestVectors struct {
+	Deposits []DepositTestVector `json:"deposits"`
+	L1Info   []L1InfoTestVector  `json:"l1Info"`
+}
+
+// TestVectorLog is the part of a L1 log that deposits are derived from
+type TestVectorLog struct {
+	Address common.Address `json:"address"`
+	Topics  []common.Hash  `json:"topics"`
+	Data    hexutil.Bytes  `json:"data"`
+}
+
+// DepositTestVector is a L1 deposit log, and the deposit transaction it derives, or an error if it is invalid.
+type DepositTestVector struct {
+	Name             string         `json:"name"`
+	BlockHeight      hexutil.Uint64 `json:"blockHeight"`
+	TransactionIndex hexutil.Uint64 `json:"transactionIndex"`
+	Log              TestVectorLog  `json:"log"`
+	// DepositTx is the binary encoding of the typed deposit transaction, empty if the log is invalid
+	DepositTx hexutil.Bytes `json:"depositTx,omitempty"`
+	Invalid   bool          `json:"invalid,omitempty"`
+}
+
+// L1InfoTestVector is a L1 block, and the calldata of the L1 info deposit it derives.
+type L1InfoTestVector struct {
+	Name        string         `json:"name"`
+	Number      hexutil.Uint64 `json:"number"`
+	Time        hexutil.Uint64 `json:"time"`
+	BaseFee     *hexutil.Big   `json:"baseFee"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	SeqNumber   hexutil.Uint64 `json:"seqNumber"`
+	BatcherHash common.Hash    `json:"batcherHash"`
+	// L1FeeOverhead and L1FeeScalar are the L1 fee scalars of the system config
+	L1FeeOverhead common.Hash   `json:"l1FeeOverhead"`
+	L1FeeScalar   common.Hash   `json:"l1FeeScalar"`
+	Calldata      hexutil.Bytes `json:"calldata"`
+}
+
+// l1InfoVectorInput implements L1Info with the inputs of a L1InfoTestVector
+type l1InfoVectorInput struct {
+	v *L1InfoTestVector
+}
+
+func (l l1InfoVectorInput) NumberU64() uint64 { return uint64(l.v.Number) }
+func (l l1InfoVectorInput) Time() uint64      { return uint64(l.v.Time) }
+func (l l1InfoVectorInput) Hash() common.Hash { return l.v.BlockHash }
+func (l l1InfoVectorInput) BaseFee() *big.Int { return (*big.Int)(l.v.BaseFee) }
+
+// ReadTestVectors decodes JSON test vectors, as written by GenerateTestVectors.
+func ReadTestVectors(r io.Reader) (*TestVectors, error) {
+	var out TestVectors
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode test vectors: %v", err)
+	}
+	return &out, nil
+}
+
+// Check replays the deposit test vector, and returns an error if the derivation does not match the vector.
+func (v *DepositTestVector) Check() error {
+	ev := &types.Log{Address: v.Log.Address, Topics: v.Log.Topics, Data: v.Log.Data}
+	dep, err := UnmarshalDepositLog(uint64(v.BlockHeight), uint64(v.TransactionIndex), ev)
+	if v.Invalid {
+		if err == nil {
+			return errors.New("expected invalid deposit log, but it was decoded")
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to decode deposit log: %v", err)
+	}
+	data, err := EncodeDeposit(dep)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(data, v.DepositTx) {
+		return fmt.Errorf("deposit tx mismatch: got %x, expected %x", data, []byte(v.DepositTx))
+	}
+	return nil
+}
+
+// Check replays the L1 info test vector, and returns an error if the derivation does not match the vector.
+func (v *L1InfoTestVector) Check() error {
+	if v.BaseFee == nil {
+		return errors.New("missing base fee")
+	}
+	data, err := EncodeL1InfoData(l1InfoVectorInput{v}, uint64(v.SeqNumber), v.BatcherHash, v.L1FeeOverhead, v.L1FeeScalar)
+	if err != nil {
+		return fmt.Errorf("failed to encode L1 info: %v", err)
+	}
+	if !bytes.Equal(data, v.Calldata) {
+		return fmt.Errorf("L1 info calldata mismatch: got %x, expected %x", data, []byte(v.Calldata))
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package l2
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testdata/test_vectors.json is generated with: go run ./cmd testvectors --count 5 --out l2/testdata/test_vectors.json
+func TestTestVectors(t *testing.T) {
+	f, err := os.Open("testdata/test_vectors.json")
+	require.NoError(t, err)
+	defer f.Close()
+	vectors, err := ReadTestVectors(f)
+	require.NoError(t, err)
+	require.NotEmpty(t, vectors.Deposits)
+	require.NotEmpty(t, vectors.L1Info)
+
+	for i := range vectors.Deposits {
+		v := &vectors.Deposits[i]
+		t.Run("deposit "+v.Name, func(t *testing.T) {
+			assert.NoError(t, v.Check())
+		})
+	}
+	for i := range vectors.L1Info {
+		v := &vectors.L1Info[i]
+		t.Run("l1 info "+v.Name, func(t *testing.T) {
+			assert.NoError(t, v.Check())
+		})
+	}
+}
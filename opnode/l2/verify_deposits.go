@@ -0,0 +1,52 @@
+package l2
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// VerifyDepositOrdering checks that the user deposits in the transactions of a built L2 block
+// match the derived deposits one-for-one, in the same order, after the L1 info deposit.
+// The user deposits in the block end at the first non-deposit transaction.
+// An error describing the first divergence is returned if the block does not match.
+func VerifyDepositOrdering(derived []*types.DepositTx, blockTxs []Data) error {
+	if len(blockTxs) == 0 {
+		return fmt.Errorf("block has no transactions, expected L1 info deposit")
+	}
+	if _, err := DecodeDeposit(blockTxs[0]); err != nil {
+		return fmt.Errorf("first tx is not the L1 info deposit: %w", err)
+	}
+
+	i := 0
+	for ; 1+i < len(blockTxs); i++ {
+		dep, err := DecodeDeposit(blockTxs[1+i])
+		if errors.Is(err, NotDepositErr) {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to decode block tx %d: %v", 1+i, err)
+		}
+		if i >= len(derived) {
+			return fmt.Errorf("block has extra deposit at deposit index %d (tx %d), expected only %d deposits", i, 1+i, len(derived))
+		}
+		got, err := EncodeDeposit(dep)
+		if err != nil {
+			return fmt.Errorf("failed to encode block tx %d: %v", 1+i, err)
+		}
+		expected, err := EncodeDeposit(derived[i])
+		if err != nil {
+			return fmt.Errorf("failed to encode derived deposit %d: %v", i, err)
+		}
+		if !bytes.Equal(got, expected) {
+			return fmt.Errorf("block deposit at deposit index %d (tx %d) is %s, expected %s", i, 1+i, crypto.Keccak256Hash(got), crypto.Keccak256Hash(expected))
+		}
+	}
+	if i < len(derived) {
+		return fmt.Errorf("block is missing deposit at deposit index %d (tx %d), expected %d deposits, got %d", i, 1+i, len(derived), i)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package l2
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func encodeTxs(t *testing.T, txs ...*types.Transaction) (out []Data) {
+	for _, tx := range txs {
+		opaqueTx, err := tx.MarshalBinary()
+		require.NoError(t, err)
+		out = append(out, opaqueTx)
+	}
+	return
+}
+
+func TestVerifyDepositOrdering(t *testing.T) {
+	rng := rand.New(rand.NewSource(1234))
+	info := randomL1Info(rng)
+	l1Info, err := DeriveL1InfoDeposit(&Config{}, info, 0)
+	require.NoError(t, err)
+	var derived []*types.DepositTx
+	for i := 0; i < 3; i++ {
+		derived = append(derived, testutil.GenerateDeposit(info.num, uint64(1+i), rng))
+	}
+	l1InfoTx := types.NewTx(l1Info)
+	dep := func(i int) *types.Transaction {
+		return types.NewTx(derived[i])
+	}
+	userTx := types.NewTx(&types.DynamicFeeTx{ChainID: big.NewInt(1), Nonce: 1, To: &common.Address{}, Value: big.NewInt(1)})
+	extra := types.NewTx(testutil.GenerateDeposit(info.num, 4, rng))
+
+	t.Run("matching", func(t *testing.T) {
+		assert.NoError(t, VerifyDepositOrdering(derived, encodeTxs(t, l1InfoTx, dep(0), dep(1), dep(2))))
+	})
+	t.Run("matching with user txs", func(t *testing.T) {
+		assert.NoError(t, VerifyDepositOrdering(derived, encodeTxs(t, l1InfoTx, dep(0), dep(1), dep(2), userTx)))
+	})
+	t.Run("no deposits", func(t *testing.T) {
+		assert.NoError(t, VerifyDepositOrdering(nil, encodeTxs(t, l1InfoTx, userTx)))
+	})
+	t.Run("reordered", func(t *testing.T) {
+		err := VerifyDepositOrdering(derived, encodeTxs(t, l1InfoTx, dep(0), dep(2), dep(1)))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "deposit index 1")
+	})
+	t.Run("missing", func(t *testing.T) {
+		err := VerifyDepositOrdering(derived, encodeTxs(t, l1InfoTx, dep(0), dep(1), userTx))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "missing deposit at deposit index 2")
+	})
+	t.Run("extra", func(t *testing.T) {
+		err := VerifyDepositOrdering(derived, encodeTxs(t, l1InfoTx, dep(0), dep(1), dep(2), extra))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "extra deposit at deposit index 3")
+	})
+	t.Run("missing L1 info", func(t *testing.T) {
+		assert.Error(t, VerifyDepositOrdering(derived, encodeTxs(t, userTx, dep(0), dep(1), dep(2))))
+		assert.Error(t, VerifyDepositOrdering(derived, nil))
+	})
+}
@@ -0,0 +1,215 @@
+package l2
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// WithdrawalContractABI is the ABI of the WithdrawalInitiated event of the withdrawal contract
+var WithdrawalContractABI = mustParseABI(`[{"anonymous":false,"inputs":[
+	{"indexed":true,"internalType":"uint256","name":"nonce","type":"uint256"},
+	{"indexed":true,"internalType":"address","name":"sender","type":"address"},
+	{"indexed":true,"internalType":"address","name":"target","type":"address"},
+	{"indexed":false,"internalType":"uint256","name":"value","type":"uint256"},
+	{"indexed":false,"internalType":"uint256","name":"gasLimit","type":"uint256"},
+	{"indexed":false,"internalType":"bytes","name":"data","type":"bytes"}
+],"name":"WithdrawalInitiated","type":"event"}]`)
+
+// WithdrawalInitiatedEventABIHash is the topic of WithdrawalInitiated events
+var WithdrawalInitiatedEventABIHash = WithdrawalContractABI.Events["WithdrawalInitiated"].ID
+
+// SentMessagesSlot is the storage slot of the sentMessages mapping of the withdrawal contract,
+// which marks the hash of every initiated withdrawal
+var SentMessagesSlot = common.Hash{}
+
+// NoWithdrawalsErr is returned when a L2 transaction did not initiate any withdrawal
+var NoWithdrawalsErr = errors.New("no withdrawals")
+
+// withdrawalHashArgs are the arguments of the withdrawal hash:
+// keccak256(abi.encode(nonce, sender, target, value, gasLimit, data))
+var withdrawalHashArgs = func() abi.Arguments {
+	mustType := func(t string) abi.Type {
+		typ, err := abi.NewType(t, "", nil)
+		if err != nil {
+			panic(err)
+		}
+		return typ
+	}
+	return abi.Arguments{
+		{Type: mustType("uint256")},
+		{Type: mustType("address")},
+		{Type: mustType("address")},
+		{Type: mustType("uint256")},
+		{Type: mustType("uint256")},
+		{Type: mustType("bytes")},
+	}
+}()
+
+// WithdrawalTransaction is a withdrawal initiated on L2, to be finalized on L1.
+// Not to be confused with the Withdrawal of the engine API.
+type WithdrawalTransaction struct {
+	Nonce    *hexutil.Big   `json:"nonce"`
+	Sender   common.Address `json:"sender"`
+	Target   common.Address `json:"target"`
+	Value    *hexutil.Big   `json:"value"`
+	GasLimit *hexutil.Big   `json:"gasLimit"`
+	Data     hexutil.Bytes  `json:"data"`
+}
+
+// Hash returns the withdrawal hash, as marked in the storage of the withdrawal contract,
+// and as verified by the L1 contracts.
+func (w *WithdrawalTransaction) Hash() (common.Hash, error) {
+	enc, err := withdrawalHashArgs.Pack((*big.Int)(w.Nonce), w.Sender, w.Target, (*big.Int)(w.Value), (*big.Int)(w.GasLimit), []byte(w.Data))
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to encode withdrawal: %v", err)
+	}
+	return crypto.Keccak256Hash(enc), nil
+}
+
+// WithdrawalStorageSlot returns the storage slot of the withdrawal contract that marks the withdrawal:
+// the sentMessages mapping entry of the withdrawal hash.
+func WithdrawalStorageSlot(withdrawalHash common.Hash) common.Hash {
+	return crypto.Keccak256Hash(withdrawalHash[:], SentMessagesSlot[:])
+}
+
+// withdrawalInitiatedEvent holds the decoded WithdrawalInitiated event
+type withdrawalInitiatedEvent struct {
+	Nonce    *big.Int
+	Sender   common.Address
+	Target   common.Address
+	Value    *big.Int
+	GasLimit *big.Int
+	Data     []byte
+}
+
+// ParseWithdrawals decodes the withdrawals initiated by a L2 transaction, from the logs of the withdrawal contract.
+func ParseWithdrawals(cfg *Config, receipt *types.Receipt) ([]*WithdrawalTransaction, error) {
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return nil, fmt.Errorf("transaction %s failed: %w", receipt.TxHash, NoWithdrawalsErr)
+	}
+	var out []*WithdrawalTransaction
+	for _, log := range receipt.Logs {
+		if log.Address != cfg.WithdrawalContract() || len(log.Topics) == 0 || log.Topics[0] != WithdrawalInitiatedEventABIHash {
+			continue
+		}
+		var ev withdrawalInitiatedEvent
+		if err := unpackEventStrict(WithdrawalContractABI, "WithdrawalInitiated", log, &ev); err != nil {
+			return nil, fmt.Errorf("failed to decode withdrawal log %d of tx %s: %v", log.Index, receipt.TxHash, err)
+		}
+		out = append(out, &WithdrawalTransaction{
+			Nonce:    (*hexutil.Big)(ev.Nonce),
+			Sender:   ev.Sender,
+			Target:   ev.Target,
+			Value:    (*hexutil.Big)(ev.Value),
+			GasLimit: (*hexutil.Big)(ev.GasLimit),
+			Data:     ev.Data,
+		})
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("transaction %s: %w", receipt.TxHash, NoWithdrawalsErr)
+	}
+	return out, nil
+}
+
+// WithdrawalProof is everything needed to finalize a withdrawal on L1:
+// the withdrawal, the output it is proven against and its components, and the storage proof of the withdrawal.
+type WithdrawalProof struct {
+	Withdrawal     *WithdrawalTransaction `json:"withdrawal"`
+	WithdrawalHash common.Hash            `json:"withdrawalHash"`
+	// Output is the output of the L2 block that the withdrawal is proven against, as proposed to L1
+	Output *Output `json:"output"`
+	// L2Timestamp is the timestamp of the L2 block of the output
+	L2Timestamp hexutil.Uint64 `json:"l2Timestamp"`
+	StorageSlot common.Hash    `json:"storageSlot"`
+	// StorageProof is the RLP-encoded list of the nodes of the proof of the storage slot,
+	// in the withdrawal contract storage trie committed to in the output
+	StorageProof hexutil.Bytes `json:"storageProof"`
+}
+
+// ProveWithdrawals proves the withdrawals initiated by the L2 transaction against the output of the L2 block with the given number,
+// with the eth_getProof storage proofs of the withdrawal slots. The block must include the transaction, or be after it.
+// A number of 0 proves against the output of the block that includes the transaction.
+func ProveWithdrawals(ctx context.Context, cfg *Config, blocks eth.BlockByNumberSource, receipts eth.ReceiptSource, proofs ProofSource, txHash common.Hash, number uint64) ([]*WithdrawalProof, error) {
+	receipt, err := receipts.TransactionReceipt(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch receipt of L2 transaction %s: %w", txHash, err)
+	}
+	withdrawals, err := ParseWithdrawals(cfg, receipt)
+	if err != nil {
+		return nil, err
+	}
+	txBlock := receipt.BlockNumber.Uint64()
+	if number == 0 {
+		number = txBlock
+	}
+	if number < txBlock {
+		return nil, fmt.Errorf("cannot prove withdrawals of L2 block %d against the output of the earlier L2 block %d", txBlock, number)
+	}
+
+	out := make([]*WithdrawalProof, len(withdrawals))
+	keys := make([]string, len(withdrawals))
+	for i, w := range withdrawals {
+		h, err := w.Hash()
+		if err != nil {
+			return nil, err
+		}
+		out[i] = &WithdrawalProof{Withdrawal: w, WithdrawalHash: h, StorageSlot: WithdrawalStorageSlot(h)}
+		keys[i] = out[i].StorageSlot.Hex()
+	}
+	output, block, account, err := outputAtBlock(ctx, cfg, blocks, proofs, number, keys)
+	if err != nil {
+		return nil, err
+	}
+	if len(account.StorageProof) != len(keys) {
+		return nil, fmt.Errorf("expected %d storage proofs, got %d", len(keys), len(account.StorageProof))
+	}
+	for i, p := range out {
+		nodes := make([][]byte, len(account.StorageProof[i].Proof))
+		for j, node := range account.StorageProof[i].Proof {
+			if nodes[j], err = hexutil.Decode(node); err != nil {
+				return nil, fmt.Errorf("invalid node %d of the proof of withdrawal %s: %v", j, p.WithdrawalHash, err)
+			}
+		}
+		if err := verifyWithdrawalProof(output.WithdrawalRoot, p.StorageSlot, nodes); err != nil {
+			return nil, fmt.Errorf("invalid proof of withdrawal %s at L2 block %d: %w", p.WithdrawalHash, number, err)
+		}
+		if p.StorageProof, err = rlp.EncodeToBytes(nodes); err != nil {
+			return nil, fmt.Errorf("failed to encode the proof of withdrawal %s: %v", p.WithdrawalHash, err)
+		}
+		p.Output = output
+		p.L2Timestamp = hexutil.Uint64(block.Time())
+	}
+	return out, nil
+}
+
+// verifyWithdrawalProof checks that the proof nodes prove that the withdrawal slot is set,
+// in the withdrawal contract storage trie with the given root.
+func verifyWithdrawalProof(storageRoot common.Hash, slot common.Hash, nodes [][]byte) error {
+	db := memorydb.New()
+	for _, node := range nodes {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+	value, err := trie.VerifyProof(storageRoot, crypto.Keccak256(slot[:]), db)
+	if err != nil {
+		return err
+	}
+	if len(value) == 0 {
+		return errors.New("withdrawal slot is not set")
+	}
+	return nil
+}
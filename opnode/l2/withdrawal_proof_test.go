@@ -0,0 +1,137 @@
+package l2
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/ethdb/memorydb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/ethereum/go-ethereum/trie"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testutil"
+)
+
+func withdrawalLog(t *testing.T, w *WithdrawalTransaction) *types.Log {
+	ev := WithdrawalContractABI.Events["WithdrawalInitiated"]
+	data, err := ev.Inputs.NonIndexed().Pack((*big.Int)(w.Value), (*big.Int)(w.GasLimit), []byte(w.Data))
+	require.NoError(t, err)
+	topics := []common.Hash{
+		WithdrawalInitiatedEventABIHash,
+		common.BigToHash((*big.Int)(w.Nonce)),
+		common.BytesToHash(w.Sender[:]),
+		common.BytesToHash(w.Target[:]),
+	}
+	return testutil.GenerateLog(WithdrawalContractAddr, topics, data)
+}
+
+func testWithdrawal(nonce int64) *WithdrawalTransaction {
+	return &WithdrawalTransaction{
+		Nonce:    (*hexutil.Big)(big.NewInt(nonce)),
+		Sender:   common.Address{0x5e},
+		Target:   common.Address{0x7a},
+		Value:    (*hexutil.Big)(big.NewInt(1000)),
+		GasLimit: (*hexutil.Big)(big.NewInt(100_000)),
+		Data:     hexutil.Bytes{0xca, 0xfe},
+	}
+}
+
+// fakeWithdrawalSource serves a L2 block with a receipt, and the withdrawal contract storage of the block
+type fakeWithdrawalSource struct {
+	block   *types.Block
+	receipt *types.Receipt
+	storage *trie.SecureTrie
+}
+
+func (f *fakeWithdrawalSource) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	return f.block, nil
+}
+
+func (f *fakeWithdrawalSource) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	return f.receipt, nil
+}
+
+func (f *fakeWithdrawalSource) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	res := &gethclient.AccountResult{Address: account, StorageHash: f.storage.Hash()}
+	for _, k := range keys {
+		key := common.HexToHash(k)
+		db := memorydb.New()
+		// like eth_getProof, prove the path of the hashed key in the secure storage trie
+		if err := f.storage.Prove(crypto.Keccak256(key[:]), 0, db); err != nil {
+			return nil, err
+		}
+		var nodes []string
+		it := db.NewIterator(nil, nil)
+		for it.Next() {
+			nodes = append(nodes, hexutil.Encode(it.Value()))
+		}
+		it.Release()
+		res.StorageProof = append(res.StorageProof, gethclient.StorageResult{Key: k, Proof: nodes})
+	}
+	return res, nil
+}
+
+func TestParseWithdrawals(t *testing.T) {
+	w := testWithdrawal(3)
+	other := withdrawalLog(t, testWithdrawal(4))
+	other.Address = common.Address{0x01}
+	receipt := &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{other, withdrawalLog(t, w)}}
+
+	withdrawals, err := ParseWithdrawals(&Config{}, receipt)
+	require.NoError(t, err)
+	require.Equal(t, []*WithdrawalTransaction{w}, withdrawals, "only logs of the withdrawal contract are withdrawals")
+
+	receipt.Status = types.ReceiptStatusFailed
+	_, err = ParseWithdrawals(&Config{}, receipt)
+	require.ErrorIs(t, err, NoWithdrawalsErr)
+
+	_, err = ParseWithdrawals(&Config{}, &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{other}})
+	require.ErrorIs(t, err, NoWithdrawalsErr)
+}
+
+func TestProveWithdrawals(t *testing.T) {
+	w := testWithdrawal(3)
+	h, err := w.Hash()
+	require.NoError(t, err)
+	slot := WithdrawalStorageSlot(h)
+
+	storage, err := trie.NewSecure(common.Hash{}, trie.NewDatabase(memorydb.New()))
+	require.NoError(t, err)
+	storage.Update(common.Hash{0x01}.Bytes(), []byte{0x01})
+	storage.Update(slot[:], []byte{0x01})
+
+	block := types.NewBlockWithHeader(&types.Header{Number: big.NewInt(10), Root: common.Hash{0xaa}, Time: 1234})
+	src := &fakeWithdrawalSource{
+		block:   block,
+		receipt: &types.Receipt{Status: types.ReceiptStatusSuccessful, Logs: []*types.Log{withdrawalLog(t, w)}, BlockNumber: big.NewInt(10)},
+		storage: storage,
+	}
+
+	proofs, err := ProveWithdrawals(context.Background(), &Config{}, src, src, src, common.Hash{0x70}, 0)
+	require.NoError(t, err)
+	require.Len(t, proofs, 1)
+	p := proofs[0]
+	require.Equal(t, w, p.Withdrawal)
+	require.Equal(t, h, p.WithdrawalHash)
+	require.Equal(t, slot, p.StorageSlot)
+	require.Equal(t, hexutil.Uint64(1234), p.L2Timestamp)
+	require.Equal(t, storage.Hash(), p.Output.WithdrawalRoot)
+	require.Equal(t, ComputeOutputRoot(block.Root(), storage.Hash(), block.Hash()), p.Output.OutputRoot)
+	var nodes [][]byte
+	require.NoError(t, rlp.DecodeBytes(p.StorageProof, &nodes))
+	require.NoError(t, verifyWithdrawalProof(p.Output.WithdrawalRoot, slot, nodes))
+
+	_, err = ProveWithdrawals(context.Background(), &Config{}, src, src, src, common.Hash{0x70}, 9)
+	require.Error(t, err, "cannot prove against an output before the withdrawal")
+
+	unset := testWithdrawal(4)
+	src.receipt.Logs = []*types.Log{withdrawalLog(t, unset)}
+	_, err = ProveWithdrawals(context.Background(), &Config{}, src, src, src, common.Hash{0x70}, 0)
+	require.Error(t, err, "withdrawals that are not in the storage cannot be proven")
+}
@@ -0,0 +1,125 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/rpc"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/version"
+)
+
+// SyncStatus is the sync status of the node, as reported by optimism_syncStatus
+type SyncStatus struct {
+	// HeadL1 is the latest L1 head seen by the node
+	HeadL1 eth.BlockID `json:"headL1"`
+	// CurrentL1 is the L1 origin of the unsafe L2 head, the L1 block that derivation has progressed to
+	CurrentL1   eth.BlockID `json:"currentL1"`
+	UnsafeL2    eth.BlockID `json:"unsafeL2"`
+	SafeL2      eth.BlockID `json:"safeL2"`
+	FinalizedL2 eth.BlockID `json:"finalizedL2"`
+}
+
+// optimismAPI serves the optimism_ JSON-RPC namespace, to query the node state with
+type optimismAPI struct {
+	l1Head func() eth.BlockID
+	engine *l2.EngineDriver
+	// proofs of the L2 state of the engine, to compute output roots with
+	proofs l2.ProofSource
+	// receipts of the L2 transactions of the engine, to find the withdrawals of a transaction with
+	receipts eth.ReceiptSource
+}
+
+// SyncStatus returns the L1 head, the current L1 origin, and the unsafe, safe and finalized L2 heads.
+func (api *optimismAPI) SyncStatus(ctx context.Context) (*SyncStatus, error) {
+	heads := api.engine.L2Heads()
+	return &SyncStatus{
+		HeadL1:      api.l1Head(),
+		CurrentL1:   api.engine.L1Head(),
+		UnsafeL2:    heads.Unsafe,
+		SafeL2:      heads.Safe,
+		FinalizedL2: heads.Finalized,
+	}, nil
+}
+
+// OutputAtBlock returns the output root of the L2 block with the given number, with its components.
+func (api *optimismAPI) OutputAtBlock(ctx context.Context, number hexutil.Uint64) (*l2.Output, error) {
+	return l2.OutputAtBlock(ctx, &api.engine.Config, api.engine.RPC, api.proofs, uint64(number))
+}
+
+// WithdrawalProof returns the proofs of the withdrawals initiated by the L2 transaction, ready to finalize on L1:
+// proven against the output of the L2 block with the given number, or of the block of the transaction if omitted.
+func (api *optimismAPI) WithdrawalProof(ctx context.Context, txHash common.Hash, number *hexutil.Uint64) ([]*l2.WithdrawalProof, error) {
+	var n uint64
+	if number != nil {
+		n = uint64(*number)
+	}
+	return l2.ProveWithdrawals(ctx, &api.engine.Config, api.engine.RPC, api.receipts, api.proofs, txHash, n)
+}
+
+// Version returns the build info of the node.
+func (api *optimismAPI) Version(ctx context.Context) (version.Info, error) {
+	return version.Get(), nil
+}
+
+// adminAPI serves the admin_ JSON-RPC namespace, to control the node with
+type adminAPI struct {
+	engine *l2.EngineDriver
+}
+
+// StartSequencer starts sequencing on top of the unsafe L2 head, which must be the handover point of the previous sequencer.
+func (api *adminAPI) StartSequencer(ctx context.Context, unsafeHead common.Hash) error {
+	return api.engine.StartSequencer(ctx, unsafeHead)
+}
+
+// StopSequencer stops sequencing, and returns the unsafe L2 head to hand over sequencing at.
+func (api *adminAPI) StopSequencer(ctx context.Context) (common.Hash, error) {
+	return api.engine.StopSequencer(ctx)
+}
+
+// rpcServer serves the JSON-RPC API of the node over HTTP
+type rpcServer struct {
+	rpc  *rpc.Server
+	http *http.Server
+}
+
+// startRPCServer starts serving the optimism_ and admin_ namespaces on the address
+func startRPCServer(addr string, api *optimismAPI, admin *adminAPI, log log.Logger) (*rpcServer, error) {
+	srv := rpc.NewServer()
+	if err := srv.RegisterName("optimism", api); err != nil {
+		return nil, fmt.Errorf("failed to register optimism API: %v", err)
+	}
+	if err := srv.RegisterName("admin", admin); err != nil {
+		return nil, fmt.Errorf("failed to register admin API: %v", err)
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on RPC address %q: %v", addr, err)
+	}
+	httpSrv := &http.Server{Handler: srv}
+	go func() {
+		if err := httpSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("RPC server failed", "err", err)
+		}
+	}()
+	log.Info("Serving RPC", "addr", listener.Addr())
+	return &rpcServer{rpc: srv, http: httpSrv}, nil
+}
+
+// Stop stops accepting new requests, and waits for the ongoing requests to complete, or closes them once the ctx is done.
+func (s *rpcServer) Stop(ctx context.Context) error {
+	defer s.rpc.Stop()
+	if err := s.http.Shutdown(ctx); err != nil {
+		_ = s.http.Close()
+		return err
+	}
+	return nil
+}
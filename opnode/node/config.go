@@ -0,0 +1,209 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/protolambda/ask"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// chainCheckTimeout bounds the time to check the chain of a single endpoint with
+const chainCheckTimeout = 10 * time.Second
+
+// LoadConfig resolves the effective configuration of the node: it applies the rollup config file, if any,
+// and the defaults that depend on other flags, and rejects inconsistent flags.
+// It does not connect to any endpoint, see CheckChains.
+func (c *OpNodeCmd) LoadConfig() error {
+	if c.RollupConfig != "" {
+		rc, err := LoadRollupConfig(c.RollupConfig)
+		if err != nil {
+			return err
+		}
+		rc.Apply(&c.Genesis, &c.Rollup)
+	}
+	if c.Genesis == (GenesisConf{}) {
+		return errors.New("genesis configuration required")
+	}
+	if c.Sequencer && len(c.L2EngineAddrs) != 1 && !c.L2Reconcile {
+		return fmt.Errorf("sequencer mode requires a single L2 engine, or reconciled L2 engines, got %d", len(c.L2EngineAddrs))
+	}
+	if c.Sequencer && c.Rollup.BlockTime == 0 {
+		return errors.New("sequencer block time must be at least 1 second")
+	}
+	if c.Sequencer && c.SequencerBuildTime >= time.Duration(c.Rollup.BlockTime)*time.Second {
+		return fmt.Errorf("sequencer build time %s must be shorter than the block time of %d seconds", c.SequencerBuildTime, c.Rollup.BlockTime)
+	}
+	// the intervals are used to create tickers, which panic on non-positive intervals
+	for _, interval := range []struct {
+		flag string
+		d    time.Duration
+	}{
+		{"--l1-poll-interval", c.L1PollInterval},
+		{"--l1-health-check-interval", c.L1HealthCheckInterval},
+		{"--batcher-poll-interval", c.BatcherPollInterval},
+		{"--proposer-poll-interval", c.ProposerPollInterval},
+		{"--challenger-poll-interval", c.ChallengerPollInterval},
+	} {
+		if interval.d <= 0 {
+			return fmt.Errorf("%s must be positive, got %s", interval.flag, interval.d)
+		}
+	}
+	// without them, complete channels queue up behind incomplete ones, and timed out channels are never pruned
+	if c.Rollup.ChannelTimeout == 0 {
+		return errors.New("channel timeout must be at least 1 L1 block, see --rollup.channel-timeout")
+	}
+	if c.Rollup.MaxChannelBankSize == 0 {
+		return errors.New("max channel bank size must be positive, see --rollup.max-channel-bank-size")
+	}
+	if c.ShutdownTimeout <= 0 {
+		return errors.New("shutdown timeout must be positive")
+	}
+	if (c.BatcherKey != "" || c.ProposerKey != "" || c.ChallengerKey != "") && c.TxMgrFeeBumpPercent < 10 {
+		return fmt.Errorf("fee bump of %d%% is too low to replace L1 transactions, must be at least 10%%", c.TxMgrFeeBumpPercent)
+	}
+	if c.ProposerKey != "" && c.Rollup.L2OutputOracleAddr == (common.Address{}) {
+		return errors.New("L2 output oracle address required to propose outputs, see --rollup.l2-output-oracle")
+	}
+	if c.Challenger && c.Rollup.L2OutputOracleAddr == (common.Address{}) {
+		return errors.New("L2 output oracle address required to check proposed outputs, see --rollup.l2-output-oracle")
+	}
+	if c.ChallengerKey != "" && !c.Challenger {
+		return errors.New("challenger key requires --challenger to be enabled")
+	}
+	if c.ProposerKey != "" && c.ProposerSubmissionInterval == 0 {
+		return errors.New("proposer submission interval must be at least 1 L2 block")
+	}
+	if c.P2P.Enabled && !c.Sequencer && c.Rollup.P2PSequencerAddr == (common.Address{}) {
+		return errors.New("p2p sequencer address required to import gossiped unsafe L2 blocks, see --rollup.p2p-sequencer")
+	}
+	if c.P2P.SequencerKey != "" && !(c.Sequencer && c.P2P.Enabled) {
+		return errors.New("p2p sequencer key requires --sequencer and --p2p.enabled")
+	}
+	if c.P2P.Enabled && c.P2P.DialInterval <= 0 {
+		return fmt.Errorf("--p2p.dial-interval must be positive, got %s", c.P2P.DialInterval)
+	}
+	if c.Heartbeat.Enabled && c.Heartbeat.URL == "" {
+		return errors.New("heartbeat URL required to post heartbeats")
+	}
+	if c.Heartbeat.Enabled && c.Heartbeat.Interval <= 0 {
+		return errors.New("heartbeat interval must be positive")
+	}
+
+	if c.DataDir != "" && c.StateFile == "" {
+		c.StateFile = filepath.Join(c.DataDir, HeadStateFileName)
+	}
+	return nil
+}
+
+// CheckChains connects to all L1 endpoints and L2 engines, and checks that they serve the configured chains:
+// the chain ID, if configured, and the L1 and L2 genesis blocks.
+// Unlike the checks on startup, an endpoint that cannot be reached fails the check.
+func (c *OpNodeCmd) CheckChains(ctx context.Context) error {
+	genesis := c.Genesis.GetGenesis()
+	for i, addr := range c.L1NodeAddrs {
+		name := fmt.Sprintf("L1 endpoint %d (%s)", i, addr)
+		if err := checkChain(ctx, name, func(ctx context.Context) (*rpc.Client, error) {
+			return rpc.DialContext(ctx, addr)
+		}, c.Rollup.L1ChainID, genesis.L1.Number, genesis.L1.Hash); err != nil {
+			return err
+		}
+	}
+	var jwtSecret *l2.JWTSecret
+	if c.L2JWTSecret != "" {
+		secret, err := l2.LoadJWTSecret(c.L2JWTSecret)
+		if err != nil {
+			return err
+		}
+		jwtSecret = &secret
+	}
+	for i, addr := range c.L2EngineAddrs {
+		name := fmt.Sprintf("L2 engine %d (%s)", i, addr)
+		if err := checkChain(ctx, name, func(ctx context.Context) (*rpc.Client, error) {
+			return l2.DialEngine(ctx, addr, jwtSecret)
+		}, c.Rollup.L2ChainID, genesis.L2.Number, genesis.L2.Hash); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkChain checks the chain ID, unless 0, and the genesis block hash of the chain of an endpoint.
+func checkChain(ctx context.Context, name string, dial func(ctx context.Context) (*rpc.Client, error),
+	chainID uint64, genesisNum uint64, genesisHash common.Hash) error {
+	ctx, cancel := context.WithTimeout(ctx, chainCheckTimeout)
+	defer cancel()
+	client, err := dial(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to dial %s: %v", name, err)
+	}
+	defer client.Close()
+	cl := ethclient.NewClient(client)
+	if chainID != 0 {
+		id, err := cl.ChainID(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to fetch chain ID of %s: %v", name, err)
+		}
+		if !id.IsUint64() || id.Uint64() != chainID {
+			return fmt.Errorf("%s has chain ID %s, but the rollup is configured with chain ID %d", name, id, chainID)
+		}
+	}
+	header, err := cl.HeaderByNumber(ctx, new(big.Int).SetUint64(genesisNum))
+	if err != nil {
+		return fmt.Errorf("failed to fetch genesis block %d of %s: %v", genesisNum, name, err)
+	}
+	if h := header.Hash(); h != genesisHash {
+		return fmt.Errorf("%s has block %s at genesis block number %d, but the rollup is configured with genesis block %s", name, h, genesisNum, genesisHash)
+	}
+	return nil
+}
+
+// EffectiveConfig returns the value of every flag of the node, by flag name, formatted as it would be set on the command line.
+// Call LoadConfig first to include the values of the rollup config file.
+func (c *OpNodeCmd) EffectiveConfig() (map[string]string, error) {
+	out := make(map[string]string)
+	if err := collectFlags("", reflect.ValueOf(c).Elem(), out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// collectFlags collects the values of the flags of a struct with ask tags, recursing into the flag groups.
+func collectFlags(prefix string, val reflect.Value, out map[string]string) error {
+	typ := val.Type()
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag, ok := f.Tag.Lookup("ask")
+		if !ok || tag == "-" {
+			continue
+		}
+		v := val.Field(i)
+		switch {
+		case tag == ".":
+			if err := collectFlags(prefix, v, out); err != nil {
+				return err
+			}
+		case strings.HasPrefix(tag, "."):
+			if err := collectFlags(prefix+tag[1:]+".", v, out); err != nil {
+				return err
+			}
+		case strings.HasPrefix(tag, "--"):
+			value, err := ask.FlagValue(f.Type, v)
+			if err != nil {
+				return fmt.Errorf("failed to format flag %s%s: %v", prefix, tag[2:], err)
+			}
+			out[prefix+tag[2:]] = value.String()
+		}
+	}
+	return nil
+}
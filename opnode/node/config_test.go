@@ -0,0 +1,134 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+func TestOpNodeCmd_LoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rollup.json")
+	data, err := json.Marshal(testRollupConfig())
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, data, 0600))
+
+	var c OpNodeCmd
+	c.Default()
+	require.Error(t, c.LoadConfig(), "genesis configuration required")
+
+	c.RollupConfig = path
+	c.DataDir = dir
+	require.NoError(t, c.LoadConfig())
+	require.Equal(t, common.Hash{0xa1}, c.Genesis.L1Hash)
+	require.Equal(t, uint64(901), c.Rollup.L2ChainID)
+	require.Equal(t, filepath.Join(dir, HeadStateFileName), c.StateFile)
+
+	c.Sequencer = true
+	c.SequencerBuildTime = 2 * time.Second
+	require.Error(t, c.LoadConfig(), "sequencer build time must be shorter than the block time")
+	c.Sequencer = false
+
+	c.ProposerKey = "proposer.key"
+	require.Error(t, c.LoadConfig(), "L2 output oracle address required")
+	c.Rollup.L2OutputOracleAddr = common.Address{0x0a}
+	require.NoError(t, c.LoadConfig())
+	c.ProposerKey = ""
+
+	c.ChallengerKey = "challenger.key"
+	require.Error(t, c.LoadConfig(), "challenger key requires the challenger")
+	c.Challenger = true
+	require.NoError(t, c.LoadConfig())
+	c.Challenger, c.ChallengerKey = false, ""
+
+	c.Rollup.ChannelTimeout = 0
+	require.Error(t, c.LoadConfig(), "channel timeout must be positive")
+	c.Rollup.ChannelTimeout = l2.DefaultChannelTimeout
+	require.NoError(t, c.LoadConfig())
+
+	c.L1PollInterval = 0
+	require.Error(t, c.LoadConfig(), "poll interval must be positive")
+	c.L1PollInterval = time.Second
+	c.ChallengerPollInterval = -time.Second
+	require.Error(t, c.LoadConfig(), "poll interval must be positive")
+	c.ChallengerPollInterval = time.Second
+	require.NoError(t, c.LoadConfig())
+
+	c.P2P.Default()
+	c.P2P.Enabled = true
+	require.Error(t, c.LoadConfig(), "p2p sequencer address required to import gossiped blocks")
+	c.Rollup.P2PSequencerAddr = common.Address{0x5e}
+	require.NoError(t, c.LoadConfig())
+	c.P2P.SequencerKey = "sequencer.key"
+	require.Error(t, c.LoadConfig(), "p2p sequencer key requires the sequencer")
+	c.P2P.Enabled, c.P2P.SequencerKey = false, ""
+
+	c.Heartbeat.Enabled = true
+	require.Error(t, c.LoadConfig(), "heartbeat URL required")
+}
+
+func TestOpNodeCmd_EffectiveConfig(t *testing.T) {
+	var c OpNodeCmd
+	c.Default()
+	c.Metrics.Default()
+	c.LogCmd.LogLvl = "debug"
+	c.L1NodeAddrs = []string{"http://a", "http://b"}
+	c.Rollup.BatcherAddr = common.Address{0xba}
+	c.ShutdownTimeout = time.Minute
+
+	flags, err := c.EffectiveConfig()
+	require.NoError(t, err)
+	require.Equal(t, "http://a,http://b", flags["l1"])
+	require.Equal(t, "ba00000000000000000000000000000000000000", flags["rollup.batcher"])
+	require.Equal(t, "1m0s", flags["shutdown-timeout"])
+	require.Equal(t, "7300", flags["metrics.port"])
+	require.Equal(t, "debug", flags["log.level"])
+	require.NotContains(t, flags, "log")
+}
+
+// testChain serves the chain ID and the genesis block of a chain, in the eth namespace
+type testChain struct {
+	chainID uint64
+	genesis *types.Header
+}
+
+func (tc *testChain) ChainId() hexutil.Big {
+	return hexutil.Big(*new(big.Int).SetUint64(tc.chainID))
+}
+
+func (tc *testChain) GetBlockByNumber(num hexutil.Uint64, full bool) *types.Header {
+	if uint64(num) != tc.genesis.Number.Uint64() {
+		return nil
+	}
+	return tc.genesis
+}
+
+func TestCheckChain(t *testing.T) {
+	chain := &testChain{chainID: 900, genesis: &types.Header{Number: big.NewInt(100), Difficulty: big.NewInt(1)}}
+	srv := rpc.NewServer()
+	require.NoError(t, srv.RegisterName("eth", chain))
+	defer srv.Stop()
+	dial := func(ctx context.Context) (*rpc.Client, error) {
+		return rpc.DialInProc(srv), nil
+	}
+	ctx := context.Background()
+
+	genesis := chain.genesis.Hash()
+	require.NoError(t, checkChain(ctx, "L1", dial, 900, 100, genesis))
+	require.NoError(t, checkChain(ctx, "L1", dial, 0, 100, genesis), "unconfigured chain IDs are not checked")
+	require.Error(t, checkChain(ctx, "L1", dial, 901, 100, genesis), "chain ID mismatch")
+	require.Error(t, checkChain(ctx, "L1", dial, 900, 100, common.Hash{0x01}), "genesis mismatch")
+	require.Error(t, checkChain(ctx, "L1", dial, 900, 101, genesis), "missing genesis block")
+}
@@ -0,0 +1,100 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+// DefaultHealthPort is the default port to serve the health endpoints on
+const DefaultHealthPort = 7301
+
+// healthProbeTimeout bounds the time to probe an engine connection, to answer health checks in time
+const healthProbeTimeout = time.Second * 2
+
+type HealthConf struct {
+	Enabled  bool   `ask:"--enabled" help:"Serve the /healthz (L1 and engine connections are live) and /readyz (and derivation is within the max L1 lag) endpoints, for orchestrators and load balancers"`
+	Addr     string `ask:"--addr" help:"Address to serve the health endpoints on"`
+	Port     int    `ask:"--port" help:"Port to serve the health endpoints on"`
+	MaxL1Lag uint64 `ask:"--max-l1-lag" help:"Number of L1 blocks that the L1 origin of the L2 head may lag behind the L1 head, for the node to be ready. 0 to not check the lag."`
+}
+
+func (c *HealthConf) Default() {
+	c.Addr = "0.0.0.0"
+	c.Port = DefaultHealthPort
+}
+
+// Endpoint returns the host:port address to serve the health endpoints on
+func (c *HealthConf) Endpoint() string {
+	return net.JoinHostPort(c.Addr, strconv.Itoa(c.Port))
+}
+
+// HealthStatus is the health of the node, as reported by the health endpoints
+type HealthStatus struct {
+	// L1 is true if at least one L1 endpoint is healthy
+	L1 bool `json:"l1"`
+	// Engines is true for every L2 engine that responds
+	Engines []bool `json:"engines"`
+	// L1Lag is the number of L1 blocks that the L1 origin of the L2 head lags behind the L1 head
+	L1Lag uint64 `json:"l1Lag"`
+	// Live is true if the L1 and all engine connections are live
+	Live bool `json:"live"`
+	// Ready is true if the node is live, and the L1 lag is within the max L1 lag
+	Ready bool `json:"ready"`
+}
+
+// healthCheck checks the health of the node components
+type healthCheck struct {
+	// l1Healthy returns true if at least one L1 endpoint is healthy
+	l1Healthy func() bool
+	// engines probe the connection of each L2 engine
+	engines []func(ctx context.Context) error
+	// l1Head returns the latest L1 head, and l1Origin the L1 block that derivation progressed to
+	l1Head   func() eth.BlockID
+	l1Origin func() eth.BlockID
+	// maxL1Lag is the max L1 lag to be ready with, 0 to not check the lag
+	maxL1Lag uint64
+}
+
+// Status checks the L1 and engine connections, and the L1 lag.
+func (h *healthCheck) Status(ctx context.Context) HealthStatus {
+	status := HealthStatus{L1: h.l1Healthy(), Engines: make([]bool, len(h.engines))}
+	status.Live = status.L1
+	for i, probe := range h.engines {
+		probeCtx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+		status.Engines[i] = probe(probeCtx) == nil
+		cancel()
+		status.Live = status.Live && status.Engines[i]
+	}
+	if head, origin := h.l1Head().Number, h.l1Origin().Number; head > origin {
+		status.L1Lag = head - origin
+	}
+	status.Ready = status.Live && (h.maxL1Lag == 0 || status.L1Lag <= h.maxL1Lag)
+	return status
+}
+
+// Handler serves the health status at /healthz and /readyz:
+// with status 200 if the node is live or ready respectively, and 503 otherwise.
+func (h *healthCheck) Handler() http.Handler {
+	serve := func(ok func(status HealthStatus) bool) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			status := h.Status(r.Context())
+			w.Header().Set("Content-Type", "application/json")
+			if ok(status) {
+				w.WriteHeader(http.StatusOK)
+			} else {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+			_ = json.NewEncoder(w).Encode(status)
+		}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", serve(func(status HealthStatus) bool { return status.Live }))
+	mux.Handle("/readyz", serve(func(status HealthStatus) bool { return status.Ready }))
+	return mux
+}
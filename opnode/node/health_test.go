@@ -0,0 +1,58 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+)
+
+func TestHealthCheck(t *testing.T) {
+	l1Healthy, engineErr := true, error(nil)
+	l1Head, l1Origin := uint64(100), uint64(95)
+	h := &healthCheck{
+		l1Healthy: func() bool { return l1Healthy },
+		engines: []func(ctx context.Context) error{
+			func(ctx context.Context) error { return nil },
+			func(ctx context.Context) error { return engineErr },
+		},
+		l1Head:   func() eth.BlockID { return eth.BlockID{Number: l1Head} },
+		l1Origin: func() eth.BlockID { return eth.BlockID{Number: l1Origin} },
+		maxL1Lag: 10,
+	}
+	handler := h.Handler()
+	check := func(path string, expectedCode int) HealthStatus {
+		rec := get(t, handler, path)
+		require.Equal(t, expectedCode, rec.Code, path)
+		var status HealthStatus
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &status))
+		return status
+	}
+
+	status := check("/healthz", http.StatusOK)
+	require.Equal(t, HealthStatus{L1: true, Engines: []bool{true, true}, L1Lag: 5, Live: true, Ready: true}, status)
+	check("/readyz", http.StatusOK)
+
+	// derivation lags too far behind L1: live, but not ready
+	l1Head = 200
+	check("/healthz", http.StatusOK)
+	require.Equal(t, uint64(105), check("/readyz", http.StatusServiceUnavailable).L1Lag)
+	h.maxL1Lag = 0
+	check("/readyz", http.StatusOK)
+
+	// an engine is down
+	engineErr = errors.New("connection refused")
+	require.Equal(t, []bool{true, false}, check("/healthz", http.StatusServiceUnavailable).Engines)
+	check("/readyz", http.StatusServiceUnavailable)
+
+	// all L1 endpoints are down
+	engineErr = nil
+	l1Healthy = false
+	require.False(t, check("/healthz", http.StatusServiceUnavailable).L1)
+	check("/readyz", http.StatusServiceUnavailable)
+}
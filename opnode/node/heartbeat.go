@@ -0,0 +1,98 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultHeartbeatInterval is the default interval to post heartbeats at
+const DefaultHeartbeatInterval = time.Minute
+
+type HeartbeatConf struct {
+	Enabled  bool          `ask:"--enabled" help:"Periodically post the node version, chain ID and head heights to the heartbeat URL, to let network operators gauge verifier participation. Opt-in: nothing is reported by default."`
+	URL      string        `ask:"--url" help:"URL to post the heartbeats to"`
+	Interval time.Duration `ask:"--interval" help:"Interval to post the heartbeats at"`
+	Moniker  string        `ask:"--moniker" help:"Optional name of the node, included in the heartbeats to identify it with"`
+}
+
+func (c *HeartbeatConf) Default() {
+	c.Interval = DefaultHeartbeatInterval
+}
+
+// Heartbeat is the status of the node, as posted to the heartbeat URL
+type Heartbeat struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit,omitempty"`
+	Moniker   string `json:"moniker,omitempty"`
+	// L2ChainID is the chain ID of the rollup, 0 if not configured
+	L2ChainID   uint64 `json:"l2ChainId"`
+	L1Head      uint64 `json:"l1Head"`
+	UnsafeL2    uint64 `json:"unsafeL2"`
+	SafeL2      uint64 `json:"safeL2"`
+	FinalizedL2 uint64 `json:"finalizedL2"`
+}
+
+// heartbeater posts a heartbeat with the status of the node every interval
+type heartbeater struct {
+	log      log.Logger
+	url      string
+	interval time.Duration
+	client   *http.Client
+	// status returns the current status of the node, to post
+	status func() Heartbeat
+}
+
+// post sends a single heartbeat, and returns an error if it is not accepted.
+func (h *heartbeater) post(ctx context.Context) error {
+	data, err := json.Marshal(h.status())
+	if err != nil {
+		return fmt.Errorf("failed to encode heartbeat: %v", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create heartbeat request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post heartbeat: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("heartbeat rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Start posts a heartbeat right away, and then every interval, until the subscription is closed.
+// Failed heartbeats are logged and skipped.
+func (h *heartbeater) Start(ctx context.Context) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(h.interval)
+		defer ticker.Stop()
+		for {
+			postCtx, cancel := context.WithTimeout(ctx, h.interval)
+			if err := h.post(postCtx); err != nil {
+				h.log.Warn("failed to post heartbeat", "url", h.url, "err", err)
+			} else {
+				h.log.Debug("posted heartbeat", "url", h.url)
+			}
+			cancel()
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,58 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeartbeater(t *testing.T) {
+	received := make(chan Heartbeat, 10)
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, http.MethodPost, r.Method)
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var hb Heartbeat
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&hb))
+		code := status
+		received <- hb
+		w.WriteHeader(code)
+	}))
+	defer srv.Close()
+
+	expected := Heartbeat{Version: "v1.2.3", Moniker: "test", L2ChainID: 901, L1Head: 100, UnsafeL2: 30, SafeL2: 20, FinalizedL2: 10}
+	h := &heartbeater{
+		log:      log.New(),
+		url:      srv.URL,
+		interval: time.Millisecond * 10,
+		client:   srv.Client(),
+		status:   func() Heartbeat { return expected },
+	}
+
+	require.NoError(t, h.post(context.Background()))
+	require.Equal(t, expected, <-received)
+
+	status = http.StatusInternalServerError
+	require.Error(t, h.post(context.Background()), "rejected heartbeats must fail")
+	<-received
+	status = http.StatusOK
+
+	// heartbeats are posted right away, and then every interval, until unsubscribed
+	sub := h.Start(context.Background())
+	for i := 0; i < 3; i++ {
+		select {
+		case hb := <-received:
+			require.Equal(t, expected, hb)
+		case <-time.After(time.Second):
+			t.Fatal("expected heartbeat")
+		}
+	}
+	sub.Unsubscribe()
+	require.NoError(t, <-sub.Err())
+}
@@ -0,0 +1,41 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// httpServer serves a handler over HTTP, e.g. the metrics or the pprof endpoints
+type httpServer struct {
+	http *http.Server
+}
+
+// startHTTPServer starts serving the handler on the address. The name describes the server in logs and errors.
+func startHTTPServer(name string, addr string, handler http.Handler, log log.Logger) (*httpServer, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s address %q: %v", name, addr, err)
+	}
+	httpSrv := &http.Server{Handler: handler}
+	go func() {
+		if err := httpSrv.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error("HTTP server failed", "name", name, "err", err)
+		}
+	}()
+	log.Info("Serving HTTP", "name", name, "addr", listener.Addr())
+	return &httpServer{http: httpSrv}, nil
+}
+
+// Stop stops accepting new requests, and waits for the ongoing requests to complete, or closes them once the ctx is done.
+func (s *httpServer) Stop(ctx context.Context) error {
+	if err := s.http.Shutdown(ctx); err != nil {
+		_ = s.http.Close()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package node
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/stretchr/testify/require"
+)
+
+func get(t *testing.T, handler http.Handler, path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func TestMetricsHandler(t *testing.T) {
+	enabled := metrics.Enabled
+	metrics.Enabled = true
+	defer func() { metrics.Enabled = enabled }()
+
+	r := metrics.NewRegistry()
+	metrics.NewRegisteredCounter("opnode/test/count", r).Inc(3)
+	handler := metricsHandler(r)
+
+	rec := get(t, handler, "/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "opnode_test_count 3")
+
+	rec = get(t, handler, "/debug/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), `"opnode/test/count": 3`)
+}
+
+func TestPprofHandler(t *testing.T) {
+	handler := pprofHandler()
+	rec := get(t, handler, "/debug/pprof/")
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "heap")
+	require.Equal(t, http.StatusOK, get(t, handler, "/debug/pprof/heap").Code)
+	require.Equal(t, http.StatusNotFound, get(t, handler, "/").Code, "only the pprof endpoints are served")
+}
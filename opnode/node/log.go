@@ -2,6 +2,7 @@ package node
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
@@ -45,7 +46,7 @@ func (lf LogFormat) String() string {
 
 func (lf *LogFormat) Set(v string) error {
 	switch v {
-	case "json", "json-pretty", "terminal", "text":
+	case "json", "json-pretty", "terminal", "text", "logfmt":
 		*lf = LogFormat(v)
 		return nil
 	default:
@@ -65,6 +66,8 @@ func (lf LogFormat) Format(color bool) log.Format {
 		return log.JSONFormatEx(true, true)
 	case "text", "terminal":
 		return log.TerminalFormat(color)
+	case "logfmt":
+		return log.LogfmtFormat()
 	default:
 		panic("lf.Set failed")
 	}
@@ -73,7 +76,7 @@ func (lf LogFormat) Format(color bool) log.Format {
 type LogCmd struct {
 	LogLvl LogLvl    `ask:"--level" help:"Log level: trace, debug, info, warn, error, crit. Capitals are accepted too."`
 	Color  bool      `ask:"--color" help:"Color the log output. Defaults to true if terminal is detected."`
-	Format LogFormat `ask:"--format" help:"Format the log output. Supported formats: 'text', 'json'"`
+	Format LogFormat `ask:"--format" help:"Format the log output. Supported formats: 'text' (or 'terminal'), 'logfmt', 'json', 'json-pretty'"`
 }
 
 func (c *LogCmd) Default() {
@@ -82,10 +85,18 @@ func (c *LogCmd) Default() {
 	c.Format = "text"
 }
 
-func (c *LogCmd) Create() log.Logger {
-	handler := log.StreamHandler(os.Stdout, c.Format.Format(c.Color))
+// Handler returns a log handler that writes the records of the configured level and above to w, in the configured format.
+func (c *LogCmd) Handler(w io.Writer) log.Handler {
+	handler := log.StreamHandler(w, c.Format.Format(c.Color))
 	handler = log.SyncHandler(handler)
-	log.LvlFilterHandler(c.LogLvl.Lvl(), handler)
+	return log.LvlFilterHandler(c.LogLvl.Lvl(), handler)
+}
+
+// Create returns the root logger of the node, writing to stdout, to derive the loggers of all components from.
+// The go-ethereum root logger, used by libraries such as the RPC client, is configured with the same handler.
+func (c *LogCmd) Create() log.Logger {
+	handler := c.Handler(os.Stdout)
+	log.Root().SetHandler(handler)
 	logger := log.New()
 	logger.SetHandler(handler)
 	return logger
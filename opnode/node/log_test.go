@@ -0,0 +1,51 @@
+package node
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLogCmd_Handler(t *testing.T) {
+	var lvl LogLvl
+	require.NoError(t, lvl.Set("WARN"))
+	var format LogFormat
+	require.Error(t, format.Set("xml"))
+
+	cases := map[string]func(t *testing.T, line string){
+		"text": func(t *testing.T, line string) {
+			require.Contains(t, line, "WARN")
+			require.Contains(t, line, "component=engine")
+		},
+		"logfmt": func(t *testing.T, line string) {
+			require.Contains(t, line, "lvl=warn")
+			require.Contains(t, line, "msg=shown")
+			require.Contains(t, line, "component=engine")
+		},
+		"json": func(t *testing.T, line string) {
+			var record map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(line), &record))
+			require.Equal(t, "warn", record["lvl"])
+			require.Equal(t, "shown", record["msg"])
+			require.Equal(t, "engine", record["component"])
+		},
+	}
+	for name, check := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, format.Set(name))
+			cmd := &LogCmd{LogLvl: lvl, Format: format}
+			var buf bytes.Buffer
+			logger := log.New("component", "engine")
+			logger.SetHandler(cmd.Handler(&buf))
+			logger.Info("hidden")
+			logger.Warn("shown")
+			lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+			require.Len(t, lines, 1, "records below the level are filtered")
+			check(t, lines[0])
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package node
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+
+	"github.com/ethereum/go-ethereum/metrics"
+	"github.com/ethereum/go-ethereum/metrics/exp"
+	"github.com/ethereum/go-ethereum/metrics/prometheus"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// DefaultMetricsPort is the default port to serve the metrics on
+const DefaultMetricsPort = 7300
+
+type MetricsConf struct {
+	Enabled bool   `ask:"--enabled" help:"Serve the node metrics in the Prometheus format at /metrics, and as JSON at /debug/metrics"`
+	Addr    string `ask:"--addr" help:"Address to serve the metrics on"`
+	Port    int    `ask:"--port" help:"Port to serve the metrics on"`
+}
+
+func (c *MetricsConf) Default() {
+	c.Addr = "0.0.0.0"
+	c.Port = DefaultMetricsPort
+}
+
+// Endpoint returns the host:port address to serve the metrics on
+func (c *MetricsConf) Endpoint() string {
+	return net.JoinHostPort(c.Addr, strconv.Itoa(c.Port))
+}
+
+// metricsHandler serves the metrics of the registry:
+// in the Prometheus text format at /metrics, and as JSON at /debug/metrics.
+func metricsHandler(r metrics.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", prometheus.Handler(r))
+	mux.Handle("/debug/metrics", exp.ExpHandler(r))
+	return mux
+}
+
+// registerEngineMetrics registers gauges of the L2 heads of the engine, read when the metrics are collected:
+// the unsafe, safe and finalized L2 block numbers, and the number of the L1 block that derivation progressed to.
+func registerEngineMetrics(r metrics.Registry, prefix string, engine *l2.EngineDriver) {
+	metrics.NewRegisteredFunctionalGauge(prefix+"/head/unsafe", r, func() int64 {
+		return int64(engine.L2Heads().Unsafe.Number)
+	})
+	metrics.NewRegisteredFunctionalGauge(prefix+"/head/safe", r, func() int64 {
+		return int64(engine.L2Heads().Safe.Number)
+	})
+	metrics.NewRegisteredFunctionalGauge(prefix+"/head/finalized", r, func() int64 {
+		return int64(engine.L2Heads().Finalized.Number)
+	})
+	metrics.NewRegisteredFunctionalGauge(prefix+"/l1_origin", r, func() int64 {
+		return int64(engine.L1Head().Number)
+	})
+}
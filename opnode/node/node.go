@@ -2,25 +2,48 @@ package node
 
 import (
 	"context"
+	"crypto/ecdsa"
 	"errors"
 	"fmt"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
 
+	"github.com/ethereum-optimism/optimistic-specs/opnode/batcher"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/challenger"
 	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/events"
 
-	"github.com/ethereum-optimism/optimistic-specs/opnode/l1"
 	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/p2p"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/proposer"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/txmgr"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/version"
 	"github.com/ethereum/go-ethereum"
 	"github.com/ethereum/go-ethereum/event"
 	"github.com/ethereum/go-ethereum/log"
+	"github.com/ethereum/go-ethereum/metrics"
 
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
 
 	"github.com/ethereum/go-ethereum/rpc"
 )
 
+// l1RequestTimeout bounds the time of a single L1 request to fetch a block or receipts with
+const l1RequestTimeout = 10 * time.Second
+
+// l1BlockCacheSize is the number of L1 blocks to cache with their receipts,
+// to not refetch them when the derivation pipeline is reset
+const l1BlockCacheSize = 500
+
 type GenesisConf struct {
 	L2Hash common.Hash `ask:"--l2-hash" help:"Genesis block hash of L2"`
 	L1Hash common.Hash `ask:"--l1-hash" help:"Block hash of L1 after (not incl.) which L1 starts deriving blocks"`
@@ -35,35 +58,231 @@ func (conf *GenesisConf) GetGenesis() l2.Genesis {
 	}
 }
 
+type RollupConf struct {
+	MaxTotalDepositGas uint64 `ask:"--max-total-deposit-gas" help:"Max combined gas of all deposits in a L2 block, including the L1 info deposit. 0 to disable."`
+	MaxDeposits        uint64 `ask:"--max-deposits" help:"Max number of user deposits in a L2 block. 0 to disable."`
+	VerifyLogsBloom    bool   `ask:"--verify-logs-bloom" help:"Verify the logs bloom of L1 blocks against the receipts, in addition to the receipts root."`
+
+	DepositContractAddr       common.Address   `ask:"--deposit-contract" help:"L1 address of the deposit contract"`
+	ExtraDepositContractAddrs []common.Address `ask:"--extra-deposit-contracts" help:"L1 addresses of additional deposit contracts to derive user deposits from, e.g. a legacy contract during a migration"`
+	L1InfoPredeployAddr       common.Address   `ask:"--l1-info-predeploy" help:"L2 address of the L1 info predeploy"`
+	WithdrawalContractAddr    common.Address   `ask:"--withdrawal-contract" help:"L2 address of the withdrawal contract, committed to in the output roots"`
+	BatcherAddr               common.Address   `ask:"--batcher" help:"L1 address of the batch submitter, committed to in the L1 info deposit"`
+	SequencerFeeRecipient     common.Address   `ask:"--sequencer-fee-recipient" help:"L2 address that receives the transaction fees of sequenced L2 blocks"`
+	BatchInboxAddr            common.Address   `ask:"--batch-inbox" help:"L1 address that batches are submitted to. Zero to only derive deposits."`
+	P2PSequencerAddr          common.Address   `ask:"--p2p-sequencer" help:"Address of the sequencer key that signs gossiped unsafe L2 blocks. Zero to not import gossiped blocks."`
+	SystemConfigAddr          common.Address   `ask:"--system-config" help:"L1 address of the SystemConfig contract, to track batcher, fee scalar and gas limit updates from. Zero to disable."`
+	L2OutputOracleAddr        common.Address   `ask:"--l2-output-oracle" help:"L1 address of the L2 output oracle, to propose the outputs of the L2 chain to"`
+	ProposerAddr              common.Address   `ask:"--proposer" help:"L1 address of the proposer that the L2 output oracle accepts outputs from"`
+
+	BlockTime          uint64 `ask:"--block-time" help:"Number of seconds between L2 blocks. 0 to not check the alignment of L2 block timestamps."`
+	MaxSequencerDrift  uint64 `ask:"--max-sequencer-drift" help:"Number of seconds that the timestamp of a L2 block may be ahead of the timestamp of its L1 origin"`
+	SeqWindowSize      uint64 `ask:"--seq-window-size" help:"Number of L1 blocks, starting at the L1 origin of an epoch, within which the batches of the epoch must be included on L1. 0 to disable."`
+	ChannelTimeout     uint64 `ask:"--channel-timeout" help:"Number of L1 blocks after the first frame of a channel within which the channel must complete"`
+	MaxChannelBankSize uint64 `ask:"--max-channel-bank-size" help:"Max combined data size of the pending channels, in bytes. The oldest channels are evicted first."`
+	PayloadV2Time      uint64 `ask:"--payload-v2-time" help:"L2 timestamp from which L2 blocks are built with V2 payload attributes. 0 to not schedule."`
+
+	GasLimit      uint64 `ask:"--gas-limit" help:"Gas limit of L2 blocks. 0 to leave the gas limit to the engine."`
+	L1FeeOverhead uint64 `ask:"--l1-fee-overhead" help:"L1 fee overhead, committed to in the L1 info deposit for the L2 gas price oracle, until updated by the SystemConfig contract"`
+	L1FeeScalar   uint64 `ask:"--l1-fee-scalar" help:"L1 fee scalar, committed to in the L1 info deposit for the L2 gas price oracle, until updated by the SystemConfig contract"`
+
+	L1ChainID uint64 `ask:"--l1-chain-id" help:"Chain ID of L1, checked against the L1 endpoints. 0 to not check."`
+	L2ChainID uint64 `ask:"--l2-chain-id" help:"Chain ID of L2, checked against the L2 engines. 0 to not check."`
+}
+
+func (conf *RollupConf) GetConfig() l2.Config {
+	return l2.Config{
+		MaxTotalDepositGas: conf.MaxTotalDepositGas,
+		MaxDeposits:        conf.MaxDeposits,
+		VerifyLogsBloom:    conf.VerifyLogsBloom,
+
+		DepositContractAddr:       conf.DepositContractAddr,
+		ExtraDepositContractAddrs: conf.ExtraDepositContractAddrs,
+		L1InfoPredeployAddr:       conf.L1InfoPredeployAddr,
+		WithdrawalContractAddr:    conf.WithdrawalContractAddr,
+		BatcherAddr:               conf.BatcherAddr,
+		SequencerFeeRecipient:     conf.SequencerFeeRecipient,
+		BatchInboxAddr:            conf.BatchInboxAddr,
+		P2PSequencerAddr:          conf.P2PSequencerAddr,
+		SystemConfigAddr:          conf.SystemConfigAddr,
+
+		BlockTime:         conf.BlockTime,
+		MaxSequencerDrift: conf.MaxSequencerDrift,
+		SeqWindowSize:     conf.SeqWindowSize,
+		PayloadV2Time:     conf.PayloadV2Time,
+
+		ChannelTimeout:     conf.ChannelTimeout,
+		MaxChannelBankSize: conf.MaxChannelBankSize,
+
+		SystemConfig: l2.SystemConfig{
+			GasLimit: conf.GasLimit,
+			Overhead: common.BigToHash(new(big.Int).SetUint64(conf.L1FeeOverhead)),
+			Scalar:   common.BigToHash(new(big.Int).SetUint64(conf.L1FeeScalar)),
+		},
+	}
+}
+
 type OpNodeCmd struct {
-	L1NodeAddrs   []string `ask:"--l1" help:"Addresses of L1 User JSON-RPC endpoints to use (eth namespace required)"`
-	L2EngineAddrs []string `ask:"--l2" help:"Addresses of L2 Engine JSON-RPC endpoints to use (engine and eth namespace required)"`
+	L1NodeAddrs                []string      `ask:"--l1" help:"Addresses of L1 User JSON-RPC endpoints to use (eth namespace required)"`
+	L1PollInterval             time.Duration `ask:"--l1-poll-interval" help:"Interval to poll for new L1 heads at, for HTTP L1 endpoints without subscription support"`
+	L1HealthCheckInterval      time.Duration `ask:"--l1-health-check-interval" help:"Interval to check the health of the L1 endpoints at, to fail back to preferred endpoints"`
+	L1MaxResubscribeFailures   int           `ask:"--l1-max-resubscribe-failures" help:"Number of consecutive failed L1 head subscriptions after which the node gives up, 0 to retry forever"`
+	L1RateLimit                float64       `ask:"--l1-rate-limit" help:"Maximum number of L1 RPC calls per second, combined over all L1 endpoints, to stay within provider quotas. 0 to disable."`
+	L1RateLimitBurst           int           `ask:"--l1-rate-limit-burst" help:"Maximum burst of L1 RPC calls, when rate limited"`
+	L1ConfDepth                uint64        `ask:"--l1-conf-depth" help:"Number of L1 confirmations to wait for before deriving from a L1 block, to avoid processing blocks that are likely to reorg. 0 to derive from the L1 head."`
+	L1FinalityDepth            uint64        `ask:"--l1-finality-depth" help:"Number of L1 confirmations after which a L1 block is regarded as finalized, to finalize the L2 blocks derived from it, for L1 nodes without the finalized block tag. 0 to only finalize with the finalized L1 head."`
+	L1HeadMode                 string        `ask:"--l1-head-mode" help:"How to track new L1 heads: 'auto' to subscribe if the transport (http, ws or ipc) supports it and poll otherwise, 'subscribe' or 'poll'"`
+	L1BatchRPC                 bool          `ask:"--l1-batch-rpc" help:"Fetch each L1 block with its receipts in a single batched JSON-RPC round trip, from the same L1 endpoint as other L1 requests"`
+	L1ReceiptsConcurrency      int           `ask:"--l1-receipts-concurrency" help:"Maximum number of concurrent receipt requests per L1 block, for L1 endpoints without eth_getBlockReceipts support"`
+	L1HeadBuffer               uint64        `ask:"--l1-head-buffer" help:"Number of recent L1 blocks to track, including reorged blocks, to look up the L1 chain and reconstruct L1 reorgs without RPC round trips"`
+	L1DepositLogs              bool          `ask:"--l1-deposit-logs" help:"Derive from the deposit and ConfigUpdate logs of L1 blocks, fetched with eth_getLogs from the first L1 endpoint, instead of all their receipts. The receipts are still fetched for blocks with possibly missing logs."`
+	L1LogsTrust                string        `ask:"--l1-logs-trust" help:"How the L1 logs of --l1-deposit-logs are checked against their block: 'strict' to fetch the receipts if the logs bloom indicates missing logs, 'bloom' to only check the logs against the logs bloom, or 'full' to trust them as-is"`
+	L1WatchDeposits            bool          `ask:"--l1-watch-deposits" help:"Subscribe to the deposit logs of new L1 blocks, to pre-warm the download of L1 blocks with deposits, from the first L1 endpoint that supports subscriptions"`
+	L2EngineAddrs              []string      `ask:"--l2" help:"Addresses of L2 Engine JSON-RPC endpoints to use (engine and eth namespace required)"`
+	L2JWTSecret                string        `ask:"--l2-jwt-secret" help:"Path of a file with the hex-encoded 32 byte secret to authenticate engine API requests to the L2 engines with. Empty to not authenticate."`
+	L2Reconcile                bool          `ask:"--l2-reconcile" help:"Drive all L2 engines with a single driver, led by the first engine, and flag the engines that diverge from it, to test different engine implementations against each other. By default each engine is driven independently."`
+	Sequencer                  bool          `ask:"--sequencer" help:"Sequence a new L2 block every block time, with the deposits of the L1 origin and transactions from the tx pool of the engine, instead of deriving L2 blocks from L1. Requires a single L2 engine."`
+	SequencerBuildTime         time.Duration `ask:"--sequencer-build-time" help:"Time the engine is given to build each sequenced L2 block, to fill it with transactions from its tx pool. Must be shorter than the block time."`
+	BatcherKey                 string        `ask:"--batcher-key" help:"Path of a file with the hex-encoded private key of the batcher, to submit the batches of new L2 blocks to the batch inbox on L1 with, through the first L1 endpoint. Empty to not submit batches."`
+	BatcherPollInterval        time.Duration `ask:"--batcher-poll-interval" help:"Interval to poll the first L2 engine for new L2 blocks to submit at"`
+	BatcherMaxChannelSize      uint64        `ask:"--batcher-max-channel-size" help:"Size of the sequenced transactions, before compression, at which a channel is submitted"`
+	BatcherMaxChannelTime      time.Duration `ask:"--batcher-max-channel-time" help:"Time after the first L2 block was added to a channel at which the channel is submitted, regardless of its size"`
+	BatcherMaxFrameSize        uint64        `ask:"--batcher-max-frame-size" help:"Size of the channel data in each batch submission to L1"`
+	TxMgrResubmissionTimeout   time.Duration `ask:"--txmgr-resubmission-timeout" help:"Time after which a L1 transaction that is not included is resubmitted with higher fees"`
+	TxMgrNumConfirmations      uint64        `ask:"--txmgr-num-confirmations" help:"Number of L1 blocks, including the block with the transaction, to wait for before a L1 transaction is confirmed"`
+	TxMgrFeeBumpPercent        uint64        `ask:"--txmgr-fee-bump-percent" help:"Percentage to bump the fees of a resubmitted L1 transaction with, at least 10"`
+	TxMgrMaxFeeCap             uint64        `ask:"--txmgr-max-fee-cap" help:"Max fee cap, in wei per gas, to bump the fees of L1 transactions to. 0 to disable."`
+	ProposerKey                string        `ask:"--proposer-key" help:"Path of a file with the hex-encoded private key of the proposer, to propose the outputs of the safe L2 chain to the L2 output oracle on L1 with, through the first L1 endpoint. Empty to not propose outputs."`
+	ProposerPollInterval       time.Duration `ask:"--proposer-poll-interval" help:"Interval to check the safe L2 head of the first L2 engine for a new output to propose at"`
+	ProposerSubmissionInterval uint64        `ask:"--proposer-submission-interval" help:"Number of L2 blocks between proposed outputs, must match the submission interval of the L2 output oracle"`
+	Challenger                 bool          `ask:"--challenger" help:"Watch the outputs proposed to the L2 output oracle on L1, and alert on outputs that do not match the outputs computed with the first L2 engine"`
+	ChallengerKey              string        `ask:"--challenger-key" help:"Path of a file with the hex-encoded private key of the challenger, to dispute invalid outputs with by deleting them from the L2 output oracle, through the first L1 endpoint. The oracle must accept deletions from it. Empty to only alert."`
+	ChallengerPollInterval     time.Duration `ask:"--challenger-poll-interval" help:"Interval to check L1 for newly proposed outputs"`
+	SequencerStopped           bool          `ask:"--sequencer-stopped" help:"Start with sequencing stopped, as standby sequencer, until sequencing is handed over with admin_startSequencer"`
 
 	LogCmd `ask:".log" help:"Log configuration"`
 
 	Genesis GenesisConf `ask:".genesis" help:"Genesis anchor point"`
 
-	// during later sequencer rollup implementation:
-	// TODO: multi-addrs option (static peers)
+	Rollup RollupConf `ask:".rollup" help:"Rollup configuration"`
+
+	RollupConfig string `ask:"--rollup-config" help:"Path of a JSON or TOML file with the rollup configuration shared by all nodes of the rollup, overriding the genesis flags and the matching rollup flags. Empty to configure the rollup with flags."`
+
+	StateFile string `ask:"--state-file" help:"Path of a JSON file to persist the last processed L1 head and the derived, safe and finalized L2 heads in, to resume derivation after a restart if the engine head cannot be fetched. Defaults to heads.json in the data directory. Engines after the first use a file with their index, e.g. heads.1.json. Empty to disable."`
+
+	DataDir string `ask:"--datadir" help:"Directory to persist the derivation state in across restarts, created if it does not exist: the heads in heads.json, and the derivation pipeline in pipeline.json. Engines after the first use files with their index, e.g. pipeline.1.json. Empty to not persist any state, unless a state file is set."`
+
+	Metrics MetricsConf `ask:".metrics" help:"Metrics configuration"`
+
+	Pprof PprofConf `ask:".pprof" help:"pprof configuration"`
+
+	Health HealthConf `ask:".health" help:"Health check configuration"`
+
+	Heartbeat HeartbeatConf `ask:".heartbeat" help:"Heartbeat reporting configuration"`
+
+	P2P P2PConf `ask:".p2p" help:"P2P gossip of unsafe L2 blocks"`
+
+	ShutdownTimeout time.Duration `ask:"--shutdown-timeout" help:"Time to wait for all subsystems to stop gracefully on shutdown, including the L2 block that is being inserted, before closing the node regardless"`
+
+	RPCAddr string `ask:"--rpc-addr" help:"Address to serve the JSON-RPC API on, over HTTP, with the sync status, L2 output roots and withdrawal proofs of the first L2 engine (optimism namespace), and to start and stop sequencing (admin namespace). The engine must serve eth_getProof. Empty to disable."`
+
 	// TODO: bootnodes option (bootstrap discovery of more peers)
 
 	log log.Logger
 
 	// (combined) source to fetch data from
 	l1Source eth.L1Source
+	// the L1 endpoints, in order of preference, failing over to the next endpoint if one fails
+	l1Failover *eth.FailoverL1Source
+
+	// node events, to observe the L1 heads and the derivation with
+	events *events.Bus
+
+	// single L1 head subscription, shared by the internal consumers of L1 heads
+	l1Heads *eth.HeadMux
+
+	// cache of the recent L1 chain, including reorged heads, fed by the L1 heads
+	l1Chain *eth.ChainTracker
+
+	// the latest block number of the first L1 endpoint, to track how far the L1 heads lag behind
+	l1BlockNumber eth.BlockNumberSource
+
+	// watches the deposit logs of new L1 blocks, nil if disabled
+	l1Deposits *l2.DepositWatcher
+
+	// tracks the safe and finalized L1 heads
+	l1LabeledHeads *eth.LabeledHeadsTracker
 
 	// engines to keep synced
 	l2Engines []*l2.EngineDriver
 
-	l1Downloader l1.Downloader
+	// serves the JSON-RPC API, nil if disabled
+	rpcServer *rpcServer
+
+	// serves the metrics, nil if disabled
+	metricsServer *httpServer
+
+	// serves the pprof endpoints, nil if disabled
+	pprofServer *httpServer
+
+	// serves the health endpoints, nil if disabled
+	healthServer *httpServer
+
+	// posts the status of the node to the heartbeat URL, nil if disabled
+	heartbeat *heartbeater
+
+	// gossips unsafe L2 blocks with peers, nil if disabled
+	p2pHost     *p2p.Host
+	blockGossip *p2p.BlockGossip
+
+	// submits the batches of new L2 blocks to L1, nil if disabled
+	batcher *batcher.Batcher
+
+	// proposes the outputs of the safe L2 chain to L1, nil if disabled
+	proposer *proposer.Proposer
+
+	// checks the outputs proposed to L1, nil if disabled
+	challenger *challenger.Challenger
 
-	ctx   context.Context
-	close chan chan error
+	// stops the running subsystems in order on shutdown
+	supervisor *Supervisor
+
+	// ctx is cancelled once all subsystems stopped, to abort anything that is left
+	ctx    context.Context
+	cancel context.CancelFunc
+	close  chan chan error
 }
 
 func (c *OpNodeCmd) Default() {
 	c.L1NodeAddrs = []string{"http://127.0.0.1:8545"}
 	c.L2EngineAddrs = []string{"http://127.0.0.1:8551"}
+	c.L1PollInterval = eth.DefaultPollInterval
+	c.L1HealthCheckInterval = eth.DefaultHealthCheckInterval
+	c.L1RateLimitBurst = 10
+	c.L1HeadMode = string(eth.AutoHeads)
+	c.L1HeadBuffer = 1000
+	c.L1ReceiptsConcurrency = eth.DefaultReceiptsConcurrency
+	c.L1LogsTrust = l2.LogsTrustStrict.String()
+	c.SequencerBuildTime = l2.DefaultSequencerBuildTime
+	c.BatcherPollInterval = batcher.DefaultPollInterval
+	c.BatcherMaxChannelSize = batcher.DefaultMaxChannelSize
+	c.BatcherMaxChannelTime = batcher.DefaultMaxChannelDuration
+	c.BatcherMaxFrameSize = batcher.DefaultMaxFrameSize
+	c.ProposerPollInterval = proposer.DefaultPollInterval
+	c.ProposerSubmissionInterval = proposer.DefaultSubmissionInterval
+	c.ChallengerPollInterval = challenger.DefaultPollInterval
+	c.TxMgrResubmissionTimeout = txmgr.DefaultResubmissionTimeout
+	c.TxMgrNumConfirmations = txmgr.DefaultNumConfirmations
+	c.TxMgrFeeBumpPercent = txmgr.DefaultFeeBumpPercent
+	c.ShutdownTimeout = DefaultShutdownTimeout
+	c.Rollup.DepositContractAddr = l2.DepositContractAddr
+	c.Rollup.L1InfoPredeployAddr = l2.L1InfoPredeployAddr
+	c.Rollup.WithdrawalContractAddr = l2.WithdrawalContractAddr
+	c.Rollup.BlockTime = l2.DefaultBlockTime
+	c.Rollup.MaxSequencerDrift = l2.DefaultMaxSequencerDrift
+	c.Rollup.SeqWindowSize = l2.DefaultSeqWindowSize
+	c.Rollup.ChannelTimeout = l2.DefaultChannelTimeout
+	c.Rollup.MaxChannelBankSize = l2.DefaultMaxChannelBankSize
 }
 
 func (c *OpNodeCmd) Help() string {
@@ -73,14 +292,45 @@ func (c *OpNodeCmd) Help() string {
 func (c *OpNodeCmd) Run(ctx context.Context, args ...string) error {
 	logger := c.LogCmd.Create()
 	c.log = logger
-	c.ctx = ctx
+	c.ctx, c.cancel = context.WithCancel(ctx)
+	c.supervisor = &Supervisor{Log: logger}
+
+	if err := c.LoadConfig(); err != nil {
+		return err
+	}
+
+	if c.DataDir != "" {
+		if err := os.MkdirAll(c.DataDir, 0700); err != nil {
+			return fmt.Errorf("failed to create data directory %q: %v", c.DataDir, err)
+		}
+	}
 
-	if c.Genesis == (GenesisConf{}) {
-		return errors.New("genesis configuration required")
+	if c.Metrics.Enabled {
+		// metrics must be enabled before they are created, or they are no-ops
+		metrics.Enabled = true
+	}
+
+	var l1Limiter *eth.RateLimiter
+	if c.L1RateLimit > 0 {
+		l1Limiter = eth.NewRateLimiter(c.L1RateLimit, c.L1RateLimitBurst, metrics.DefaultRegistry)
 	}
 
 	l1Sources := make([]eth.L1Source, 0, len(c.L1NodeAddrs))
+	l1Fetchers := make([]eth.FetchSource, 0, len(c.L1NodeAddrs))
+	l1ReceiptsMetrics := eth.NewReceiptsMetrics(metrics.DefaultRegistry)
+	var l1Logs eth.LogSubscriber
+	var l1Eth *ethclient.Client
+	var l1Blocks eth.BlockByHashSource
 	for i, addr := range c.L1NodeAddrs {
+		transport, err := eth.DetectTransport(addr)
+		if err != nil {
+			return fmt.Errorf("invalid L1 address %d: %v", i, err)
+		}
+		polling, err := eth.HeadTrackingMode(c.L1HeadMode).Polling(transport)
+		if err != nil {
+			return fmt.Errorf("cannot track heads of L1 address %d (%s): %v", i, addr, err)
+		}
+		c.log.Info("Connecting to L1 endpoint", "i", i, "transport", transport, "polling", polling)
 		// L1 exec engine: read-only, to update L2 consensus with
 		l1Node, err := rpc.DialContext(ctx, addr)
 		if err != nil {
@@ -92,51 +342,381 @@ func (c *OpNodeCmd) Run(ctx context.Context, args ...string) error {
 		}
 		// TODO: we may need to authenticate the connection with L1
 		// l1Node.SetHeader()
-		cl := ethclient.NewClient(l1Node)
-		l1Sources = append(l1Sources, cl)
+		l1Client := eth.NewClient(l1Node)
+		l1Log := c.log.New("l1", i)
+		l1Client.OnResponse = func(method string, d time.Duration, err error) {
+			l1Log.Trace("L1 RPC call", "method", method, "duration", d, "err", err)
+		}
+		var l1RPC eth.RPCCaller = l1Client
+		var l1Batch eth.BatchCaller = l1Client
+		var cl interface {
+			eth.L1Source
+			eth.BlockNumberSource
+		} = eth.NewVerifyingL1Source(l1Client)
+		if l1Limiter != nil {
+			l1RPC = eth.NewRateLimitedRPC(l1RPC, l1Limiter)
+			l1Batch = eth.NewRateLimitedBatchCaller(l1Batch, l1Limiter)
+			cl = eth.NewRateLimitedL1Source(cl, l1Limiter)
+		}
+		if c.L1BatchRPC {
+			// the batch fetcher verifies the fetched blocks and receipts by itself
+			l1Fetchers = append(l1Fetchers, &eth.BatchFetcher{RPC: l1Batch})
+		} else {
+			// all receipts of a block are fetched at once if the endpoint supports it, and verified against the block
+			l1Fetchers = append(l1Fetchers, &eth.ReceiptsDownloader{
+				Blocks: cl,
+				Receipts: &eth.ReceiptsFetcher{
+					Block:          eth.RPCBlockReceipts{RPC: l1RPC},
+					Tx:             cl,
+					Concurrency:    c.L1ReceiptsConcurrency,
+					RequestTimeout: l1RequestTimeout,
+					OnFetched:      l1ReceiptsMetrics.RecordFetch,
+				},
+			})
+		}
+		if l1Logs == nil && transport.SupportsSubscriptions() {
+			l1Logs = l1Client
+		}
+		if l1Eth == nil {
+			c.l1BlockNumber = cl
+			l1Blocks = cl
+			l1Eth = ethclient.NewClient(l1Node)
+		}
+		if c.l1LabeledHeads == nil {
+			c.l1LabeledHeads = &eth.LabeledHeadsTracker{
+				RPC:      l1RPC,
+				Interval: c.L1PollInterval,
+				Labels:   []eth.HeadLabel{eth.SafeHead, eth.FinalizedHead},
+			}
+		}
+		if polling {
+			l1Sources = append(l1Sources, eth.NewPollingL1Source(cl, c.L1PollInterval))
+		} else {
+			l1Sources = append(l1Sources, cl)
+		}
 	}
 	if len(l1Sources) == 0 {
 		return fmt.Errorf("need at least one L1 source endpoint, see --l1")
 	}
+	if err := checkChainID(ctx, c.log, "L1", l1Eth, c.Rollup.L1ChainID); err != nil {
+		return err
+	}
 
-	// Combine L1 sources, so work can be balanced between them
-	c.l1Source = eth.NewCombinedL1Source(l1Sources)
-	l1CanonicalChain := eth.CanonicalChain(c.l1Source)
+	// Combine L1 sources, so a single flaky endpoint does not stall the derivation
+	c.l1Failover = eth.NewFailoverL1Source(l1Sources)
+	c.l1Failover.OnFailure = func(i int, err error) {
+		c.log.Warn("L1 endpoint failed, failing over to the next endpoint", "i", i, "err", err)
+		metrics.GetOrRegisterCounter(fmt.Sprintf("opnode/l1/rpc_errors/%d", i), metrics.DefaultRegistry).Inc(1)
+	}
+	c.l1Source = c.l1Failover
+	c.events = events.NewBus()
+	c.l1Heads = &eth.HeadMux{
+		Source: c.l1Source,
+		OnSlowConsumer: func(consumer int, header *types.Header) {
+			c.log.Warn("L1 head consumer is falling behind, dropping old head", "consumer", consumer, "head", header.Hash())
+		},
+	}
+	c.l1Chain = eth.NewChainTracker(eth.CanonicalChain(c.l1Source), c.l1Source, c.L1HeadBuffer)
 
-	c.l1Downloader = l1.NewDownloader(c.l1Source)
+	// blocks are fetched with their receipts from the preferred L1 endpoint, and cached
+	l1Cache, err := eth.NewChainCache(eth.NewFailoverFetchSource(c.l1Failover, l1Fetchers), l1BlockCacheSize)
+	if err != nil {
+		return err
+	}
+	var l1DL l2.Downloader = l1Cache
 	genesis := c.Genesis.GetGenesis()
+	rollupConfig := c.Rollup.GetConfig()
 
+	var l1LogsFetcher *l2.L1LogsFetcher
+	if c.L1DepositLogs {
+		trust, err := l2.ParseLogsTrustLevel(c.L1LogsTrust)
+		if err != nil {
+			return fmt.Errorf("invalid --l1-logs-trust: %v", err)
+		}
+		l1LogsFetcher = &l2.L1LogsFetcher{Blocks: l1Blocks, Logs: l1Eth, Trust: trust}
+	}
+
+	if c.L1WatchDeposits {
+		if l1Logs == nil {
+			return errors.New("watching L1 deposits requires a L1 endpoint with subscription support (ws or ipc)")
+		}
+		c.l1Deposits = &l2.DepositWatcher{
+			Config:   &rollupConfig,
+			Logs:     l1Logs,
+			Prefetch: l1DL,
+			OnDeposit: func(dep l2.PendingDeposit) {
+				c.log.Debug("New pending L1 deposit", "l1", dep.L1, "tx", dep.TxHash, "log_index", dep.LogIndex, "source_hash", dep.SourceHash)
+			},
+			OnDecodeErr: func(log types.Log, err error) {
+				c.log.Warn("Failed to decode L1 deposit log", "l1", log.BlockHash, "tx", log.TxHash, "log_index", log.Index, "err", err)
+			},
+		}
+	}
+
+	derivationMetrics := l2.NewGethMetrics(metrics.DefaultRegistry)
+
+	var jwtSecret *l2.JWTSecret
+	if c.L2JWTSecret != "" {
+		secret, err := l2.LoadJWTSecret(c.L2JWTSecret)
+		if err != nil {
+			return err
+		}
+		jwtSecret = &secret
+	}
+
+	var clients []l2.DriverAPI
+	var proofs l2.ProofSource
+	var receipts eth.ReceiptSource
+	// probes of the engine connections, to check the health of the node with
+	var engineProbes []func(ctx context.Context) error
 	for i, addr := range c.L2EngineAddrs {
 		// L2 exec engine: updated by this OpNode (L2 consensus layer node)
-		backend, err := rpc.DialContext(ctx, addr)
+		backend, err := l2.DialEngine(ctx, addr, jwtSecret)
 		if err != nil {
 			if backend == nil {
 				return fmt.Errorf("failed to dial L2 address %d (%s): %v", i, addr, err)
 			}
 			c.log.Warn("failed to dial L2 address, but may connect later", "i", i, "addr", addr, "err", err)
 		}
-		// TODO: we may need to authenticate the connection with L2
-		// backend.SetHeader()
-		client := &l2.EngineClient{
-			RPCBackend: backend,
-			EthBackend: ethclient.NewClient(backend),
+		if proofs == nil {
+			proofs = gethclient.New(backend)
+		}
+		l2Eth := ethclient.NewClient(backend)
+		if receipts == nil {
+			receipts = l2Eth
+		}
+		if err := checkChainID(ctx, c.log, fmt.Sprintf("L2 engine %d", i), l2Eth, c.Rollup.L2ChainID); err != nil {
+			return err
+		}
+		engineRPC := l2.NewMeteredRPC(backend, metrics.DefaultRegistry, fmt.Sprintf("opnode/engine/%d", i))
+		engineProbes = append(engineProbes, func(ctx context.Context) error {
+			var num hexutil.Uint64
+			return engineRPC.CallContext(ctx, &num, "eth_blockNumber")
+		})
+		clients = append(clients, &l2.EngineClient{
+			RPCBackend: engineRPC,
+			EthBackend: l2Eth,
 			Log:        c.log.New("engine_client", i),
+		})
+	}
+	if c.L2Reconcile && len(clients) > 1 {
+		clients = []l2.DriverAPI{&l2.MultiEngine{
+			DriverAPI:   clients[0],
+			Secondaries: clients[1:],
+			Log:         c.log.New("engine", "multi"),
+		}}
+	}
+
+	for i, api := range clients {
+		// pause derivation while the engine is syncing, e.g. snap-syncing
+		client := &l2.EngineSync{DriverAPI: api, Log: c.log.New("engine_sync", i)}
+		// the node follows the first engine, the other engines publish their events on their own bus,
+		// to persist their heads separately
+		engineEvents := c.events
+		if i > 0 {
+			engineEvents = events.NewBus()
 		}
 		engine := &l2.EngineDriver{
-			Log: c.log.New("engine", i),
-			RPC: client,
-			DL:  c.l1Downloader,
+			Log:        c.log.New("engine", i),
+			Config:     rollupConfig,
+			RPC:        client,
+			EngineSync: client,
+			L1:         c.l1Source,
+			DL:         l1DL,
+			L1Logs:     l1LogsFetcher,
+			Metrics:    derivationMetrics,
+			Events:     engineEvents,
 			SyncRef: l2.SyncSource{
-				L1: l1CanonicalChain,
+				L1: c.l1Chain,
 				L2: client,
 			},
-			EngineDriverState: l2.EngineDriverState{Genesis: genesis},
+			EngineDriverState: l2.EngineDriverState{Genesis: genesis, L1Recent: c.l1Chain},
+		}
+		if c.DataDir != "" {
+			engine.PipelineStore = EnginePipelineFile(filepath.Join(c.DataDir, PipelineStateFileName), i)
+		}
+		if c.Sequencer {
+			engine.Sequencer = &l2.Sequencer{
+				Log:    c.log.New("sequencer", i),
+				Config: &engine.Config,
+				SeqConfig: l2.SequencerConfig{
+					BuildTime: c.SequencerBuildTime,
+				},
+				Genesis: &engine.Genesis,
+				RPC:     client,
+				L1:      c.l1Source,
+				DL:      l1DL,
+				Events:  engineEvents,
+			}
 		}
+		registerEngineMetrics(metrics.DefaultRegistry, fmt.Sprintf("opnode/engine/%d", i), engine)
 		c.l2Engines = append(c.l2Engines, engine)
 	}
 
-	// TODO: maybe spin up an API server
+	if c.BatcherKey != "" {
+		key, err := crypto.LoadECDSA(c.BatcherKey)
+		if err != nil {
+			return fmt.Errorf("failed to load batcher key: %v", err)
+		}
+		if addr := crypto.PubkeyToAddress(key.PublicKey); addr != rollupConfig.BatcherAddr {
+			return fmt.Errorf("batcher key address %s does not match the batcher address %s of the rollup", addr, rollupConfig.BatcherAddr)
+		}
+		manager, err := c.newTxManager(ctx, "batcher", key, l1Eth)
+		if err != nil {
+			return err
+		}
+		c.batcher = &batcher.Batcher{
+			Log:    c.log.New("batcher", 0),
+			Rollup: &rollupConfig,
+			Config: batcher.Config{
+				PollInterval:       c.BatcherPollInterval,
+				MaxChannelSize:     c.BatcherMaxChannelSize,
+				MaxChannelDuration: c.BatcherMaxChannelTime,
+				MaxFrameSize:       c.BatcherMaxFrameSize,
+				Compression:        l2.ZlibCompression,
+			},
+			L2:     c.l2Engines[0].RPC,
+			Sender: txmgr.ManagerSender{Manager: manager},
+		}
+	}
+
+	if c.ProposerKey != "" {
+		key, err := crypto.LoadECDSA(c.ProposerKey)
+		if err != nil {
+			return fmt.Errorf("failed to load proposer key: %v", err)
+		}
+		if addr := crypto.PubkeyToAddress(key.PublicKey); addr != c.Rollup.ProposerAddr {
+			return fmt.Errorf("proposer key address %s does not match the proposer address %s of the L2 output oracle", addr, c.Rollup.ProposerAddr)
+		}
+		manager, err := c.newTxManager(ctx, "proposer", key, l1Eth)
+		if err != nil {
+			return err
+		}
+		c.proposer = &proposer.Proposer{
+			Log:    c.log.New("proposer", 0),
+			Rollup: &rollupConfig,
+			Config: proposer.Config{
+				PollInterval:       c.ProposerPollInterval,
+				SubmissionInterval: c.ProposerSubmissionInterval,
+				OracleAddr:         c.Rollup.L2OutputOracleAddr,
+			},
+			L2:       c.l2Engines[0].RPC,
+			Proofs:   proofs,
+			SafeHead: func() eth.BlockID { return c.l2Engines[0].L2Heads().Safe },
+			L1Head:   c.l1Chain.Head,
+			Oracle:   l1Eth,
+			// the proposer sends transactions with the same interface as the batcher
+			Sender: txmgr.ManagerSender{Manager: manager},
+		}
+	}
+
+	if c.Challenger {
+		invalidOutputs := metrics.GetOrRegisterCounter("opnode/challenger/invalid_outputs", metrics.DefaultRegistry)
+		c.challenger = &challenger.Challenger{
+			Log:    c.log.New("challenger", 0),
+			Rollup: &rollupConfig,
+			Config: challenger.Config{
+				PollInterval: c.ChallengerPollInterval,
+				OracleAddr:   c.Rollup.L2OutputOracleAddr,
+				ConfDepth:    c.L1ConfDepth,
+			},
+			L1:        l1Eth,
+			L1Head:    c.l1Chain.Head,
+			L2:        c.l2Engines[0].RPC,
+			Proofs:    proofs,
+			SafeHead:  func() eth.BlockID { return c.l2Engines[0].L2Heads().Safe },
+			OnInvalid: func(p challenger.Proposal, expected *l2.Output) { invalidOutputs.Inc(1) },
+		}
+		if c.ChallengerKey != "" {
+			key, err := crypto.LoadECDSA(c.ChallengerKey)
+			if err != nil {
+				return fmt.Errorf("failed to load challenger key: %v", err)
+			}
+			manager, err := c.newTxManager(ctx, "challenger", key, l1Eth)
+			if err != nil {
+				return err
+			}
+			c.challenger.Sender = txmgr.ManagerSender{Manager: manager}
+		}
+	}
+
+	if c.Metrics.Enabled {
+		srv, err := startHTTPServer("metrics", c.Metrics.Endpoint(), metricsHandler(metrics.DefaultRegistry), c.log)
+		if err != nil {
+			return err
+		}
+		c.metricsServer = srv
+	}
+
+	if c.Pprof.Enabled {
+		srv, err := startHTTPServer("pprof", c.Pprof.Endpoint(), pprofHandler(), c.log)
+		if err != nil {
+			c.stopHTTPServers(ctx)
+			return err
+		}
+		c.pprofServer = srv
+	}
+
+	if c.Health.Enabled {
+		health := &healthCheck{
+			l1Healthy: c.l1Failover.AnyHealthy,
+			engines:   engineProbes,
+			l1Head:    c.l1Chain.Head,
+			l1Origin:  c.l2Engines[0].L1Head,
+			maxL1Lag:  c.Health.MaxL1Lag,
+		}
+		srv, err := startHTTPServer("health", c.Health.Endpoint(), health.Handler(), c.log)
+		if err != nil {
+			c.stopHTTPServers(ctx)
+			return err
+		}
+		c.healthServer = srv
+	}
+
+	if c.Heartbeat.Enabled {
+		c.heartbeat = &heartbeater{
+			log:      c.log.New("heartbeat", c.Heartbeat.URL),
+			url:      c.Heartbeat.URL,
+			interval: c.Heartbeat.Interval,
+			client:   &http.Client{Timeout: c.Heartbeat.Interval},
+			status: func() Heartbeat {
+				heads := c.l2Engines[0].L2Heads()
+				return Heartbeat{
+					Version:     version.Version,
+					GitCommit:   version.GitCommit,
+					Moniker:     c.Heartbeat.Moniker,
+					L2ChainID:   c.Rollup.L2ChainID,
+					L1Head:      c.l1Chain.Head().Number,
+					UnsafeL2:    heads.Unsafe.Number,
+					SafeL2:      heads.Safe.Number,
+					FinalizedL2: heads.Finalized.Number,
+				}
+			},
+		}
+	}
+
+	// TODO: extend the API server
 	//  (to get debug data, change runtime settings like logging, serve pprof, get peering info, node health, etc.)
+	if c.RPCAddr != "" {
+		api := &optimismAPI{l1Head: c.l1Chain.Head, engine: c.l2Engines[0], proofs: proofs, receipts: receipts}
+		admin := &adminAPI{engine: c.l2Engines[0]}
+		srv, err := startRPCServer(c.RPCAddr, api, admin, c.log.New("rpc", "optimism"))
+		if err != nil {
+			c.stopHTTPServers(ctx)
+			return err
+		}
+		c.rpcServer = srv
+	}
+
+	if c.P2P.Enabled {
+		if err := c.startP2P(&rollupConfig); err != nil {
+			c.stopHTTPServers(ctx)
+			if c.rpcServer != nil {
+				_ = c.rpcServer.Stop(ctx)
+			}
+			return err
+		}
+	}
 
 	c.close = make(chan chan error)
 
@@ -146,87 +726,262 @@ func (c *OpNodeCmd) Run(ctx context.Context, args ...string) error {
 }
 
 func (c *OpNodeCmd) RunNode() {
-	c.log.Info("Starting OpNode")
-
-	var unsub []func()
-	handleUnsubscribe := func(sub ethereum.Subscription, errMsg string) {
-		unsub = append(unsub, sub.Unsubscribe)
-		go func() {
-			err, ok := <-sub.Err()
-			if !ok {
-				return
-			}
-			c.log.Error(errMsg, "err", err)
-		}()
-	}
+	c.log.Info("Starting OpNode", "version", version.Get())
 
 	c.log.Info("Fetching rollup starting point")
 
-	// We download receipts in parallel
-	c.l1Downloader.AddReceiptWorkers(4)
-
-	// Feed of eth.HeadSignal
-	var l1HeadsFeed event.Feed
+	// Feed of eth.HeadSignal, delayed by the L1 confirmation depth, to derive from
+	var l1ConfHeadsFeed event.Feed
 
 	c.log.Info("Attaching execution engine(s)")
-	for _, eng := range c.l2Engines {
-		// Request initial head update, default to genesis otherwise
-		reqCtx, reqCancel := context.WithTimeout(c.ctx, time.Second*10)
-		if !eng.RequestUpdate(reqCtx, eng.Log, eng) {
-			eng.Log.Error("failed to fetch engine head, defaulting to genesis")
-			eng.UpdateHead(eng.Genesis.L1, eng.Genesis.L2)
+	for i, eng := range c.l2Engines {
+		// Anchor on the engine head, walking back if it was built on L1 blocks that are not canonical anymore,
+		// default to the persisted head state or genesis otherwise.
+		reqCtx, reqCancel := context.WithTimeout(c.ctx, time.Second*30)
+		state, haveState := c.loadHeadState(i)
+		if err := eng.SyncStartup(reqCtx); err != nil {
+			if haveState {
+				eng.Log.Warn("failed to find engine head anchor, resuming from persisted head state", "err", err, "l1_head", state.L1Head, "l2_head", state.L2Head)
+				eng.UpdateHead(state.L1Head, state.L2Head)
+			} else {
+				eng.Log.Error("failed to find engine head anchor, defaulting to genesis", "err", err)
+				eng.UpdateHead(eng.Genesis.L1, eng.Genesis.L2)
+			}
+		}
+		// the persisted safe and finalized heads are not tracked by the engine, and would otherwise only be known again
+		// after deriving from L1 up to them
+		if haveState && state.SafeL2 != (eth.BlockID{}) {
+			if err := eng.RestoreSafeHeads(reqCtx, state.SafeL2, state.FinalizedL2); err != nil {
+				eng.Log.Warn("failed to restore persisted safe and finalized heads", "err", err, "safe", state.SafeL2, "finalized", state.FinalizedL2)
+			} else {
+				heads := eng.L2Heads()
+				eng.Log.Info("Restored persisted safe and finalized heads", "safe", heads.Safe, "finalized", heads.Finalized)
+			}
 		}
 		reqCancel()
 
+		if c.StateFile != "" {
+			c.supervisor.AddSubscription("engine head state", c.persistHeads(i, eng))
+		}
+
 		// driver subscribes to L1 head changes
 		l1SubCh := make(chan eth.HeadSignal, 10)
-		l1HeadsFeed.Subscribe(l1SubCh)
+		l1ConfHeadsFeed.Subscribe(l1SubCh)
 		// start driving engine: sync blocks by deriving them from L1 and driving them into the engine
 		engDriveSub := eng.Drive(c.ctx, l1SubCh)
-		handleUnsubscribe(engDriveSub, "engine driver unexpectedly failed")
+		c.supervisor.AddSubscription("engine driver", engDriveSub)
+		// once the L1 heads stop, the driver completes the L2 block it is inserting before it stops,
+		// instead of leaving the engine with a partially applied forkchoice update
+		c.supervisor.Add("engine driver drain", eng.Pause)
+		if c.Sequencer && c.SequencerStopped {
+			if _, err := eng.StopSequencer(c.ctx); err != nil {
+				eng.Log.Error("failed to stop sequencer", "err", err)
+			}
+		}
 	}
 
-	// Keep subscribed to the L1 heads, which keeps the L1 maintainer pointing to the best headers to sync
-	l1HeadsSub := event.ResubscribeErr(time.Second*10, func(ctx context.Context, err error) (event.Subscription, error) {
-		if err != nil {
-			c.log.Warn("resubscribing after failed L1 subscription", "err", err)
+	if c.blockGossip != nil {
+		c.supervisor.Add("p2p host", func(ctx context.Context) error { return c.p2pHost.Close() })
+		c.supervisor.AddSubscription("p2p static peers", c.p2pHost.KeepConnected(c.ctx, c.P2P.DialInterval))
+		if c.Rollup.P2PSequencerAddr != (common.Address{}) {
+			sub, err := c.blockGossip.Subscribe(c.ctx)
+			if err != nil {
+				c.log.Error("failed to subscribe to gossiped unsafe L2 blocks", "err", err)
+			} else {
+				c.supervisor.AddSubscription("p2p block import", sub)
+			}
 		}
-		return eth.WatchHeadChanges(c.ctx, c.l1Source, func(sig eth.HeadSignal) {
-			l1HeadsFeed.Send(sig)
+		if c.blockGossip.SignerKey != nil {
+			c.supervisor.AddSubscription("p2p block publishing", c.publishSequenced(c.l2Engines[0]))
+		}
+	}
+
+	if c.batcher != nil {
+		// the safe L2 blocks were derived from L1, and were submitted already
+		from := c.l2Engines[0].L2Heads().Safe
+		if from == (eth.BlockID{}) {
+			from = c.l2Engines[0].Genesis.L2
+		}
+		c.log.Info("Starting batcher", "from", from)
+		c.supervisor.AddSubscription("batcher", c.batcher.Start(c.ctx, from))
+	}
+
+	if c.proposer != nil {
+		c.log.Info("Starting proposer", "oracle", c.Rollup.L2OutputOracleAddr, "submission_interval", c.ProposerSubmissionInterval)
+		c.supervisor.AddSubscription("proposer", c.proposer.Start(c.ctx))
+	}
+
+	if c.challenger != nil {
+		// outputs can only be proposed after the L1 genesis of the rollup
+		from := c.l2Engines[0].Genesis.L1.Number
+		c.log.Info("Starting challenger", "oracle", c.Rollup.L2OutputOracleAddr, "from", from, "dispute", c.challenger.Sender != nil)
+		c.supervisor.AddSubscription("challenger", c.challenger.Start(c.ctx, from))
+	}
+
+	// Keep subscribed to the L1 heads, which keeps the L1 maintainer pointing to the best headers to sync
+	l1HeadMetrics := eth.NewHeadMetrics(metrics.DefaultRegistry)
+	l1Reorgs := &eth.ReorgDetector{
+		Window:  64,
+		Headers: c.l1Chain,
+		Metrics: l1HeadMetrics,
+		OnReorg: func(sig eth.ReorgSignal) {
+			c.log.Warn("L1 reorg detected", "old_head", sig.OldHead, "new_head", sig.NewHead,
+				"common_ancestor", sig.CommonAncestor, "depth", sig.Depth)
+			c.events.Publish(events.L1Reorg{ReorgSignal: sig})
+		},
+	}
+	l1Resub := &eth.Resubscriber{
+		MaxFailures: c.L1MaxResubscribeFailures,
+		OnDisconnect: func(err error, failures int) {
+			l1HeadMetrics.RecordReconnect()
+			c.log.Warn("resubscribing after failed L1 subscription", "err", err, "failures", failures)
+		},
+	}
+	l1ConfDepth := &eth.ConfDepth{Depth: c.L1ConfDepth, Headers: c.l1Source}
+	onL1ConfHead := l1ConfDepth.Wrap(c.ctx, func(sig eth.HeadSignal) {
+		l1ConfHeadsFeed.Send(sig)
+	})
+	onL1FinalizedHead := func(sig eth.HeadSignal) {}
+	if c.L1FinalityDepth > 0 {
+		l1FinalityDepth := &eth.ConfDepth{Depth: c.L1FinalityDepth, Headers: c.l1Source}
+		onL1FinalizedHead = l1FinalityDepth.Wrap(c.ctx, func(sig eth.HeadSignal) {
+			for _, eng := range c.l2Engines {
+				eng.NotifyL1Finalized(c.log, sig.Self)
+			}
+		})
+	}
+	l1HeadsSub := l1Resub.Subscribe(c.ctx, func(ctx context.Context) (ethereum.Subscription, error) {
+		return l1Reorgs.WatchHeadChanges(ctx, c.l1Heads, func(sig eth.HeadSignal) {
+			c.l1Chain.AddHead(sig)
+			c.events.Publish(events.L1Head{HeadSignal: sig})
+			onL1ConfHead(sig)
+			onL1FinalizedHead(sig)
 		})
 	})
-	handleUnsubscribe(l1HeadsSub, "l1 heads subscription failed")
+	c.supervisor.AddSubscription("l1 heads", l1HeadsSub)
+
+	l1RemoteHeadSub := l1HeadMetrics.WatchRemoteHead(c.ctx, c.l1BlockNumber, c.L1PollInterval)
+	c.supervisor.AddSubscription("l1 head lag tracking", l1RemoteHeadSub)
 
 	// subscribe to L1 heads for info
-	l1Heads := make(chan eth.HeadSignal, 10)
-	l1HeadsFeed.Subscribe(l1Heads)
+	l1Heads := make(chan events.L1Head, 10)
+	c.supervisor.AddSubscription("l1 heads events", c.events.Subscribe(l1Heads))
+
+	// the L2 blocks derived from the safe and finalized L1 heads are safe and finalized
+	l1LabeledHeadsSub := c.l1LabeledHeads.Watch(c.ctx, func(label eth.HeadLabel, sig eth.HeadSignal) {
+		c.log.Info("New labeled L1 head", "label", label, "head", sig.Self, "parent", sig.Parent)
+		for _, eng := range c.l2Engines {
+			switch label {
+			case eth.SafeHead:
+				eng.NotifyL1Safe(c.log, sig.Self)
+			case eth.FinalizedHead:
+				eng.NotifyL1Finalized(c.log, sig.Self)
+			}
+		}
+	})
+	c.supervisor.AddSubscription("l1 labeled heads tracking", l1LabeledHeadsSub)
+
+	l1HealthSub := c.l1Failover.WatchHealth(c.ctx, c.L1HealthCheckInterval)
+	c.supervisor.AddSubscription("l1 health checks", l1HealthSub)
+
+	// the pending deposits are processed once a L1 block is derived
+	l2Inserted := make(chan l2.PayloadInsertedEvent, 10)
+	c.supervisor.AddSubscription("l2 payload events", c.events.Subscribe(l2Inserted))
+	if c.l1Deposits != nil {
+		l1DepositsResub := &eth.Resubscriber{
+			OnDisconnect: func(err error, failures int) {
+				c.log.Warn("resubscribing after failed L1 deposits subscription", "err", err, "failures", failures)
+			},
+		}
+		l1DepositsSub := l1DepositsResub.Subscribe(c.ctx, c.l1Deposits.Watch)
+		c.supervisor.AddSubscription("l1 deposits", l1DepositsSub)
+	}
+
+	if c.heartbeat != nil {
+		c.supervisor.AddSubscription("heartbeat", c.heartbeat.Start(c.ctx))
+	}
+
+	// the metrics, pprof, health and RPC servers were started before, but serve the state of all other subsystems, and are stopped first
+	if c.metricsServer != nil {
+		c.supervisor.Add("metrics server", c.metricsServer.Stop)
+	}
+	if c.pprofServer != nil {
+		c.supervisor.Add("pprof server", c.pprofServer.Stop)
+	}
+	if c.healthServer != nil {
+		c.supervisor.Add("health server", c.healthServer.Stop)
+	}
+	if c.rpcServer != nil {
+		c.supervisor.Add("rpc server", c.rpcServer.Stop)
+	}
 
 	c.log.Info("Start-up complete!")
 
 	for {
 		select {
+		case ev := <-l2Inserted:
+			if c.l1Deposits != nil {
+				c.l1Deposits.Processed(ev.L1)
+			}
 		case l1Head := <-l1Heads:
-			c.log.Info("New L1 head", "head", l1Head.Self, "parent", l1Head.Parent)
+			c.log.Info("New L1 head", "head", l1Head.Self, "parent", l1Head.Parent, "time", l1Head.Time, "base_fee", l1Head.BaseFee)
 		// TODO: maybe log other info on interval or other chain events (individual engines also log things)
 		case done := <-c.close:
-			c.log.Info("Closing OpNode")
-			// close all tasks
-			for _, f := range unsub {
-				f()
-			}
+			c.log.Info("Closing OpNode", "timeout", c.ShutdownTimeout)
+			// stop all subsystems in order, then abort anything that did not stop in time
+			ctx, cancel := context.WithTimeout(context.Background(), c.ShutdownTimeout)
+			err := c.supervisor.Stop(ctx)
+			cancel()
+			c.cancel()
 			// close L1 data source
 			c.l1Source.Close()
 			// close L2 engines
 			for _, eng := range c.l2Engines {
 				eng.Close()
 			}
-			// signal back everything closed without error
-			done <- nil
+			if err == nil {
+				c.log.Info("Closed OpNode")
+			}
+			done <- err
 			return
 		}
 	}
 }
 
+// newTxManager creates a transaction manager to land the L1 transactions of the key with, through the L1 client.
+func (c *OpNodeCmd) newTxManager(ctx context.Context, name string, key *ecdsa.PrivateKey, l1Eth *ethclient.Client) (*txmgr.TxManager, error) {
+	chainID, err := l1Eth.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch L1 chain ID: %v", err)
+	}
+	return &txmgr.TxManager{
+		Log: c.log.New("txmgr", name),
+		Config: txmgr.Config{
+			ChainID:              chainID,
+			ResubmissionTimeout:  c.TxMgrResubmissionTimeout,
+			ReceiptQueryInterval: txmgr.DefaultReceiptQueryInterval,
+			NumConfirmations:     c.TxMgrNumConfirmations,
+			FeeBumpPercent:       c.TxMgrFeeBumpPercent,
+			MaxFeeCap:            new(big.Int).SetUint64(c.TxMgrMaxFeeCap),
+		},
+		Backend: l1Eth,
+		Key:     key,
+	}, nil
+}
+
+// stopHTTPServers stops the metrics, pprof and health servers that were started, if the node fails to start.
+func (c *OpNodeCmd) stopHTTPServers(ctx context.Context) {
+	if c.metricsServer != nil {
+		_ = c.metricsServer.Stop(ctx)
+	}
+	if c.pprofServer != nil {
+		_ = c.pprofServer.Stop(ctx)
+	}
+	if c.healthServer != nil {
+		_ = c.healthServer.Stop(ctx)
+	}
+}
+
 func (c *OpNodeCmd) Close() error {
 	if c.close != nil {
 		done := make(chan error)
@@ -236,3 +991,41 @@ func (c *OpNodeCmd) Close() error {
 	}
 	return nil
 }
+
+// loadHeadState loads the persisted head state of the engine with the given index, if any
+func (c *OpNodeCmd) loadHeadState(i int) (HeadState, bool) {
+	if c.StateFile == "" {
+		return HeadState{}, false
+	}
+	state, ok, err := EngineStateFile(c.StateFile, i).Load()
+	if err != nil {
+		c.log.Error("failed to load head state", "engine", i, "err", err)
+		return HeadState{}, false
+	}
+	return state, ok
+}
+
+// persistHeads persists the head state of the engine with the given index after every payload it inserts,
+// until the subscription is closed.
+func (c *OpNodeCmd) persistHeads(i int, eng *l2.EngineDriver) ethereum.Subscription {
+	sf := EngineStateFile(c.StateFile, i)
+	inserted := make(chan l2.PayloadInsertedEvent, 10)
+	insertedSub := eng.Events.Subscribe(inserted)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer insertedSub.Unsubscribe()
+		for {
+			select {
+			case ev := <-inserted:
+				heads := eng.L2Heads()
+				state := HeadState{L1Head: ev.L1, L2Head: ev.Payload.ID(), SafeL2: heads.Safe, FinalizedL2: heads.Finalized}
+				if err := sf.Store(state); err != nil {
+					eng.Log.Error("failed to persist head state", "err", err)
+				}
+			case err := <-insertedSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
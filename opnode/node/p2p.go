@@ -0,0 +1,111 @@
+package node
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/p2p"
+)
+
+// gossipTimeout bounds the time to publish a single sequenced L2 block, or to import a single gossiped L2 block into an engine
+const gossipTimeout = 10 * time.Second
+
+type P2PConf struct {
+	Enabled      bool          `ask:"--enabled" help:"Gossip unsafe L2 blocks with peers over libp2p: the sequencer publishes the blocks it sequences, and verifiers import the blocks signed by --rollup.p2p-sequencer as unsafe L2 head, ahead of their confirmation on L1"`
+	ListenAddr   string        `ask:"--listen-addr" help:"Multiaddr to accept the connections of peers on. Empty to only dial the static peers."`
+	StaticPeers  []string      `ask:"--static-peers" help:"Multiaddrs of peers to stay connected to, including their peer ID, e.g. /ip4/10.0.0.1/tcp/9003/p2p/16Uiu2HAm..."`
+	DialInterval time.Duration `ask:"--dial-interval" help:"Interval to redial disconnected static peers at"`
+	PeerKey      string        `ask:"--peer-key" help:"Path of a file with the hex-encoded secp256k1 private key that identifies the node to its peers. Empty to use a random identity."`
+	SequencerKey string        `ask:"--sequencer-key" help:"Path of a file with the hex-encoded private key of --rollup.p2p-sequencer, to sign the sequenced L2 blocks with before publishing them. Requires --sequencer. Empty to not publish blocks."`
+}
+
+func (c *P2PConf) Default() {
+	c.ListenAddr = p2p.DefaultListenAddr
+	c.DialInterval = p2p.DefaultPeerDialInterval
+}
+
+// startP2P starts the libp2p host, and creates the block gossip of the rollup on it.
+// The host runs until it is closed, or until the node is closed.
+func (c *OpNodeCmd) startP2P(rollupConfig *l2.Config) error {
+	var peerKey, signerKey *ecdsa.PrivateKey
+	if c.P2P.PeerKey != "" {
+		key, err := crypto.LoadECDSA(c.P2P.PeerKey)
+		if err != nil {
+			return fmt.Errorf("failed to load p2p peer key: %v", err)
+		}
+		peerKey = key
+	}
+	if c.P2P.SequencerKey != "" {
+		key, err := crypto.LoadECDSA(c.P2P.SequencerKey)
+		if err != nil {
+			return fmt.Errorf("failed to load p2p sequencer key: %v", err)
+		}
+		if addr := crypto.PubkeyToAddress(key.PublicKey); addr != rollupConfig.P2PSequencerAddr {
+			return fmt.Errorf("p2p sequencer key address %s does not match the p2p sequencer address %s of the rollup", addr, rollupConfig.P2PSequencerAddr)
+		}
+		signerKey = key
+	}
+	host, err := p2p.NewHost(c.ctx, p2p.HostConfig{
+		ListenAddr:  c.P2P.ListenAddr,
+		PeerKey:     peerKey,
+		StaticPeers: c.P2P.StaticPeers,
+	}, c.log.New("p2p", "host"))
+	if err != nil {
+		return err
+	}
+	c.log.Info("Started p2p host", "peer_id", host.ID(), "addrs", host.Addrs())
+	c.p2pHost = host
+	c.blockGossip = p2p.NewBlockGossip(rollupConfig, c.Genesis.GetGenesis().L2, host, c.log.New("p2p", "blocks"), signerKey, c.importGossipPayload)
+	return nil
+}
+
+// importGossipPayload imports a gossiped unsafe L2 block into every engine that is not sequencing.
+// Blocks that do not build on the unsafe L2 head of an engine are dropped: the engine derives them from L1 later instead.
+func (c *OpNodeCmd) importGossipPayload(ctx context.Context, from string, payload *l2.ExecutionPayload) {
+	for _, eng := range c.l2Engines {
+		if eng.Sequencing() {
+			continue
+		}
+		importCtx, cancel := context.WithTimeout(ctx, gossipTimeout)
+		if err := eng.ImportUnsafePayload(importCtx, payload); err != nil {
+			eng.Log.Warn("Failed to import gossiped unsafe L2 block", "from", from, "payload", payload.ID(), "err", err)
+		} else {
+			eng.Log.Info("Imported gossiped unsafe L2 block", "from", from, "payload", payload.ID())
+		}
+		cancel()
+	}
+}
+
+// publishSequenced publishes the L2 blocks that the engine sequences on the block gossip, until the subscription is closed.
+// The blocks that the engine derives from L1, while sequencing is stopped, are not published.
+func (c *OpNodeCmd) publishSequenced(eng *l2.EngineDriver) ethereum.Subscription {
+	inserted := make(chan l2.PayloadInsertedEvent, 10)
+	insertedSub := eng.Events.Subscribe(inserted)
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer insertedSub.Unsubscribe()
+		for {
+			select {
+			case ev := <-inserted:
+				if !eng.Sequencing() {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(c.ctx, gossipTimeout)
+				if err := c.blockGossip.Publish(ctx, ev.Payload); err != nil {
+					eng.Log.Error("Failed to publish sequenced L2 block", "payload", ev.Payload.ID(), "err", err)
+				}
+				cancel()
+			case err := <-insertedSub.Err():
+				return err
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
@@ -0,0 +1,39 @@
+package node
+
+import (
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+)
+
+// DefaultPprofPort is the default port to serve the pprof endpoints on
+const DefaultPprofPort = 6060
+
+type PprofConf struct {
+	Enabled bool   `ask:"--enabled" help:"Serve the pprof endpoints at /debug/pprof, to capture CPU and heap profiles of the running node with. Bind to a private address: profiles expose the internals of the node."`
+	Addr    string `ask:"--addr" help:"Address to serve the pprof endpoints on"`
+	Port    int    `ask:"--port" help:"Port to serve the pprof endpoints on"`
+}
+
+func (c *PprofConf) Default() {
+	c.Addr = "127.0.0.1"
+	c.Port = DefaultPprofPort
+}
+
+// Endpoint returns the host:port address to serve the pprof endpoints on
+func (c *PprofConf) Endpoint() string {
+	return net.JoinHostPort(c.Addr, strconv.Itoa(c.Port))
+}
+
+// pprofHandler serves the pprof endpoints at /debug/pprof, like the net/http/pprof package does on the default mux,
+// without exposing anything else that is registered on the default mux.
+func pprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
@@ -0,0 +1,185 @@
+package node
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/naoina/toml"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// RollupConfig is the configuration that all nodes of a rollup must share, to derive the same L2 chain.
+// It is loaded from a JSON or TOML file with --rollup-config, instead of configuring every node with the genesis and rollup flags.
+// Both formats use the same keys.
+type RollupConfig struct {
+	// Genesis anchors of L1 and L2
+	Genesis l2.Genesis `json:"genesis" toml:"genesis"`
+
+	BlockTime         uint64 `json:"blockTime" toml:"blockTime"`
+	MaxSequencerDrift uint64 `json:"maxSequencerDrift" toml:"maxSequencerDrift"`
+	SeqWindowSize     uint64 `json:"seqWindowSize" toml:"seqWindowSize"`
+	// ChannelTimeout and MaxChannelBankSize are optional, the defaults of the flags apply if zero
+	ChannelTimeout     uint64 `json:"channelTimeout,omitempty" toml:"channelTimeout,omitempty"`
+	MaxChannelBankSize uint64 `json:"maxChannelBankSize,omitempty" toml:"maxChannelBankSize,omitempty"`
+	// PayloadV2Time is optional, to schedule the PayloadV2 hardfork
+	PayloadV2Time uint64 `json:"payloadV2Time,omitempty" toml:"payloadV2Time,omitempty"`
+
+	L1ChainID uint64 `json:"l1ChainId" toml:"l1ChainId"`
+	L2ChainID uint64 `json:"l2ChainId" toml:"l2ChainId"`
+
+	DepositContractAddr common.Address `json:"depositContractAddress" toml:"depositContractAddress"`
+	BatchInboxAddr      common.Address `json:"batchInboxAddress" toml:"batchInboxAddress"`
+	BatcherAddr         common.Address `json:"batcherAddress" toml:"batcherAddress"`
+	// SequencerFeeRecipient is optional, to pay the transaction fees of sequenced L2 blocks to
+	SequencerFeeRecipient common.Address `json:"sequencerFeeRecipient,omitempty" toml:"sequencerFeeRecipient,omitempty"`
+	// P2PSequencerAddr is optional, to import the unsafe L2 blocks gossiped by the sequencer with this key
+	P2PSequencerAddr common.Address `json:"p2pSequencerAddress,omitempty" toml:"p2pSequencerAddress,omitempty"`
+	// L1FeeOverhead and L1FeeScalar are optional, the initial L1 fee scalars of the system config
+	L1FeeOverhead uint64 `json:"l1FeeOverhead,omitempty" toml:"l1FeeOverhead,omitempty"`
+	L1FeeScalar   uint64 `json:"l1FeeScalar,omitempty" toml:"l1FeeScalar,omitempty"`
+	// SystemConfigAddr is optional, to track the system config on L1
+	SystemConfigAddr common.Address `json:"systemConfigAddress,omitempty" toml:"systemConfigAddress,omitempty"`
+	// L2OutputOracleAddr and ProposerAddr are optional, to propose outputs to the L2 output oracle
+	L2OutputOracleAddr common.Address `json:"l2OutputOracleAddress,omitempty" toml:"l2OutputOracleAddress,omitempty"`
+	ProposerAddr       common.Address `json:"proposerAddress,omitempty" toml:"proposerAddress,omitempty"`
+}
+
+// LoadRollupConfig reads the rollup config from a JSON or TOML file, by file extension, and checks it.
+func LoadRollupConfig(path string) (*RollupConfig, error) {
+	ext := filepath.Ext(path)
+	if ext != ".json" && ext != ".toml" {
+		return nil, fmt.Errorf("unsupported rollup config format %q, expected a .json or .toml file", ext)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rollup config %q: %v", path, err)
+	}
+	var rc RollupConfig
+	if ext == ".toml" {
+		// unknown keys are rejected by default
+		err = toml.NewDecoder(bytes.NewReader(data)).Decode(&rc)
+	} else {
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&rc)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode rollup config %q: %v", path, err)
+	}
+	if err := rc.Check(); err != nil {
+		return nil, fmt.Errorf("invalid rollup config %q: %w", path, err)
+	}
+	return &rc, nil
+}
+
+// Check rejects rollup configs that are incomplete or inconsistent.
+func (rc *RollupConfig) Check() error {
+	if rc.Genesis.L1.Hash == (common.Hash{}) {
+		return errors.New("missing L1 genesis block hash")
+	}
+	if rc.Genesis.L2.Hash == (common.Hash{}) {
+		return errors.New("missing L2 genesis block hash")
+	}
+	if rc.Genesis.L2.Number != 0 {
+		return fmt.Errorf("L2 genesis must be block 0, got %d", rc.Genesis.L2.Number)
+	}
+	if rc.BlockTime == 0 {
+		return errors.New("block time must be at least 1 second")
+	}
+	if rc.MaxSequencerDrift < rc.BlockTime {
+		return fmt.Errorf("max sequencer drift %d must be at least the block time %d", rc.MaxSequencerDrift, rc.BlockTime)
+	}
+	if rc.L1ChainID == 0 {
+		return errors.New("missing L1 chain ID")
+	}
+	if rc.L2ChainID == 0 {
+		return errors.New("missing L2 chain ID")
+	}
+	if rc.L1ChainID == rc.L2ChainID {
+		return fmt.Errorf("L1 and L2 chain ID must differ, both are %d", rc.L1ChainID)
+	}
+	if rc.DepositContractAddr == (common.Address{}) {
+		return errors.New("missing deposit contract address")
+	}
+	if rc.BatchInboxAddr == (common.Address{}) {
+		return errors.New("missing batch inbox address")
+	}
+	if rc.BatchInboxAddr == rc.DepositContractAddr {
+		return errors.New("batch inbox and deposit contract must differ")
+	}
+	if rc.BatcherAddr == (common.Address{}) {
+		return errors.New("missing batcher address")
+	}
+	return nil
+}
+
+// Apply overrides the genesis and rollup flags with the rollup config.
+// Rollup flags that are not part of the rollup config keep their value.
+func (rc *RollupConfig) Apply(genesis *GenesisConf, rollup *RollupConf) {
+	genesis.L1Hash = rc.Genesis.L1.Hash
+	genesis.L1Num = rc.Genesis.L1.Number
+	genesis.L2Hash = rc.Genesis.L2.Hash
+	rollup.BlockTime = rc.BlockTime
+	rollup.MaxSequencerDrift = rc.MaxSequencerDrift
+	rollup.SeqWindowSize = rc.SeqWindowSize
+	rollup.PayloadV2Time = rc.PayloadV2Time
+	if rc.ChannelTimeout != 0 {
+		rollup.ChannelTimeout = rc.ChannelTimeout
+	}
+	if rc.MaxChannelBankSize != 0 {
+		rollup.MaxChannelBankSize = rc.MaxChannelBankSize
+	}
+	rollup.L1ChainID = rc.L1ChainID
+	rollup.L2ChainID = rc.L2ChainID
+	rollup.DepositContractAddr = rc.DepositContractAddr
+	rollup.BatchInboxAddr = rc.BatchInboxAddr
+	rollup.BatcherAddr = rc.BatcherAddr
+	rollup.SequencerFeeRecipient = rc.SequencerFeeRecipient
+	rollup.L1FeeOverhead = rc.L1FeeOverhead
+	rollup.L1FeeScalar = rc.L1FeeScalar
+	rollup.SystemConfigAddr = rc.SystemConfigAddr
+	// the output oracle is only used by proposers, and may be configured with flags instead
+	if rc.L2OutputOracleAddr != (common.Address{}) {
+		rollup.L2OutputOracleAddr = rc.L2OutputOracleAddr
+	}
+	if rc.ProposerAddr != (common.Address{}) {
+		rollup.ProposerAddr = rc.ProposerAddr
+	}
+	// the p2p sequencer is only used to gossip unsafe L2 blocks, and may be configured with a flag instead
+	if rc.P2PSequencerAddr != (common.Address{}) {
+		rollup.P2PSequencerAddr = rc.P2PSequencerAddr
+	}
+}
+
+type chainIDSource interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+}
+
+// checkChainID rejects an endpoint that serves a different chain than configured.
+// The check is skipped if no chain ID is configured, or if the endpoint is not reachable yet.
+func checkChainID(ctx context.Context, log log.Logger, name string, src chainIDSource, expected uint64) error {
+	if expected == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	chainID, err := src.ChainID(ctx)
+	if err != nil {
+		log.Warn("failed to fetch chain ID, skipping the chain ID check", "endpoint", name, "err", err)
+		return nil
+	}
+	if !chainID.IsUint64() || chainID.Uint64() != expected {
+		return fmt.Errorf("%s has chain ID %s, but the rollup is configured with chain ID %d", name, chainID, expected)
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+func testRollupConfig() RollupConfig {
+	return RollupConfig{
+		Genesis: l2.Genesis{
+			L1: eth.BlockID{Hash: common.Hash{0xa1}, Number: 100},
+			L2: eth.BlockID{Hash: common.Hash{0xb2}, Number: 0},
+		},
+		BlockTime:           2,
+		MaxSequencerDrift:   600,
+		SeqWindowSize:       64,
+		L1ChainID:           900,
+		L2ChainID:           901,
+		DepositContractAddr: common.Address{0xde},
+		BatchInboxAddr:      common.Address{0xff, 0x01},
+		BatcherAddr:         common.Address{0xba},
+	}
+}
+
+func TestRollupConfig_Check(t *testing.T) {
+	valid := testRollupConfig()
+	require.NoError(t, valid.Check())
+
+	for name, mutate := range map[string]func(rc *RollupConfig){
+		"no L1 genesis":       func(rc *RollupConfig) { rc.Genesis.L1.Hash = common.Hash{} },
+		"non-zero L2 genesis": func(rc *RollupConfig) { rc.Genesis.L2.Number = 1 },
+		"no block time":       func(rc *RollupConfig) { rc.BlockTime = 0 },
+		"drift below block":   func(rc *RollupConfig) { rc.MaxSequencerDrift = 1 },
+		"same chain IDs":      func(rc *RollupConfig) { rc.L2ChainID = rc.L1ChainID },
+		"no deposit contract": func(rc *RollupConfig) { rc.DepositContractAddr = common.Address{} },
+		"inbox is deposit":    func(rc *RollupConfig) { rc.BatchInboxAddr = rc.DepositContractAddr },
+		"no batcher":          func(rc *RollupConfig) { rc.BatcherAddr = common.Address{} },
+	} {
+		rc := testRollupConfig()
+		mutate(&rc)
+		require.Error(t, rc.Check(), name)
+	}
+}
+
+func TestLoadRollupConfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "rollup.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+  "genesis": {
+    "l1": {"hash": "0xa100000000000000000000000000000000000000000000000000000000000000", "number": 100},
+    "l2": {"hash": "0xb200000000000000000000000000000000000000000000000000000000000000", "number": 0}
+  },
+  "blockTime": 2,
+  "maxSequencerDrift": 600,
+  "seqWindowSize": 64,
+  "l1ChainId": 900,
+  "l2ChainId": 901,
+  "depositContractAddress": "0xde00000000000000000000000000000000000000",
+  "batchInboxAddress": "0xff01000000000000000000000000000000000000",
+  "batcherAddress": "0xba00000000000000000000000000000000000000"
+}`), 0600))
+	rc, err := LoadRollupConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, testRollupConfig(), *rc)
+
+	var genesis GenesisConf
+	rollup := RollupConf{L2OutputOracleAddr: common.Address{0x0a}, ChannelTimeout: l2.DefaultChannelTimeout, MaxChannelBankSize: l2.DefaultMaxChannelBankSize}
+	rc.Apply(&genesis, &rollup)
+	require.Equal(t, rc.Genesis, genesis.GetGenesis())
+	require.Equal(t, rc.BatchInboxAddr, rollup.GetConfig().BatchInboxAddr)
+	require.Equal(t, common.Address{0x0a}, rollup.L2OutputOracleAddr, "optional addresses that are not in the rollup config keep their flag value")
+	require.Equal(t, uint64(l2.DefaultChannelTimeout), rollup.GetConfig().ChannelTimeout, "the channel timeout defaults to the flag value")
+	require.Equal(t, uint64(l2.DefaultMaxChannelBankSize), rollup.GetConfig().MaxChannelBankSize)
+
+	rc.ChannelTimeout = 50
+	rc.P2PSequencerAddr = common.Address{0x5e}
+	rc.Apply(&genesis, &rollup)
+	require.Equal(t, uint64(50), rollup.GetConfig().ChannelTimeout)
+	require.Equal(t, common.Address{0x5e}, rollup.GetConfig().P2PSequencerAddr)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"blockTime": 2, "blocktimes": 3}`), 0600))
+	_, err = LoadRollupConfig(path)
+	require.Error(t, err, "unknown fields are rejected")
+
+	_, err = LoadRollupConfig(filepath.Join(dir, "rollup.yaml"))
+	require.Error(t, err)
+}
+
+func TestLoadRollupConfig_TOML(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rollup.toml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+blockTime = 2
+maxSequencerDrift = 600
+seqWindowSize = 64
+l1ChainId = 900
+l2ChainId = 901
+depositContractAddress = "0xde00000000000000000000000000000000000000"
+batchInboxAddress = "0xff01000000000000000000000000000000000000"
+batcherAddress = "0xba00000000000000000000000000000000000000"
+
+[genesis.l1]
+hash = "0xa100000000000000000000000000000000000000000000000000000000000000"
+number = 100
+
+[genesis.l2]
+hash = "0xb200000000000000000000000000000000000000000000000000000000000000"
+number = 0
+`), 0600))
+	rc, err := LoadRollupConfig(path)
+	require.NoError(t, err)
+	require.Equal(t, testRollupConfig(), *rc)
+
+	require.NoError(t, os.WriteFile(path, []byte("blockTime = 2\nblockTimes = 3\n"), 0600))
+	_, err = LoadRollupConfig(path)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "blockTimes", "unknown keys are rejected")
+}
@@ -0,0 +1,131 @@
+package node
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// HeadState is the head-tracking state that is persisted across restarts:
+// the last processed L1 head, the L2 head that was derived from it, and the safe and finalized L2 heads.
+type HeadState struct {
+	L1Head eth.BlockID `json:"l1Head"`
+	L2Head eth.BlockID `json:"l2Head"`
+	// SafeL2 and FinalizedL2 are zero in state files of older versions
+	SafeL2      eth.BlockID `json:"safeL2"`
+	FinalizedL2 eth.BlockID `json:"finalizedL2"`
+}
+
+// HeadStateFileName is the name of the head state file in the data directory
+const HeadStateFileName = "heads.json"
+
+// PipelineStateFileName is the name of the derivation pipeline state file in the data directory
+const PipelineStateFileName = "pipeline.json"
+
+// engineFilePath returns the path of the file of the L2 engine with the given index, to not share state between engines:
+// the first engine uses the given path, the other engines a file next to it with the engine index, e.g. heads.1.json.
+func engineFilePath(path string, i int) string {
+	if i == 0 {
+		return path
+	}
+	ext := filepath.Ext(path)
+	return fmt.Sprintf("%s.%d%s", strings.TrimSuffix(path, ext), i, ext)
+}
+
+// EngineStateFile returns the state file of the L2 engine with the given index, see engineFilePath.
+func EngineStateFile(path string, i int) *StateFile {
+	return &StateFile{Path: engineFilePath(path, i)}
+}
+
+// StateFile persists the HeadState in a JSON file, to resume derivation after a restart,
+// when the engine cannot tell where derivation left off.
+type StateFile struct {
+	Path string
+}
+
+// Load reads the head state, or returns false if the state file does not exist yet.
+func (sf *StateFile) Load() (HeadState, bool, error) {
+	var state HeadState
+	ok, err := loadJSON(sf.Path, &state)
+	return state, ok, err
+}
+
+// Store writes the head state. The state file is replaced atomically, so a crash never leaves a partially written state.
+func (sf *StateFile) Store(state HeadState) error {
+	return storeJSON(sf.Path, state)
+}
+
+// PipelineFile persists the state of the derivation pipeline of an engine in a JSON file,
+// to resume derivation after a restart without replaying the L1 blocks the pipeline state was built from.
+type PipelineFile struct {
+	Path string
+}
+
+var _ l2.PipelineStore = (*PipelineFile)(nil)
+
+// EnginePipelineFile returns the pipeline state file of the L2 engine with the given index, see engineFilePath.
+func EnginePipelineFile(path string, i int) *PipelineFile {
+	return &PipelineFile{Path: engineFilePath(path, i)}
+}
+
+// LoadPipeline reads the pipeline state, or returns false if the state file does not exist yet.
+func (pf *PipelineFile) LoadPipeline() (l2.PipelineState, bool, error) {
+	var state l2.PipelineState
+	ok, err := loadJSON(pf.Path, &state)
+	return state, ok, err
+}
+
+// StorePipeline writes the pipeline state, replacing the state file atomically.
+func (pf *PipelineFile) StorePipeline(state l2.PipelineState) error {
+	return storeJSON(pf.Path, state)
+}
+
+// loadJSON decodes the JSON file at the given path into v, or returns false if the file does not exist.
+func loadJSON(path string, v interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to read state file %q: %v", path, err)
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return false, fmt.Errorf("failed to decode state file %q: %v", path, err)
+	}
+	return true, nil
+}
+
+// storeJSON writes v as JSON to the given path. The file is replaced atomically,
+// so a crash never leaves a partially written state.
+func storeJSON(path string, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode state: %v", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary state file: %v", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after the rename
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write temporary state file: %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync temporary state file: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary state file: %v", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace state file %q: %v", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package node
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+func TestStateFile(t *testing.T) {
+	dir := t.TempDir()
+	sf := &StateFile{Path: filepath.Join(dir, HeadStateFileName)}
+
+	_, ok, err := sf.Load()
+	require.NoError(t, err)
+	require.False(t, ok, "no state persisted yet")
+
+	state := HeadState{
+		L1Head:      eth.BlockID{Hash: common.Hash{0x01}, Number: 10},
+		L2Head:      eth.BlockID{Hash: common.Hash{0x02}, Number: 20},
+		SafeL2:      eth.BlockID{Hash: common.Hash{0x03}, Number: 19},
+		FinalizedL2: eth.BlockID{Hash: common.Hash{0x04}, Number: 5},
+	}
+	require.NoError(t, sf.Store(state))
+	loaded, ok, err := sf.Load()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state, loaded)
+
+	state.L2Head = eth.BlockID{Hash: common.Hash{0x05}, Number: 21}
+	require.NoError(t, sf.Store(state))
+	loaded, _, err = sf.Load()
+	require.NoError(t, err)
+	require.Equal(t, state, loaded, "the state is replaced")
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "no temporary files are left behind")
+
+	require.NoError(t, os.WriteFile(sf.Path, []byte("{"), 0600))
+	_, _, err = sf.Load()
+	require.Error(t, err)
+}
+
+func TestEngineStateFile(t *testing.T) {
+	path := filepath.Join("data", HeadStateFileName)
+	require.Equal(t, path, EngineStateFile(path, 0).Path)
+	require.Equal(t, filepath.Join("data", "heads.1.json"), EngineStateFile(path, 1).Path)
+	require.Equal(t, "heads.2", EngineStateFile("heads", 2).Path)
+
+	// engines do not overwrite the state of each other
+	dir := t.TempDir()
+	path = filepath.Join(dir, HeadStateFileName)
+	require.NoError(t, EngineStateFile(path, 0).Store(HeadState{L2Head: eth.BlockID{Number: 1}}))
+	require.NoError(t, EngineStateFile(path, 1).Store(HeadState{L2Head: eth.BlockID{Number: 2}}))
+	state, _, err := EngineStateFile(path, 0).Load()
+	require.NoError(t, err)
+	require.Equal(t, uint64(1), state.L2Head.Number)
+}
+
+func TestPipelineFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), PipelineStateFileName)
+	pf := EnginePipelineFile(path, 0)
+	_, ok, err := pf.LoadPipeline()
+	require.NoError(t, err)
+	require.False(t, ok, "no state persisted yet")
+
+	lastFrame := uint16(1)
+	state := l2.PipelineState{
+		L1Base: eth.BlockID{Hash: common.Hash{0x01}, Number: 10},
+		Bank: l2.ChannelBankState{
+			Channels: []l2.ChannelState{{ID: l2.ChannelID{0x02}, OpenBlock: 9, Frames: map[uint16]hexutil.Bytes{1: {0x03}}, LastFrame: &lastFrame}},
+			Closed:   map[l2.ChannelID]uint64{{0x04}: 8},
+		},
+		Batches:  []*l2.BatchData{{EpochNum: 9, Timestamp: 20, Transactions: []l2.Data{{0x06}}}},
+		Origins:  []*types.Header{{Number: big.NewInt(10), Difficulty: big.NewInt(0)}},
+		L2Parent: common.Hash{0x05},
+	}
+	require.NoError(t, pf.StorePipeline(state))
+	loaded, ok, err := pf.LoadPipeline()
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, state.L1Base, loaded.L1Base)
+	require.Equal(t, state.Bank, loaded.Bank)
+	require.Equal(t, state.L2Parent, loaded.L2Parent)
+	require.Len(t, loaded.Batches, 1)
+	require.Equal(t, state.Batches[0], loaded.Batches[0])
+	require.Equal(t, state.Origins[0].Hash(), loaded.Origins[0].Hash())
+
+	require.Equal(t, filepath.Join(filepath.Dir(path), "pipeline.1.json"), EnginePipelineFile(path, 1).Path)
+}
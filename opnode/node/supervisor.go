@@ -0,0 +1,90 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+// DefaultShutdownTimeout bounds the graceful shutdown of all subsystems, after which the node closes regardless
+const DefaultShutdownTimeout = 30 * time.Second
+
+type component struct {
+	name string
+	stop func(ctx context.Context) error
+}
+
+// Supervisor tracks the running subsystems of the node, to stop them in reverse order of starting:
+// subsystems that feed others are started after them, e.g. the engine drivers are started before the L1 head trackers
+// that feed them, so the trackers are stopped first, and the drivers do not get new work while they shut down.
+type Supervisor struct {
+	Log log.Logger
+
+	mu         sync.Mutex
+	components []component
+}
+
+// Add adds a subsystem that was started, with the function to stop it gracefully with.
+// The stop function must return once the subsystem stopped, or with an error once the ctx is done.
+func (s *Supervisor) Add(name string, stop func(ctx context.Context) error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.components = append(s.components, component{name: name, stop: stop})
+}
+
+// AddSubscription adds a subsystem that runs as subscription, and logs the error if it stops unexpectedly.
+func (s *Supervisor) AddSubscription(name string, sub ethereum.Subscription) {
+	go func() {
+		err, ok := <-sub.Err()
+		if !ok {
+			return
+		}
+		s.Log.Error("subsystem unexpectedly failed", "name", name, "err", err)
+	}()
+	s.Add(name, func(ctx context.Context) error {
+		return unsubscribe(ctx, sub)
+	})
+}
+
+// Stop stops all subsystems in reverse order of starting. Subsystems that fail to stop, or do not stop before the ctx is done,
+// do not block the remaining subsystems from stopping. The first error is returned.
+func (s *Supervisor) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	components := s.components
+	s.components = nil
+	s.mu.Unlock()
+
+	var result error
+	for i := len(components) - 1; i >= 0; i-- {
+		comp := components[i]
+		start := time.Now()
+		if err := comp.stop(ctx); err != nil {
+			s.Log.Error("failed to stop subsystem", "name", comp.name, "err", err)
+			if result == nil {
+				result = fmt.Errorf("failed to stop %s: %w", comp.name, err)
+			}
+			continue
+		}
+		s.Log.Debug("stopped subsystem", "name", comp.name, "duration", time.Since(start))
+	}
+	return result
+}
+
+// unsubscribe unsubscribes and waits for the subscription to stop, or returns the ctx error if it is done first.
+func unsubscribe(ctx context.Context, sub ethereum.Subscription) error {
+	done := make(chan struct{})
+	go func() {
+		sub.Unsubscribe()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,43 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/internal/testlog"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSupervisor_Stop(t *testing.T) {
+	s := &Supervisor{Log: testlog.Logger(t, log.LvlDebug)}
+	var stopped []string
+	stop := func(name string, err error) func(ctx context.Context) error {
+		return func(ctx context.Context) error {
+			stopped = append(stopped, name)
+			return err
+		}
+	}
+	s.Add("a", stop("a", nil))
+	s.Add("b", stop("b", errors.New("boom")))
+	// a subsystem that does not stop in time
+	s.AddSubscription("c", event.NewSubscription(func(quit <-chan struct{}) error {
+		<-quit
+		time.Sleep(time.Second)
+		return nil
+	}))
+	s.Add("d", stop("d", nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := s.Stop(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded, "the first error is returned")
+	require.Equal(t, []string{"d", "b", "a"}, stopped, "subsystems stop in reverse order, regardless of failures")
+
+	stopped = nil
+	require.NoError(t, s.Stop(context.Background()), "stopped subsystems are not stopped again")
+	require.Empty(t, stopped)
+}
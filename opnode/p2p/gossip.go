@@ -0,0 +1,225 @@
+// Package p2p gossips unsafe L2 blocks between rollup nodes:
+// the sequencer broadcasts the payloads of the L2 blocks it sequences,
+// and verifiers import them as their unsafe L2 head, ahead of the confirmation of the blocks on L1.
+//
+// Host runs the gossip on libp2p gossipsub, over TCP connections to static peers.
+// TODO: discover peers, instead of only connecting to static peers.
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	lru "github.com/hashicorp/golang-lru"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+const (
+	// maxPayloadSize limits the decompressed size of a gossiped payload
+	maxPayloadSize = l2.MaxChannelDataLen
+	// maxTimeDrift is how far ahead of the local clock the timestamp of a gossiped payload may be
+	maxTimeDrift = time.Minute
+	// seenPayloads is the number of recent payload hashes that are remembered, to ignore duplicates
+	seenPayloads = 1000
+)
+
+// Message is a message received on a gossip topic
+type Message struct {
+	// From identifies the peer that the message was received from
+	From string
+	Data []byte
+}
+
+// Subscription is a subscription to a gossip topic
+type Subscription interface {
+	// Next blocks until the next message on the topic is received, the ctx is done, or the subscription is cancelled.
+	Next(ctx context.Context) (*Message, error)
+	Cancel()
+}
+
+// PubSub is the publish-subscribe gossip network, like libp2p gossipsub, to broadcast messages to all peers with
+type PubSub interface {
+	Publish(ctx context.Context, topic string, data []byte) error
+	Subscribe(topic string) (Subscription, error)
+}
+
+// BlocksTopic is the gossip topic of the unsafe L2 blocks of the rollup with the given L2 genesis block
+func BlocksTopic(genesis eth.BlockID) string {
+	return fmt.Sprintf("/optimism/%s/blocks/v1", genesis.Hash)
+}
+
+// EncodePayload encodes an execution payload as gossip message: the compression algorithm, followed by the compressed JSON payload.
+func EncodePayload(payload *l2.ExecutionPayload) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %v", err)
+	}
+	compressed, err := l2.Codecs[l2.ZlibCompression].Compress(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compress payload: %v", err)
+	}
+	return append([]byte{byte(l2.ZlibCompression)}, compressed...), nil
+}
+
+// DecodePayload decodes an execution payload from a gossip message
+func DecodePayload(data []byte) (*l2.ExecutionPayload, error) {
+	if len(data) == 0 {
+		return nil, errors.New("empty message")
+	}
+	codec, ok := l2.Codecs[l2.CompressionAlgo(data[0])]
+	if !ok {
+		return nil, fmt.Errorf("unknown compression algorithm %d", data[0])
+	}
+	raw, err := codec.Decompress(data[1:], maxPayloadSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress payload: %v", err)
+	}
+	var payload l2.ExecutionPayload
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return nil, fmt.Errorf("failed to decode payload: %v", err)
+	}
+	return &payload, nil
+}
+
+// UnsafePayloadFn is called for every new valid payload received from the given peer
+type UnsafePayloadFn func(ctx context.Context, from string, payload *l2.ExecutionPayload)
+
+// BlockGossip publishes and receives the payloads of unsafe L2 blocks on the blocks topic of the rollup.
+// Payloads are published in envelopes signed by the sequencer.
+// Received payloads are validated before they are passed on: payloads that are not signed by the sequencer,
+// malformed payloads, duplicates, and payloads with a timestamp too far in the future are dropped.
+type BlockGossip struct {
+	PubSub PubSub
+	Topic  string
+	Log    log.Logger
+	// SignerKey is optional, to sign the published payloads with. It is required to publish.
+	SignerKey *ecdsa.PrivateKey
+	// SequencerAddr is the address of the sequencer key that received payloads must be signed with
+	SequencerAddr common.Address
+	// OnPayload is called for every valid payload, e.g. to import it as unsafe L2 head
+	OnPayload UnsafePayloadFn
+
+	seen *lru.Cache
+}
+
+// NewBlockGossip creates the block gossip of the rollup with the given L2 genesis block:
+// received payloads must be signed by the P2PSequencerAddr of the rollup config.
+// The signerKey is optional, and only required to publish payloads.
+func NewBlockGossip(cfg *l2.Config, genesis eth.BlockID, ps PubSub, log log.Logger, signerKey *ecdsa.PrivateKey, onPayload UnsafePayloadFn) *BlockGossip {
+	return &BlockGossip{
+		PubSub:        ps,
+		Topic:         BlocksTopic(genesis),
+		Log:           log,
+		SignerKey:     signerKey,
+		SequencerAddr: cfg.P2PSequencerAddr,
+		OnPayload:     onPayload,
+	}
+}
+
+func (g *BlockGossip) markSeen(hash common.Hash) (seenBefore bool) {
+	if g.seen == nil {
+		g.seen, _ = lru.New(seenPayloads)
+	}
+	seenBefore, _ = g.seen.ContainsOrAdd(hash, struct{}{})
+	return seenBefore
+}
+
+// Publish broadcasts the payload of a new unsafe L2 block
+func (g *BlockGossip) Publish(ctx context.Context, payload *l2.ExecutionPayload) error {
+	if g.SignerKey == nil {
+		return errors.New("cannot publish payload without signer key")
+	}
+	data, err := EncodePayload(payload)
+	if err != nil {
+		return err
+	}
+	envelope, err := SignEnvelope(g.SignerKey, g.Topic, data)
+	if err != nil {
+		return err
+	}
+	g.markSeen(payload.BlockHash)
+	return g.PubSub.Publish(ctx, g.Topic, envelope)
+}
+
+// validate checks that a received payload may be passed on
+func (g *BlockGossip) validate(payload *l2.ExecutionPayload) error {
+	if payload.BlockHash == (common.Hash{}) {
+		return errors.New("missing block hash")
+	}
+	if len(payload.Transactions) == 0 {
+		return errors.New("missing L1 info deposit")
+	}
+	if t := time.Unix(int64(payload.Timestamp), 0); time.Until(t) > maxTimeDrift {
+		return fmt.Errorf("timestamp %s is too far in the future", t)
+	}
+	return nil
+}
+
+// open checks that the envelope is signed by the sequencer, and decodes the payload
+func (g *BlockGossip) open(envelope []byte) (*l2.ExecutionPayload, error) {
+	signer, data, err := OpenEnvelope(g.Topic, envelope)
+	if err != nil {
+		return nil, err
+	}
+	if signer != g.SequencerAddr {
+		return nil, fmt.Errorf("payload signed by %s instead of sequencer %s: %w", signer, g.SequencerAddr, UnauthorizedSignerErr)
+	}
+	return DecodePayload(data)
+}
+
+// Subscribe receives the payloads on the blocks topic, until the subscription is closed or fails.
+func (g *BlockGossip) Subscribe(ctx context.Context) (ethereum.Subscription, error) {
+	if g.SequencerAddr == (common.Address{}) {
+		return nil, errors.New("cannot verify received payloads without sequencer address")
+	}
+	sub, err := g.PubSub.Subscribe(g.Topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to subscribe to %s: %v", g.Topic, err)
+	}
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		defer sub.Cancel()
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+		for {
+			msg, err := sub.Next(ctx)
+			if err != nil {
+				select {
+				case <-quit:
+					return nil
+				default:
+					return err
+				}
+			}
+			payload, err := g.open(msg.Data)
+			if err != nil {
+				g.Log.Warn("Dropping malformed or unauthorized gossip payload", "from", msg.From, "err", err)
+				continue
+			}
+			if err := g.validate(payload); err != nil {
+				g.Log.Warn("Dropping invalid gossip payload", "from", msg.From, "payload", payload.ID(), "err", err)
+				continue
+			}
+			if g.markSeen(payload.BlockHash) {
+				continue
+			}
+			g.Log.Debug("Received gossip payload", "from", msg.From, "payload", payload.ID())
+			if g.OnPayload != nil {
+				g.OnPayload(ctx, msg.From, payload)
+			}
+		}
+	}), nil
+}
@@ -0,0 +1,141 @@
+package p2p
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// memPubSub is an in-memory PubSub that delivers every published message to all subscribers of the topic
+type memPubSub struct {
+	mu   sync.Mutex
+	subs map[string][]chan *Message
+}
+
+func (m *memPubSub) Publish(ctx context.Context, topic string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, ch := range m.subs[topic] {
+		ch <- &Message{From: "peer", Data: data}
+	}
+	return nil
+}
+
+func (m *memPubSub) Subscribe(topic string) (Subscription, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.subs == nil {
+		m.subs = make(map[string][]chan *Message)
+	}
+	ch := make(chan *Message, 10)
+	m.subs[topic] = append(m.subs[topic], ch)
+	return memSub(ch), nil
+}
+
+type memSub chan *Message
+
+func (s memSub) Next(ctx context.Context) (*Message, error) {
+	select {
+	case msg := <-s:
+		return msg, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s memSub) Cancel() {}
+
+func testPayload(n uint64, timestamp uint64) *l2.ExecutionPayload {
+	return &l2.ExecutionPayload{
+		ParentHash:   common.Hash{byte(n - 1)},
+		BlockNumber:  l2.Uint64Quantity(n),
+		Timestamp:    l2.Uint64Quantity(timestamp),
+		BlockHash:    common.Hash{byte(n)},
+		ExtraData:    l2.BytesMax32{},
+		Transactions: []l2.Data{{0x7e, 0x01}},
+	}
+}
+
+func TestPayloadEncoding(t *testing.T) {
+	payload := testPayload(1, 1000)
+	data, err := EncodePayload(payload)
+	require.NoError(t, err)
+	out, err := DecodePayload(data)
+	require.NoError(t, err)
+	require.Equal(t, payload, out)
+
+	_, err = DecodePayload(nil)
+	require.Error(t, err)
+	_, err = DecodePayload(append([]byte{0xff}, data[1:]...))
+	require.Error(t, err, "unknown compression algorithm")
+}
+
+func TestBlockGossip(t *testing.T) {
+	ps := new(memPubSub)
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	cfg := &l2.Config{P2PSequencerAddr: crypto.PubkeyToAddress(key.PublicKey)}
+	genesis := eth.BlockID{Hash: common.Hash{0x01}}
+	topic := BlocksTopic(genesis)
+	received := make(chan *l2.ExecutionPayload, 10)
+	verifier := NewBlockGossip(cfg, genesis, ps, log.New(), nil, func(ctx context.Context, from string, payload *l2.ExecutionPayload) {
+		received <- payload
+	})
+	_, err = NewBlockGossip(&l2.Config{}, genesis, ps, log.New(), nil, nil).Subscribe(context.Background())
+	require.Error(t, err, "cannot verify payloads without sequencer address in the rollup config")
+	sub, err := verifier.Subscribe(context.Background())
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+
+	sequencer := &BlockGossip{PubSub: ps, Topic: topic, Log: log.New(), SignerKey: key}
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	impostor := &BlockGossip{PubSub: ps, Topic: topic, Log: log.New(), SignerKey: otherKey}
+	now := uint64(time.Now().Unix())
+	future := testPayload(2, now+3600)
+	payload := testPayload(1, now)
+	require.NoError(t, sequencer.Publish(context.Background(), future))
+	require.NoError(t, sequencer.Publish(context.Background(), payload))
+	require.NoError(t, sequencer.Publish(context.Background(), payload))
+	require.NoError(t, ps.Publish(context.Background(), topic, []byte("garbage")))
+	require.NoError(t, impostor.Publish(context.Background(), testPayload(4, now)))
+	last := testPayload(3, now)
+	require.NoError(t, sequencer.Publish(context.Background(), last))
+
+	require.Equal(t, payload, <-received, "payload from the future is dropped")
+	require.Equal(t, last, <-received, "duplicates, malformed and unauthorized messages are dropped")
+	require.Empty(t, received)
+}
+
+func TestSignedEnvelope(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	envelope, err := SignEnvelope(key, "topic", []byte("data"))
+	require.NoError(t, err)
+
+	signer, data, err := OpenEnvelope("topic", envelope)
+	require.NoError(t, err)
+	require.Equal(t, crypto.PubkeyToAddress(key.PublicKey), signer)
+	require.Equal(t, []byte("data"), data)
+
+	signer, _, err = OpenEnvelope("other topic", envelope)
+	if err == nil {
+		require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), signer, "signature does not carry over to other topics")
+	}
+	envelope[len(envelope)-1] ^= 1
+	signer, _, err = OpenEnvelope("topic", envelope)
+	if err == nil {
+		require.NotEqual(t, crypto.PubkeyToAddress(key.PublicKey), signer, "data is signed")
+	}
+	_, _, err = OpenEnvelope("topic", []byte("short"))
+	require.Error(t, err)
+}
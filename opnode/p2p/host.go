@@ -0,0 +1,301 @@
+package p2p
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+	csms "github.com/libp2p/go-conn-security-multistream"
+	blankhost "github.com/libp2p/go-libp2p-blankhost"
+	p2pcrypto "github.com/libp2p/go-libp2p-core/crypto"
+	"github.com/libp2p/go-libp2p-core/host"
+	"github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/libp2p/go-libp2p-core/peerstore"
+	noise "github.com/libp2p/go-libp2p-noise"
+	"github.com/libp2p/go-libp2p-peerstore/pstoremem"
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	pubsub_pb "github.com/libp2p/go-libp2p-pubsub/pb"
+	swarm "github.com/libp2p/go-libp2p-swarm"
+	tptu "github.com/libp2p/go-libp2p-transport-upgrader"
+	yamux "github.com/libp2p/go-libp2p-yamux"
+	msmux "github.com/libp2p/go-stream-muxer-multistream"
+	tcp "github.com/libp2p/go-tcp-transport"
+	ma "github.com/multiformats/go-multiaddr"
+)
+
+const (
+	// DefaultListenAddr is the default multiaddr to accept the connections of peers on
+	DefaultListenAddr = "/ip4/0.0.0.0/tcp/9003"
+	// DefaultPeerDialInterval is the default interval to redial disconnected static peers at
+	DefaultPeerDialInterval = 30 * time.Second
+	// maxMessageSize limits the size of gossip messages: a signed envelope of a compressed payload
+	maxMessageSize = 1 << 20
+	// yamuxID is the protocol ID of the yamux stream multiplexer
+	yamuxID = "/yamux/1.0.0"
+)
+
+// HostConfig configures the libp2p host to gossip with
+type HostConfig struct {
+	// ListenAddr is the multiaddr to accept the connections of peers on, e.g. /ip4/0.0.0.0/tcp/9003. Empty to only dial.
+	ListenAddr string
+	// PeerKey is optional, the secp256k1 key that identifies the host to its peers. A random key is used if nil.
+	PeerKey *ecdsa.PrivateKey
+	// StaticPeers are the multiaddrs of the peers to stay connected to, including their peer ID,
+	// e.g. /ip4/10.0.0.1/tcp/9003/p2p/16Uiu2HAm...
+	StaticPeers []string
+}
+
+// Host is a libp2p host that gossips with gossipsub over TCP, secured with noise and multiplexed with yamux.
+// Host implements PubSub.
+type Host struct {
+	host   host.Host
+	pubsub *pubsub.PubSub
+	peers  []peer.AddrInfo
+	log    log.Logger
+
+	topicsLock sync.Mutex
+	topics     map[string]*pubsub.Topic
+}
+
+var _ PubSub = (*Host)(nil)
+
+// NewHost creates a libp2p host, and starts to listen for peers. The host runs until closed, or until the ctx is done.
+func NewHost(ctx context.Context, cfg HostConfig, log log.Logger) (*Host, error) {
+	peers := make([]peer.AddrInfo, 0, len(cfg.StaticPeers))
+	for i, addr := range cfg.StaticPeers {
+		info, err := peer.AddrInfoFromString(addr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid static peer %d (%s): %v", i, addr, err)
+		}
+		peers = append(peers, *info)
+	}
+	var priv p2pcrypto.PrivKey
+	var err error
+	if cfg.PeerKey != nil {
+		priv, err = p2pcrypto.UnmarshalSecp256k1PrivateKey(crypto.FromECDSA(cfg.PeerKey))
+	} else {
+		priv, _, err = p2pcrypto.GenerateSecp256k1Key(rand.Reader)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer key: %v", err)
+	}
+	id, err := peer.IDFromPrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive peer ID: %v", err)
+	}
+	ps, err := pstoremem.NewPeerstore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create peerstore: %v", err)
+	}
+	if err := ps.AddPrivKey(id, priv); err != nil {
+		return nil, err
+	}
+	if err := ps.AddPubKey(id, priv.GetPublic()); err != nil {
+		return nil, err
+	}
+	net, err := newSwarm(id, priv, ps)
+	if err != nil {
+		_ = ps.Close()
+		return nil, err
+	}
+	if cfg.ListenAddr != "" {
+		addr, err := ma.NewMultiaddr(cfg.ListenAddr)
+		if err != nil {
+			_ = net.Close()
+			return nil, fmt.Errorf("invalid listen address %q: %v", cfg.ListenAddr, err)
+		}
+		if err := net.Listen(addr); err != nil {
+			_ = net.Close()
+			return nil, fmt.Errorf("failed to listen on %s: %v", addr, err)
+		}
+	}
+	h := blankhost.NewBlankHost(net)
+	gossip, err := pubsub.NewGossipSub(ctx, h,
+		pubsub.WithMaxMessageSize(maxMessageSize),
+		// the blocks are signed by the sequencer, the peers relaying them do not need to be known
+		pubsub.WithMessageSignaturePolicy(pubsub.StrictNoSign),
+		pubsub.WithMessageIdFn(messageID),
+	)
+	if err != nil {
+		_ = h.Close()
+		return nil, fmt.Errorf("failed to start gossipsub: %v", err)
+	}
+	return &Host{
+		host:   h,
+		pubsub: gossip,
+		peers:  peers,
+		log:    log,
+		topics: make(map[string]*pubsub.Topic),
+	}, nil
+}
+
+// newSwarm creates the network of the host: TCP connections, secured with noise, and multiplexed with yamux.
+func newSwarm(id peer.ID, priv p2pcrypto.PrivKey, ps peerstore.Peerstore) (*swarm.Swarm, error) {
+	net, err := swarm.NewSwarm(id, ps)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create swarm: %v", err)
+	}
+	security, err := noise.New(priv)
+	if err != nil {
+		_ = net.Close()
+		return nil, fmt.Errorf("failed to create noise transport: %v", err)
+	}
+	secMuxer := new(csms.SSMuxer)
+	secMuxer.AddTransport(noise.ID, security)
+	streamMuxer := msmux.NewBlankTransport()
+	streamMuxer.AddTransport(yamuxID, yamux.DefaultTransport)
+	upgrader, err := tptu.New(secMuxer, streamMuxer)
+	if err != nil {
+		_ = net.Close()
+		return nil, fmt.Errorf("failed to create connection upgrader: %v", err)
+	}
+	transport, err := tcp.NewTCPTransport(upgrader, nil)
+	if err != nil {
+		_ = net.Close()
+		return nil, fmt.Errorf("failed to create TCP transport: %v", err)
+	}
+	if err := net.AddTransport(transport); err != nil {
+		_ = net.Close()
+		return nil, fmt.Errorf("failed to add TCP transport: %v", err)
+	}
+	return net, nil
+}
+
+// messageID identifies gossip messages by their content, so the same block that is relayed by different peers is only delivered once
+func messageID(msg *pubsub_pb.Message) string {
+	return string(crypto.Keccak256(msg.Data))
+}
+
+// ID is the peer ID of the host
+func (h *Host) ID() string {
+	return h.host.ID().String()
+}
+
+// Addrs are the multiaddrs that the host listens on, including the peer ID, to connect to the host as static peer with
+func (h *Host) Addrs() []string {
+	addrs, err := peer.AddrInfoToP2pAddrs(&peer.AddrInfo{ID: h.host.ID(), Addrs: h.host.Addrs()})
+	if err != nil {
+		return nil
+	}
+	out := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		out = append(out, addr.String())
+	}
+	return out
+}
+
+// PeerCount is the number of connected peers
+func (h *Host) PeerCount() int {
+	return len(h.host.Network().Peers())
+}
+
+// Connect connects to the peer with the given multiaddr, including its peer ID
+func (h *Host) Connect(ctx context.Context, addr string) error {
+	info, err := peer.AddrInfoFromString(addr)
+	if err != nil {
+		return fmt.Errorf("invalid peer address %q: %v", addr, err)
+	}
+	return h.host.Connect(ctx, *info)
+}
+
+// KeepConnected dials the static peers that are not connected, right away and then every interval,
+// until the subscription is closed.
+func (h *Host) KeepConnected(ctx context.Context, interval time.Duration) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			for _, info := range h.peers {
+				if h.host.Network().Connectedness(info.ID) == network.Connected {
+					continue
+				}
+				dialCtx, cancel := context.WithTimeout(ctx, interval)
+				if err := h.host.Connect(dialCtx, info); err != nil {
+					h.log.Warn("Failed to connect to static peer", "peer", info.ID, "err", err)
+				} else {
+					h.log.Info("Connected to static peer", "peer", info.ID)
+				}
+				cancel()
+			}
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-quit:
+				return nil
+			}
+		}
+	})
+}
+
+// topic joins the topic once, topics can only be joined once per host
+func (h *Host) topic(name string) (*pubsub.Topic, error) {
+	h.topicsLock.Lock()
+	defer h.topicsLock.Unlock()
+	if t, ok := h.topics[name]; ok {
+		return t, nil
+	}
+	t, err := h.pubsub.Join(name)
+	if err != nil {
+		return nil, err
+	}
+	h.topics[name] = t
+	return t, nil
+}
+
+// Publish broadcasts the data to the peers on the topic
+func (h *Host) Publish(ctx context.Context, topic string, data []byte) error {
+	t, err := h.topic(topic)
+	if err != nil {
+		return fmt.Errorf("failed to join topic %s: %v", topic, err)
+	}
+	return t.Publish(ctx, data)
+}
+
+// Subscribe receives the messages of peers on the topic. Messages that the host published itself are not received.
+func (h *Host) Subscribe(topic string) (Subscription, error) {
+	t, err := h.topic(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join topic %s: %v", topic, err)
+	}
+	sub, err := t.Subscribe()
+	if err != nil {
+		return nil, err
+	}
+	return &hostSubscription{sub: sub, self: h.host.ID()}, nil
+}
+
+// Close disconnects from all peers, and stops the host
+func (h *Host) Close() error {
+	return h.host.Close()
+}
+
+type hostSubscription struct {
+	sub  *pubsub.Subscription
+	self peer.ID
+}
+
+func (s *hostSubscription) Next(ctx context.Context) (*Message, error) {
+	for {
+		msg, err := s.sub.Next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if msg.ReceivedFrom == s.self {
+			continue
+		}
+		return &Message{From: msg.ReceivedFrom.String(), Data: msg.Data}, nil
+	}
+}
+
+func (s *hostSubscription) Cancel() {
+	s.sub.Cancel()
+}
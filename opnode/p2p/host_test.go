@@ -0,0 +1,62 @@
+package p2p
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+func TestHostGossip(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
+	defer cancel()
+
+	seqHost, err := NewHost(ctx, HostConfig{ListenAddr: "/ip4/127.0.0.1/tcp/0"}, log.New())
+	require.NoError(t, err)
+	defer seqHost.Close()
+	require.NotEmpty(t, seqHost.Addrs())
+
+	verHost, err := NewHost(ctx, HostConfig{StaticPeers: seqHost.Addrs()}, log.New())
+	require.NoError(t, err)
+	defer verHost.Close()
+	peersSub := verHost.KeepConnected(ctx, time.Second)
+	defer peersSub.Unsubscribe()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	cfg := &l2.Config{P2PSequencerAddr: crypto.PubkeyToAddress(key.PublicKey)}
+	genesis := eth.BlockID{Hash: common.Hash{0x01}}
+	received := make(chan *l2.ExecutionPayload, 10)
+	senders := make(chan string, 10)
+	verifier := NewBlockGossip(cfg, genesis, verHost, log.New(), nil, func(ctx context.Context, from string, payload *l2.ExecutionPayload) {
+		senders <- from
+		received <- payload
+	})
+	sub, err := verifier.Subscribe(ctx)
+	require.NoError(t, err)
+	defer sub.Unsubscribe()
+	sequencer := NewBlockGossip(cfg, genesis, seqHost, log.New(), key, nil)
+
+	// the gossipsub mesh forms shortly after the peers connect, publish until the payload arrives
+	now := uint64(time.Now().Unix())
+	for i := uint64(1); ; i++ {
+		require.NoError(t, sequencer.Publish(ctx, testPayload(i, now)))
+		select {
+		case payload := <-received:
+			require.Equal(t, seqHost.ID(), <-senders)
+			require.Equal(t, 1, verHost.PeerCount())
+			require.Equal(t, common.Hash{byte(payload.BlockNumber)}, payload.BlockHash)
+			return
+		case <-time.After(200 * time.Millisecond):
+		case <-ctx.Done():
+			t.Fatal("payload not received over libp2p gossip")
+		}
+	}
+}
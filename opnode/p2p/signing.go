@@ -0,0 +1,48 @@
+package p2p
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// signatureLen is the length of the signature that prefixes the data of a signed envelope: r, s and the recovery id
+const signatureLen = crypto.SignatureLength
+
+// UnauthorizedSignerErr is returned when a signed envelope is valid, but not signed by the authorized sequencer.
+var UnauthorizedSignerErr = errors.New("unauthorized signer")
+
+// signingDomain separates the signatures of gossiped blocks from other signatures by the same key.
+// The first byte is the version of the signing scheme.
+var signingDomain = [32]byte{0}
+
+// SigningHash is the hash that the sequencer signs to gossip the data on the topic.
+// The topic is committed to, so signatures cannot be replayed on other rollups.
+func SigningHash(topic string, data []byte) common.Hash {
+	return crypto.Keccak256Hash(signingDomain[:], crypto.Keccak256([]byte(topic)), crypto.Keccak256(data))
+}
+
+// SignEnvelope signs the data for the topic, and returns the signed envelope: the signature followed by the data.
+func SignEnvelope(key *ecdsa.PrivateKey, topic string, data []byte) ([]byte, error) {
+	sig, err := crypto.Sign(SigningHash(topic, data).Bytes(), key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign envelope: %v", err)
+	}
+	return append(sig, data...), nil
+}
+
+// OpenEnvelope recovers the signer of a signed envelope on the topic, and returns it along with the data.
+func OpenEnvelope(topic string, envelope []byte) (signer common.Address, data []byte, err error) {
+	if len(envelope) < signatureLen {
+		return common.Address{}, nil, fmt.Errorf("envelope of %d bytes is too short to be signed", len(envelope))
+	}
+	sig, data := envelope[:signatureLen], envelope[signatureLen:]
+	pub, err := crypto.SigToPub(SigningHash(topic, data).Bytes(), sig)
+	if err != nil {
+		return common.Address{}, nil, fmt.Errorf("invalid envelope signature: %v", err)
+	}
+	return crypto.PubkeyToAddress(*pub), data, nil
+}
@@ -0,0 +1,130 @@
+// Package proposer proposes the outputs of the safe L2 chain to the L2 output oracle on L1,
+// every submission interval of L2 blocks, for withdrawals to be proven against.
+package proposer
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/event"
+	"github.com/ethereum/go-ethereum/log"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/txmgr"
+)
+
+const (
+	// DefaultPollInterval is the default interval to check the safe L2 head for a new output to propose at
+	DefaultPollInterval = time.Second * 12
+	// DefaultSubmissionInterval is the default number of L2 blocks between proposed outputs
+	DefaultSubmissionInterval = 64
+)
+
+// Config configures the proposing of outputs
+type Config struct {
+	// PollInterval is the interval to check the safe L2 head for a new output to propose at
+	PollInterval time.Duration
+	// SubmissionInterval is the number of L2 blocks between proposed outputs, as accepted by the L2 output oracle
+	SubmissionInterval uint64
+	// OracleAddr is the L1 address of the L2 output oracle
+	OracleAddr common.Address
+}
+
+// Proposer proposes the output of every submission interval of L2 blocks to the L2 output oracle,
+// once the L2 block is safe: derived from L1, and thus the same for all nodes.
+type Proposer struct {
+	Log    log.Logger
+	Rollup *l2.Config
+	Config Config
+	// L2 is the engine to compute the outputs with
+	L2     eth.BlockByNumberSource
+	Proofs l2.ProofSource
+	// SafeHead returns the safe L2 head
+	SafeHead func() eth.BlockID
+	// L1Head returns the L1 head, which the proposals commit to, to not be included after a L1 reorg
+	L1Head func() eth.BlockID
+	// Oracle reads the state of the L2 output oracle on L1
+	Oracle ethereum.ContractCaller
+	Sender txmgr.TxSender
+}
+
+// Start proposes the outputs of the safe L2 chain every poll interval, until the subscription is closed.
+// Failed proposals are retried every poll interval.
+func (p *Proposer) Start(ctx context.Context) ethereum.Subscription {
+	return event.NewSubscription(func(quit <-chan struct{}) error {
+		ticker := time.NewTicker(p.Config.PollInterval)
+		defer ticker.Stop()
+		// proposals wait for confirmation, abort them when unsubscribing
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		go func() {
+			<-quit
+			cancel()
+		}()
+		for {
+			select {
+			case <-ticker.C:
+				if err := p.Step(ctx); err != nil {
+					p.Log.Warn("Failed to propose output", "err", err)
+				}
+			case <-quit:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// Step proposes the outputs that are due, after the latest output of the oracle, up to the safe L2 head.
+func (p *Proposer) Step(ctx context.Context) error {
+	for {
+		latest, err := p.latestBlockNumber(ctx)
+		if err != nil {
+			return err
+		}
+		next := latest + p.Config.SubmissionInterval
+		if safe := p.SafeHead(); safe.Number < next {
+			return nil
+		}
+		output, err := l2.OutputAtBlock(ctx, p.Rollup, p.L2, p.Proofs, next)
+		if err != nil {
+			return err
+		}
+		l1 := p.L1Head()
+		data, err := l2.L2OutputOracleABI.Pack("appendL2Output", output.OutputRoot, new(big.Int).SetUint64(next), l1.Hash, new(big.Int).SetUint64(l1.Number))
+		if err != nil {
+			return fmt.Errorf("failed to encode proposal of L2 block %d: %v", next, err)
+		}
+		p.Log.Info("Proposing output", "l2", output.Block, "output_root", output.OutputRoot, "l1", l1)
+		if err := p.Sender.SendTx(ctx, p.Config.OracleAddr, data); err != nil {
+			return fmt.Errorf("failed to propose output of L2 block %d: %w", next, err)
+		}
+	}
+}
+
+// latestBlockNumber returns the L2 block number of the latest output of the oracle
+func (p *Proposer) latestBlockNumber(ctx context.Context) (uint64, error) {
+	data, err := l2.L2OutputOracleABI.Pack("latestBlockNumber")
+	if err != nil {
+		return 0, err
+	}
+	res, err := p.Oracle.CallContract(ctx, ethereum.CallMsg{To: &p.Config.OracleAddr, Data: data}, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch the latest output of the L2 output oracle: %w", err)
+	}
+	values, err := l2.L2OutputOracleABI.Unpack("latestBlockNumber", res)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decode the latest output of the L2 output oracle: %v", err)
+	}
+	latest := values[0].(*big.Int)
+	if !latest.IsUint64() {
+		return 0, fmt.Errorf("latest output of L2 block %d overflows", latest)
+	}
+	return latest.Uint64(), nil
+}
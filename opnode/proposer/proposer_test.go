@@ -0,0 +1,100 @@
+package proposer
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/ethclient/gethclient"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/ethereum-optimism/optimistic-specs/opnode/eth"
+	"github.com/ethereum-optimism/optimistic-specs/opnode/l2"
+)
+
+// fakeL2 is a L2 chain, with a withdrawal storage root per block
+type fakeL2 []*types.Block
+
+func (f fakeL2) BlockByNumber(ctx context.Context, number *big.Int) (*types.Block, error) {
+	if n := number.Uint64(); n < uint64(len(f)) {
+		return f[n], nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f fakeL2) GetProof(ctx context.Context, account common.Address, keys []string, blockNumber *big.Int) (*gethclient.AccountResult, error) {
+	return &gethclient.AccountResult{Address: account, StorageHash: common.BigToHash(blockNumber)}, nil
+}
+
+// fakeOracle is a L2 output oracle, that accepts the proposals sent to it
+type fakeOracle struct {
+	fail    bool
+	latest  uint64
+	outputs map[uint64]common.Hash
+}
+
+func (o *fakeOracle) CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error) {
+	return l2.L2OutputOracleABI.Methods["latestBlockNumber"].Outputs.Pack(new(big.Int).SetUint64(o.latest))
+}
+
+func (o *fakeOracle) SendTx(ctx context.Context, to common.Address, data []byte) error {
+	if o.fail {
+		return errors.New("tx pool full")
+	}
+	args, err := l2.L2OutputOracleABI.Methods["appendL2Output"].Inputs.Unpack(data[4:])
+	if err != nil {
+		return err
+	}
+	number := args[1].(*big.Int).Uint64()
+	o.outputs[number] = args[0].([32]byte)
+	o.latest = number
+	return nil
+}
+
+func TestProposer(t *testing.T) {
+	var chain fakeL2
+	for i := int64(0); i <= 10; i++ {
+		chain = append(chain, types.NewBlockWithHeader(&types.Header{Number: big.NewInt(i), Root: common.Hash{byte(i)}}))
+	}
+	oracle := &fakeOracle{outputs: make(map[uint64]common.Hash)}
+	safe := uint64(3)
+	p := &Proposer{
+		Log:      log.New(),
+		Rollup:   &l2.Config{},
+		Config:   Config{SubmissionInterval: 4, OracleAddr: common.Address{0x0a}},
+		L2:       chain,
+		Proofs:   chain,
+		SafeHead: func() eth.BlockID { return eth.BlockID{Hash: chain[safe].Hash(), Number: safe} },
+		L1Head:   func() eth.BlockID { return eth.BlockID{Hash: common.Hash{0x11}, Number: 100} },
+		Oracle:   oracle,
+		Sender:   oracle,
+	}
+	ctx := context.Background()
+
+	require.NoError(t, p.Step(ctx))
+	require.Empty(t, oracle.outputs, "the next output is not safe yet")
+
+	// outputs that are due are proposed in order, up to the safe head
+	safe = 9
+	require.NoError(t, p.Step(ctx))
+	require.Len(t, oracle.outputs, 2)
+	for _, n := range []uint64{4, 8} {
+		expected, err := l2.OutputAtBlock(ctx, &l2.Config{}, chain, chain, n)
+		require.NoError(t, err)
+		require.Equal(t, expected.OutputRoot, oracle.outputs[n])
+	}
+
+	// failed proposals are retried
+	safe = 10
+	oracle.latest = 6
+	oracle.fail = true
+	require.Error(t, p.Step(ctx))
+	oracle.fail = false
+	require.NoError(t, p.Step(ctx))
+	require.Contains(t, oracle.outputs, uint64(10), "the next output follows the latest output of the oracle")
+}
@@ -0,0 +1,25 @@
+package txmgr
+
+import (
+	"context"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// TxSender submits transactions to L1
+type TxSender interface {
+	// SendTx sends a transaction with the data to the address, and returns once it is included and confirmed.
+	SendTx(ctx context.Context, to common.Address, data []byte) error
+}
+
+// ManagerSender submits transactions with a TxManager, which resubmits them until they are confirmed.
+type ManagerSender struct {
+	Manager *TxManager
+}
+
+var _ TxSender = ManagerSender{}
+
+func (s ManagerSender) SendTx(ctx context.Context, to common.Address, data []byte) error {
+	_, err := s.Manager.Send(ctx, TxCandidate{To: to, Data: data})
+	return err
+}
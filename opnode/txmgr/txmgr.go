@@ -0,0 +1,262 @@
+// Package txmgr lands transactions on L1 reliably: it tracks the nonce of the sender,
+// bumps the fees of transactions that are not included in time, and waits for the confirmation of the receipt.
+package txmgr
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+)
+
+const (
+	// DefaultResubmissionTimeout is the default time after which a transaction that is not included is resubmitted with higher fees
+	DefaultResubmissionTimeout = time.Minute
+	// DefaultReceiptQueryInterval is the default interval to poll for the receipt of a sent transaction at
+	DefaultReceiptQueryInterval = time.Second * 6
+	// DefaultNumConfirmations is the default number of L1 blocks, including the block with the transaction, to wait for
+	DefaultNumConfirmations = 3
+	// DefaultFeeBumpPercent is the default percentage to bump the fees of a resubmitted transaction with.
+	// Nodes only accept a replacement transaction with fees at least 10% higher.
+	DefaultFeeBumpPercent = 15
+)
+
+// RevertedErr is returned when a transaction was included, but reverted.
+var RevertedErr = errors.New("transaction reverted")
+
+// Backend is the L1 RPC client to send transactions with, as implemented by the ethclient.
+type Backend interface {
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+	BlockNumber(ctx context.Context) (uint64, error)
+}
+
+// Config configures the submission of transactions
+type Config struct {
+	ChainID *big.Int
+	// ResubmissionTimeout is the time after which a transaction that is not included is resubmitted with higher fees
+	ResubmissionTimeout time.Duration
+	// ReceiptQueryInterval is the interval to poll for the receipt of a sent transaction at
+	ReceiptQueryInterval time.Duration
+	// NumConfirmations is the number of L1 blocks, including the block with the transaction, to wait for
+	NumConfirmations uint64
+	// FeeBumpPercent is the percentage to bump the fees of a resubmitted transaction with
+	FeeBumpPercent uint64
+	// MaxFeeCap limits the fee cap of bumped transactions. Zero disables the limit.
+	MaxFeeCap *big.Int
+}
+
+// TxCandidate is a transaction to send: the manager fills in the nonce and fees
+type TxCandidate struct {
+	To   common.Address
+	Data []byte
+	// GasLimit is optional, the gas is estimated if zero
+	GasLimit uint64
+}
+
+// TxManager signs and sends the transactions of a single key, one at a time, in nonce order.
+// Each transaction is resubmitted with higher fees until it is included, and then confirmed.
+type TxManager struct {
+	Log     log.Logger
+	Config  Config
+	Backend Backend
+	Key     *ecdsa.PrivateKey
+
+	// Locks the sending of transactions, to send one transaction at a time
+	mu sync.Mutex
+	// next nonce to use, nil if it must be fetched from the pending state first
+	nonce *uint64
+}
+
+// Send sends the transaction, and returns its receipt once it is included and confirmed.
+// If the ctx is done first, the nonce is fetched again for the next transaction,
+// as the transaction may or may not still be included.
+func (m *TxManager) Send(ctx context.Context, candidate TxCandidate) (*types.Receipt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := crypto.PubkeyToAddress(m.Key.PublicKey)
+	if m.nonce == nil {
+		nonce, err := m.Backend.PendingNonceAt(ctx, from)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+		}
+		m.nonce = &nonce
+	}
+	tip, feeCap, err := m.suggestFees(ctx)
+	if err != nil {
+		return nil, err
+	}
+	gas := candidate.GasLimit
+	if gas == 0 {
+		gas, err = m.Backend.EstimateGas(ctx, ethereum.CallMsg{From: from, To: &candidate.To, GasFeeCap: feeCap, GasTipCap: tip, Data: candidate.Data})
+		if err != nil {
+			return nil, fmt.Errorf("failed to estimate gas: %w", err)
+		}
+	}
+
+	receipt, err := m.send(ctx, &types.DynamicFeeTx{
+		ChainID:   m.Config.ChainID,
+		Nonce:     *m.nonce,
+		GasTipCap: tip,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        &candidate.To,
+		Data:      candidate.Data,
+	})
+	if err != nil {
+		m.nonce = nil
+		return nil, err
+	}
+	*m.nonce++
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return receipt, fmt.Errorf("tx %s in block %d: %w", receipt.TxHash, receipt.BlockNumber, RevertedErr)
+	}
+	return receipt, nil
+}
+
+// suggestFees returns the suggested tip, and a fee cap of twice the latest base fee plus the tip.
+func (m *TxManager) suggestFees(ctx context.Context) (tip *big.Int, feeCap *big.Int, err error) {
+	tip, err = m.Backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch gas tip: %w", err)
+	}
+	head, err := m.Backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch L1 head: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("L1 head %s has no base fee", head.Hash())
+	}
+	return tip, new(big.Int).Add(tip, new(big.Int).Mul(head.BaseFee, big.NewInt(2))), nil
+}
+
+// send sends the transaction, and the bumped replacements of it, until one of them is included and confirmed.
+func (m *TxManager) send(ctx context.Context, txData *types.DynamicFeeTx) (*types.Receipt, error) {
+	signer := types.LatestSignerForChainID(m.Config.ChainID)
+	// all the sent versions of the transaction, any of them may be included
+	var sent []common.Hash
+	for {
+		tx, err := types.SignNewTx(m.Key, signer, txData)
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign tx: %v", err)
+		}
+		logger := m.Log.New("tx", tx.Hash(), "nonce", txData.Nonce, "tip", txData.GasTipCap, "fee_cap", txData.GasFeeCap)
+		err = m.Backend.SendTransaction(ctx, tx)
+		switch {
+		case err == nil || isErr(err, core.ErrAlreadyKnown):
+			logger.Info("Sent transaction")
+			sent = append(sent, tx.Hash())
+		case isErr(err, core.ErrNonceTooLow) && len(sent) > 0:
+			// a previous version was included already
+			logger.Info("Previous transaction was included, not resubmitting")
+		case isErr(err, core.ErrReplaceUnderpriced) && len(sent) > 0:
+			logger.Warn("Replacement transaction underpriced, waiting for previous transaction")
+		default:
+			return nil, fmt.Errorf("failed to send tx %s: %w", tx.Hash(), err)
+		}
+
+		receipt, err := m.waitMined(ctx, sent)
+		if err != nil {
+			return nil, err
+		}
+		if receipt != nil {
+			logger.Info("Transaction confirmed", "included", receipt.TxHash, "block", receipt.BlockNumber)
+			return receipt, nil
+		}
+		m.bumpFees(ctx, txData)
+	}
+}
+
+// waitMined polls for the receipt of any of the sent transactions, until it is confirmed,
+// or nil if no transaction is included within the resubmission timeout.
+func (m *TxManager) waitMined(ctx context.Context, sent []common.Hash) (*types.Receipt, error) {
+	timeout := time.NewTimer(m.Config.ResubmissionTimeout)
+	defer timeout.Stop()
+	ticker := time.NewTicker(m.Config.ReceiptQueryInterval)
+	defer ticker.Stop()
+	for {
+		for _, hash := range sent {
+			receipt, err := m.Backend.TransactionReceipt(ctx, hash)
+			if err != nil || receipt == nil {
+				continue // not included yet
+			}
+			confirmed, err := m.confirmed(ctx, receipt)
+			if err != nil {
+				m.Log.Warn("Failed to check confirmations", "tx", hash, "err", err)
+			}
+			if confirmed {
+				return receipt, nil
+			}
+			// the included transaction is not resubmitted, wait for confirmations
+			if !timeout.Stop() {
+				<-timeout.C
+			}
+			timeout.Reset(m.Config.ResubmissionTimeout)
+		}
+		select {
+		case <-ticker.C:
+		case <-timeout.C:
+			return nil, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// confirmed checks if the receipt has enough confirmations
+func (m *TxManager) confirmed(ctx context.Context, receipt *types.Receipt) (bool, error) {
+	tip, err := m.Backend.BlockNumber(ctx)
+	if err != nil {
+		return false, err
+	}
+	return tip+1 >= receipt.BlockNumber.Uint64()+m.Config.NumConfirmations, nil
+}
+
+// bumpFees increases the fees of the transaction by the fee bump percentage,
+// or to the currently suggested fees if those are higher, within the max fee cap.
+func (m *TxManager) bumpFees(ctx context.Context, txData *types.DynamicFeeTx) {
+	bump := func(v *big.Int) *big.Int {
+		out := new(big.Int).Mul(v, new(big.Int).SetUint64(100+m.Config.FeeBumpPercent))
+		return out.Div(out, big.NewInt(100))
+	}
+	tip, feeCap := bump(txData.GasTipCap), bump(txData.GasFeeCap)
+	if suggestedTip, suggestedFeeCap, err := m.suggestFees(ctx); err != nil {
+		m.Log.Warn("Failed to fetch suggested fees, bumping previous fees", "err", err)
+	} else {
+		if suggestedTip.Cmp(tip) > 0 {
+			tip = suggestedTip
+		}
+		if suggestedFeeCap.Cmp(feeCap) > 0 {
+			feeCap = suggestedFeeCap
+		}
+	}
+	if max := m.Config.MaxFeeCap; max != nil && max.Sign() > 0 && feeCap.Cmp(max) > 0 {
+		m.Log.Warn("Fee cap exceeds the max fee cap, not bumping further", "fee_cap", feeCap, "max", max)
+		return
+	}
+	if tip.Cmp(feeCap) > 0 {
+		tip = feeCap
+	}
+	txData.GasTipCap, txData.GasFeeCap = tip, feeCap
+}
+
+// isErr checks if the error is, or is the JSON-RPC error message of, the target error
+func isErr(err error, target error) bool {
+	return errors.Is(err, target) || strings.Contains(err.Error(), target.Error())
+}
@@ -0,0 +1,147 @@
+package txmgr
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/log"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend includes the sent transactions with a tip of at least minTip, and advances a block with every block number query.
+type fakeBackend struct {
+	mu       sync.Mutex
+	minTip   *big.Int
+	head     uint64
+	nonce    uint64
+	sent     []*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	status   uint64
+}
+
+func (f *fakeBackend) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.nonce, nil
+}
+
+func (f *fakeBackend) SuggestGasTipCap(ctx context.Context) (*big.Int, error) {
+	return big.NewInt(1), nil
+}
+
+func (f *fakeBackend) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	return &types.Header{BaseFee: big.NewInt(10)}, nil
+}
+
+func (f *fakeBackend) EstimateGas(ctx context.Context, msg ethereum.CallMsg) (uint64, error) {
+	return 21000 + uint64(len(msg.Data))*16, nil
+}
+
+func (f *fakeBackend) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx.Nonce() < f.nonce {
+		return core.ErrNonceTooLow
+	}
+	f.sent = append(f.sent, tx)
+	if tx.GasTipCap().Cmp(f.minTip) >= 0 {
+		f.receipts[tx.Hash()] = &types.Receipt{TxHash: tx.Hash(), BlockNumber: new(big.Int).SetUint64(f.head), Status: f.status}
+		f.nonce++
+	}
+	return nil
+}
+
+func (f *fakeBackend) TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r, ok := f.receipts[txHash]; ok {
+		return r, nil
+	}
+	return nil, ethereum.NotFound
+}
+
+func (f *fakeBackend) BlockNumber(ctx context.Context) (uint64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.head++
+	return f.head, nil
+}
+
+func newTestManager(t *testing.T, backend *fakeBackend) *TxManager {
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	return &TxManager{
+		Log: log.New(),
+		Config: Config{
+			ChainID:              big.NewInt(900),
+			ResubmissionTimeout:  time.Millisecond * 20,
+			ReceiptQueryInterval: time.Millisecond * 2,
+			NumConfirmations:     3,
+			FeeBumpPercent:       DefaultFeeBumpPercent,
+		},
+		Backend: backend,
+		Key:     key,
+	}
+}
+
+func TestTxManager_Send(t *testing.T) {
+	backend := &fakeBackend{minTip: big.NewInt(1), receipts: make(map[common.Hash]*types.Receipt), status: types.ReceiptStatusSuccessful}
+	m := newTestManager(t, backend)
+	ctx := context.Background()
+
+	receipt, err := m.Send(ctx, TxCandidate{To: common.Address{0xff}, Data: []byte{1}})
+	require.NoError(t, err)
+	require.Equal(t, backend.sent[0].Hash(), receipt.TxHash)
+	require.Equal(t, uint64(21016), backend.sent[0].Gas())
+
+	receipt, err = m.Send(ctx, TxCandidate{To: common.Address{0xff}, Data: []byte{2}, GasLimit: 50000})
+	require.NoError(t, err)
+	require.Len(t, backend.sent, 2)
+	require.Equal(t, uint64(1), backend.sent[1].Nonce(), "nonce is tracked")
+	require.Equal(t, uint64(50000), backend.sent[1].Gas())
+}
+
+func TestTxManager_FeeBump(t *testing.T) {
+	// the suggested tip of 1 is not included, the tip doubles to 2 after the resubmission timeout
+	backend := &fakeBackend{minTip: big.NewInt(2), receipts: make(map[common.Hash]*types.Receipt), status: types.ReceiptStatusSuccessful}
+	m := newTestManager(t, backend)
+	m.Config.FeeBumpPercent = 100
+
+	receipt, err := m.Send(context.Background(), TxCandidate{To: common.Address{0xff}})
+	require.NoError(t, err)
+	require.Len(t, backend.sent, 2, "resubmitted once with bumped fees")
+	require.Equal(t, backend.sent[0].Nonce(), backend.sent[1].Nonce(), "replacement has the same nonce")
+	require.Equal(t, big.NewInt(2), backend.sent[1].GasTipCap())
+	require.Equal(t, big.NewInt(42), backend.sent[1].GasFeeCap())
+	require.Equal(t, backend.sent[1].Hash(), receipt.TxHash)
+}
+
+func TestTxManager_Reverted(t *testing.T) {
+	backend := &fakeBackend{minTip: big.NewInt(1), receipts: make(map[common.Hash]*types.Receipt), status: types.ReceiptStatusFailed}
+	m := newTestManager(t, backend)
+	_, err := m.Send(context.Background(), TxCandidate{To: common.Address{0xff}})
+	require.ErrorIs(t, err, RevertedErr)
+}
+
+func TestTxManager_Cancel(t *testing.T) {
+	backend := &fakeBackend{minTip: big.NewInt(1000), receipts: make(map[common.Hash]*types.Receipt), status: types.ReceiptStatusSuccessful}
+	m := newTestManager(t, backend)
+	m.Config.MaxFeeCap = big.NewInt(100)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	_, err := m.Send(ctx, TxCandidate{To: common.Address{0xff}})
+	require.True(t, errors.Is(err, context.DeadlineExceeded))
+	require.Nil(t, m.nonce, "nonce is fetched again after a failed send")
+	for _, tx := range backend.sent {
+		require.LessOrEqual(t, tx.GasFeeCap().Int64(), int64(100), "fee cap is limited")
+	}
+}
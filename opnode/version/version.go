@@ -0,0 +1,56 @@
+// Package version identifies the build of the rollup node.
+package version
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Version is the semantic version of the rollup node
+const Version = "v0.1.0"
+
+// GitCommit and GitDate identify the source of the build, set at compile time with:
+//
+//	go build -ldflags "-X github.com/ethereum-optimism/optimistic-specs/opnode/version.GitCommit=$(git rev-parse HEAD) \
+//	  -X github.com/ethereum-optimism/optimistic-specs/opnode/version.GitDate=$(git show -s --format=%ct)"
+//
+// Both are empty if not set.
+var (
+	GitCommit = ""
+	GitDate   = ""
+)
+
+// Info describes the build of the rollup node, to identify the exact build in bug reports with
+type Info struct {
+	Version   string `json:"version"`
+	GitCommit string `json:"gitCommit,omitempty"`
+	GitDate   string `json:"gitDate,omitempty"`
+	GoVersion string `json:"goVersion"`
+	OS        string `json:"os"`
+	Arch      string `json:"arch"`
+}
+
+// Get returns the build info of the running binary
+func Get() Info {
+	return Info{
+		Version:   Version,
+		GitCommit: GitCommit,
+		GitDate:   GitDate,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+	}
+}
+
+// String formats the build info on a single line, e.g. "v0.1.0-1a2b3c4d (go1.17.5 linux/amd64)"
+func (info Info) String() string {
+	v := info.Version
+	if info.GitCommit != "" {
+		commit := info.GitCommit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+		v += "-" + commit
+	}
+	return fmt.Sprintf("%s (%s %s/%s)", v, info.GoVersion, info.OS, info.Arch)
+}
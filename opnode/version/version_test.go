@@ -0,0 +1,14 @@
+package version
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfo_String(t *testing.T) {
+	info := Info{Version: "v0.1.0", GoVersion: "go1.17.5", OS: "linux", Arch: "amd64"}
+	require.Equal(t, "v0.1.0 (go1.17.5 linux/amd64)", info.String())
+	info.GitCommit = "1a2b3c4d5e6f7a8b9c0d1a2b3c4d5e6f7a8b9c0d"
+	require.Equal(t, "v0.1.0-1a2b3c4d (go1.17.5 linux/amd64)", info.String())
+}
@@ -16,12 +16,20 @@ contract L1Block {
     uint256 public timestamp;
     uint256 public basefee;
     bytes32 public hash;
+    uint64 public sequenceNumber;
+    bytes32 public batcherHash;
+    uint256 public l1FeeOverhead;
+    uint256 public l1FeeScalar;
 
     function setL1BlockValues(
         uint256 _number,
         uint256 _timestamp,
         uint256 _basefee,
-        bytes32 _hash
+        bytes32 _hash,
+        uint64 _sequenceNumber,
+        bytes32 _batcherHash,
+        uint256 _l1FeeOverhead,
+        uint256 _l1FeeScalar
     ) external {
         if (msg.sender != DEPOSITOR_ACCOUNT) {
             revert OnlyDepositor();
@@ -31,5 +39,9 @@ contract L1Block {
         timestamp = _timestamp;
         basefee = _basefee;
         hash = _hash;
+        sequenceNumber = _sequenceNumber;
+        batcherHash = _batcherHash;
+        l1FeeOverhead = _l1FeeOverhead;
+        l1FeeScalar = _l1FeeScalar;
     }
 }
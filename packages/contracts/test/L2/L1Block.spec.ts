@@ -6,6 +6,7 @@ import { L1Block__factory, L1Block } from '../../typechain'
 
 const DEPOSITOR_ACCOUNT = '0xdeaddeaddeaddeaddeaddeaddeaddeaddead0001'
 const NON_ZERO_HASH = '0x' + 'ab'.repeat(32)
+const BATCHER_HASH = '0x' + '00'.repeat(12) + 'cd'.repeat(20)
 
 describe('L1Block contract', () => {
   let signer: Signer
@@ -23,7 +24,7 @@ describe('L1Block contract', () => {
 
   it('setL1BlockValues: Should revert if not called by L1 Attributes Depositor Account', async () => {
     await expect(
-      l1Block.connect(signer).setL1BlockValues(1, 2, 3, NON_ZERO_HASH)
+      l1Block.connect(signer).setL1BlockValues(1, 2, 3, NON_ZERO_HASH, 4, BATCHER_HASH, 5, 6)
     ).to.be.revertedWith('OnlyDepositor()')
   })
 
@@ -36,7 +37,7 @@ describe('L1Block contract', () => {
         DEPOSITOR_ACCOUNT,
         '0xFFFFFFFFFFFF',
       ])
-      await l1Block.connect(depositor).setL1BlockValues(1, 2, 3, NON_ZERO_HASH)
+      await l1Block.connect(depositor).setL1BlockValues(1, 2, 3, NON_ZERO_HASH, 4, BATCHER_HASH, 5, 6)
       await ethers.provider.send('hardhat_stopImpersonatingAccount', [
         DEPOSITOR_ACCOUNT,
       ])
@@ -58,5 +59,21 @@ describe('L1Block contract', () => {
     it('hash', async () => {
       expect(await l1Block.hash()).to.equal(NON_ZERO_HASH)
     })
+
+    it('sequenceNumber', async () => {
+      expect(await l1Block.sequenceNumber()).to.equal(4)
+    })
+
+    it('batcherHash', async () => {
+      expect(await l1Block.batcherHash()).to.equal(BATCHER_HASH)
+    })
+
+    it('l1FeeOverhead', async () => {
+      expect(await l1Block.l1FeeOverhead()).to.equal(5)
+    })
+
+    it('l1FeeScalar', async () => {
+      expect(await l1Block.l1FeeScalar()).to.equal(6)
+    })
   })
 })